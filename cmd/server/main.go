@@ -6,17 +6,42 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/microservice-go/product-service/internal/audit"
+	"github.com/microservice-go/product-service/internal/auth"
+	"github.com/microservice-go/product-service/internal/cache"
 	"github.com/microservice-go/product-service/internal/constants"
 	"github.com/microservice-go/product-service/internal/database"
+	"github.com/microservice-go/product-service/internal/events"
 	"github.com/microservice-go/product-service/internal/handler"
+	"github.com/microservice-go/product-service/internal/outbox"
+	"github.com/microservice-go/product-service/internal/ratelimit"
+	"github.com/microservice-go/product-service/internal/renewal"
 	"github.com/microservice-go/product-service/internal/repository"
+	"github.com/microservice-go/product-service/internal/scheduler"
 	"github.com/microservice-go/product-service/internal/service"
+	"github.com/microservice-go/product-service/internal/tax"
+	"github.com/microservice-go/product-service/internal/tenant"
+	"github.com/microservice-go/product-service/internal/tlsconfig"
+	"github.com/microservice-go/product-service/internal/webhook"
+	apikeypb "github.com/microservice-go/product-service/proto/apikey"
+	auditpb "github.com/microservice-go/product-service/proto/audit"
+	billingpb "github.com/microservice-go/product-service/proto/billing"
+	couponpb "github.com/microservice-go/product-service/proto/coupon"
+	invoicepb "github.com/microservice-go/product-service/proto/invoice"
 	productpb "github.com/microservice-go/product-service/proto/product"
+	schedulepb "github.com/microservice-go/product-service/proto/schedule"
 	subscriptionpb "github.com/microservice-go/product-service/proto/subscription"
+	usagepb "github.com/microservice-go/product-service/proto/usage"
+	webhookpb "github.com/microservice-go/product-service/proto/webhook"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
@@ -26,13 +51,14 @@ func main() {
 	log.Println("========================================")
 
 	dbConfig := database.Config{
-		Driver:   getEnv("DB_DRIVER", constants.DefaultDBDriver),
-		Host:     getEnv("DB_HOST", constants.DefaultDBHost),
-		Port:     getEnv("DB_PORT", constants.DefaultDBPort),
-		User:     getEnv("DB_USER", constants.DefaultDBUser),
-		Password: getEnv("DB_PASSWORD", constants.DefaultDBPassword),
-		DBName:   getEnv("DB_NAME", constants.DefaultDBName),
-		SSLMode:  getEnv("DB_SSLMODE", constants.DefaultDBSSLMode),
+		Driver:      getEnv("DB_DRIVER", constants.DefaultDBDriver),
+		Host:        getEnv("DB_HOST", constants.DefaultDBHost),
+		Port:        getEnv("DB_PORT", constants.DefaultDBPort),
+		User:        getEnv("DB_USER", constants.DefaultDBUser),
+		Password:    getEnv("DB_PASSWORD", constants.DefaultDBPassword),
+		DBName:      getEnv("DB_NAME", constants.DefaultDBName),
+		SSLMode:     getEnv("DB_SSLMODE", constants.DefaultDBSSLMode),
+		ReplicaDSNs: getEnvList("DB_REPLICA_DSNS", ""),
 	}
 
 	db, err := database.NewDatabase(dbConfig)
@@ -43,21 +69,201 @@ func main() {
 	if err := database.RunMigrations(db); err != nil {
 		log.Fatalf("✗ Failed to run migrations: %v", err)
 	}
-	
+
 	productRepo := repository.NewProductRepository(db)
 	subscriptionRepo := repository.NewSubscriptionRepository(db)
+	cacheSize := getEnvInt("CACHE_SIZE", constants.DefaultCacheSize)
+	cacheTTL := time.Duration(getEnvInt("CACHE_TTL_SECONDS", constants.DefaultCacheTTLSeconds)) * time.Second
+	if cacheSize > 0 && cacheTTL > 0 {
+		lru := cache.NewLRU(cacheSize)
+		productRepo = repository.NewCachedProductRepository(productRepo, lru, cacheTTL)
+		subscriptionRepo = repository.NewCachedSubscriptionRepository(subscriptionRepo, lru, cacheTTL)
+		log.Printf("✓ Caching up to %d products and plans for %s", cacheSize, cacheTTL)
+	}
+	auditRepo := repository.NewAuditRepository(db)
+	scheduledChangeRepo := repository.NewScheduledChangeRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	customerSubscriptionRepo := repository.NewCustomerSubscriptionRepository(db)
+	couponRepo := repository.NewCouponRepository(db)
+	usageRepo := repository.NewUsageRepository(db)
+	invoiceRepo := repository.NewInvoiceRepository(db)
+
+	broker := events.NewBroker(getEnvInt("EVENT_BUFFER_SIZE", constants.DefaultEventBuffer))
+
+	serviceOpts := []service.Option{
+		service.WithMaxBatchSize(getEnvInt("BATCH_MAX_SIZE", constants.DefaultMaxBatchSize)),
+		service.WithEventBroker(broker),
+		service.WithCurrency(os.Getenv("CATALOG_CURRENCY")),
+		service.WithDraftInvoices(getEnvBool("INVOICE_DRAFTS", false)),
+	}
+	if path := os.Getenv("TAX_RATES_FILE"); path != "" {
+		rates, err := tax.LoadTable(path)
+		if err != nil {
+			log.Fatalf("✗ Failed to load tax rates: %v", err)
+		}
+		serviceOpts = append(serviceOpts, service.WithTaxCalculator(rates))
+		log.Printf("✓ Tax rates loaded from %s", path)
+	}
+
+	webhookRepo := repository.NewWebhookRepository(db)
+	publishers := events.MultiPublisher{broker, webhook.NewDispatcher(webhookRepo)}
+	if path := os.Getenv("OUTBOX_FILE"); path != "" {
+		out := os.Stdout
+		if path != "-" {
+			out, err = os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+			if err != nil {
+				log.Fatalf("✗ Failed to open outbox file: %v", err)
+			}
+			defer out.Close()
+		}
+		publishers = append(publishers, events.NewWriterPublisher(out))
+	}
+	if url := os.Getenv("OUTBOX_WEBHOOK_URL"); url != "" {
+		publishers = append(publishers, events.NewHTTPPublisher(url, 10*time.Second))
+	}
+
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	defer stopRelay()
+	relay := outbox.NewRelay(repository.NewOutboxRepository(db), publishers, outbox.Config{
+		MaxAttempts: getEnvInt("OUTBOX_MAX_ATTEMPTS", 0),
+	})
+	go relay.Run(relayCtx)
+	go database.WatchReplicas(relayCtx, db, time.Duration(getEnvInt("DB_REPLICA_CHECK_SECONDS", 0))*time.Second)
+	go webhook.NewDeliverer(webhookRepo, webhook.Config{
+		MaxAttempts: getEnvInt("WEBHOOK_MAX_ATTEMPTS", 0),
+	}).Run(relayCtx)
 
-	productService := service.NewProductService(productRepo)
-	subscriptionService := service.NewSubscriptionService(subscriptionRepo, productRepo)
+	productService := service.NewProductService(productRepo, serviceOpts...)
+	subscriptionService := service.NewSubscriptionService(subscriptionRepo, productRepo, serviceOpts...)
+	webhookService := service.NewWebhookService(webhookRepo)
+	auditService := service.NewAuditService(auditRepo)
+	scheduledChangeService := service.NewScheduledChangeService(scheduledChangeRepo, productRepo, subscriptionRepo)
+	policy := auth.DefaultPolicy()
+	if path := os.Getenv("AUTH_POLICY_FILE"); path != "" {
+		policy, err = auth.LoadPolicy(path)
+		if err != nil {
+			log.Fatalf("✗ Failed to load authorization policy: %v", err)
+		}
+	}
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, policy)
+	billingService := service.NewBillingService(customerSubscriptionRepo, subscriptionRepo, productRepo, couponRepo, usageRepo, serviceOpts...)
+	couponService := service.NewCouponService(couponRepo, productRepo, subscriptionRepo, serviceOpts...)
+	usageService := service.NewUsageService(usageRepo, customerSubscriptionRepo, subscriptionRepo, serviceOpts...)
+	invoiceService := service.NewInvoiceService(invoiceRepo, serviceOpts...)
+
+	go scheduler.New(scheduledChangeService, scheduler.Config{
+		PollInterval: time.Duration(getEnvInt("SCHEDULER_POLL_SECONDS", 0)) * time.Second,
+	}).Run(relayCtx)
+	go renewal.New(billingService, renewal.Config{
+		PollInterval: time.Duration(getEnvInt("RENEWAL_POLL_SECONDS", 0)) * time.Second,
+	}).Run(relayCtx)
 
 	productHandler := handler.NewProductHandler(productService)
 	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionService)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	auditHandler := handler.NewAuditHandler(auditService)
+	scheduledChangeHandler := handler.NewScheduledChangeHandler(scheduledChangeService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	billingHandler := handler.NewBillingHandler(billingService)
+	couponHandler := handler.NewCouponHandler(couponService)
+	usageHandler := handler.NewUsageHandler(usageService)
+	invoiceHandler := handler.NewInvoiceHandler(invoiceService)
+
+	tlsConfig := tlsconfig.Config{
+		CertFile:          os.Getenv("TLS_CERT_FILE"),
+		KeyFile:           os.Getenv("TLS_KEY_FILE"),
+		ClientCAFile:      os.Getenv("TLS_CLIENT_CA_FILE"),
+		RequireClientCert: getEnvBool("TLS_REQUIRE_CLIENT_CERT", false),
+	}
+	var serverOpts []grpc.ServerOption
+	if tlsConfig.Enabled() {
+		reloader, err := tlsconfig.NewReloader(tlsConfig)
+		if err != nil {
+			log.Fatalf("✗ Failed to load TLS certificates: %v", err)
+		}
+		go reloader.Watch(relayCtx, time.Duration(getEnvInt("TLS_RELOAD_SECONDS", 0))*time.Second)
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(reloader.ServerConfig())))
+		log.Println("✓ TLS enabled")
+	} else {
+		log.Println("! TLS disabled: set TLS_CERT_FILE and TLS_KEY_FILE to enable it")
+	}
+
+	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		audit.UnaryServerInterceptor(),
+		tenant.UnaryServerInterceptor(),
+	}
+	streamInterceptors := []grpc.StreamServerInterceptor{tenant.StreamServerInterceptor()}
+	authConfig := auth.Config{
+		HMACSecret:    []byte(os.Getenv("AUTH_JWT_SECRET")),
+		JWKSFile:      os.Getenv("AUTH_JWKS_FILE"),
+		Issuer:        os.Getenv("AUTH_ISSUER"),
+		Audience:      os.Getenv("AUTH_AUDIENCE"),
+		PublicMethods: getEnvList("AUTH_PUBLIC_METHODS", constants.DefaultAuthPublicMethods),
+		// Verified client certificates identify their callers.
+		ClientCertificates: tlsConfig.ClientCAFile != "",
+	}
+	// API keys are accepted whenever authentication is on, and can turn it on
+	// without any JWT configuration.
+	if authConfig.Enabled() || getEnvBool("AUTH_API_KEYS", false) {
+		authConfig.APIKeys = apiKeyService
+	}
+	if authConfig.Enabled() {
+		authenticator, err := auth.NewAuthenticator(authConfig)
+		if err != nil {
+			log.Fatalf("✗ Failed to configure authentication: %v", err)
+		}
+		// Runs after the audit and tenant interceptors so the token subject
+		// and tenant override the x-actor and x-tenant-id headers.
+		unaryInterceptors = append(unaryInterceptors, auth.UnaryServerInterceptor(authenticator))
+		streamInterceptors = append(streamInterceptors, auth.StreamServerInterceptor(authenticator))
+		log.Println("✓ Authentication enabled")
+	} else {
+		log.Println("! Authentication disabled: set AUTH_JWT_SECRET, AUTH_JWKS_FILE, AUTH_API_KEYS or TLS_CLIENT_CA_FILE to enable it")
+	}
+
+	// Rate limits are keyed by principal, so they apply after authentication.
+	methodLimits, err := ratelimit.ParseMethodLimits(os.Getenv("RATE_LIMIT_METHODS"))
+	if err != nil {
+		log.Fatalf("✗ Failed to parse RATE_LIMIT_METHODS: %v", err)
+	}
+	limiter := ratelimit.NewLimiter(ratelimit.Config{
+		Default: ratelimit.Limit{
+			Rate:  getEnvFloat("RATE_LIMIT_RPS", 0),
+			Burst: getEnvInt("RATE_LIMIT_BURST", 0),
+		},
+		Methods:     methodLimits,
+		MaxInFlight: getEnvInt("MAX_IN_FLIGHT_REQUESTS", 0),
+		Exempt:      getEnvList("RATE_LIMIT_EXEMPT_METHODS", constants.DefaultRateLimitExemptMethods),
+	})
+	unaryInterceptors = append(unaryInterceptors, ratelimit.UnaryServerInterceptor(limiter))
+	streamInterceptors = append(streamInterceptors, ratelimit.StreamServerInterceptor(limiter))
+
+	if authConfig.Enabled() {
+		unaryInterceptors = append(unaryInterceptors, auth.UnaryAuthorizationInterceptor(policy))
+		streamInterceptors = append(streamInterceptors, auth.StreamAuthorizationInterceptor(policy))
+	}
+
+	// Replica reads are enabled last so API key checks always see revocations.
+	unaryInterceptors = append(unaryInterceptors, database.UnaryServerInterceptor())
+	streamInterceptors = append(streamInterceptors, database.StreamServerInterceptor())
+
+	grpcServer := grpc.NewServer(append(serverOpts,
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+	)...)
 
-	grpcServer := grpc.NewServer()
-		
 	productpb.RegisterProductServiceServer(grpcServer, productHandler)
 	subscriptionpb.RegisterSubscriptionServiceServer(grpcServer, subscriptionHandler)
+	webhookpb.RegisterWebhookServiceServer(grpcServer, webhookHandler)
+	auditpb.RegisterAuditServiceServer(grpcServer, auditHandler)
+	schedulepb.RegisterScheduledChangeServiceServer(grpcServer, scheduledChangeHandler)
+	apikeypb.RegisterAPIKeyServiceServer(grpcServer, apiKeyHandler)
+	billingpb.RegisterBillingServiceServer(grpcServer, billingHandler)
+	couponpb.RegisterCouponServiceServer(grpcServer, couponHandler)
+	usagepb.RegisterUsageServiceServer(grpcServer, usageHandler)
+	invoicepb.RegisterInvoiceServiceServer(grpcServer, invoiceHandler)
 
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer())
 	reflection.Register(grpcServer)
 	port := getEnv("PORT", constants.DefaultGRPCPort)
 	listener, err := net.Listen("tcp", ":"+port)
@@ -100,6 +306,7 @@ func main() {
 		log.Println("Shutdown timeout exceeded, forcing stop")
 		grpcServer.Stop()
 	}
+	stopRelay()
 
 	log.Println("========================================")
 	log.Println("  Server shutdown complete")
@@ -113,3 +320,52 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid value %q for %s, using default %d", value, key, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Invalid value %q for %s, using default %g", value, key, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+func getEnvList(key, defaultValue string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, defaultValue), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid value %q for %s, using default %t", value, key, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
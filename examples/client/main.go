@@ -2,18 +2,27 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"log"
+	"os"
+	"strings"
 	"time"
 
 	productpb "github.com/microservice-go/product-service/proto/product"
 	subscriptionpb "github.com/microservice-go/product-service/proto/subscription"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
 func main() {
-	conn, err := grpc.Dial("localhost:50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	creds, err := transportCredentials()
+	if err != nil {
+		log.Fatalf("Failed to load TLS configuration: %v", err)
+	}
+	conn, err := grpc.Dial("localhost:50051", grpc.WithTransportCredentials(creds))
 	if err != nil {
 		log.Fatalf("Failed to connect: %v", err)
 	}
@@ -56,26 +65,26 @@ func main() {
 	monthlyPlanResp, err := subscriptionClient.CreateSubscriptionPlan(ctx, &subscriptionpb.CreateSubscriptionPlanRequest{
 		ProductId: productID,
 		PlanName:  "Monthly Plan",
-		Duration:  30,
+		Interval:  &subscriptionpb.BillingInterval{Unit: subscriptionpb.IntervalUnit_INTERVAL_UNIT_MONTH, Count: 1},
 		Price:     29.99,
 	})
 	if err != nil {
 		log.Fatalf("Failed to create monthly plan: %v", err)
 	}
-	fmt.Printf(" Created plan: %s - $%.2f for %d days\n", 
-		monthlyPlanResp.Plan.PlanName, monthlyPlanResp.Plan.Price, monthlyPlanResp.Plan.Duration)
+	fmt.Printf(" Created plan: %s - $%.2f every %s\n", 
+		monthlyPlanResp.Plan.PlanName, monthlyPlanResp.Plan.Price, formatInterval(monthlyPlanResp.Plan.Interval))
 
 	annualPlanResp, err := subscriptionClient.CreateSubscriptionPlan(ctx, &subscriptionpb.CreateSubscriptionPlanRequest{
 		ProductId: productID,
 		PlanName:  "Annual Plan",
-		Duration:  365,
+		Interval:  &subscriptionpb.BillingInterval{Unit: subscriptionpb.IntervalUnit_INTERVAL_UNIT_YEAR, Count: 1},
 		Price:     299.99,
 	})
 	if err != nil {
 		log.Fatalf("Failed to create annual plan: %v", err)
 	}
-	fmt.Printf("Created plan: %s - $%.2f for %d days\n\n", 
-		annualPlanResp.Plan.PlanName, annualPlanResp.Plan.Price, annualPlanResp.Plan.Duration)
+	fmt.Printf("Created plan: %s - $%.2f every %s\n\n", 
+		annualPlanResp.Plan.PlanName, annualPlanResp.Plan.Price, formatInterval(annualPlanResp.Plan.Interval))
 
 	fmt.Println("4. Listing all subscription plans for the product...")
 	listPlansResp, err := subscriptionClient.ListSubscriptionPlans(ctx, &subscriptionpb.ListSubscriptionPlansRequest{
@@ -86,7 +95,7 @@ func main() {
 	}
 	fmt.Printf(" Found %d subscription plans:\n", listPlansResp.Total)
 	for i, plan := range listPlansResp.Plans {
-		fmt.Printf("  %d. %s - $%.2f (%d days)\n", i+1, plan.PlanName, plan.Price, plan.Duration)
+		fmt.Printf("  %d. %s - $%.2f (every %s)\n", i+1, plan.PlanName, plan.Price, formatInterval(plan.Interval))
 	}
 	fmt.Println()
 
@@ -149,7 +158,7 @@ func main() {
 		Id:        monthlyPlanResp.Plan.Id,
 		ProductId: productID,
 		PlanName:  "Monthly Plan - Special Offer",
-		Duration:  30,
+		Interval:  monthlyPlanResp.Plan.Interval,
 		Price:     24.99,
 	})
 	if err != nil {
@@ -164,8 +173,8 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to get plan: %v", err)
 	}
-	fmt.Printf("Retrieved plan: %s - $%.2f for %d days\n\n", 
-		getPlanResp.Plan.PlanName, getPlanResp.Plan.Price, getPlanResp.Plan.Duration)
+	fmt.Printf("Retrieved plan: %s - $%.2f every %s\n\n", 
+		getPlanResp.Plan.PlanName, getPlanResp.Plan.Price, formatInterval(getPlanResp.Plan.Interval))
 
 	fmt.Println("11. Deleting the annual plan...")
 	deletePlanResp, err := subscriptionClient.DeleteSubscriptionPlan(ctx, &subscriptionpb.DeleteSubscriptionPlanRequest{
@@ -197,3 +206,40 @@ func main() {
 	fmt.Println("=== All operations completed successfully! ===")
 }
 
+// transportCredentials uses TLS when TLS_CA_FILE names the CA that signed the
+// server certificate, presenting TLS_CERT_FILE and TLS_KEY_FILE as the client
+// certificate when set, and plaintext otherwise.
+func transportCredentials() (credentials.TransportCredentials, error) {
+	caFile := os.Getenv("TLS_CA_FILE")
+	if caFile == "" {
+		return insecure.NewCredentials(), nil
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+	config := &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+
+	if certFile := os.Getenv("TLS_CERT_FILE"); certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, os.Getenv("TLS_KEY_FILE"))
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return credentials.NewTLS(config), nil
+}
+
+// formatInterval renders an interval such as "1 month" or "2 weeks".
+func formatInterval(interval *subscriptionpb.BillingInterval) string {
+	unit := strings.ToLower(strings.TrimPrefix(interval.GetUnit().String(), "INTERVAL_UNIT_"))
+	if interval.GetCount() != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s", interval.GetCount(), unit)
+}
@@ -0,0 +1,46 @@
+package audit
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// MetadataKey is the gRPC metadata header callers use to identify themselves.
+const MetadataKey = "x-actor"
+
+// AnonymousActor is recorded when a change carries no actor.
+const AnonymousActor = "anonymous"
+
+// SystemActor is recorded for changes made by background workers on nobody's
+// behalf, such as subscription renewals.
+const SystemActor = "system"
+
+type actorKey struct{}
+
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	if ctx != nil {
+		if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+			return actor
+		}
+	}
+	return AnonymousActor
+}
+
+// UnaryServerInterceptor copies the x-actor request header into the context
+// so repositories can attribute the changes they record.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(MetadataKey); len(values) > 0 {
+				ctx = WithActor(ctx, values[0])
+			}
+		}
+		return handler(ctx, req)
+	}
+}
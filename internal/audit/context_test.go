@@ -0,0 +1,33 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestActorFromContext(t *testing.T) {
+	assert.Equal(t, AnonymousActor, ActorFromContext(context.Background()))
+	assert.Equal(t, "alice@example.com", ActorFromContext(WithActor(context.Background(), "alice@example.com")))
+}
+
+func TestUnaryServerInterceptor_ReadsActorMetadata(t *testing.T) {
+	interceptor := UnaryServerInterceptor()
+	var actor string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		actor = ActorFromContext(ctx)
+		return nil, nil
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, "billing-admin"))
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "billing-admin", actor)
+
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, AnonymousActor, actor)
+}
@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Change is the before and after value of a single field.
+type Change struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// Diff compares two JSON objects and returns the top-level fields whose values
+// differ. Either side may be empty, as for creates and deletes.
+func Diff(before, after []byte) (map[string]Change, error) {
+	beforeFields, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]Change)
+	for name, from := range beforeFields {
+		if to := afterFields[name]; !bytes.Equal(from, to) {
+			changes[name] = Change{Before: orNull(from), After: orNull(to)}
+		}
+	}
+	for name, to := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			changes[name] = Change{Before: orNull(nil), After: to}
+		}
+	}
+	return changes, nil
+}
+
+func fields(data []byte) (map[string]json.RawMessage, error) {
+	out := make(map[string]json.RawMessage)
+	if len(data) == 0 {
+		return out, nil
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func orNull(value json.RawMessage) json.RawMessage {
+	if value == nil {
+		return json.RawMessage("null")
+	}
+	return value
+}
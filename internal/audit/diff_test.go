@@ -0,0 +1,40 @@
+package audit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff_ReportsChangedFields(t *testing.T) {
+	before := []byte(`{"plan_name":"Monthly","price":29.99,"duration":30}`)
+	after := []byte(`{"plan_name":"Monthly","price":39.99,"duration":30,"trial_days":7}`)
+
+	changes, err := Diff(before, after)
+
+	require.NoError(t, err)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, json.RawMessage("29.99"), changes["price"].Before)
+	assert.Equal(t, json.RawMessage("39.99"), changes["price"].After)
+	assert.Equal(t, json.RawMessage("null"), changes["trial_days"].Before)
+	assert.Equal(t, json.RawMessage("7"), changes["trial_days"].After)
+}
+
+func TestDiff_CreateAndDelete(t *testing.T) {
+	snapshot := []byte(`{"name":"Widget"}`)
+
+	created, err := Diff(nil, snapshot)
+	require.NoError(t, err)
+	assert.Equal(t, json.RawMessage("null"), created["name"].Before)
+	assert.Equal(t, json.RawMessage(`"Widget"`), created["name"].After)
+
+	deleted, err := Diff(snapshot, nil)
+	require.NoError(t, err)
+	assert.Equal(t, json.RawMessage(`"Widget"`), deleted["name"].Before)
+	assert.Equal(t, json.RawMessage("null"), deleted["name"].After)
+
+	_, err = Diff([]byte("not json"), snapshot)
+	assert.Error(t, err)
+}
@@ -0,0 +1,261 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/microservice-go/product-service/internal/audit"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/tenant"
+	"google.golang.org/grpc/metadata"
+)
+
+// MetadataKey is the gRPC metadata header carrying the bearer token.
+const MetadataKey = "authorization"
+
+// APIKeyMetadataKey is the gRPC metadata header carrying an API key.
+const APIKeyMetadataKey = "x-api-key"
+
+var (
+	ErrMissingToken = errors.New("missing bearer token or API key")
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// APIKeyVerifier looks up the active API key matching a plaintext key.
+type APIKeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key string) (*models.APIKey, error)
+}
+
+type Config struct {
+	// HMACSecret enables HS256 tokens signed with this shared secret.
+	HMACSecret []byte
+	// JWKSFile enables RS256 and ES256 tokens signed by a key in this JWK Set.
+	JWKSFile string
+	// Issuer and Audience, when set, must match the iss and aud claims.
+	Issuer   string
+	Audience string
+	// APIKeys, when set, accepts API keys in the x-api-key header.
+	APIKeys APIKeyVerifier
+	// ClientCertificates accepts verified TLS client certificates from
+	// callers that send neither an API key nor a token.
+	ClientCertificates bool
+	// PublicMethods are full method names ("/pkg.Service/Method") or service
+	// names ("pkg.Service") that may be called without a token.
+	PublicMethods []string
+}
+
+// Enabled reports whether any way to authenticate is configured.
+func (c Config) Enabled() bool {
+	return c.jwtEnabled() || c.APIKeys != nil || c.ClientCertificates
+}
+
+func (c Config) jwtEnabled() bool {
+	return len(c.HMACSecret) > 0 || c.JWKSFile != ""
+}
+
+type Authenticator struct {
+	config  Config
+	keys    map[string]interface{}
+	methods []string
+	parser  *jwt.Parser
+}
+
+func NewAuthenticator(config Config) (*Authenticator, error) {
+	if !config.Enabled() {
+		return nil, errors.New("auth: no HMAC secret, JWKS file, API keys or client certificates configured")
+	}
+
+	a := &Authenticator{config: config}
+	if len(config.HMACSecret) > 0 {
+		a.methods = append(a.methods, jwt.SigningMethodHS256.Alg())
+	}
+	if config.JWKSFile != "" {
+		keys, err := loadJWKS(config.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("auth: load JWKS: %w", err)
+		}
+		a.keys = keys
+		a.methods = append(a.methods, jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg())
+	}
+	if !config.jwtEnabled() {
+		return a, nil
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(a.methods),
+		jwt.WithExpirationRequired(),
+	}
+	if config.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		opts = append(opts, jwt.WithAudience(config.Audience))
+	}
+	a.parser = jwt.NewParser(opts...)
+
+	return a, nil
+}
+
+// IsPublic reports whether fullMethod may be called without a token.
+func (a *Authenticator) IsPublic(fullMethod string) bool {
+	for _, method := range a.config.PublicMethods {
+		if method == fullMethod || strings.HasPrefix(fullMethod, "/"+method+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticate verifies the API key or bearer token in the incoming metadata,
+// or else the client certificate, and returns a context carrying its
+// principal, which is also recorded as the audit actor. An API key takes
+// precedence when both are sent. A principal bound to a tenant also fixes the
+// request's tenant.
+func (a *Authenticator) Authenticate(ctx context.Context) (context.Context, error) {
+	var principal *Principal
+	var err error
+	if key := metadataValue(ctx, APIKeyMetadataKey); key != "" && a.config.APIKeys != nil {
+		principal, err = a.verifyAPIKey(ctx, key)
+	} else {
+		var token string
+		token, err = bearerToken(ctx)
+		if err == nil {
+			principal, err = a.Verify(token)
+		} else if cert := verifiedClientCert(ctx); cert != nil && a.config.ClientCertificates {
+			principal, err = certificatePrincipal(cert), nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = WithPrincipal(ctx, principal)
+	if principal.Tenant != "" {
+		ctx = tenant.WithTenant(ctx, principal.Tenant)
+	}
+	return audit.WithActor(ctx, principal.Subject), nil
+}
+
+func (a *Authenticator) Verify(tokenString string) (*Principal, error) {
+	if a.parser == nil {
+		return nil, fmt.Errorf("%w: bearer tokens are not accepted", ErrInvalidToken)
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := a.parser.ParseWithClaims(tokenString, claims, a.keyFunc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+
+	return &Principal{
+		Subject: subject,
+		Tenant:  stringClaim(claims["tenant_id"]),
+		Roles:   stringsClaim(claims["roles"]),
+		Scopes:  strings.Fields(stringClaim(claims["scope"])),
+		Claims:  claims,
+	}, nil
+}
+
+// verifyAPIKey maps an API key to a principal whose scopes are the key's.
+func (a *Authenticator) verifyAPIKey(ctx context.Context, key string) (*Principal, error) {
+	apiKey, err := a.config.APIKeys.VerifyAPIKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{
+		Subject: "api-key:" + apiKey.ID.String(),
+		Tenant:  apiKey.TenantID,
+		Scopes:  apiKey.ScopeList(),
+	}, nil
+}
+
+func stringClaim(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
+
+func stringsClaim(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func (a *Authenticator) keyFunc(token *jwt.Token) (interface{}, error) {
+	if token.Method.Alg() == jwt.SigningMethodHS256.Alg() {
+		return a.config.HMACSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if key, ok := a.keys[kid]; ok {
+		return key, nil
+	}
+	if kid != "" {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	// Without a kid, fall back to the only key of the right type.
+	var match interface{}
+	for _, key := range a.keys {
+		switch key.(type) {
+		case *rsa.PublicKey:
+			if token.Method.Alg() != jwt.SigningMethodRS256.Alg() {
+				continue
+			}
+		case *ecdsa.PublicKey:
+			if token.Method.Alg() != jwt.SigningMethodES256.Alg() {
+				continue
+			}
+		}
+		if match != nil {
+			return nil, errors.New("token has no kid and several keys match")
+		}
+		match = key
+	}
+	if match == nil {
+		return nil, errors.New("no key for token")
+	}
+	return match, nil
+}
+
+func metadataValue(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func bearerToken(ctx context.Context) (string, error) {
+	value := metadataValue(ctx, MetadataKey)
+	if value == "" {
+		return "", ErrMissingToken
+	}
+
+	scheme, token, found := strings.Cut(value, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", ErrMissingToken
+	}
+	return strings.TrimSpace(token), nil
+}
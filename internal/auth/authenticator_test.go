@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/audit"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func signHS256(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(testSecret)
+	require.NoError(t, err)
+	return token
+}
+
+func validClaims(subject string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub": subject,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func encodeBigInt(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
+
+func writeJWKS(t *testing.T, rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) string {
+	t.Helper()
+	set := map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": "rsa-1",
+				"use": "sig",
+				"n":   encodeBigInt(rsaKey.N),
+				"e":   encodeBigInt(big.NewInt(int64(rsaKey.E))),
+			},
+			{
+				"kty": "EC",
+				"kid": "ec-1",
+				"crv": "P-256",
+				"x":   encodeBigInt(ecKey.X),
+				"y":   encodeBigInt(ecKey.Y),
+			},
+		},
+	}
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestAuthenticator_HS256(t *testing.T) {
+	a, err := NewAuthenticator(Config{HMACSecret: testSecret, Issuer: "https://issuer.example.com"})
+	require.NoError(t, err)
+
+	claims := validClaims("alice")
+	claims["iss"] = "https://issuer.example.com"
+	principal, err := a.Verify(signHS256(t, claims))
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", principal.Subject)
+
+	expired := validClaims("alice")
+	expired["iss"] = "https://issuer.example.com"
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	_, err = a.Verify(signHS256(t, expired))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = a.Verify(signHS256(t, validClaims("alice")))
+	assert.ErrorIs(t, err, ErrInvalidToken, "issuer is required")
+
+	noExpiry := jwt.MapClaims{"sub": "alice", "iss": "https://issuer.example.com"}
+	_, err = a.Verify(signHS256(t, noExpiry))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("wrong-secret"))
+	require.NoError(t, err)
+	_, err = a.Verify(forged)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestAuthenticator_JWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	a, err := NewAuthenticator(Config{JWKSFile: writeJWKS(t, rsaKey, ecKey), Audience: "product-service"})
+	require.NoError(t, err)
+
+	claims := validClaims("billing-job")
+	claims["aud"] = "product-service"
+
+	rsaToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	rsaToken.Header["kid"] = "rsa-1"
+	signed, err := rsaToken.SignedString(rsaKey)
+	require.NoError(t, err)
+	principal, err := a.Verify(signed)
+	assert.NoError(t, err)
+	assert.Equal(t, "billing-job", principal.Subject)
+
+	// No kid: the only EC key in the set is used.
+	signed, err = jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(ecKey)
+	require.NoError(t, err)
+	_, err = a.Verify(signed)
+	assert.NoError(t, err)
+
+	unknown := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	unknown.Header["kid"] = "rsa-2"
+	signed, err = unknown.SignedString(rsaKey)
+	require.NoError(t, err)
+	_, err = a.Verify(signed)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// HS256 is not accepted when only a JWKS is configured.
+	_, err = a.Verify(signHS256(t, claims))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	a, err := NewAuthenticator(Config{HMACSecret: testSecret})
+	require.NoError(t, err)
+
+	_, err = a.Authenticate(context.Background())
+	assert.ErrorIs(t, err, ErrMissingToken)
+
+	md := metadata.Pairs(MetadataKey, "Basic dXNlcjpwYXNz")
+	_, err = a.Authenticate(metadata.NewIncomingContext(context.Background(), md))
+	assert.ErrorIs(t, err, ErrMissingToken)
+
+	claims := validClaims("alice")
+	claims["roles"] = []string{"editor"}
+	claims["scope"] = "catalog.read webhooks.manage"
+	md = metadata.Pairs(MetadataKey, "Bearer "+signHS256(t, claims))
+	ctx, err := a.Authenticate(metadata.NewIncomingContext(context.Background(), md))
+	require.NoError(t, err)
+
+	principal, ok := PrincipalFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "alice", principal.Subject)
+	assert.Equal(t, []string{"editor"}, principal.Roles)
+	assert.Equal(t, []string{"catalog.read", "webhooks.manage"}, principal.Scopes)
+	assert.Equal(t, "alice", audit.ActorFromContext(ctx))
+}
+
+func TestAuthenticator_Tenant(t *testing.T) {
+	a, err := NewAuthenticator(Config{HMACSecret: testSecret})
+	require.NoError(t, err)
+
+	claims := validClaims("alice")
+	claims["tenant_id"] = "acme"
+	md := metadata.Pairs(MetadataKey, "Bearer "+signHS256(t, claims))
+	ctx, err := a.Authenticate(tenant.WithTenant(metadata.NewIncomingContext(context.Background(), md), "globex"))
+	require.NoError(t, err)
+
+	principal, _ := PrincipalFromContext(ctx)
+	assert.Equal(t, "acme", principal.Tenant)
+	assert.Equal(t, "acme", tenant.FromContext(ctx))
+
+	// Tokens without a tenant leave the requested tenant alone.
+	md = metadata.Pairs(MetadataKey, "Bearer "+signHS256(t, validClaims("ops")))
+	ctx, err = a.Authenticate(tenant.WithTenant(metadata.NewIncomingContext(context.Background(), md), "globex"))
+	require.NoError(t, err)
+	assert.Equal(t, "globex", tenant.FromContext(ctx))
+}
+
+type fakeAPIKeyVerifier map[string]*models.APIKey
+
+func (v fakeAPIKeyVerifier) VerifyAPIKey(ctx context.Context, key string) (*models.APIKey, error) {
+	if apiKey, ok := v[key]; ok {
+		return apiKey, nil
+	}
+	return nil, apperrors.ErrInvalidAPIKey
+}
+
+func TestAuthenticator_APIKey(t *testing.T) {
+	keyID := uuid.New()
+	a, err := NewAuthenticator(Config{APIKeys: fakeAPIKeyVerifier{
+		"psk_valid": {ID: keyID, Scopes: "catalog.read,catalog.write"},
+	}})
+	require.NoError(t, err)
+
+	md := metadata.Pairs(APIKeyMetadataKey, "psk_valid")
+	ctx, err := a.Authenticate(metadata.NewIncomingContext(context.Background(), md))
+	require.NoError(t, err)
+
+	principal, ok := PrincipalFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "api-key:"+keyID.String(), principal.Subject)
+	assert.Equal(t, []string{"catalog.read", "catalog.write"}, principal.Scopes)
+	assert.Equal(t, principal.Subject, audit.ActorFromContext(ctx))
+
+	md = metadata.Pairs(APIKeyMetadataKey, "psk_revoked")
+	_, err = a.Authenticate(metadata.NewIncomingContext(context.Background(), md))
+	assert.ErrorIs(t, err, apperrors.ErrInvalidAPIKey)
+
+	// Without JWT configuration bearer tokens are rejected.
+	md = metadata.Pairs(MetadataKey, "Bearer "+signHS256(t, validClaims("alice")))
+	_, err = a.Authenticate(metadata.NewIncomingContext(context.Background(), md))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestAuthenticator_ClientCertificate(t *testing.T) {
+	a, err := NewAuthenticator(Config{HMACSecret: testSecret, ClientCertificates: true})
+	require.NoError(t, err)
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "billing-job", OrganizationalUnit: []string{"editor"}}}
+	withCert := func(verified bool) context.Context {
+		state := tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+		if verified {
+			state.VerifiedChains = [][]*x509.Certificate{{cert}}
+		}
+		return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+	}
+
+	ctx, err := a.Authenticate(withCert(true))
+	require.NoError(t, err)
+	principal, _ := PrincipalFromContext(ctx)
+	assert.Equal(t, "cert:billing-job", principal.Subject)
+	assert.Equal(t, []string{"editor"}, principal.Roles)
+	assert.Equal(t, "cert:billing-job", audit.ActorFromContext(ctx))
+
+	_, err = a.Authenticate(withCert(false))
+	assert.ErrorIs(t, err, ErrMissingToken)
+
+	// A bearer token takes precedence over the certificate.
+	md := metadata.Pairs(MetadataKey, "Bearer "+signHS256(t, validClaims("alice")))
+	ctx, err = a.Authenticate(metadata.NewIncomingContext(withCert(true), md))
+	require.NoError(t, err)
+	principal, _ = PrincipalFromContext(ctx)
+	assert.Equal(t, "alice", principal.Subject)
+
+	a, err = NewAuthenticator(Config{HMACSecret: testSecret})
+	require.NoError(t, err)
+	_, err = a.Authenticate(withCert(true))
+	assert.ErrorIs(t, err, ErrMissingToken, "certificates are only accepted when enabled")
+}
+
+func TestNewAuthenticator_RequiresKey(t *testing.T) {
+	_, err := NewAuthenticator(Config{})
+	assert.Error(t, err)
+}
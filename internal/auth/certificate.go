@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// verifiedClientCert returns the caller's TLS client certificate if it was
+// verified against the server's client CA bundle.
+func verifiedClientCert(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.PeerCertificates) == 0 {
+		return nil
+	}
+	return info.State.PeerCertificates[0]
+}
+
+// certificatePrincipal maps a client certificate to a principal named after
+// its common name, with its organizational units as roles.
+func certificatePrincipal(cert *x509.Certificate) *Principal {
+	return &Principal{
+		Subject: "cert:" + cert.Subject.CommonName,
+		Roles:   cert.Subject.OrganizationalUnit,
+	}
+}
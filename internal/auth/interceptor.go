@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/microservice-go/product-service/internal/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor rejects calls to non-public methods that do not carry
+// a valid bearer token.
+func UnaryServerInterceptor(a *Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if a.IsPublic(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		ctx, err := a.Authenticate(ctx)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return handler(ctx, req)
+	}
+}
+
+func StreamServerInterceptor(a *Authenticator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if a.IsPublic(info.FullMethod) {
+			return handler(srv, stream)
+		}
+		ctx, err := a.Authenticate(stream.Context())
+		if err != nil {
+			return status.Error(codes.Unauthenticated, err.Error())
+		}
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// UnaryAuthorizationInterceptor rejects calls whose principal lacks the
+// permission the policy requires, or that ask for a tenant other than the
+// principal's. Principals without a tenant are confined to the default tenant
+// unless they hold PermissionAllTenants. It must run after authentication;
+// calls without a principal, such as public methods, are passed through.
+func UnaryAuthorizationInterceptor(policy *Policy) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := authorize(ctx, policy, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func StreamAuthorizationInterceptor(policy *Policy) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(stream.Context(), policy, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+func authorize(ctx context.Context, policy *Policy, fullMethod string) error {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	if requested := metadataValue(ctx, tenant.MetadataKey); requested != "" && !allowsTenant(policy, principal, requested) {
+		return status.Errorf(codes.PermissionDenied, "not allowed to act in tenant %q", requested)
+	}
+	permission := policy.RequiredPermission(fullMethod)
+	if permission == "" || policy.Allows(principal, permission) {
+		return nil
+	}
+	return status.Errorf(codes.PermissionDenied, "missing permission %q for %s", permission, fullMethod)
+}
+
+func allowsTenant(policy *Policy, principal *Principal, requested string) bool {
+	if principal.Tenant != "" {
+		return requested == principal.Tenant
+	}
+	return requested == tenant.Default || policy.Allows(principal, PermissionAllTenants)
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
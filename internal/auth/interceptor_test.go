@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	a, err := NewAuthenticator(Config{
+		HMACSecret:    testSecret,
+		PublicMethods: []string{"grpc.health.v1.Health", "/product.ProductService/GetProduct"},
+	})
+	require.NoError(t, err)
+	interceptor := UnaryServerInterceptor(a)
+
+	var subject string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		subject = ""
+		if principal, ok := PrincipalFromContext(ctx); ok {
+			subject = principal.Subject
+		}
+		return "ok", nil
+	}
+
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/product.ProductService/DeleteProduct"}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	for _, method := range []string{"/grpc.health.v1.Health/Check", "/product.ProductService/GetProduct"} {
+		resp, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		assert.NoError(t, err, method)
+		assert.Equal(t, "ok", resp)
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, "Bearer "+signHS256(t, validClaims("alice"))))
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/product.ProductService/DeleteProduct"}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", subject)
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	a, err := NewAuthenticator(Config{
+		HMACSecret:    testSecret,
+		PublicMethods: []string{"grpc.reflection.v1.ServerReflection"},
+	})
+	require.NoError(t, err)
+	interceptor := StreamServerInterceptor(a)
+
+	var subject string
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		subject = ""
+		if principal, ok := PrincipalFromContext(stream.Context()); ok {
+			subject = principal.Subject
+		}
+		return nil
+	}
+
+	stream := &fakeServerStream{ctx: context.Background()}
+	err = interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: "/product.ProductService/WatchProducts"}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	err = interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo"}, handler)
+	assert.NoError(t, err)
+
+	stream.ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, "Bearer "+signHS256(t, validClaims("bob"))))
+	err = interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: "/product.ProductService/WatchProducts"}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", subject)
+}
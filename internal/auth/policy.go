@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	PermissionCatalogRead  = "catalog.read"
+	PermissionCatalogWrite = "catalog.write"
+	PermissionCatalogAdmin = "catalog.admin"
+	// PermissionAllTenants lets a principal that is not bound to a tenant
+	// choose any tenant with the x-tenant-id header. No built-in role has it.
+	PermissionAllTenants = "tenant.all"
+)
+
+// Policy maps the roles carried in tokens to permissions, and RPCs to the
+// permission they require. Method keys are full method names
+// ("/pkg.Service/Method") or service names ("pkg.Service"); a method rule
+// takes precedence over its service's. Methods without a rule only require
+// authentication.
+type Policy struct {
+	Roles   map[string][]string `json:"roles"`
+	Methods map[string]string   `json:"methods"`
+}
+
+// DefaultPolicy lets viewers read the catalog, editors create and update it,
+// and admins also delete from it and manage webhooks, audit, schedules and
+// API keys.
+func DefaultPolicy() *Policy {
+	policy := &Policy{
+		Roles: map[string][]string{
+			"viewer": {PermissionCatalogRead},
+			"editor": {PermissionCatalogRead, PermissionCatalogWrite},
+			"admin":  {PermissionCatalogRead, PermissionCatalogWrite, PermissionCatalogAdmin},
+		},
+		Methods: map[string]string{
+			"webhook.WebhookService":          PermissionCatalogAdmin,
+			"audit.AuditService":              PermissionCatalogAdmin,
+			"schedule.ScheduledChangeService": PermissionCatalogAdmin,
+			"apikey.APIKeyService":            PermissionCatalogAdmin,
+		},
+	}
+
+	for service, methods := range map[string]map[string]string{
+		"product.ProductService": {
+			"GetProduct":          PermissionCatalogRead,
+			"ListProducts":        PermissionCatalogRead,
+			"BatchGetProducts":    PermissionCatalogRead,
+			"WatchProducts":       PermissionCatalogRead,
+			"CreateProduct":       PermissionCatalogWrite,
+			"UpdateProduct":       PermissionCatalogWrite,
+			"BatchCreateProducts": PermissionCatalogWrite,
+			"BatchUpdateProducts": PermissionCatalogWrite,
+			"DeleteProduct":       PermissionCatalogAdmin,
+			"BatchDeleteProducts": PermissionCatalogAdmin,
+		},
+		"subscription.SubscriptionService": {
+			"GetSubscriptionPlan":          PermissionCatalogRead,
+			"ListSubscriptionPlans":        PermissionCatalogRead,
+			"BatchGetSubscriptionPlans":    PermissionCatalogRead,
+			"WatchSubscriptionPlans":       PermissionCatalogRead,
+			"GetPriceSchedule":             PermissionCatalogRead,
+			"CalculatePrice":               PermissionCatalogRead,
+			"CreateSubscriptionPlan":       PermissionCatalogWrite,
+			"UpdateSubscriptionPlan":       PermissionCatalogWrite,
+			"BatchCreateSubscriptionPlans": PermissionCatalogWrite,
+			"BatchUpdateSubscriptionPlans": PermissionCatalogWrite,
+			"DeleteSubscriptionPlan":       PermissionCatalogAdmin,
+			"BatchDeleteSubscriptionPlans": PermissionCatalogAdmin,
+		},
+		"billing.BillingService": {
+			"GetSubscription":        PermissionCatalogRead,
+			"ListSubscriptions":      PermissionCatalogRead,
+			"PreviewPlanChange":      PermissionCatalogRead,
+			"Subscribe":              PermissionCatalogWrite,
+			"CancelSubscription":     PermissionCatalogWrite,
+			"ResumeSubscription":     PermissionCatalogWrite,
+			"ChangeSubscriptionPlan": PermissionCatalogWrite,
+		},
+		"coupon.CouponService": {
+			"GetCoupon":    PermissionCatalogRead,
+			"ListCoupons":  PermissionCatalogRead,
+			"QuotePrice":   PermissionCatalogRead,
+			"CreateCoupon": PermissionCatalogWrite,
+			"UpdateCoupon": PermissionCatalogWrite,
+			"DeleteCoupon": PermissionCatalogAdmin,
+		},
+		"usage.UsageService": {
+			"GetUsageSummary": PermissionCatalogRead,
+			"RecordUsage":     PermissionCatalogWrite,
+		},
+		"invoice.InvoiceService": {
+			"GetInvoice":      PermissionCatalogRead,
+			"ListInvoices":    PermissionCatalogRead,
+			"RenderInvoice":   PermissionCatalogRead,
+			"FinalizeInvoice": PermissionCatalogWrite,
+			"PayInvoice":      PermissionCatalogWrite,
+			"VoidInvoice":     PermissionCatalogWrite,
+		},
+	} {
+		for method, permission := range methods {
+			policy.Methods["/"+service+"/"+method] = permission
+		}
+	}
+
+	return policy
+}
+
+// LoadPolicy reads a policy from a JSON file.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("parse policy: %w", err)
+	}
+	return &policy, nil
+}
+
+// Defines reports whether permission means anything under the policy: a
+// role grants it, a method requires it, or it is PermissionAllTenants.
+func (p *Policy) Defines(permission string) bool {
+	if permission == PermissionAllTenants {
+		return true
+	}
+	for _, required := range p.Methods {
+		if required == permission {
+			return true
+		}
+	}
+	for _, granted := range p.Roles {
+		for _, g := range granted {
+			if g == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RequiredPermission returns the permission needed to call fullMethod, or ""
+// when any authenticated caller may call it.
+func (p *Policy) RequiredPermission(fullMethod string) string {
+	if permission, ok := p.Methods[fullMethod]; ok {
+		return permission
+	}
+	service := strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(service, "/"); i >= 0 {
+		service = service[:i]
+	}
+	return p.Methods[service]
+}
+
+// Allows reports whether the principal holds permission, either as a token
+// scope or through one of its roles.
+func (p *Policy) Allows(principal *Principal, permission string) bool {
+	for _, scope := range principal.Scopes {
+		if scope == permission {
+			return true
+		}
+	}
+	for _, role := range principal.Roles {
+		for _, granted := range p.Roles[role] {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/microservice-go/product-service/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestDefaultPolicy(t *testing.T) {
+	policy := DefaultPolicy()
+
+	assert.Equal(t, PermissionCatalogRead, policy.RequiredPermission("/product.ProductService/GetProduct"))
+	assert.Equal(t, PermissionCatalogWrite, policy.RequiredPermission("/subscription.SubscriptionService/UpdateSubscriptionPlan"))
+	assert.Equal(t, PermissionCatalogAdmin, policy.RequiredPermission("/product.ProductService/DeleteProduct"))
+	assert.Equal(t, PermissionCatalogAdmin, policy.RequiredPermission("/coupon.CouponService/DeleteCoupon"))
+	assert.Equal(t, PermissionCatalogAdmin, policy.RequiredPermission("/webhook.WebhookService/CreateWebhook"))
+	assert.Equal(t, "", policy.RequiredPermission("/grpc.health.v1.Health/Check"))
+
+	storefront := &Principal{Subject: "storefront", Roles: []string{"viewer"}}
+	assert.True(t, policy.Allows(storefront, PermissionCatalogRead))
+	assert.False(t, policy.Allows(storefront, PermissionCatalogAdmin))
+
+	job := &Principal{Subject: "importer", Scopes: []string{PermissionCatalogWrite}}
+	assert.True(t, policy.Allows(job, PermissionCatalogWrite))
+	assert.False(t, policy.Allows(job, PermissionCatalogRead))
+
+	assert.True(t, policy.Defines(PermissionCatalogAdmin))
+	assert.True(t, policy.Defines(PermissionAllTenants))
+	assert.False(t, policy.Defines("catalog.everything"))
+}
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"roles": {"pricing": ["catalog.read", "pricing.write"]},
+		"methods": {
+			"product.ProductService": "catalog.read",
+			"/product.ProductService/UpdateProduct": "pricing.write"
+		}
+	}`), 0o600))
+
+	policy, err := LoadPolicy(path)
+	require.NoError(t, err)
+	assert.Equal(t, "catalog.read", policy.RequiredPermission("/product.ProductService/DeleteProduct"))
+	assert.Equal(t, "pricing.write", policy.RequiredPermission("/product.ProductService/UpdateProduct"))
+	assert.True(t, policy.Allows(&Principal{Roles: []string{"pricing"}}, "pricing.write"))
+	assert.True(t, policy.Defines("pricing.write"))
+	assert.False(t, policy.Defines(PermissionCatalogAdmin))
+
+	_, err = LoadPolicy(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestUnaryAuthorizationInterceptor(t *testing.T) {
+	interceptor := UnaryAuthorizationInterceptor(DefaultPolicy())
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	deleteInfo := &grpc.UnaryServerInfo{FullMethod: "/product.ProductService/DeleteProduct"}
+
+	storefront := WithPrincipal(context.Background(), &Principal{Subject: "storefront", Roles: []string{"viewer"}})
+	_, err := interceptor(storefront, nil, deleteInfo, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), `"catalog.admin"`)
+
+	resp, err := interceptor(storefront, nil, &grpc.UnaryServerInfo{FullMethod: "/product.ProductService/ListProducts"}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+
+	admin := WithPrincipal(context.Background(), &Principal{Subject: "alice", Roles: []string{"admin"}})
+	_, err = interceptor(admin, nil, deleteInfo, handler)
+	assert.NoError(t, err)
+
+	// Public methods carry no principal and are not checked.
+	_, err = interceptor(context.Background(), nil, deleteInfo, handler)
+	assert.NoError(t, err)
+}
+
+func TestUnaryAuthorizationInterceptor_Tenant(t *testing.T) {
+	interceptor := UnaryAuthorizationInterceptor(DefaultPolicy())
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/product.ProductService/ListProducts"}
+	incoming := func(tenantID string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs(tenant.MetadataKey, tenantID))
+	}
+
+	acme := &Principal{Subject: "alice", Tenant: "acme", Roles: []string{"viewer"}}
+	_, err := interceptor(WithPrincipal(incoming("globex"), acme), nil, info, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = interceptor(WithPrincipal(incoming("acme"), acme), nil, info, handler)
+	assert.NoError(t, err)
+
+	// Tokens and certificates without a tenant cannot pick one by header.
+	tenantless := &Principal{Subject: "ops", Roles: []string{"admin"}}
+	_, err = interceptor(WithPrincipal(incoming("globex"), tenantless), nil, info, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = interceptor(WithPrincipal(incoming(tenant.Default), tenantless), nil, info, handler)
+	assert.NoError(t, err)
+
+	operator := &Principal{Subject: "ops", Roles: []string{"viewer"}, Scopes: []string{PermissionAllTenants}}
+	_, err = interceptor(WithPrincipal(incoming("globex"), operator), nil, info, handler)
+	assert.NoError(t, err)
+}
+
+func TestStreamAuthorizationInterceptor(t *testing.T) {
+	interceptor := StreamAuthorizationInterceptor(DefaultPolicy())
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	}
+	info := &grpc.StreamServerInfo{FullMethod: "/subscription.SubscriptionService/WatchSubscriptionPlans"}
+
+	stream := &fakeServerStream{ctx: WithPrincipal(context.Background(), &Principal{Subject: "importer", Scopes: []string{PermissionCatalogWrite}})}
+	err := interceptor(nil, stream, info, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	stream.ctx = WithPrincipal(context.Background(), &Principal{Subject: "storefront", Scopes: []string{PermissionCatalogRead}})
+	assert.NoError(t, interceptor(nil, stream, info, handler))
+}
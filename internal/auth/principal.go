@@ -0,0 +1,29 @@
+package auth
+
+import "context"
+
+// Principal is the authenticated caller of a request. Roles come from the
+// "roles" claim, Scopes from the space-separated "scope" claim and Tenant from
+// the "tenant_id" claim. A principal without a tenant acts in the default
+// tenant unless the policy lets it choose any.
+type Principal struct {
+	Subject string
+	Tenant  string
+	Roles   []string
+	Scopes  []string
+	Claims  map[string]interface{}
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(2)
+
+	c.Set(ctx, "a", []byte("1"), time.Minute)
+	c.Set(ctx, "b", []byte("2"), time.Minute)
+	_, ok := c.Get(ctx, "a")
+	assert.True(t, ok)
+
+	c.Set(ctx, "c", []byte("3"), time.Minute)
+	_, ok = c.Get(ctx, "b")
+	assert.False(t, ok, "b was the least recently used")
+	value, ok := c.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+	assert.Equal(t, 2, c.Len())
+
+	c.Set(ctx, "a", []byte("4"), time.Minute)
+	value, _ = c.Get(ctx, "a")
+	assert.Equal(t, []byte("4"), value)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestLRU_TTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewLRU(10)
+	c.now = func() time.Time { return now }
+
+	c.Set(ctx, "a", []byte("1"), time.Second)
+	_, ok := c.Get(ctx, "a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	_, ok = c.Get(ctx, "a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestLRU_Delete(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(10)
+	c.Set(ctx, "a", []byte("1"), time.Minute)
+	c.Set(ctx, "b", []byte("2"), time.Minute)
+
+	c.Delete(ctx, "a", "missing")
+	_, ok := c.Get(ctx, "a")
+	assert.False(t, ok)
+	_, ok = c.Get(ctx, "b")
+	assert.True(t, ok)
+}
+
+func TestLRU_Disabled(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(0)
+	c.Set(ctx, "a", []byte("1"), time.Minute)
+	_, ok := c.Get(ctx, "a")
+	assert.False(t, ok)
+}
@@ -14,6 +14,18 @@ const (
 	DefaultDBUser       = "postgres"
 	DefaultDBPassword   = "postgres"
 	DefaultDBSSLMode    = "disable"
+	DefaultMaxBatchSize = 100
+	DefaultEventBuffer  = 1024
+	DefaultOutboxBatch  = 100
+
+	DefaultAuthPublicMethods = "grpc.health.v1.Health,grpc.reflection.v1.ServerReflection,grpc.reflection.v1alpha.ServerReflection"
+
+	DefaultRateLimitExemptMethods = "grpc.health.v1.Health"
+
+	DefaultCacheSize       = 10000
+	DefaultCacheTTLSeconds = 30
+
+	DefaultCurrency = "USD"
 )
 
 const (
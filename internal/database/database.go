@@ -1,6 +1,8 @@
 package database
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"time"
@@ -26,6 +28,9 @@ type Config struct {
 	Password string
 	DBName   string
 	SSLMode  string
+	// ReplicaDSNs are PostgreSQL connection strings of read replicas. Reads
+	// are only sent to them for contexts from WithReplicaReads.
+	ReplicaDSNs []string
 }
 
 func NewDatabase(config Config) (*gorm.DB, error) {
@@ -64,12 +69,46 @@ func NewDatabase(config Config) (*gorm.DB, error) {
 		sqlDB.SetMaxOpenConns(maxOpenConns)
 		sqlDB.SetMaxIdleConns(maxIdleConns)
 		sqlDB.SetConnMaxLifetime(connMaxLifetime)
+
+		if len(config.ReplicaDSNs) > 0 {
+			if err := useReplicas(db, config.ReplicaDSNs, gormConfig); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	log.Printf("Database connection established (driver: %s)", config.Driver)
 	return db, nil
 }
 
+// useReplicas opens the replicas and routes reads to them. Replicas that are
+// down at startup are skipped until a health check finds them up.
+func useReplicas(db *gorm.DB, dsns []string, gormConfig *gorm.Config) error {
+	pools := make([]*sql.DB, 0, len(dsns))
+	for i, dsn := range dsns {
+		replica, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormConfig.Logger, DisableAutomaticPing: true})
+		if err != nil {
+			return apperrors.NewDatabaseError(fmt.Sprintf("replica %d connection", i+1), err)
+		}
+		pool, err := replica.DB()
+		if err != nil {
+			return apperrors.NewDatabaseError("pool configuration", err)
+		}
+		pool.SetMaxOpenConns(maxOpenConns)
+		pool.SetMaxIdleConns(maxIdleConns)
+		pool.SetConnMaxLifetime(connMaxLifetime)
+		pools = append(pools, pool)
+	}
+
+	router := newReplicaRouter(pools...)
+	router.check(context.Background())
+	if err := db.Use(router); err != nil {
+		return apperrors.NewDatabaseError("replica routing", err)
+	}
+	log.Printf("Read replicas configured: %d", len(pools))
+	return nil
+}
+
 func RunMigrations(db *gorm.DB) error {
 	if db == nil {
 		return apperrors.NewValidationError("db", "database connection is nil")
@@ -80,16 +119,80 @@ func RunMigrations(db *gorm.DB) error {
 	err := db.AutoMigrate(
 		&models.Product{},
 		&models.SubscriptionPlan{},
+		&models.OutboxEvent{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.AuditEvent{},
+		&models.ProductVersion{},
+		&models.SubscriptionPlanVersion{},
+		&models.ScheduledChange{},
+		&models.APIKey{},
+		&models.Subscription{},
+		&models.Coupon{},
+		&models.UsageRecord{},
+		&models.Invoice{},
+		&models.InvoiceSequence{},
 	)
 
 	if err != nil {
 		return apperrors.NewDatabaseError("migration", err)
 	}
 
+	if err := migratePlanIntervals(db); err != nil {
+		return apperrors.NewDatabaseError("migration", err)
+	}
+
+	if err := backfillVersions(db); err != nil {
+		return apperrors.NewDatabaseError("migration", err)
+	}
+
 	log.Println("Database migrations completed successfully")
 	return nil
 }
 
+// migratePlanIntervals sets the billing interval of plans, and plan versions,
+// saved when plans only had a duration in days.
+func migratePlanIntervals(db *gorm.DB) error {
+	for _, table := range []string{"subscription_plans", "subscription_plan_versions"} {
+		var durations []int
+		err := db.Table(table).Where("interval_unit = ''").Distinct().Pluck("duration", &durations).Error
+		if err != nil {
+			return err
+		}
+		for _, duration := range durations {
+			interval := models.IntervalFromDays(duration)
+			err := db.Table(table).Where("interval_unit = '' AND duration = ?", duration).
+				Updates(map[string]interface{}{"interval_unit": interval.Unit, "interval_count": interval.Count}).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// backfillVersions seeds a current version for rows that predate version
+// history. Their earlier states are unknown, so history starts at the last
+// update.
+func backfillVersions(db *gorm.DB) error {
+	err := db.Exec(`INSERT INTO product_versions
+		(tenant_id, product_id, name, description, price, product_type, product_created_at, valid_from)
+		SELECT p.tenant_id, p.id, p.name, p.description, p.price, p.product_type, p.created_at, p.updated_at
+		FROM products p
+		WHERE p.deleted_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM product_versions v WHERE v.product_id = p.id)`).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Exec(`INSERT INTO subscription_plan_versions
+		(tenant_id, plan_id, product_id, plan_name, interval_unit, interval_count, duration, price, plan_created_at, valid_from)
+		SELECT s.tenant_id, s.id, s.product_id, s.plan_name, s.interval_unit, s.interval_count, s.duration, s.price, s.created_at, s.updated_at
+		FROM subscription_plans s
+		WHERE s.deleted_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM subscription_plan_versions v WHERE v.plan_id = s.id)`).Error
+}
+
 func validatePostgresConfig(config Config) error {
 	if config.Host == "" {
 		return apperrors.NewValidationError("host", "host is required for PostgreSQL")
@@ -0,0 +1,53 @@
+//go:build cgo
+// +build cgo
+
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestRunMigrations_PlanIntervals(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "products.db")), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, RunMigrations(db))
+
+	product := models.Product{Name: "Streaming", Price: 10, ProductType: "digital"}
+	require.NoError(t, db.Create(&product).Error)
+	durations := map[int]models.BillingInterval{
+		30:  {Unit: models.IntervalMonth, Count: 1},
+		365: {Unit: models.IntervalYear, Count: 1},
+		14:  {Unit: models.IntervalWeek, Count: 2},
+		45:  {Unit: models.IntervalDay, Count: 45},
+	}
+	ids := make(map[int]uuid.UUID)
+	for duration := range durations {
+		plan := models.SubscriptionPlan{ProductID: product.ID, PlanName: "Plan", Duration: duration, Price: 5}
+		require.NoError(t, db.Create(&plan).Error)
+		ids[duration] = plan.ID
+	}
+	// Rows saved before intervals existed have none.
+	require.NoError(t, db.Exec("UPDATE subscription_plans SET interval_unit = '', interval_count = 0").Error)
+
+	require.NoError(t, RunMigrations(db))
+
+	for duration, want := range durations {
+		var plan models.SubscriptionPlan
+		require.NoError(t, db.First(&plan, "id = ?", ids[duration]).Error)
+		assert.Equal(t, want, plan.Interval, "%d days", duration)
+		assert.Equal(t, duration, plan.Duration)
+
+		var version models.SubscriptionPlanVersion
+		require.NoError(t, db.First(&version, "plan_id = ?", ids[duration]).Error)
+		assert.Equal(t, want, version.Interval, "version of %d days", duration)
+	}
+}
@@ -0,0 +1,29 @@
+package database
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// UnaryServerInterceptor lets each call read from replicas until it writes.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(WithReplicaReads(ctx), req)
+	}
+}
+
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &routedStream{ServerStream: stream, ctx: WithReplicaReads(stream.Context())})
+	}
+}
+
+type routedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *routedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,222 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	gormcallbacks "gorm.io/gorm/callbacks"
+)
+
+// DefaultReplicaCheckInterval is how often WatchReplicas pings the replicas
+// when no interval is given.
+const DefaultReplicaCheckInterval = 5 * time.Second
+
+const (
+	replicaRouterName  = "replica_router"
+	replicaPingTimeout = 2 * time.Second
+)
+
+type routingKey struct{}
+
+type routing struct {
+	primary atomic.Bool
+}
+
+// WithReplicaReads allows reads made with ctx to be served by a replica. Once
+// a write runs with ctx, its later reads go to the primary, so a request
+// always reads its own writes.
+func WithReplicaReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, routingKey{}, &routing{})
+}
+
+// WithPrimary sends every read made with ctx to the primary.
+func WithPrimary(ctx context.Context) context.Context {
+	r := &routing{}
+	r.primary.Store(true)
+	return context.WithValue(ctx, routingKey{}, r)
+}
+
+func routingOf(db *gorm.DB) *routing {
+	if db.Statement.Context == nil {
+		return nil
+	}
+	r, _ := db.Statement.Context.Value(routingKey{}).(*routing)
+	return r
+}
+
+type replica struct {
+	name    string
+	pool    *sql.DB
+	healthy atomic.Bool
+}
+
+// replicaRouter is a GORM plugin sending reads made outside transactions to a
+// healthy replica, falling back to the primary when none is. Reads only go to
+// replicas when their context allows it, so background workers, which act on
+// what they read, always see the primary.
+type replicaRouter struct {
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+// newReplicaRouter returns a router whose replicas stay unused until a health
+// check has passed.
+func newReplicaRouter(pools ...*sql.DB) *replicaRouter {
+	r := &replicaRouter{}
+	for i, pool := range pools {
+		r.replicas = append(r.replicas, &replica{name: fmt.Sprintf("replica %d", i+1), pool: pool})
+	}
+	return r
+}
+
+func (r *replicaRouter) Name() string {
+	return replicaRouterName
+}
+
+func (r *replicaRouter) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Query().Before("gorm:query").Register("replica_router:read", r.read); err != nil {
+		return err
+	}
+	err := callbacks.Query().After("gorm:query").Before("gorm:preload").
+		Register("replica_router:read_error", r.readError(gormcallbacks.Query))
+	if err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("replica_router:read", r.read); err != nil {
+		return err
+	}
+	if err := callbacks.Row().After("gorm:row").Register("replica_router:read_error", r.readError(gormcallbacks.RowQuery)); err != nil {
+		return err
+	}
+	if err := callbacks.Create().Before("gorm:create").Register("replica_router:write", r.write); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("replica_router:write", r.write); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("replica_router:write", r.write); err != nil {
+		return err
+	}
+	return callbacks.Raw().Before("gorm:raw").Register("replica_router:write", r.write)
+}
+
+func (r *replicaRouter) read(db *gorm.DB) {
+	routing := routingOf(db)
+	if routing == nil || routing.primary.Load() {
+		return
+	}
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok {
+		return
+	}
+	// SELECT ... FOR UPDATE takes locks, which only mean something on the
+	// primary.
+	if _, ok := db.Statement.Clauses["FOR"]; ok {
+		return
+	}
+	if replica := r.pick(); replica != nil {
+		db.Statement.ConnPool = replica.pool
+	}
+}
+
+// readError returns a callback that takes a replica out of rotation as soon
+// as its connection fails, rather than waiting for the next health check, and
+// runs the failed read again on the primary with query.
+func (r *replicaRouter) readError(query func(*gorm.DB)) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error == nil || !isConnectionError(db.Error) {
+			return
+		}
+		for _, replica := range r.replicas {
+			if db.Statement.ConnPool != gorm.ConnPool(replica.pool) {
+				continue
+			}
+			if replica.healthy.CompareAndSwap(true, false) {
+				log.Printf("✗ Read %s is unreachable, reading from the primary: %v", replica.name, db.Error)
+			}
+			db.Error = nil
+			db.Statement.ConnPool = db.Config.ConnPool
+			query(db)
+			return
+		}
+	}
+}
+
+func (r *replicaRouter) write(db *gorm.DB) {
+	if routing := routingOf(db); routing != nil {
+		routing.primary.Store(true)
+	}
+	// A statement that read before writing may still point at a replica.
+	for _, replica := range r.replicas {
+		if db.Statement.ConnPool == gorm.ConnPool(replica.pool) {
+			db.Statement.ConnPool = db.Config.ConnPool
+		}
+	}
+}
+
+// pick returns the next healthy replica in turn, or nil if there is none.
+func (r *replicaRouter) pick() *replica {
+	n := uint64(len(r.replicas))
+	start := r.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		replica := r.replicas[(start+i)%n]
+		if replica.healthy.Load() {
+			return replica
+		}
+	}
+	return nil
+}
+
+// check pings every replica and updates its health, logging changes.
+func (r *replicaRouter) check(ctx context.Context) {
+	for _, replica := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+		err := replica.pool.PingContext(pingCtx)
+		cancel()
+
+		healthy := err == nil
+		if replica.healthy.Swap(healthy) == healthy {
+			continue
+		}
+		if healthy {
+			log.Printf("✓ Read %s is healthy", replica.name)
+		} else {
+			log.Printf("✗ Read %s failed its health check, reading from the primary: %v", replica.name, err)
+		}
+	}
+}
+
+func isConnectionError(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.As(err, &netErr)
+}
+
+// WatchReplicas checks the health of the read replicas of db every interval
+// until ctx is done. It returns at once when db has no replicas.
+func WatchReplicas(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	router, ok := db.Config.Plugins[replicaRouterName].(*replicaRouter)
+	if !ok {
+		return
+	}
+	if interval <= 0 {
+		interval = DefaultReplicaCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			router.check(ctx)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+//go:build cgo
+// +build cgo
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type item struct {
+	ID   uint
+	Name string
+}
+
+func openTestDB(t *testing.T, name string) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), name)), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&item{}))
+	return db
+}
+
+// setupReplica returns a primary holding "primary" and a replica holding
+// "replica", so each read shows where it was served from.
+func setupReplica(t *testing.T) (*gorm.DB, *replicaRouter, *sql.DB) {
+	primary := openTestDB(t, "primary.db")
+	replicaDB := openTestDB(t, "replica.db")
+	require.NoError(t, primary.Create(&item{ID: 1, Name: "primary"}).Error)
+	require.NoError(t, replicaDB.Create(&item{ID: 1, Name: "replica"}).Error)
+
+	pool, err := replicaDB.DB()
+	require.NoError(t, err)
+	router := newReplicaRouter(pool)
+	router.check(context.Background())
+	require.NoError(t, primary.Use(router))
+	return primary, router, pool
+}
+
+func readFrom(t *testing.T, db *gorm.DB, ctx context.Context) string {
+	t.Helper()
+	var it item
+	require.NoError(t, db.WithContext(ctx).First(&it, 1).Error)
+	return it.Name
+}
+
+func TestReplicaRouter_Routing(t *testing.T) {
+	db, _, _ := setupReplica(t)
+
+	assert.Equal(t, "primary", readFrom(t, db, context.Background()), "reads without opting in stay on the primary")
+	assert.Equal(t, "primary", readFrom(t, db, WithPrimary(context.Background())))
+
+	ctx := WithReplicaReads(context.Background())
+	assert.Equal(t, "replica", readFrom(t, db, ctx))
+
+	var count int64
+	require.NoError(t, db.WithContext(ctx).Model(&item{}).Where("name = ?", "replica").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var it item
+		require.NoError(t, tx.First(&it, 1).Error)
+		assert.Equal(t, "primary", it.Name, "transactions stay on the primary")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "replica", readFrom(t, db, ctx), "reading in a transaction does not pin the request")
+
+	require.NoError(t, db.WithContext(ctx).Create(&item{ID: 2, Name: "written"}).Error)
+	assert.Equal(t, "primary", readFrom(t, db, ctx), "reads after a write go to the primary")
+
+	var written item
+	require.NoError(t, db.WithContext(ctx).First(&written, 2).Error)
+	assert.Equal(t, "written", written.Name)
+
+	assert.Equal(t, "replica", readFrom(t, db, WithReplicaReads(context.Background())), "other requests are unaffected")
+}
+
+func TestReplicaRouter_FallsBackWhenReplicaIsDown(t *testing.T) {
+	db, router, pool := setupReplica(t)
+	ctx := WithReplicaReads(context.Background())
+	assert.Equal(t, "replica", readFrom(t, db, ctx))
+
+	require.NoError(t, pool.Close())
+	router.check(context.Background())
+	assert.Equal(t, "primary", readFrom(t, db, ctx))
+}
+
+// unreachable is a connector whose connections always fail, like a replica
+// that went away between health checks.
+type unreachable struct{}
+
+func (unreachable) Connect(context.Context) (driver.Conn, error) { return nil, driver.ErrBadConn }
+func (c unreachable) Driver() driver.Driver                      { return c }
+func (unreachable) Open(string) (driver.Conn, error)             { return nil, driver.ErrBadConn }
+
+func TestReplicaRouter_RetriesFailedReadsOnThePrimary(t *testing.T) {
+	primary := openTestDB(t, "primary.db")
+	require.NoError(t, primary.Create(&item{ID: 1, Name: "primary"}).Error)
+	pool := sql.OpenDB(unreachable{})
+	defer pool.Close()
+
+	router := newReplicaRouter(pool)
+	router.replicas[0].healthy.Store(true)
+	require.NoError(t, primary.Use(router))
+
+	ctx := WithReplicaReads(context.Background())
+	assert.Equal(t, "primary", readFrom(t, primary, ctx))
+	assert.False(t, router.replicas[0].healthy.Load(), "the replica leaves the rotation")
+
+	router.replicas[0].healthy.Store(true)
+	var items []item
+	require.NoError(t, primary.WithContext(ctx).Find(&items).Error)
+	assert.Len(t, items, 1)
+}
+
+func TestReplicaRouter_UnusedUntilHealthy(t *testing.T) {
+	primary := openTestDB(t, "primary.db")
+	require.NoError(t, primary.Create(&item{ID: 1, Name: "primary"}).Error)
+	replicaDB := openTestDB(t, "replica.db")
+	require.NoError(t, replicaDB.Create(&item{ID: 1, Name: "replica"}).Error)
+	pool, err := replicaDB.DB()
+	require.NoError(t, err)
+
+	router := newReplicaRouter(pool)
+	require.NoError(t, primary.Use(router))
+	ctx := WithReplicaReads(context.Background())
+	assert.Equal(t, "primary", readFrom(t, primary, ctx))
+
+	watchCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go WatchReplicas(watchCtx, primary, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return readFrom(t, primary, ctx) == "replica"
+	}, time.Second, 10*time.Millisecond)
+}
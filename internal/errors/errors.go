@@ -17,6 +17,16 @@ var (
 	ErrDatabaseOperation = errors.New("database operation failed")
 	ErrMigration         = errors.New("migration failed")
 	ErrConnection        = errors.New("connection failed")
+
+	ErrSequenceExpired  = errors.New("resume sequence is no longer available")
+	ErrSubscriberLagged = errors.New("subscriber fell behind the change feed")
+
+	ErrInvalidAPIKey = errors.New("invalid or revoked API key")
+	ErrScopeNotHeld  = errors.New("cannot grant a scope the caller does not hold")
+
+	ErrCouponExhausted = errors.New("coupon has reached its maximum redemptions")
+
+	ErrSubscriptionChanged = errors.New("subscription was changed by another request")
 )
 
 type ValidationError struct {
@@ -71,6 +81,26 @@ func NewDatabaseError(operation string, err error) error {
 	}
 }
 
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("batch item %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+func NewBatchItemError(index int, err error) error {
+	return &BatchItemError{
+		Index: index,
+		Err:   err,
+	}
+}
+
 func IsValidationError(err error) bool {
 	var validationErr *ValidationError
 	return errors.As(err, &validationErr)
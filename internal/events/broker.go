@@ -0,0 +1,204 @@
+package events
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+)
+
+// maxPending bounds how far a single subscriber may fall behind before it is
+// disconnected and has to resume from its last sequence.
+const maxPending = 1024
+
+// Broker fans published events out to live subscribers and keeps the most
+// recent events in a ring buffer, ordered by sequence, so reconnecting clients
+// can resume.
+type Broker struct {
+	mu          sync.Mutex
+	sequence    uint64
+	buffer      []Event
+	start       int
+	count       int
+	subscribers map[*Subscription]struct{}
+}
+
+func NewBroker(capacity int) *Broker {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Broker{
+		buffer:      make([]Event, capacity),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish records event and delivers it to every matching subscriber. Events
+// without a sequence number are assigned the next one; events that are
+// already buffered are ignored so redelivery from the outbox is harmless.
+// Events the outbox retried after later ones are buffered in sequence order.
+func (b *Broker) Publish(ctx context.Context, event Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if event.Sequence == 0 {
+		event.Sequence = b.sequence + 1
+	}
+	i := b.search(event.Sequence)
+	if i < b.count && b.at(i).Sequence == event.Sequence {
+		return nil
+	}
+	if event.Sequence > b.sequence {
+		b.sequence = event.Sequence
+	}
+	b.insert(i, event)
+
+	for sub := range b.subscribers {
+		if sub.filter.Matches(event) {
+			sub.push(event)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe registers a subscriber for events matching filter. When
+// fromSequence is non-zero, buffered events after that sequence are replayed
+// first; if some of them have already been evicted ErrSequenceExpired is
+// returned so the caller can fall back to a full listing.
+func (b *Broker) Subscribe(fromSequence uint64, filter Filter) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &Subscription{
+		broker: b,
+		filter: filter,
+		notify: make(chan struct{}, 1),
+	}
+
+	if fromSequence > 0 {
+		if fromSequence > b.sequence {
+			return nil, apperrors.NewValidationError("resumeFromSequence", "sequence is ahead of the change feed")
+		}
+		if fromSequence < b.oldestSequence()-1 {
+			return nil, apperrors.ErrSequenceExpired
+		}
+		for i := b.search(fromSequence + 1); i < b.count; i++ {
+			if event := b.at(i); filter.Matches(event) {
+				sub.pending = append(sub.pending, event)
+			}
+		}
+	}
+
+	b.subscribers[sub] = struct{}{}
+	return sub, nil
+}
+
+// LastSequence returns the sequence number of the most recently published event.
+func (b *Broker) LastSequence() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sequence
+}
+
+func (b *Broker) unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subscribers, sub)
+}
+
+// oldestSequence returns the sequence of the oldest buffered event. Sequences
+// come from the outbox, so after a restart the buffer starts wherever the
+// outbox was rather than at 1.
+func (b *Broker) oldestSequence() uint64 {
+	if b.count == 0 {
+		return b.sequence + 1
+	}
+	return b.at(0).Sequence
+}
+
+// at returns the ith oldest buffered event.
+func (b *Broker) at(i int) Event {
+	return b.buffer[(b.start+i)%len(b.buffer)]
+}
+
+// search returns the index of the oldest buffered event with a sequence of at
+// least sequence, or the number of buffered events if there is none.
+func (b *Broker) search(sequence uint64) int {
+	return sort.Search(b.count, func(i int) bool {
+		return b.at(i).Sequence >= sequence
+	})
+}
+
+// insert buffers event at index i, evicting the oldest event when the buffer
+// is full. An event older than everything in a full buffer is not kept.
+func (b *Broker) insert(i int, event Event) {
+	size := len(b.buffer)
+	if b.count == size {
+		if i == 0 {
+			return
+		}
+		b.start = (b.start + 1) % size
+		b.count--
+		i--
+	}
+	for j := b.count; j > i; j-- {
+		b.buffer[(b.start+j)%size] = b.buffer[(b.start+j-1)%size]
+	}
+	b.buffer[(b.start+i)%size] = event
+	b.count++
+}
+
+type Subscription struct {
+	broker  *Broker
+	filter  Filter
+	notify  chan struct{}
+	mu      sync.Mutex
+	pending []Event
+	lagged  bool
+}
+
+// Next blocks until the next matching event is available or ctx is done.
+func (s *Subscription) Next(ctx context.Context) (Event, error) {
+	for {
+		s.mu.Lock()
+		if s.lagged {
+			s.mu.Unlock()
+			return Event{}, apperrors.ErrSubscriberLagged
+		}
+		if len(s.pending) > 0 {
+			event := s.pending[0]
+			s.pending = s.pending[1:]
+			s.mu.Unlock()
+			return event, nil
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return Event{}, ctx.Err()
+		case <-s.notify:
+		}
+	}
+}
+
+func (s *Subscription) Close() {
+	s.broker.unsubscribe(s)
+}
+
+func (s *Subscription) push(event Event) {
+	s.mu.Lock()
+	if len(s.pending) >= maxPending {
+		s.lagged = true
+		s.pending = nil
+	} else if !s.lagged {
+		s.pending = append(s.pending, event)
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
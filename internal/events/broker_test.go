@@ -0,0 +1,173 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nextEvent(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	event, err := sub.Next(ctx)
+	require.NoError(t, err)
+	return event
+}
+
+func publish(t *testing.T, broker *Broker, event Event) {
+	t.Helper()
+	require.NoError(t, broker.Publish(context.Background(), event))
+}
+
+func TestBroker_PublishAssignsSequence(t *testing.T) {
+	broker := NewBroker(10)
+
+	publish(t, broker, NewProductEvent(TypeCreated, &models.Product{ID: uuid.New()}))
+	assert.Equal(t, uint64(1), broker.LastSequence())
+
+	publish(t, broker, NewProductEvent(TypeUpdated, &models.Product{ID: uuid.New()}))
+	assert.Equal(t, uint64(2), broker.LastSequence())
+}
+
+func TestBroker_PublishKeepsOutboxSequenceAndDropsDuplicates(t *testing.T) {
+	broker := NewBroker(10)
+	sub, err := broker.Subscribe(0, Filter{})
+	require.NoError(t, err)
+	defer sub.Close()
+
+	event := NewProductEvent(TypeCreated, &models.Product{ID: uuid.New()})
+	event.Sequence = 7
+	publish(t, broker, event)
+	publish(t, broker, event)
+	next := NewProductEvent(TypeUpdated, &models.Product{ID: uuid.New()})
+	next.Sequence = 8
+	publish(t, broker, next)
+
+	assert.Equal(t, uint64(7), nextEvent(t, sub).Sequence)
+	assert.Equal(t, uint64(8), nextEvent(t, sub).Sequence)
+	assert.Equal(t, uint64(8), broker.LastSequence())
+}
+
+func TestBroker_SubscribeFiltersLiveEvents(t *testing.T) {
+	broker := NewBroker(10)
+	sub, err := broker.Subscribe(0, Filter{Aggregate: AggregateProduct, ProductType: "digital"})
+	require.NoError(t, err)
+	defer sub.Close()
+
+	publish(t, broker, NewProductEvent(TypeCreated, &models.Product{ID: uuid.New(), ProductType: "physical"}))
+	publish(t, broker, NewPlanEvent(TypeCreated, &models.SubscriptionPlan{ID: uuid.New(), Product: models.Product{ProductType: "digital"}}))
+	publish(t, broker, NewProductEvent(TypeCreated, &models.Product{ID: uuid.New(), ProductType: "digital"}))
+
+	event := nextEvent(t, sub)
+	assert.Equal(t, uint64(3), event.Sequence)
+	assert.Equal(t, "digital", event.Product.ProductType)
+}
+
+func TestBroker_ResumeReplaysMissedEvents(t *testing.T) {
+	broker := NewBroker(10)
+	productID := uuid.New()
+	publish(t, broker, NewProductEvent(TypeCreated, &models.Product{ID: productID}))
+	publish(t, broker, NewProductEvent(TypeUpdated, &models.Product{ID: productID}))
+	publish(t, broker, NewProductEvent(TypeDeleted, &models.Product{ID: productID}))
+
+	sub, err := broker.Subscribe(1, Filter{ProductID: productID})
+	require.NoError(t, err)
+	defer sub.Close()
+
+	assert.Equal(t, TypeUpdated, nextEvent(t, sub).Type)
+	assert.Equal(t, TypeDeleted, nextEvent(t, sub).Type)
+}
+
+func TestBroker_ResumeFromEvictedSequence(t *testing.T) {
+	broker := NewBroker(2)
+	for i := 0; i < 5; i++ {
+		publish(t, broker, NewProductEvent(TypeCreated, &models.Product{ID: uuid.New()}))
+	}
+
+	_, err := broker.Subscribe(1, Filter{})
+	assert.ErrorIs(t, err, apperrors.ErrSequenceExpired)
+
+	sub, err := broker.Subscribe(3, Filter{})
+	require.NoError(t, err)
+	defer sub.Close()
+	assert.Equal(t, uint64(4), nextEvent(t, sub).Sequence)
+	assert.Equal(t, uint64(5), nextEvent(t, sub).Sequence)
+}
+
+func TestBroker_ResumeAfterRestart(t *testing.T) {
+	// A restarted broker only holds what the outbox delivered since.
+	broker := NewBroker(10)
+	event := NewProductEvent(TypeCreated, &models.Product{ID: uuid.New()})
+	event.Sequence = 1000
+	publish(t, broker, event)
+
+	_, err := broker.Subscribe(990, Filter{})
+	assert.ErrorIs(t, err, apperrors.ErrSequenceExpired)
+
+	sub, err := broker.Subscribe(999, Filter{})
+	require.NoError(t, err)
+	defer sub.Close()
+	assert.Equal(t, uint64(1000), nextEvent(t, sub).Sequence)
+}
+
+func TestBroker_ReplaysRetriedEventsInOrder(t *testing.T) {
+	broker := NewBroker(3)
+	for _, sequence := range []uint64{10, 12, 13, 11, 12} {
+		event := NewProductEvent(TypeUpdated, &models.Product{ID: uuid.New()})
+		event.Sequence = sequence
+		publish(t, broker, event)
+	}
+
+	sub, err := broker.Subscribe(10, Filter{})
+	require.NoError(t, err)
+	defer sub.Close()
+	assert.Equal(t, uint64(11), nextEvent(t, sub).Sequence)
+	assert.Equal(t, uint64(12), nextEvent(t, sub).Sequence)
+	assert.Equal(t, uint64(13), nextEvent(t, sub).Sequence)
+	assert.Equal(t, uint64(13), broker.LastSequence())
+
+	_, err = broker.Subscribe(9, Filter{})
+	assert.ErrorIs(t, err, apperrors.ErrSequenceExpired, "10 was evicted to make room for 11")
+}
+
+func TestBroker_ResumeAheadOfFeed(t *testing.T) {
+	broker := NewBroker(2)
+
+	_, err := broker.Subscribe(42, Filter{})
+
+	assert.True(t, apperrors.IsValidationError(err))
+}
+
+func TestBroker_SlowSubscriberIsDisconnected(t *testing.T) {
+	broker := NewBroker(10)
+	sub, err := broker.Subscribe(0, Filter{})
+	require.NoError(t, err)
+	defer sub.Close()
+
+	for i := 0; i <= maxPending; i++ {
+		publish(t, broker, NewProductEvent(TypeCreated, &models.Product{ID: uuid.New()}))
+	}
+
+	_, err = sub.Next(context.Background())
+	assert.ErrorIs(t, err, apperrors.ErrSubscriberLagged)
+}
+
+func TestSubscription_NextHonoursContext(t *testing.T) {
+	broker := NewBroker(10)
+	sub, err := broker.Subscribe(0, Filter{})
+	require.NoError(t, err)
+	sub.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = sub.Next(ctx)
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
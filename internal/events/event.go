@@ -0,0 +1,242 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/tenant"
+)
+
+type Type string
+
+const (
+	TypeCreated Type = "created"
+	TypeUpdated Type = "updated"
+	TypeDeleted Type = "deleted"
+	TypeRenewed Type = "renewed"
+	TypeEnded   Type = "ended"
+)
+
+const (
+	AggregateProduct          = "product"
+	AggregateSubscriptionPlan = "subscription_plan"
+	AggregateSubscription     = "subscription"
+)
+
+type Event struct {
+	Sequence    uint64
+	TenantID    string
+	Type        Type
+	Aggregate   string
+	AggregateID uuid.UUID
+	ProductID   uuid.UUID
+	ProductType string
+	OccurredAt  time.Time
+
+	Product      *models.Product
+	Plan         *models.SubscriptionPlan
+	Subscription *models.Subscription
+}
+
+// Names lists every event name that can be subscribed to, in the form
+// "<aggregate>.<type>".
+func Names() []string {
+	var names []string
+	for _, aggregate := range []string{AggregateProduct, AggregateSubscriptionPlan} {
+		for _, eventType := range []Type{TypeCreated, TypeUpdated, TypeDeleted} {
+			names = append(names, aggregate+"."+string(eventType))
+		}
+	}
+	for _, eventType := range []Type{TypeRenewed, TypeEnded} {
+		names = append(names, AggregateSubscription+"."+string(eventType))
+	}
+	return names
+}
+
+// Name identifies the event as "<aggregate>.<type>", e.g. "product.updated".
+func (e Event) Name() string {
+	return e.Aggregate + "." + string(e.Type)
+}
+
+func NewProductEvent(eventType Type, product *models.Product) Event {
+	return Event{
+		TenantID:    tenantOrDefault(product.TenantID),
+		Type:        eventType,
+		Aggregate:   AggregateProduct,
+		AggregateID: product.ID,
+		ProductID:   product.ID,
+		ProductType: product.ProductType,
+		OccurredAt:  time.Now().UTC(),
+		Product:     product,
+	}
+}
+
+func NewPlanEvent(eventType Type, plan *models.SubscriptionPlan) Event {
+	return Event{
+		TenantID:    tenantOrDefault(plan.TenantID),
+		Type:        eventType,
+		Aggregate:   AggregateSubscriptionPlan,
+		AggregateID: plan.ID,
+		ProductID:   plan.ProductID,
+		ProductType: plan.Product.ProductType,
+		OccurredAt:  time.Now().UTC(),
+		Plan:        plan,
+	}
+}
+
+// NewSubscriptionEvent records a change to a customer's subscription to plan,
+// whose product it is attributed to.
+func NewSubscriptionEvent(eventType Type, sub *models.Subscription, plan *models.SubscriptionPlan) Event {
+	return Event{
+		TenantID:     tenantOrDefault(sub.TenantID),
+		Type:         eventType,
+		Aggregate:    AggregateSubscription,
+		AggregateID:  sub.ID,
+		ProductID:    plan.ProductID,
+		ProductType:  plan.Product.ProductType,
+		OccurredAt:   time.Now().UTC(),
+		Subscription: sub,
+	}
+}
+
+// tenantOrDefault maps rows saved before they had a tenant to the default one.
+func tenantOrDefault(tenantID string) string {
+	if tenantID == "" {
+		return tenant.Default
+	}
+	return tenantID
+}
+
+// Filter selects events for a subscriber. Zero values match everything.
+type Filter struct {
+	TenantID    string
+	Aggregate   string
+	ProductType string
+	ProductID   uuid.UUID
+}
+
+func (f Filter) Matches(event Event) bool {
+	if f.TenantID != "" && f.TenantID != event.TenantID {
+		return false
+	}
+	if f.Aggregate != "" && f.Aggregate != event.Aggregate {
+		return false
+	}
+	if f.ProductType != "" && f.ProductType != event.ProductType {
+		return false
+	}
+	if f.ProductID != uuid.Nil && f.ProductID != event.ProductID {
+		return false
+	}
+	return true
+}
+
+// Publisher delivers events to a downstream consumer. Implementations must be
+// safe for repeated delivery of the same event; the outbox relay retries
+// failed publications.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+type envelope struct {
+	Sequence    uint64          `json:"sequence"`
+	TenantID    string          `json:"tenant_id"`
+	Type        Type            `json:"type"`
+	Aggregate   string          `json:"aggregate"`
+	AggregateID uuid.UUID       `json:"aggregate_id"`
+	ProductID   uuid.UUID       `json:"product_id"`
+	ProductType string          `json:"product_type"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Data        json.RawMessage `json:"data"`
+}
+
+func (e Event) MarshalJSON() ([]byte, error) {
+	data, err := e.payload()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(envelope{
+		Sequence:    e.Sequence,
+		TenantID:    e.TenantID,
+		Type:        e.Type,
+		Aggregate:   e.Aggregate,
+		AggregateID: e.AggregateID,
+		ProductID:   e.ProductID,
+		ProductType: e.ProductType,
+		OccurredAt:  e.OccurredAt,
+		Data:        data,
+	})
+}
+
+func (e Event) payload() ([]byte, error) {
+	switch e.Aggregate {
+	case AggregateProduct:
+		return json.Marshal(e.Product)
+	case AggregateSubscriptionPlan:
+		return json.Marshal(e.Plan)
+	case AggregateSubscription:
+		return json.Marshal(e.Subscription)
+	default:
+		return nil, fmt.Errorf("unknown aggregate %q", e.Aggregate)
+	}
+}
+
+// ToOutbox converts the event into a row for the transactional outbox.
+func ToOutbox(event Event) (*models.OutboxEvent, error) {
+	payload, err := event.payload()
+	if err != nil {
+		return nil, err
+	}
+	return &models.OutboxEvent{
+		TenantID:      event.TenantID,
+		EventType:     string(event.Type),
+		Aggregate:     event.Aggregate,
+		AggregateID:   event.AggregateID,
+		ProductID:     event.ProductID,
+		ProductType:   event.ProductType,
+		Payload:       string(payload),
+		OccurredAt:    event.OccurredAt,
+		NextAttemptAt: event.OccurredAt,
+	}, nil
+}
+
+// FromOutbox rebuilds the event stored in an outbox row, using the row ID as
+// its sequence number.
+func FromOutbox(row models.OutboxEvent) (Event, error) {
+	event := Event{
+		Sequence:    row.ID,
+		TenantID:    row.TenantID,
+		Type:        Type(row.EventType),
+		Aggregate:   row.Aggregate,
+		AggregateID: row.AggregateID,
+		ProductID:   row.ProductID,
+		ProductType: row.ProductType,
+		OccurredAt:  row.OccurredAt,
+	}
+
+	switch row.Aggregate {
+	case AggregateProduct:
+		event.Product = &models.Product{}
+		if err := json.Unmarshal([]byte(row.Payload), event.Product); err != nil {
+			return Event{}, err
+		}
+	case AggregateSubscriptionPlan:
+		event.Plan = &models.SubscriptionPlan{}
+		if err := json.Unmarshal([]byte(row.Payload), event.Plan); err != nil {
+			return Event{}, err
+		}
+	case AggregateSubscription:
+		event.Subscription = &models.Subscription{}
+		if err := json.Unmarshal([]byte(row.Payload), event.Subscription); err != nil {
+			return Event{}, err
+		}
+	default:
+		return Event{}, fmt.Errorf("unknown aggregate %q", row.Aggregate)
+	}
+
+	return event, nil
+}
@@ -0,0 +1,121 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(ctx context.Context, event Event) error {
+	return errors.New("unavailable")
+}
+
+func TestOutboxRoundTrip(t *testing.T) {
+	plan := &models.SubscriptionPlan{
+		ID:        uuid.New(),
+		ProductID: uuid.New(),
+		PlanName:  "Annual Plan",
+		Duration:  365,
+		Price:     299.99,
+		Product:   models.Product{ProductType: "digital"},
+	}
+	event := NewPlanEvent(TypeUpdated, plan)
+
+	row, err := ToOutbox(event)
+	require.NoError(t, err)
+	row.ID = 12
+
+	restored, err := FromOutbox(*row)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(12), restored.Sequence)
+	assert.Equal(t, TypeUpdated, restored.Type)
+	assert.Equal(t, plan.ID, restored.AggregateID)
+	assert.Equal(t, "digital", restored.ProductType)
+	assert.Equal(t, "Annual Plan", restored.Plan.PlanName)
+	assert.Equal(t, 299.99, restored.Plan.Price)
+}
+
+func TestOutboxRoundTrip_Subscription(t *testing.T) {
+	plan := &models.SubscriptionPlan{ID: uuid.New(), ProductID: uuid.New(), Product: models.Product{ProductType: "digital"}}
+	sub := &models.Subscription{ID: uuid.New(), CustomerID: "cus_1", PlanID: plan.ID, Status: models.SubscriptionActive, Cycle: 2}
+	event := NewSubscriptionEvent(TypeRenewed, sub, plan)
+	assert.Equal(t, "subscription.renewed", event.Name())
+
+	row, err := ToOutbox(event)
+	require.NoError(t, err)
+
+	restored, err := FromOutbox(*row)
+	require.NoError(t, err)
+	assert.Equal(t, sub.ID, restored.AggregateID)
+	assert.Equal(t, plan.ProductID, restored.ProductID)
+	assert.Equal(t, "cus_1", restored.Subscription.CustomerID)
+	assert.Equal(t, 2, restored.Subscription.Cycle)
+}
+
+func TestWriterPublisher_WritesJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	publisher := NewWriterPublisher(&buf)
+	product := &models.Product{ID: uuid.New(), Name: "Premium Software", ProductType: "digital"}
+
+	require.NoError(t, publisher.Publish(context.Background(), NewProductEvent(TypeCreated, product)))
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, "created", decoded["type"])
+	assert.Equal(t, "product", decoded["aggregate"])
+	assert.Equal(t, "Premium Software", decoded["data"].(map[string]interface{})["name"])
+	assert.Equal(t, byte('\n'), buf.Bytes()[buf.Len()-1])
+}
+
+func TestHTTPPublisher_PostsEvent(t *testing.T) {
+	received := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		received <- body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	publisher := NewHTTPPublisher(server.URL, time.Second)
+	err := publisher.Publish(context.Background(), NewProductEvent(TypeDeleted, &models.Product{ID: uuid.New()}))
+
+	require.NoError(t, err)
+	assert.Contains(t, string(<-received), `"type":"deleted"`)
+}
+
+func TestHTTPPublisher_NonSuccessStatusFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	publisher := NewHTTPPublisher(server.URL, time.Second)
+	err := publisher.Publish(context.Background(), NewProductEvent(TypeCreated, &models.Product{ID: uuid.New()}))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "503")
+}
+
+func TestMultiPublisher_StopsAtFirstFailure(t *testing.T) {
+	var buf bytes.Buffer
+	publisher := MultiPublisher{failingPublisher{}, NewWriterPublisher(&buf)}
+
+	err := publisher.Publish(context.Background(), NewProductEvent(TypeCreated, &models.Product{ID: uuid.New()}))
+
+	assert.Error(t, err)
+	assert.Zero(t, buf.Len())
+}
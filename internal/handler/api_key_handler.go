@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/microservice-go/product-service/internal/service"
+	pb "github.com/microservice-go/product-service/proto/apikey"
+)
+
+type APIKeyHandler struct {
+	pb.UnimplementedAPIKeyServiceServer
+	service service.APIKeyService
+}
+
+func NewAPIKeyHandler(service service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+func (h *APIKeyHandler) CreateAPIKey(ctx context.Context, req *pb.CreateAPIKeyRequest) (*pb.APIKeySecretResponse, error) {
+	key, plaintext, err := h.service.CreateAPIKey(ctx, req.Name, req.Scopes)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.APIKeySecretResponse{
+		ApiKey: toAPIKeyProto(key),
+		Key:    plaintext,
+	}, nil
+}
+
+func (h *APIKeyHandler) ListAPIKeys(ctx context.Context, req *pb.ListAPIKeysRequest) (*pb.ListAPIKeysResponse, error) {
+	keys, err := h.service.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	pbKeys := make([]*pb.APIKey, len(keys))
+	for i := range keys {
+		pbKeys[i] = toAPIKeyProto(&keys[i])
+	}
+
+	return &pb.ListAPIKeysResponse{
+		ApiKeys: pbKeys,
+	}, nil
+}
+
+func (h *APIKeyHandler) RotateAPIKey(ctx context.Context, req *pb.RotateAPIKeyRequest) (*pb.APIKeySecretResponse, error) {
+	key, plaintext, err := h.service.RotateAPIKey(ctx, req.Id)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.APIKeySecretResponse{
+		ApiKey: toAPIKeyProto(key),
+		Key:    plaintext,
+	}, nil
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(ctx context.Context, req *pb.RevokeAPIKeyRequest) (*pb.APIKeyResponse, error) {
+	key, err := h.service.RevokeAPIKey(ctx, req.Id)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.APIKeyResponse{
+		ApiKey: toAPIKeyProto(key),
+	}, nil
+}
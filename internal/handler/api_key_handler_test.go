@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/models"
+	pb "github.com/microservice-go/product-service/proto/apikey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAPIKeyService struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyService) CreateAPIKey(ctx context.Context, name string, scopes []string) (*models.APIKey, string, error) {
+	args := m.Called(name, scopes)
+	if args.Get(0) == nil {
+		return nil, "", args.Error(2)
+	}
+	return args.Get(0).(*models.APIKey), args.String(1), args.Error(2)
+}
+
+func (m *MockAPIKeyService) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	args := m.Called()
+	return args.Get(0).([]models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyService) RotateAPIKey(ctx context.Context, id string) (*models.APIKey, string, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, "", args.Error(2)
+	}
+	return args.Get(0).(*models.APIKey), args.String(1), args.Error(2)
+}
+
+func (m *MockAPIKeyService) RevokeAPIKey(ctx context.Context, id string) (*models.APIKey, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyService) VerifyAPIKey(ctx context.Context, key string) (*models.APIKey, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func TestAPIKeyHandler_CreateAPIKey(t *testing.T) {
+	mockService := new(MockAPIKeyService)
+	handler := NewAPIKeyHandler(mockService)
+
+	mockService.On("CreateAPIKey", "nightly-import", []string{"catalog.write"}).Return(&models.APIKey{
+		ID:     uuid.New(),
+		Name:   "nightly-import",
+		Prefix: "psk_0123abcd",
+		Scopes: "catalog.write",
+	}, "psk_0123abcdef", nil)
+
+	resp, err := handler.CreateAPIKey(context.Background(), &pb.CreateAPIKeyRequest{
+		Name:   "nightly-import",
+		Scopes: []string{"catalog.write"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "psk_0123abcdef", resp.Key)
+	assert.Equal(t, "psk_0123abcd", resp.ApiKey.Prefix)
+	assert.Equal(t, []string{"catalog.write"}, resp.ApiKey.Scopes)
+	mockService.AssertExpectations(t)
+}
+
+func TestAPIKeyHandler_ListAPIKeys(t *testing.T) {
+	mockService := new(MockAPIKeyService)
+	handler := NewAPIKeyHandler(mockService)
+
+	revokedAt := time.Now()
+	mockService.On("ListAPIKeys").Return([]models.APIKey{
+		{ID: uuid.New(), Name: "storefront", Scopes: "catalog.read"},
+		{ID: uuid.New(), Name: "old-job", Scopes: "catalog.write", RevokedAt: &revokedAt},
+	}, nil)
+
+	resp, err := handler.ListAPIKeys(context.Background(), &pb.ListAPIKeysRequest{})
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.ApiKeys, 2)
+	assert.Nil(t, resp.ApiKeys[0].RevokedAt)
+	assert.NotNil(t, resp.ApiKeys[1].RevokedAt)
+}
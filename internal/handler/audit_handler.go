@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/microservice-go/product-service/internal/service"
+	pb "github.com/microservice-go/product-service/proto/audit"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type AuditHandler struct {
+	pb.UnimplementedAuditServiceServer
+	service service.AuditService
+}
+
+func NewAuditHandler(service service.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+func (h *AuditHandler) ListAuditEvents(ctx context.Context, req *pb.ListAuditEventsRequest) (*pb.ListAuditEventsResponse, error) {
+	auditEvents, total, err := h.service.ListAuditEvents(ctx, req.ResourceId, req.Actor,
+		toTime(req.StartTime), toTime(req.EndTime), int(req.Page), int(req.PageSize))
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	pbEvents := make([]*pb.AuditEvent, len(auditEvents))
+	for i := range auditEvents {
+		pbEvents[i] = toAuditEventProto(&auditEvents[i])
+	}
+
+	return &pb.ListAuditEventsResponse{
+		Events: pbEvents,
+		Total:  int32(total),
+	}, nil
+}
+
+// toTime converts an optional timestamp, mapping unset to the zero time.
+func toTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
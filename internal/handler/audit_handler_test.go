@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/models"
+	pb "github.com/microservice-go/product-service/proto/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) ListAuditEvents(ctx context.Context, resourceID, actor string, from, to time.Time, page, pageSize int) ([]models.AuditEvent, int64, error) {
+	args := m.Called(resourceID, actor, from, to, page, pageSize)
+	return args.Get(0).([]models.AuditEvent), args.Get(1).(int64), args.Error(2)
+}
+
+func TestAuditHandler_ListAuditEvents(t *testing.T) {
+	mockService := new(MockAuditService)
+	handler := NewAuditHandler(mockService)
+
+	resourceID := uuid.New()
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockService.On("ListAuditEvents", resourceID.String(), "alice", from, time.Time{}, 1, 20).Return([]models.AuditEvent{
+		{
+			ID:           uuid.New(),
+			Actor:        "alice",
+			Action:       models.AuditActionUpdate,
+			ResourceType: models.AuditResourceSubscriptionPlan,
+			ResourceID:   resourceID,
+			Diff:         `{"price":{"before":29.99,"after":39.99}}`,
+			OccurredAt:   from.Add(time.Hour),
+		},
+	}, int64(1), nil)
+
+	resp, err := handler.ListAuditEvents(context.Background(), &pb.ListAuditEventsRequest{
+		ResourceId: resourceID.String(),
+		Actor:      "alice",
+		StartTime:  timestamppb.New(from),
+		Page:       1,
+		PageSize:   20,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), resp.Total)
+	assert.Len(t, resp.Events, 1)
+	assert.Equal(t, "update", resp.Events[0].Action)
+	assert.Equal(t, resourceID.String(), resp.Events[0].ResourceId)
+	assert.Contains(t, resp.Events[0].Diff, "39.99")
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/microservice-go/product-service/internal/service"
+	pb "github.com/microservice-go/product-service/proto/billing"
+)
+
+type BillingHandler struct {
+	pb.UnimplementedBillingServiceServer
+	service service.BillingService
+}
+
+func NewBillingHandler(service service.BillingService) *BillingHandler {
+	return &BillingHandler{service: service}
+}
+
+func (h *BillingHandler) Subscribe(ctx context.Context, req *pb.SubscribeRequest) (*pb.SubscriptionResponse, error) {
+	sub, err := h.service.Subscribe(ctx, req.CustomerId, req.PlanId, req.CouponCode, service.TaxLocation{Country: req.Country, Region: req.Region})
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.SubscriptionResponse{
+		Subscription: toSubscriptionProto(sub),
+	}, nil
+}
+
+func (h *BillingHandler) GetSubscription(ctx context.Context, req *pb.GetSubscriptionRequest) (*pb.SubscriptionResponse, error) {
+	sub, err := h.service.GetSubscription(ctx, req.Id)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.SubscriptionResponse{
+		Subscription: toSubscriptionProto(sub),
+	}, nil
+}
+
+func (h *BillingHandler) ListSubscriptions(ctx context.Context, req *pb.ListSubscriptionsRequest) (*pb.ListSubscriptionsResponse, error) {
+	subs, total, err := h.service.ListSubscriptions(ctx, req.CustomerId, req.PlanId, req.Status, int(req.Page), int(req.PageSize))
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	pbSubs := make([]*pb.Subscription, len(subs))
+	for i := range subs {
+		pbSubs[i] = toSubscriptionProto(&subs[i])
+	}
+
+	return &pb.ListSubscriptionsResponse{
+		Subscriptions: pbSubs,
+		Total:         int32(total),
+	}, nil
+}
+
+func (h *BillingHandler) CancelSubscription(ctx context.Context, req *pb.CancelSubscriptionRequest) (*pb.SubscriptionResponse, error) {
+	sub, err := h.service.CancelSubscription(ctx, req.Id, req.AtPeriodEnd)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.SubscriptionResponse{
+		Subscription: toSubscriptionProto(sub),
+	}, nil
+}
+
+func (h *BillingHandler) ResumeSubscription(ctx context.Context, req *pb.ResumeSubscriptionRequest) (*pb.SubscriptionResponse, error) {
+	sub, err := h.service.ResumeSubscription(ctx, req.Id)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.SubscriptionResponse{
+		Subscription: toSubscriptionProto(sub),
+	}, nil
+}
+
+func (h *BillingHandler) ChangeSubscriptionPlan(ctx context.Context, req *pb.ChangeSubscriptionPlanRequest) (*pb.PlanChangeResponse, error) {
+	change, err := h.service.ChangeSubscriptionPlan(ctx, req.Id, req.PlanId, req.AtPeriodEnd)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return toPlanChangeResponse(change), nil
+}
+
+func (h *BillingHandler) PreviewPlanChange(ctx context.Context, req *pb.ChangeSubscriptionPlanRequest) (*pb.PlanChangeResponse, error) {
+	change, err := h.service.PreviewPlanChange(ctx, req.Id, req.PlanId, req.AtPeriodEnd)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return toPlanChangeResponse(change), nil
+}
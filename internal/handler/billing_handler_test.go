@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/service"
+	pb "github.com/microservice-go/product-service/proto/billing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type MockBillingService struct {
+	mock.Mock
+}
+
+func (m *MockBillingService) Subscribe(ctx context.Context, customerID, planID, couponCode string, location service.TaxLocation) (*models.Subscription, error) {
+	args := m.Called(customerID, planID, couponCode, location)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Subscription), args.Error(1)
+}
+
+func (m *MockBillingService) GetSubscription(ctx context.Context, id string) (*models.Subscription, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Subscription), args.Error(1)
+}
+
+func (m *MockBillingService) ListSubscriptions(ctx context.Context, customerID, planID, status string, page, pageSize int) ([]models.Subscription, int64, error) {
+	args := m.Called(customerID, planID, status, page, pageSize)
+	return args.Get(0).([]models.Subscription), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockBillingService) CancelSubscription(ctx context.Context, id string, atPeriodEnd bool) (*models.Subscription, error) {
+	args := m.Called(id, atPeriodEnd)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Subscription), args.Error(1)
+}
+
+func (m *MockBillingService) ResumeSubscription(ctx context.Context, id string) (*models.Subscription, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Subscription), args.Error(1)
+}
+
+func (m *MockBillingService) ChangeSubscriptionPlan(ctx context.Context, id, planID string, atPeriodEnd bool) (*service.PlanChange, error) {
+	args := m.Called(id, planID, atPeriodEnd)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.PlanChange), args.Error(1)
+}
+
+func (m *MockBillingService) PreviewPlanChange(ctx context.Context, id, planID string, atPeriodEnd bool) (*service.PlanChange, error) {
+	args := m.Called(id, planID, atPeriodEnd)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.PlanChange), args.Error(1)
+}
+
+func (m *MockBillingService) RenewDue(ctx context.Context, now time.Time, limit int) (int, error) {
+	args := m.Called(now, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func TestBillingHandler_Subscribe(t *testing.T) {
+	mockService := new(MockBillingService)
+	handler := NewBillingHandler(mockService)
+
+	planID := uuid.New()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockService.On("Subscribe", "cus_1", planID.String(), "", service.TaxLocation{Country: "US", Region: "CA"}).Return(&models.Subscription{
+		ID:                 uuid.New(),
+		CustomerID:         "cus_1",
+		PlanID:             planID,
+		Status:             models.SubscriptionTrialing,
+		CurrentPeriodStart: start,
+		CurrentPeriodEnd:   start.AddDate(0, 0, 14),
+		TaxCountry:         "US",
+		TaxRegion:          "CA",
+	}, nil)
+
+	resp, err := handler.Subscribe(context.Background(), &pb.SubscribeRequest{CustomerId: "cus_1", PlanId: planID.String(), Country: "US", Region: "CA"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "cus_1", resp.Subscription.CustomerId)
+	assert.Equal(t, planID.String(), resp.Subscription.PlanId)
+	assert.Equal(t, models.SubscriptionTrialing, resp.Subscription.Status)
+	assert.Equal(t, start.AddDate(0, 0, 14), resp.Subscription.CurrentPeriodEnd.AsTime())
+	assert.Nil(t, resp.Subscription.CanceledAt)
+	assert.Equal(t, "US", resp.Subscription.TaxCountry)
+	assert.Equal(t, "CA", resp.Subscription.TaxRegion)
+	mockService.AssertExpectations(t)
+}
+
+func TestBillingHandler_CancelSubscription(t *testing.T) {
+	mockService := new(MockBillingService)
+	handler := NewBillingHandler(mockService)
+
+	id := uuid.New()
+	canceledAt := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	mockService.On("CancelSubscription", id.String(), true).Return(&models.Subscription{
+		ID:                id,
+		Status:            models.SubscriptionActive,
+		CancelAtPeriodEnd: true,
+		CanceledAt:        &canceledAt,
+	}, nil)
+
+	resp, err := handler.CancelSubscription(context.Background(), &pb.CancelSubscriptionRequest{Id: id.String(), AtPeriodEnd: true})
+
+	assert.NoError(t, err)
+	assert.True(t, resp.Subscription.CancelAtPeriodEnd)
+	assert.Equal(t, canceledAt, resp.Subscription.CanceledAt.AsTime())
+	mockService.AssertExpectations(t)
+}
+
+func TestBillingHandler_ListSubscriptions(t *testing.T) {
+	mockService := new(MockBillingService)
+	handler := NewBillingHandler(mockService)
+
+	mockService.On("ListSubscriptions", "cus_1", "", "", 1, 10).
+		Return([]models.Subscription{{ID: uuid.New(), CustomerID: "cus_1"}}, int64(1), nil)
+
+	resp, err := handler.ListSubscriptions(context.Background(), &pb.ListSubscriptionsRequest{CustomerId: "cus_1", Page: 1, PageSize: 10})
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Subscriptions, 1)
+	assert.Equal(t, int32(1), resp.Total)
+}
+
+func TestBillingHandler_ResumeSubscription_Error(t *testing.T) {
+	mockService := new(MockBillingService)
+	handler := NewBillingHandler(mockService)
+
+	id := uuid.New()
+	mockService.On("ResumeSubscription", id.String()).
+		Return(nil, apperrors.NewValidationError("status", "canceled subscriptions cannot be resumed"))
+
+	resp, err := handler.ResumeSubscription(context.Background(), &pb.ResumeSubscriptionRequest{Id: id.String()})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestBillingHandler_PreviewPlanChange(t *testing.T) {
+	mockService := new(MockBillingService)
+	handler := NewBillingHandler(mockService)
+
+	id, from, to := uuid.New(), uuid.New(), uuid.New()
+	at := time.Date(2024, 4, 11, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	mockService.On("PreviewPlanChange", id.String(), to.String(), false).Return(&service.PlanChange{
+		Subscription: &models.Subscription{ID: id, PlanID: to},
+		LineItems: []models.LineItem{
+			{Kind: models.LineItemProration, PlanID: from, Amount: -20, PeriodStart: at, PeriodEnd: end},
+			{Kind: models.LineItemProration, PlanID: to, Amount: 40, PeriodStart: at, PeriodEnd: end},
+		},
+		EffectiveAt: at,
+	}, nil)
+
+	resp, err := handler.PreviewPlanChange(context.Background(), &pb.ChangeSubscriptionPlanRequest{Id: id.String(), PlanId: to.String()})
+
+	assert.NoError(t, err)
+	assert.Equal(t, to.String(), resp.Subscription.PlanId)
+	assert.Len(t, resp.LineItems, 2)
+	assert.Equal(t, -20.0, resp.LineItems[0].Amount)
+	assert.Equal(t, from.String(), resp.LineItems[0].PlanId)
+	assert.Equal(t, 20.0, resp.Total)
+	assert.Equal(t, at, resp.EffectiveAt.AsTime())
+	mockService.AssertNotCalled(t, "ChangeSubscriptionPlan", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBillingHandler_ChangeSubscriptionPlan_AtPeriodEnd(t *testing.T) {
+	mockService := new(MockBillingService)
+	handler := NewBillingHandler(mockService)
+
+	id, to := uuid.New(), uuid.New()
+	end := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	mockService.On("ChangeSubscriptionPlan", id.String(), to.String(), true).Return(&service.PlanChange{
+		Subscription: &models.Subscription{ID: id, PlanID: uuid.New(), PendingPlanID: &to},
+		LineItems:    []models.LineItem{{Kind: models.LineItemPlan, PlanID: to, Amount: 60}},
+		EffectiveAt:  end,
+	}, nil)
+
+	resp, err := handler.ChangeSubscriptionPlan(context.Background(), &pb.ChangeSubscriptionPlanRequest{Id: id.String(), PlanId: to.String(), AtPeriodEnd: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, to.String(), resp.Subscription.PendingPlanId)
+	assert.Equal(t, 60.0, resp.Total)
+	assert.Equal(t, end, resp.EffectiveAt.AsTime())
+	assert.Empty(t, resp.InvoiceId, "changes at the period end are invoiced on renewal")
+	mockService.AssertExpectations(t)
+}
@@ -1,10 +1,24 @@
 package handler
 
 import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
 	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/events"
 	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/service"
+	apikeypb "github.com/microservice-go/product-service/proto/apikey"
+	auditpb "github.com/microservice-go/product-service/proto/audit"
+	billingpb "github.com/microservice-go/product-service/proto/billing"
+	couponpb "github.com/microservice-go/product-service/proto/coupon"
+	invoicepb "github.com/microservice-go/product-service/proto/invoice"
 	productpb "github.com/microservice-go/product-service/proto/product"
+	schedulepb "github.com/microservice-go/product-service/proto/schedule"
 	subscriptionpb "github.com/microservice-go/product-service/proto/subscription"
+	usagepb "github.com/microservice-go/product-service/proto/usage"
+	webhookpb "github.com/microservice-go/product-service/proto/webhook"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -16,13 +30,14 @@ func toProductProto(product *models.Product) *productpb.Product {
 	}
 
 	return &productpb.Product{
-		Id:          product.ID.String(),
-		Name:        product.Name,
-		Description: product.Description,
-		Price:       product.Price,
-		ProductType: product.ProductType,
-		CreatedAt:   timestamppb.New(product.CreatedAt),
-		UpdatedAt:   timestamppb.New(product.UpdatedAt),
+		Id:           product.ID.String(),
+		Name:         product.Name,
+		Description:  product.Description,
+		Price:        product.Price,
+		ProductType:  product.ProductType,
+		CreatedAt:    timestamppb.New(product.CreatedAt),
+		UpdatedAt:    timestamppb.New(product.UpdatedAt),
+		TaxInclusive: product.TaxInclusive,
 	}
 }
 
@@ -32,13 +47,550 @@ func toSubscriptionPlanProto(plan *models.SubscriptionPlan) *subscriptionpb.Subs
 	}
 
 	return &subscriptionpb.SubscriptionPlan{
-		Id:        plan.ID.String(),
-		ProductId: plan.ProductID.String(),
-		PlanName:  plan.PlanName,
-		Duration:  int32(plan.Duration),
-		Price:     plan.Price,
-		CreatedAt: timestamppb.New(plan.CreatedAt),
-		UpdatedAt: timestamppb.New(plan.UpdatedAt),
+		Id:           plan.ID.String(),
+		ProductId:    plan.ProductID.String(),
+		PlanName:     plan.PlanName,
+		Duration:     int32(plan.Duration),
+		Price:        plan.Price,
+		CreatedAt:    timestamppb.New(plan.CreatedAt),
+		UpdatedAt:    timestamppb.New(plan.UpdatedAt),
+		Interval:     toBillingIntervalProto(plan.Interval),
+		TrialDays:    int32(plan.TrialDays),
+		IntroPhases:  toIntroPhasesProto(plan.IntroPhases),
+		PricingModel: plan.PricingModelOrDefault(),
+		Tiers:        toPriceTiersProto(plan.Tiers),
+		Meter:        toMeterProto(plan.Meter),
+		TaxInclusive: plan.Product.TaxInclusive,
+	}
+}
+
+func toIntroPhasesProto(phases []models.IntroPhase) []*subscriptionpb.IntroPhase {
+	result := make([]*subscriptionpb.IntroPhase, len(phases))
+	for i, phase := range phases {
+		result[i] = &subscriptionpb.IntroPhase{Price: phase.Price, Cycles: int32(phase.Cycles)}
+	}
+	return result
+}
+
+func fromIntroPhasesProto(phases []*subscriptionpb.IntroPhase) []models.IntroPhase {
+	if len(phases) == 0 {
+		return nil
+	}
+	result := make([]models.IntroPhase, len(phases))
+	for i, phase := range phases {
+		result[i] = models.IntroPhase{Price: phase.GetPrice(), Cycles: int(phase.GetCycles())}
+	}
+	return result
+}
+
+func toPriceTiersProto(tiers []models.PriceTier) []*subscriptionpb.PriceTier {
+	result := make([]*subscriptionpb.PriceTier, len(tiers))
+	for i, tier := range tiers {
+		result[i] = &subscriptionpb.PriceTier{UpTo: int32(tier.UpTo), UnitPrice: tier.UnitPrice, FlatFee: tier.FlatFee}
+	}
+	return result
+}
+
+func fromPriceTiersProto(tiers []*subscriptionpb.PriceTier) []models.PriceTier {
+	if len(tiers) == 0 {
+		return nil
+	}
+	result := make([]models.PriceTier, len(tiers))
+	for i, tier := range tiers {
+		result[i] = models.PriceTier{UpTo: int(tier.GetUpTo()), UnitPrice: tier.GetUnitPrice(), FlatFee: tier.GetFlatFee()}
+	}
+	return result
+}
+
+func toMeterProto(meter *models.Meter) *subscriptionpb.Meter {
+	if meter == nil {
+		return nil
+	}
+	return &subscriptionpb.Meter{Unit: meter.Unit, Aggregation: meter.Aggregation}
+}
+
+func fromMeterProto(meter *subscriptionpb.Meter) *models.Meter {
+	if meter == nil {
+		return nil
+	}
+	return &models.Meter{Unit: meter.Unit, Aggregation: meter.Aggregation}
+}
+
+func toCalculatePriceProto(calculation *service.PriceCalculation) *subscriptionpb.CalculatePriceResponse {
+	breakdown := calculation.Breakdown
+	tiers := make([]*subscriptionpb.TierCharge, len(breakdown.Tiers))
+	for i, charge := range breakdown.Tiers {
+		tiers[i] = &subscriptionpb.TierCharge{
+			Tier:      int32(charge.Tier),
+			FromUnit:  int32(charge.FromUnit),
+			ToUnit:    int32(charge.ToUnit),
+			Quantity:  int32(charge.Quantity),
+			UnitPrice: charge.UnitPrice,
+			FlatFee:   charge.FlatFee,
+			Amount:    charge.Amount,
+		}
+	}
+	return &subscriptionpb.CalculatePriceResponse{
+		PlanId:       calculation.Plan.ID.String(),
+		Quantity:     int32(breakdown.Quantity),
+		PricingModel: calculation.Plan.PricingModelOrDefault(),
+		Total:        breakdown.Total,
+		Tiers:        tiers,
+		Net:          calculation.Tax.Net,
+		Tax:          calculation.Tax.Tax,
+		Gross:        calculation.Tax.Gross,
+		TaxRate:      calculation.Tax.Rate,
+		TaxInclusive: calculation.Tax.Inclusive,
+	}
+}
+
+func toPricePeriodProto(period *models.PricePeriod) *subscriptionpb.PricePeriod {
+	return &subscriptionpb.PricePeriod{
+		Cycle:     int32(period.Cycle),
+		Trial:     period.Trial,
+		StartTime: timestamppb.New(period.Start),
+		EndTime:   timestamppb.New(period.End),
+		Price:     period.Price,
+	}
+}
+
+var intervalUnits = map[string]subscriptionpb.IntervalUnit{
+	models.IntervalDay:   subscriptionpb.IntervalUnit_INTERVAL_UNIT_DAY,
+	models.IntervalWeek:  subscriptionpb.IntervalUnit_INTERVAL_UNIT_WEEK,
+	models.IntervalMonth: subscriptionpb.IntervalUnit_INTERVAL_UNIT_MONTH,
+	models.IntervalYear:  subscriptionpb.IntervalUnit_INTERVAL_UNIT_YEAR,
+}
+
+func toBillingIntervalProto(interval models.BillingInterval) *subscriptionpb.BillingInterval {
+	return &subscriptionpb.BillingInterval{
+		Unit:  intervalUnits[interval.Unit],
+		Count: int32(interval.Count),
+	}
+}
+
+// fromBillingIntervalProto returns interval, or for clients that predate
+// intervals, duration converted from days.
+func fromBillingIntervalProto(interval *subscriptionpb.BillingInterval, duration int32) models.BillingInterval {
+	if interval == nil {
+		return models.IntervalFromDays(int(duration))
+	}
+	result := models.BillingInterval{Count: int(interval.Count)}
+	for unit, value := range intervalUnits {
+		if value == interval.Unit {
+			result.Unit = unit
+		}
+	}
+	return result
+}
+
+func toWebhookProto(webhook *models.Webhook) *webhookpb.Webhook {
+	if webhook == nil {
+		return nil
+	}
+
+	return &webhookpb.Webhook{
+		Id:         webhook.ID.String(),
+		Url:        webhook.URL,
+		EventTypes: webhook.EventTypeList(),
+		Active:     webhook.Active,
+		CreatedAt:  timestamppb.New(webhook.CreatedAt),
+		UpdatedAt:  timestamppb.New(webhook.UpdatedAt),
+	}
+}
+
+func toWebhookDeliveryProto(delivery *models.WebhookDelivery) *webhookpb.WebhookDelivery {
+	if delivery == nil {
+		return nil
+	}
+
+	pbDelivery := &webhookpb.WebhookDelivery{
+		Id:             delivery.ID.String(),
+		WebhookId:      delivery.WebhookID.String(),
+		EventSequence:  delivery.EventSequence,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       int32(delivery.Attempts),
+		ResponseStatus: int32(delivery.ResponseStatus),
+		LastError:      delivery.LastError,
+		NextAttemptAt:  timestamppb.New(delivery.NextAttemptAt),
+		CreatedAt:      timestamppb.New(delivery.CreatedAt),
+	}
+	if delivery.DeliveredAt != nil {
+		pbDelivery.DeliveredAt = timestamppb.New(*delivery.DeliveredAt)
+	}
+	return pbDelivery
+}
+
+func toAuditEventProto(auditEvent *models.AuditEvent) *auditpb.AuditEvent {
+	if auditEvent == nil {
+		return nil
+	}
+
+	return &auditpb.AuditEvent{
+		Id:           auditEvent.ID.String(),
+		Actor:        auditEvent.Actor,
+		Action:       auditEvent.Action,
+		ResourceType: auditEvent.ResourceType,
+		ResourceId:   auditEvent.ResourceID.String(),
+		Before:       auditEvent.Before,
+		After:        auditEvent.After,
+		Diff:         auditEvent.Diff,
+		OccurredAt:   timestamppb.New(auditEvent.OccurredAt),
+	}
+}
+
+func toScheduledChangeProto(change *models.ScheduledChange) *schedulepb.ScheduledChange {
+	if change == nil {
+		return nil
+	}
+
+	pbChange := &schedulepb.ScheduledChange{
+		Id:           change.ID.String(),
+		ResourceType: change.ResourceType,
+		ResourceId:   change.ResourceID.String(),
+		Changes:      change.Changes,
+		EffectiveAt:  timestamppb.New(change.EffectiveAt),
+		Status:       change.Status,
+		Actor:        change.Actor,
+		LastError:    change.LastError,
+		CreatedAt:    timestamppb.New(change.CreatedAt),
+	}
+	if change.AppliedAt != nil {
+		pbChange.AppliedAt = timestamppb.New(*change.AppliedAt)
+	}
+	return pbChange
+}
+
+func toAPIKeyProto(key *models.APIKey) *apikeypb.APIKey {
+	if key == nil {
+		return nil
+	}
+
+	pbKey := &apikeypb.APIKey{
+		Id:        key.ID.String(),
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    key.ScopeList(),
+		CreatedAt: timestamppb.New(key.CreatedAt),
+	}
+	if key.LastUsedAt != nil {
+		pbKey.LastUsedAt = timestamppb.New(*key.LastUsedAt)
+	}
+	if key.RevokedAt != nil {
+		pbKey.RevokedAt = timestamppb.New(*key.RevokedAt)
+	}
+	return pbKey
+}
+
+func toSubscriptionProto(sub *models.Subscription) *billingpb.Subscription {
+	if sub == nil {
+		return nil
+	}
+
+	pbSub := &billingpb.Subscription{
+		Id:                 sub.ID.String(),
+		CustomerId:         sub.CustomerID,
+		PlanId:             sub.PlanID.String(),
+		Status:             sub.Status,
+		Cycle:              int32(sub.Cycle),
+		CurrentPeriodStart: timestamppb.New(sub.CurrentPeriodStart),
+		CurrentPeriodEnd:   timestamppb.New(sub.CurrentPeriodEnd),
+		CancelAtPeriodEnd:  sub.CancelAtPeriodEnd,
+		CreatedAt:          timestamppb.New(sub.CreatedAt),
+		UpdatedAt:          timestamppb.New(sub.UpdatedAt),
+	}
+	if sub.CanceledAt != nil {
+		pbSub.CanceledAt = timestamppb.New(*sub.CanceledAt)
+	}
+	if sub.EndedAt != nil {
+		pbSub.EndedAt = timestamppb.New(*sub.EndedAt)
+	}
+	if sub.PendingPlanID != nil {
+		pbSub.PendingPlanId = sub.PendingPlanID.String()
+	}
+	if sub.CouponID != nil {
+		pbSub.CouponId = sub.CouponID.String()
+	}
+	pbSub.TaxCountry = sub.TaxCountry
+	pbSub.TaxRegion = sub.TaxRegion
+	return pbSub
+}
+
+func toCouponProto(coupon *models.Coupon) *couponpb.Coupon {
+	if coupon == nil {
+		return nil
+	}
+
+	pbCoupon := &couponpb.Coupon{
+		Id:             coupon.ID.String(),
+		Code:           coupon.Code,
+		PercentOff:     coupon.PercentOff,
+		AmountOff:      coupon.AmountOff,
+		Currency:       coupon.Currency,
+		Duration:       coupon.Duration,
+		DurationCycles: int32(coupon.DurationCycles),
+		MaxRedemptions: int32(coupon.MaxRedemptions),
+		TimesRedeemed:  int32(coupon.TimesRedeemed),
+		ProductIds:     uuidStrings(coupon.ProductIDs),
+		PlanIds:        uuidStrings(coupon.PlanIDs),
+		CreatedAt:      timestamppb.New(coupon.CreatedAt),
+		UpdatedAt:      timestamppb.New(coupon.UpdatedAt),
+	}
+	if coupon.ExpiresAt != nil {
+		pbCoupon.ExpiresAt = timestamppb.New(*coupon.ExpiresAt)
+	}
+	return pbCoupon
+}
+
+// fromCouponTermsProto builds the coupon described by the fields shared by
+// the create and update requests.
+func fromCouponTermsProto(percentOff, amountOff float64, currency, duration string, durationCycles, maxRedemptions int32, expiresAt *timestamppb.Timestamp, productIDs, planIDs []string) (models.Coupon, error) {
+	coupon := models.Coupon{
+		PercentOff:     percentOff,
+		AmountOff:      amountOff,
+		Currency:       currency,
+		Duration:       duration,
+		DurationCycles: int(durationCycles),
+		MaxRedemptions: int(maxRedemptions),
+	}
+	if expiresAt != nil {
+		t := expiresAt.AsTime()
+		coupon.ExpiresAt = &t
+	}
+
+	var err error
+	if coupon.ProductIDs, err = parseUUIDs(productIDs, "productIds", "invalid product ID format"); err != nil {
+		return models.Coupon{}, err
+	}
+	if coupon.PlanIDs, err = parseUUIDs(planIDs, "planIds", "invalid subscription plan ID format"); err != nil {
+		return models.Coupon{}, err
+	}
+	return coupon, nil
+}
+
+func toQuotePriceProto(quote *service.PriceQuote) *couponpb.QuotePriceResponse {
+	return &couponpb.QuotePriceResponse{
+		PlanId:           quote.PlanID.String(),
+		CouponCode:       quote.CouponCode,
+		Currency:         quote.Currency,
+		Price:            quote.Price,
+		Discount:         quote.Discount,
+		Amount:           quote.Amount,
+		DiscountCycles:   int32(quote.DiscountCycles),
+		Accepted:         quote.CouponCode != "" && len(quote.Rejections) == 0,
+		RejectionReasons: quote.Rejections,
+		Net:              quote.Tax.Net,
+		Tax:              quote.Tax.Tax,
+		Gross:            quote.Tax.Gross,
+		TaxRate:          quote.Tax.Rate,
+		TaxInclusive:     quote.Tax.Inclusive,
+	}
+}
+
+func toUsageRecordProto(record *models.UsageRecord) *usagepb.UsageRecord {
+	return &usagepb.UsageRecord{
+		Id:             record.ID.String(),
+		SubscriptionId: record.SubscriptionID.String(),
+		EventId:        record.EventID,
+		Quantity:       record.Quantity,
+		Timestamp:      timestamppb.New(record.Timestamp),
+		CreatedAt:      timestamppb.New(record.CreatedAt),
+	}
+}
+
+func toUsageSummaryProto(summary *service.UsageSummary) *usagepb.UsageSummary {
+	tiers := make([]*usagepb.TierCharge, len(summary.Price.Tiers))
+	for i, charge := range summary.Price.Tiers {
+		tiers[i] = &usagepb.TierCharge{
+			Tier:      int32(charge.Tier),
+			FromUnit:  int32(charge.FromUnit),
+			ToUnit:    int32(charge.ToUnit),
+			Quantity:  int32(charge.Quantity),
+			UnitPrice: charge.UnitPrice,
+			FlatFee:   charge.FlatFee,
+			Amount:    charge.Amount,
+		}
+	}
+	return &usagepb.UsageSummary{
+		SubscriptionId: summary.Subscription.ID.String(),
+		PlanId:         summary.Plan.ID.String(),
+		Unit:           summary.Plan.Meter.Unit,
+		Aggregation:    summary.Plan.Meter.Aggregation,
+		PeriodStart:    timestamppb.New(summary.PeriodStart),
+		PeriodEnd:      timestamppb.New(summary.PeriodEnd),
+		Quantity:       summary.Quantity,
+		Records:        summary.Records,
+		PricingModel:   summary.Plan.PricingModelOrDefault(),
+		Amount:         summary.Price.Total,
+		Tiers:          tiers,
+	}
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	if len(ids) == 0 {
+		return nil
+	}
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = id.String()
+	}
+	return strs
+}
+
+func parseUUIDs(strs []string, field, message string) ([]uuid.UUID, error) {
+	if len(strs) == 0 {
+		return nil, nil
+	}
+	ids := make([]uuid.UUID, len(strs))
+	for i, s := range strs {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return nil, apperrors.NewValidationError(field, message)
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+func toLineItemsProto(items []models.LineItem) []*billingpb.LineItem {
+	pbItems := make([]*billingpb.LineItem, len(items))
+	for i, item := range items {
+		pbItems[i] = &billingpb.LineItem{
+			Kind:        item.Kind,
+			Description: item.Description,
+			PlanId:      item.PlanID.String(),
+			Amount:      item.Amount,
+			PeriodStart: timestamppb.New(item.PeriodStart),
+			PeriodEnd:   timestamppb.New(item.PeriodEnd),
+		}
+	}
+	return pbItems
+}
+
+func toPlanChangeResponse(change *service.PlanChange) *billingpb.PlanChangeResponse {
+	resp := &billingpb.PlanChangeResponse{
+		Subscription: toSubscriptionProto(change.Subscription),
+		LineItems:    toLineItemsProto(change.LineItems),
+		Total:        change.Total(),
+		EffectiveAt:  timestamppb.New(change.EffectiveAt),
+	}
+	if change.Invoice != nil {
+		resp.InvoiceId = change.Invoice.ID.String()
+	}
+	return resp
+}
+
+func toInvoiceProto(invoice *models.Invoice) *invoicepb.Invoice {
+	if invoice == nil {
+		return nil
+	}
+
+	pbItems := make([]*invoicepb.LineItem, len(invoice.LineItems))
+	for i, item := range invoice.LineItems {
+		pbItems[i] = &invoicepb.LineItem{
+			Kind:        item.Kind,
+			Description: item.Description,
+			Amount:      item.Amount,
+			PeriodStart: timestamppb.New(item.PeriodStart),
+			PeriodEnd:   timestamppb.New(item.PeriodEnd),
+		}
+		if item.PlanID != uuid.Nil {
+			pbItems[i].PlanId = item.PlanID.String()
+		}
+	}
+
+	pbInvoice := &invoicepb.Invoice{
+		Id:             invoice.ID.String(),
+		Number:         invoice.Number,
+		SubscriptionId: invoice.SubscriptionID.String(),
+		CustomerId:     invoice.CustomerID,
+		Status:         invoice.Status,
+		Currency:       invoice.Currency,
+		PeriodStart:    timestamppb.New(invoice.PeriodStart),
+		PeriodEnd:      timestamppb.New(invoice.PeriodEnd),
+		LineItems:      pbItems,
+		Subtotal:       invoice.Subtotal,
+		Discount:       invoice.Discount,
+		Tax:            invoice.Tax,
+		TaxRate:        invoice.TaxRate,
+		TaxInclusive:   invoice.TaxInclusive,
+		TaxCountry:     invoice.TaxCountry,
+		TaxRegion:      invoice.TaxRegion,
+		Total:          invoice.Total,
+		CreatedAt:      timestamppb.New(invoice.CreatedAt),
+		UpdatedAt:      timestamppb.New(invoice.UpdatedAt),
+	}
+	if invoice.IssuedAt != nil {
+		pbInvoice.IssuedAt = timestamppb.New(*invoice.IssuedAt)
+	}
+	if invoice.PaidAt != nil {
+		pbInvoice.PaidAt = timestamppb.New(*invoice.PaidAt)
+	}
+	if invoice.VoidedAt != nil {
+		pbInvoice.VoidedAt = timestamppb.New(*invoice.VoidedAt)
+	}
+	return pbInvoice
+}
+
+func toProductResultsProto(results []service.ProductResult) []*productpb.ProductResult {
+	pbResults := make([]*productpb.ProductResult, len(results))
+	for i, result := range results {
+		pbResults[i] = &productpb.ProductResult{
+			Index:   int32(i),
+			Product: toProductProto(result.Product),
+		}
+		if result.Err != nil {
+			st := status.Convert(mapServiceError(result.Err))
+			pbResults[i].Code = int32(st.Code())
+			pbResults[i].Error = st.Message()
+		}
+	}
+	return pbResults
+}
+
+func toSubscriptionPlanResultsProto(results []service.SubscriptionPlanResult) []*subscriptionpb.SubscriptionPlanResult {
+	pbResults := make([]*subscriptionpb.SubscriptionPlanResult, len(results))
+	for i, result := range results {
+		pbResults[i] = &subscriptionpb.SubscriptionPlanResult{
+			Index: int32(i),
+			Plan:  toSubscriptionPlanProto(result.Plan),
+		}
+		if result.Err != nil {
+			st := status.Convert(mapServiceError(result.Err))
+			pbResults[i].Code = int32(st.Code())
+			pbResults[i].Error = st.Message()
+		}
+	}
+	return pbResults
+}
+
+func toProductEventProto(event events.Event) *productpb.ProductEvent {
+	eventTypes := map[events.Type]productpb.EventType{
+		events.TypeCreated: productpb.EventType_EVENT_TYPE_CREATED,
+		events.TypeUpdated: productpb.EventType_EVENT_TYPE_UPDATED,
+		events.TypeDeleted: productpb.EventType_EVENT_TYPE_DELETED,
+	}
+
+	return &productpb.ProductEvent{
+		Sequence:   event.Sequence,
+		Type:       eventTypes[event.Type],
+		Product:    toProductProto(event.Product),
+		OccurredAt: timestamppb.New(event.OccurredAt),
+	}
+}
+
+func toSubscriptionPlanEventProto(event events.Event) *subscriptionpb.SubscriptionPlanEvent {
+	eventTypes := map[events.Type]subscriptionpb.EventType{
+		events.TypeCreated: subscriptionpb.EventType_EVENT_TYPE_CREATED,
+		events.TypeUpdated: subscriptionpb.EventType_EVENT_TYPE_UPDATED,
+		events.TypeDeleted: subscriptionpb.EventType_EVENT_TYPE_DELETED,
+	}
+
+	return &subscriptionpb.SubscriptionPlanEvent{
+		Sequence:   event.Sequence,
+		Type:       eventTypes[event.Type],
+		Plan:       toSubscriptionPlanProto(event.Plan),
+		OccurredAt: timestamppb.New(event.OccurredAt),
 	}
 }
 
@@ -47,6 +599,30 @@ func mapServiceError(err error) error {
 		return nil
 	}
 
+	if errors.Is(err, context.Canceled) {
+		return status.Error(codes.Canceled, err.Error())
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	if errors.Is(err, apperrors.ErrSequenceExpired) {
+		return status.Error(codes.OutOfRange, err.Error())
+	}
+
+	if errors.Is(err, apperrors.ErrSubscriberLagged) {
+		return status.Error(codes.Aborted, err.Error())
+	}
+
+	if errors.Is(err, apperrors.ErrSubscriptionChanged) {
+		return status.Error(codes.Aborted, err.Error())
+	}
+
+	if errors.Is(err, apperrors.ErrScopeNotHeld) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+
 
 	if apperrors.IsValidationError(err) {
 		return status.Error(codes.InvalidArgument, err.Error())
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/microservice-go/product-service/internal/service"
+	pb "github.com/microservice-go/product-service/proto/coupon"
+)
+
+type CouponHandler struct {
+	pb.UnimplementedCouponServiceServer
+	service service.CouponService
+}
+
+func NewCouponHandler(service service.CouponService) *CouponHandler {
+	return &CouponHandler{service: service}
+}
+
+func (h *CouponHandler) CreateCoupon(ctx context.Context, req *pb.CreateCouponRequest) (*pb.CouponResponse, error) {
+	coupon, err := fromCouponTermsProto(req.PercentOff, req.AmountOff, req.Currency, req.Duration, req.DurationCycles, req.MaxRedemptions, req.ExpiresAt, req.ProductIds, req.PlanIds)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+	coupon.Code = req.Code
+
+	created, err := h.service.CreateCoupon(ctx, coupon)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.CouponResponse{
+		Coupon: toCouponProto(created),
+	}, nil
+}
+
+func (h *CouponHandler) GetCoupon(ctx context.Context, req *pb.GetCouponRequest) (*pb.CouponResponse, error) {
+	coupon, err := h.service.GetCoupon(ctx, req.Id)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.CouponResponse{
+		Coupon: toCouponProto(coupon),
+	}, nil
+}
+
+func (h *CouponHandler) ListCoupons(ctx context.Context, req *pb.ListCouponsRequest) (*pb.ListCouponsResponse, error) {
+	coupons, total, err := h.service.ListCoupons(ctx, int(req.Page), int(req.PageSize))
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	pbCoupons := make([]*pb.Coupon, len(coupons))
+	for i := range coupons {
+		pbCoupons[i] = toCouponProto(&coupons[i])
+	}
+
+	return &pb.ListCouponsResponse{
+		Coupons: pbCoupons,
+		Total:   int32(total),
+	}, nil
+}
+
+func (h *CouponHandler) UpdateCoupon(ctx context.Context, req *pb.UpdateCouponRequest) (*pb.CouponResponse, error) {
+	coupon, err := fromCouponTermsProto(req.PercentOff, req.AmountOff, req.Currency, req.Duration, req.DurationCycles, req.MaxRedemptions, req.ExpiresAt, req.ProductIds, req.PlanIds)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	updated, err := h.service.UpdateCoupon(ctx, req.Id, coupon)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.CouponResponse{
+		Coupon: toCouponProto(updated),
+	}, nil
+}
+
+func (h *CouponHandler) DeleteCoupon(ctx context.Context, req *pb.DeleteCouponRequest) (*pb.DeleteCouponResponse, error) {
+	err := h.service.DeleteCoupon(ctx, req.Id)
+	if err != nil {
+		return &pb.DeleteCouponResponse{
+			Success: false,
+			Message: err.Error(),
+		}, mapServiceError(err)
+	}
+
+	return &pb.DeleteCouponResponse{
+		Success: true,
+		Message: "Coupon deleted successfully",
+	}, nil
+}
+
+func (h *CouponHandler) QuotePrice(ctx context.Context, req *pb.QuotePriceRequest) (*pb.QuotePriceResponse, error) {
+	quote, err := h.service.QuotePrice(ctx, req.PlanId, req.CouponCode, service.TaxLocation{Country: req.Country, Region: req.Region})
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return toQuotePriceProto(quote), nil
+}
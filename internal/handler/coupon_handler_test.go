@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/service"
+	"github.com/microservice-go/product-service/internal/tax"
+	pb "github.com/microservice-go/product-service/proto/coupon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type MockCouponService struct {
+	mock.Mock
+}
+
+func (m *MockCouponService) CreateCoupon(ctx context.Context, coupon models.Coupon) (*models.Coupon, error) {
+	args := m.Called(coupon)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Coupon), args.Error(1)
+}
+
+func (m *MockCouponService) GetCoupon(ctx context.Context, id string) (*models.Coupon, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Coupon), args.Error(1)
+}
+
+func (m *MockCouponService) ListCoupons(ctx context.Context, page, pageSize int) ([]models.Coupon, int64, error) {
+	args := m.Called(page, pageSize)
+	return args.Get(0).([]models.Coupon), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockCouponService) UpdateCoupon(ctx context.Context, id string, coupon models.Coupon) (*models.Coupon, error) {
+	args := m.Called(id, coupon)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Coupon), args.Error(1)
+}
+
+func (m *MockCouponService) DeleteCoupon(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockCouponService) QuotePrice(ctx context.Context, planID, couponCode string, location service.TaxLocation) (*service.PriceQuote, error) {
+	args := m.Called(planID, couponCode, location)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.PriceQuote), args.Error(1)
+}
+
+func TestCouponHandler_CreateCoupon(t *testing.T) {
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	productID := uuid.New()
+	expiresAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	expected := models.Coupon{
+		Code:           "SAVE5",
+		AmountOff:      5,
+		Currency:       "USD",
+		Duration:       models.CouponRepeating,
+		DurationCycles: 3,
+		MaxRedemptions: 100,
+		ExpiresAt:      &expiresAt,
+		ProductIDs:     []uuid.UUID{productID},
+	}
+	created := expected
+	created.ID = uuid.New()
+	mockService.On("CreateCoupon", expected).Return(&created, nil)
+
+	resp, err := handler.CreateCoupon(context.Background(), &pb.CreateCouponRequest{
+		Code:           "SAVE5",
+		AmountOff:      5,
+		Currency:       "USD",
+		Duration:       models.CouponRepeating,
+		DurationCycles: 3,
+		MaxRedemptions: 100,
+		ExpiresAt:      timestamppb.New(expiresAt),
+		ProductIds:     []string{productID.String()},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID.String(), resp.Coupon.Id)
+	assert.Equal(t, []string{productID.String()}, resp.Coupon.ProductIds)
+	assert.Empty(t, resp.Coupon.PlanIds)
+	assert.Equal(t, expiresAt, resp.Coupon.ExpiresAt.AsTime())
+	mockService.AssertExpectations(t)
+}
+
+func TestCouponHandler_CreateCoupon_InvalidRestriction(t *testing.T) {
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	resp, err := handler.CreateCoupon(context.Background(), &pb.CreateCouponRequest{Code: "A", PercentOff: 10, Duration: models.CouponOnce, PlanIds: []string{"invalid-uuid"}})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	mockService.AssertNotCalled(t, "CreateCoupon", mock.Anything)
+}
+
+func TestCouponHandler_QuotePrice(t *testing.T) {
+	mockService := new(MockCouponService)
+	handler := NewCouponHandler(mockService)
+
+	planID := uuid.New()
+	mockService.On("QuotePrice", planID.String(), "LAUNCH25", service.TaxLocation{Country: "GB"}).Return(&service.PriceQuote{
+		PlanID:         planID,
+		CouponCode:     "LAUNCH25",
+		Currency:       "USD",
+		Price:          29.99,
+		Discount:       7.5,
+		Amount:         22.49,
+		DiscountCycles: 1,
+		Tax:            tax.Result{Net: 22.49, Tax: 4.5, Gross: 26.99, Rate: 0.2},
+	}, nil)
+	mockService.On("QuotePrice", planID.String(), "SPENT", service.TaxLocation{}).Return(&service.PriceQuote{
+		PlanID:     planID,
+		CouponCode: "SPENT",
+		Price:      29.99,
+		Amount:     29.99,
+		Rejections: []string{"coupon has expired"},
+	}, nil)
+
+	resp, err := handler.QuotePrice(context.Background(), &pb.QuotePriceRequest{PlanId: planID.String(), CouponCode: "LAUNCH25", Country: "GB"})
+	assert.NoError(t, err)
+	assert.True(t, resp.Accepted)
+	assert.Equal(t, 22.49, resp.Amount)
+	assert.Equal(t, int32(1), resp.DiscountCycles)
+	assert.Equal(t, 22.49, resp.Net)
+	assert.Equal(t, 4.5, resp.Tax)
+	assert.Equal(t, 26.99, resp.Gross)
+	assert.Equal(t, 0.2, resp.TaxRate)
+	assert.False(t, resp.TaxInclusive)
+
+	resp, err = handler.QuotePrice(context.Background(), &pb.QuotePriceRequest{PlanId: planID.String(), CouponCode: "SPENT"})
+	assert.NoError(t, err)
+	assert.False(t, resp.Accepted)
+	assert.Equal(t, []string{"coupon has expired"}, resp.RejectionReasons)
+}
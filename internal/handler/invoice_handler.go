@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/microservice-go/product-service/internal/service"
+	pb "github.com/microservice-go/product-service/proto/invoice"
+)
+
+type InvoiceHandler struct {
+	pb.UnimplementedInvoiceServiceServer
+	service service.InvoiceService
+}
+
+func NewInvoiceHandler(service service.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{service: service}
+}
+
+func (h *InvoiceHandler) GetInvoice(ctx context.Context, req *pb.GetInvoiceRequest) (*pb.InvoiceResponse, error) {
+	invoice, err := h.service.GetInvoice(ctx, req.Id)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.InvoiceResponse{
+		Invoice: toInvoiceProto(invoice),
+	}, nil
+}
+
+func (h *InvoiceHandler) ListInvoices(ctx context.Context, req *pb.ListInvoicesRequest) (*pb.ListInvoicesResponse, error) {
+	invoices, total, err := h.service.ListInvoices(ctx, req.CustomerId, req.SubscriptionId, req.Status, int(req.Page), int(req.PageSize))
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	pbInvoices := make([]*pb.Invoice, len(invoices))
+	for i := range invoices {
+		pbInvoices[i] = toInvoiceProto(&invoices[i])
+	}
+
+	return &pb.ListInvoicesResponse{
+		Invoices: pbInvoices,
+		Total:    int32(total),
+	}, nil
+}
+
+func (h *InvoiceHandler) FinalizeInvoice(ctx context.Context, req *pb.InvoiceActionRequest) (*pb.InvoiceResponse, error) {
+	invoice, err := h.service.FinalizeInvoice(ctx, req.Id)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.InvoiceResponse{
+		Invoice: toInvoiceProto(invoice),
+	}, nil
+}
+
+func (h *InvoiceHandler) PayInvoice(ctx context.Context, req *pb.InvoiceActionRequest) (*pb.InvoiceResponse, error) {
+	invoice, err := h.service.PayInvoice(ctx, req.Id)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.InvoiceResponse{
+		Invoice: toInvoiceProto(invoice),
+	}, nil
+}
+
+func (h *InvoiceHandler) VoidInvoice(ctx context.Context, req *pb.InvoiceActionRequest) (*pb.InvoiceResponse, error) {
+	invoice, err := h.service.VoidInvoice(ctx, req.Id)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.InvoiceResponse{
+		Invoice: toInvoiceProto(invoice),
+	}, nil
+}
+
+func (h *InvoiceHandler) RenderInvoice(ctx context.Context, req *pb.RenderInvoiceRequest) (*pb.RenderInvoiceResponse, error) {
+	rendered, err := h.service.RenderInvoice(ctx, req.Id)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.RenderInvoiceResponse{
+		ContentType: rendered.ContentType,
+		Filename:    rendered.Filename,
+		Content:     rendered.Content,
+	}, nil
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/service"
+	pb "github.com/microservice-go/product-service/proto/invoice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type MockInvoiceService struct {
+	mock.Mock
+}
+
+func (m *MockInvoiceService) GetInvoice(ctx context.Context, id string) (*models.Invoice, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Invoice), args.Error(1)
+}
+
+func (m *MockInvoiceService) ListInvoices(ctx context.Context, customerID, subscriptionID, status string, page, pageSize int) ([]models.Invoice, int64, error) {
+	args := m.Called(customerID, subscriptionID, status, page, pageSize)
+	return args.Get(0).([]models.Invoice), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockInvoiceService) FinalizeInvoice(ctx context.Context, id string) (*models.Invoice, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Invoice), args.Error(1)
+}
+
+func (m *MockInvoiceService) PayInvoice(ctx context.Context, id string) (*models.Invoice, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Invoice), args.Error(1)
+}
+
+func (m *MockInvoiceService) VoidInvoice(ctx context.Context, id string) (*models.Invoice, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Invoice), args.Error(1)
+}
+
+func (m *MockInvoiceService) RenderInvoice(ctx context.Context, id string) (*service.RenderedInvoice, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.RenderedInvoice), args.Error(1)
+}
+
+func TestInvoiceHandler_GetInvoice(t *testing.T) {
+	mockService := new(MockInvoiceService)
+	handler := NewInvoiceHandler(mockService)
+
+	id, planID := uuid.New(), uuid.New()
+	issuedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockService.On("GetInvoice", id.String()).Return(&models.Invoice{
+		ID:       id,
+		Number:   "INV-000001",
+		Status:   models.InvoiceOpen,
+		Currency: "USD",
+		LineItems: []models.LineItem{
+			{Kind: models.LineItemPlan, PlanID: planID, Amount: 29.99},
+			{Kind: models.LineItemDiscount, Amount: -7.5},
+		},
+		Subtotal: 29.99,
+		Discount: 7.5,
+		Total:    22.49,
+		IssuedAt: &issuedAt,
+	}, nil)
+
+	resp, err := handler.GetInvoice(context.Background(), &pb.GetInvoiceRequest{Id: id.String()})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "INV-000001", resp.Invoice.Number)
+	assert.Equal(t, models.InvoiceOpen, resp.Invoice.Status)
+	assert.Len(t, resp.Invoice.LineItems, 2)
+	assert.Equal(t, planID.String(), resp.Invoice.LineItems[0].PlanId)
+	assert.Empty(t, resp.Invoice.LineItems[1].PlanId)
+	assert.Equal(t, 22.49, resp.Invoice.Total)
+	assert.Equal(t, issuedAt, resp.Invoice.IssuedAt.AsTime())
+	assert.Nil(t, resp.Invoice.PaidAt)
+}
+
+func TestInvoiceHandler_ListInvoices(t *testing.T) {
+	mockService := new(MockInvoiceService)
+	handler := NewInvoiceHandler(mockService)
+
+	mockService.On("ListInvoices", "cus_1", "", models.InvoiceOpen, 1, 10).
+		Return([]models.Invoice{{ID: uuid.New(), CustomerID: "cus_1"}}, int64(1), nil)
+
+	resp, err := handler.ListInvoices(context.Background(), &pb.ListInvoicesRequest{CustomerId: "cus_1", Status: models.InvoiceOpen, Page: 1, PageSize: 10})
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Invoices, 1)
+	assert.Equal(t, int32(1), resp.Total)
+}
+
+func TestInvoiceHandler_PayInvoice_Error(t *testing.T) {
+	mockService := new(MockInvoiceService)
+	handler := NewInvoiceHandler(mockService)
+
+	id := uuid.New()
+	mockService.On("PayInvoice", id.String()).
+		Return(nil, apperrors.NewValidationError("status", "only open invoices can be paid"))
+
+	resp, err := handler.PayInvoice(context.Background(), &pb.InvoiceActionRequest{Id: id.String()})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestInvoiceHandler_RenderInvoice(t *testing.T) {
+	mockService := new(MockInvoiceService)
+	handler := NewInvoiceHandler(mockService)
+
+	id := uuid.New()
+	mockService.On("RenderInvoice", id.String()).Return(&service.RenderedInvoice{
+		Invoice:     &models.Invoice{ID: id},
+		Filename:    "INV-000001.html",
+		ContentType: "text/html; charset=utf-8",
+		Content:     []byte("<html></html>"),
+	}, nil)
+
+	resp, err := handler.RenderInvoice(context.Background(), &pb.RenderInvoiceRequest{Id: id.String()})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "INV-000001.html", resp.Filename)
+	assert.Equal(t, "text/html; charset=utf-8", resp.ContentType)
+	assert.Equal(t, []byte("<html></html>"), resp.Content)
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 
+	"github.com/microservice-go/product-service/internal/models"
 	"github.com/microservice-go/product-service/internal/service"
 	pb "github.com/microservice-go/product-service/proto/product"
 )
@@ -17,7 +18,7 @@ func NewProductHandler(service service.ProductService) *ProductHandler {
 }
 
 func (h *ProductHandler) CreateProduct(ctx context.Context, req *pb.CreateProductRequest) (*pb.ProductResponse, error) {
-	product, err := h.service.CreateProduct(req.Name, req.Description, req.Price, req.ProductType)
+	product, err := h.service.CreateProduct(ctx, req.Name, req.Description, req.Price, req.ProductType, req.TaxInclusive)
 	if err != nil {
 		return nil, mapServiceError(err)
 	}
@@ -28,7 +29,13 @@ func (h *ProductHandler) CreateProduct(ctx context.Context, req *pb.CreateProduc
 }
 
 func (h *ProductHandler) GetProduct(ctx context.Context, req *pb.GetProductRequest) (*pb.ProductResponse, error) {
-	product, err := h.service.GetProduct(req.Id)
+	var product *models.Product
+	var err error
+	if req.AsOf != nil {
+		product, err = h.service.GetProductAsOf(ctx, req.Id, req.AsOf.AsTime())
+	} else {
+		product, err = h.service.GetProduct(ctx, req.Id)
+	}
 	if err != nil {
 		return nil, mapServiceError(err)
 	}
@@ -39,7 +46,7 @@ func (h *ProductHandler) GetProduct(ctx context.Context, req *pb.GetProductReque
 }
 
 func (h *ProductHandler) UpdateProduct(ctx context.Context, req *pb.UpdateProductRequest) (*pb.ProductResponse, error) {
-	product, err := h.service.UpdateProduct(req.Id, req.Name, req.Description, req.Price, req.ProductType)
+	product, err := h.service.UpdateProduct(ctx, req.Id, req.Name, req.Description, req.Price, req.ProductType, req.TaxInclusive)
 	if err != nil {
 		return nil, mapServiceError(err)
 	}
@@ -50,7 +57,7 @@ func (h *ProductHandler) UpdateProduct(ctx context.Context, req *pb.UpdateProduc
 }
 
 func (h *ProductHandler) DeleteProduct(ctx context.Context, req *pb.DeleteProductRequest) (*pb.DeleteProductResponse, error) {
-	err := h.service.DeleteProduct(req.Id)
+	err := h.service.DeleteProduct(ctx, req.Id)
 	if err != nil {
 		return &pb.DeleteProductResponse{
 			Success: false,
@@ -65,7 +72,14 @@ func (h *ProductHandler) DeleteProduct(ctx context.Context, req *pb.DeleteProduc
 }
 
 func (h *ProductHandler) ListProducts(ctx context.Context, req *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
-	products, total, err := h.service.ListProducts(req.ProductType, int(req.Page), int(req.PageSize))
+	var products []models.Product
+	var total int64
+	var err error
+	if req.AsOf != nil {
+		products, total, err = h.service.ListProductsAsOf(ctx, req.ProductType, int(req.Page), int(req.PageSize), req.AsOf.AsTime())
+	} else {
+		products, total, err = h.service.ListProducts(ctx, req.ProductType, int(req.Page), int(req.PageSize))
+	}
 	if err != nil {
 		return nil, mapServiceError(err)
 	}
@@ -80,3 +94,89 @@ func (h *ProductHandler) ListProducts(ctx context.Context, req *pb.ListProductsR
 		Total:    int32(total),
 	}, nil
 }
+
+func (h *ProductHandler) BatchGetProducts(ctx context.Context, req *pb.BatchGetProductsRequest) (*pb.BatchProductsResponse, error) {
+	results, err := h.service.BatchGetProducts(ctx, req.Ids)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.BatchProductsResponse{
+		Results: toProductResultsProto(results),
+	}, nil
+}
+
+func (h *ProductHandler) BatchCreateProducts(ctx context.Context, req *pb.BatchCreateProductsRequest) (*pb.BatchProductsResponse, error) {
+	inputs := make([]service.ProductInput, len(req.Items))
+	for i, item := range req.Items {
+		inputs[i] = service.ProductInput{
+			Name:         item.Name,
+			Description:  item.Description,
+			Price:        item.Price,
+			ProductType:  item.ProductType,
+			TaxInclusive: item.TaxInclusive,
+		}
+	}
+
+	results, err := h.service.BatchCreateProducts(ctx, inputs, req.Atomic)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.BatchProductsResponse{
+		Results: toProductResultsProto(results),
+	}, nil
+}
+
+func (h *ProductHandler) BatchUpdateProducts(ctx context.Context, req *pb.BatchUpdateProductsRequest) (*pb.BatchProductsResponse, error) {
+	inputs := make([]service.ProductInput, len(req.Items))
+	for i, item := range req.Items {
+		inputs[i] = service.ProductInput{
+			ID:           item.Id,
+			Name:         item.Name,
+			Description:  item.Description,
+			Price:        item.Price,
+			ProductType:  item.ProductType,
+			TaxInclusive: item.TaxInclusive,
+		}
+	}
+
+	results, err := h.service.BatchUpdateProducts(ctx, inputs, req.Atomic)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.BatchProductsResponse{
+		Results: toProductResultsProto(results),
+	}, nil
+}
+
+func (h *ProductHandler) BatchDeleteProducts(ctx context.Context, req *pb.BatchDeleteProductsRequest) (*pb.BatchProductsResponse, error) {
+	results, err := h.service.BatchDeleteProducts(ctx, req.Ids, req.Atomic)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.BatchProductsResponse{
+		Results: toProductResultsProto(results),
+	}, nil
+}
+
+func (h *ProductHandler) WatchProducts(req *pb.WatchProductsRequest, stream pb.ProductService_WatchProductsServer) error {
+	ctx := stream.Context()
+	sub, err := h.service.WatchProducts(ctx, req.ProductType, req.ProductId, req.ResumeFromSequence)
+	if err != nil {
+		return mapServiceError(err)
+	}
+	defer sub.Close()
+
+	for {
+		event, err := sub.Next(ctx)
+		if err != nil {
+			return mapServiceError(err)
+		}
+		if err := stream.Send(toProductEventProto(event)); err != nil {
+			return err
+		}
+	}
+}
@@ -3,27 +3,34 @@ package handler
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/events"
 	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/service"
 	pb "github.com/microservice-go/product-service/proto/product"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type MockProductService struct {
 	mock.Mock
 }
 
-func (m *MockProductService) CreateProduct(name, description string, price float64, productType string) (*models.Product, error) {
-	args := m.Called(name, description, price, productType)
+func (m *MockProductService) CreateProduct(ctx context.Context, name, description string, price float64, productType string, taxInclusive bool) (*models.Product, error) {
+	args := m.Called(name, description, price, productType, taxInclusive)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Product), args.Error(1)
 }
 
-func (m *MockProductService) GetProduct(id string) (*models.Product, error) {
+func (m *MockProductService) GetProduct(ctx context.Context, id string) (*models.Product, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -31,24 +38,92 @@ func (m *MockProductService) GetProduct(id string) (*models.Product, error) {
 	return args.Get(0).(*models.Product), args.Error(1)
 }
 
-func (m *MockProductService) UpdateProduct(id, name, description string, price float64, productType string) (*models.Product, error) {
-	args := m.Called(id, name, description, price, productType)
+func (m *MockProductService) GetProductAsOf(ctx context.Context, id string, asOf time.Time) (*models.Product, error) {
+	args := m.Called(id, asOf)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Product), args.Error(1)
 }
 
-func (m *MockProductService) DeleteProduct(id string) error {
+func (m *MockProductService) UpdateProduct(ctx context.Context, id, name, description string, price float64, productType string, taxInclusive bool) (*models.Product, error) {
+	args := m.Called(id, name, description, price, productType, taxInclusive)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductService) DeleteProduct(ctx context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *MockProductService) ListProducts(productType string, page, pageSize int) ([]models.Product, int64, error) {
+func (m *MockProductService) ListProducts(ctx context.Context, productType string, page, pageSize int) ([]models.Product, int64, error) {
 	args := m.Called(productType, page, pageSize)
 	return args.Get(0).([]models.Product), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockProductService) ListProductsAsOf(ctx context.Context, productType string, page, pageSize int, asOf time.Time) ([]models.Product, int64, error) {
+	args := m.Called(productType, page, pageSize, asOf)
+	return args.Get(0).([]models.Product), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockProductService) BatchGetProducts(ctx context.Context, ids []string) ([]service.ProductResult, error) {
+	args := m.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]service.ProductResult), args.Error(1)
+}
+
+func (m *MockProductService) BatchCreateProducts(ctx context.Context, inputs []service.ProductInput, atomic bool) ([]service.ProductResult, error) {
+	args := m.Called(inputs, atomic)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]service.ProductResult), args.Error(1)
+}
+
+func (m *MockProductService) BatchUpdateProducts(ctx context.Context, inputs []service.ProductInput, atomic bool) ([]service.ProductResult, error) {
+	args := m.Called(inputs, atomic)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]service.ProductResult), args.Error(1)
+}
+
+func (m *MockProductService) BatchDeleteProducts(ctx context.Context, ids []string, atomic bool) ([]service.ProductResult, error) {
+	args := m.Called(ids, atomic)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]service.ProductResult), args.Error(1)
+}
+
+func (m *MockProductService) WatchProducts(ctx context.Context, productType, productID string, fromSequence uint64) (*events.Subscription, error) {
+	args := m.Called(productType, productID, fromSequence)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*events.Subscription), args.Error(1)
+}
+
+type mockProductEventStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan *pb.ProductEvent
+}
+
+func (s *mockProductEventStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *mockProductEventStream) Send(event *pb.ProductEvent) error {
+	s.sent <- event
+	return nil
+}
+
 func TestProductHandler_CreateProduct(t *testing.T) {
 	mockService := new(MockProductService)
 	handler := NewProductHandler(mockService)
@@ -62,7 +137,7 @@ func TestProductHandler_CreateProduct(t *testing.T) {
 		ProductType: "digital",
 	}
 
-	mockService.On("CreateProduct", "Test Product", "Test Description", 99.99, "digital").
+	mockService.On("CreateProduct", "Test Product", "Test Description", 99.99, "digital", false).
 		Return(expectedProduct, nil)
 
 	req := &pb.CreateProductRequest{
@@ -156,3 +231,97 @@ func TestProductHandler_ListProducts(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestProductHandler_BatchCreateProducts(t *testing.T) {
+	mockService := new(MockProductService)
+	handler := NewProductHandler(mockService)
+
+	productID := uuid.New()
+	inputs := []service.ProductInput{
+		{Name: "Valid", Price: 10, ProductType: "digital"},
+		{Name: "", Price: 10, ProductType: "digital"},
+	}
+	mockService.On("BatchCreateProducts", inputs, false).Return([]service.ProductResult{
+		{Product: &models.Product{ID: productID, Name: "Valid"}},
+		{Err: apperrors.NewValidationError("name", "product name is required")},
+	}, nil)
+
+	resp, err := handler.BatchCreateProducts(context.Background(), &pb.BatchCreateProductsRequest{
+		Items: []*pb.CreateProductRequest{
+			{Name: "Valid", Price: 10, ProductType: "digital"},
+			{Name: "", Price: 10, ProductType: "digital"},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Results, 2)
+	assert.Equal(t, productID.String(), resp.Results[0].Product.Id)
+	assert.Equal(t, int32(codes.OK), resp.Results[0].Code)
+	assert.Nil(t, resp.Results[1].Product)
+	assert.Equal(t, int32(1), resp.Results[1].Index)
+	assert.Equal(t, int32(codes.InvalidArgument), resp.Results[1].Code)
+	assert.Contains(t, resp.Results[1].Error, "product name is required")
+	mockService.AssertExpectations(t)
+}
+
+func TestProductHandler_BatchDeleteProducts_AtomicFailure(t *testing.T) {
+	mockService := new(MockProductService)
+	handler := NewProductHandler(mockService)
+
+	ids := []string{uuid.New().String()}
+	mockService.On("BatchDeleteProducts", ids, true).
+		Return(nil, apperrors.NewBatchItemError(0, apperrors.NewNotFoundError("Product", ids[0])))
+
+	resp, err := handler.BatchDeleteProducts(context.Background(), &pb.BatchDeleteProductsRequest{
+		Ids:    ids,
+		Atomic: true,
+	})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	mockService.AssertExpectations(t)
+}
+
+func TestProductHandler_WatchProducts(t *testing.T) {
+	mockService := new(MockProductService)
+	handler := NewProductHandler(mockService)
+
+	broker := events.NewBroker(10)
+	productID := uuid.New()
+	assert.NoError(t, broker.Publish(context.Background(), events.NewProductEvent(events.TypeCreated, &models.Product{ID: productID, Name: "Missed"})))
+	assert.NoError(t, broker.Publish(context.Background(), events.NewProductEvent(events.TypeUpdated, &models.Product{ID: productID, Name: "Seen"})))
+	sub, err := broker.Subscribe(1, events.Filter{})
+	assert.NoError(t, err)
+
+	mockService.On("WatchProducts", "digital", "", uint64(1)).Return(sub, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &mockProductEventStream{ctx: ctx, sent: make(chan *pb.ProductEvent, 10)}
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.WatchProducts(&pb.WatchProductsRequest{ProductType: "digital", ResumeFromSequence: 1}, stream)
+	}()
+
+	select {
+	case event := <-stream.sent:
+		assert.Equal(t, uint64(2), event.Sequence)
+		assert.Equal(t, pb.EventType_EVENT_TYPE_UPDATED, event.Type)
+		assert.Equal(t, "Seen", event.Product.Name)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for replayed event")
+	}
+
+	cancel()
+	assert.Equal(t, codes.Canceled, status.Code(<-done))
+	mockService.AssertExpectations(t)
+}
+
+func TestProductHandler_WatchProducts_SequenceExpired(t *testing.T) {
+	mockService := new(MockProductService)
+	handler := NewProductHandler(mockService)
+
+	mockService.On("WatchProducts", "", "", uint64(5)).Return(nil, apperrors.ErrSequenceExpired)
+
+	err := handler.WatchProducts(&pb.WatchProductsRequest{ResumeFromSequence: 5}, &mockProductEventStream{ctx: context.Background(), sent: make(chan *pb.ProductEvent, 1)})
+
+	assert.Equal(t, codes.OutOfRange, status.Code(err))
+}
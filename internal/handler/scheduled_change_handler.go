@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/service"
+	pb "github.com/microservice-go/product-service/proto/schedule"
+)
+
+type ScheduledChangeHandler struct {
+	pb.UnimplementedScheduledChangeServiceServer
+	service service.ScheduledChangeService
+}
+
+func NewScheduledChangeHandler(service service.ScheduledChangeService) *ScheduledChangeHandler {
+	return &ScheduledChangeHandler{service: service}
+}
+
+func (h *ScheduledChangeHandler) ScheduleProductChange(ctx context.Context, req *pb.ScheduleProductChangeRequest) (*pb.ScheduledChangeResponse, error) {
+	change := models.ProductChange{
+		Name:        req.Name,
+		Description: req.Description,
+		Price:       req.Price,
+		ProductType: req.ProductType,
+	}
+
+	scheduled, err := h.service.ScheduleProductChange(ctx, req.ProductId, change, toTime(req.EffectiveAt))
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.ScheduledChangeResponse{
+		Change: toScheduledChangeProto(scheduled),
+	}, nil
+}
+
+func (h *ScheduledChangeHandler) SchedulePlanChange(ctx context.Context, req *pb.SchedulePlanChangeRequest) (*pb.ScheduledChangeResponse, error) {
+	change := models.SubscriptionPlanChange{
+		PlanName: req.PlanName,
+		Price:    req.Price,
+	}
+	if req.IntervalUnit != nil || req.IntervalCount != nil {
+		change.Interval = &models.BillingInterval{Unit: req.GetIntervalUnit(), Count: int(req.GetIntervalCount())}
+	} else if req.Duration != nil {
+		duration := int(*req.Duration)
+		change.Duration = &duration
+	}
+
+	scheduled, err := h.service.SchedulePlanChange(ctx, req.PlanId, change, toTime(req.EffectiveAt))
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.ScheduledChangeResponse{
+		Change: toScheduledChangeProto(scheduled),
+	}, nil
+}
+
+func (h *ScheduledChangeHandler) ListScheduledChanges(ctx context.Context, req *pb.ListScheduledChangesRequest) (*pb.ListScheduledChangesResponse, error) {
+	changes, total, err := h.service.ListScheduledChanges(ctx, req.ResourceId, req.Status, int(req.Page), int(req.PageSize))
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	pbChanges := make([]*pb.ScheduledChange, len(changes))
+	for i := range changes {
+		pbChanges[i] = toScheduledChangeProto(&changes[i])
+	}
+
+	return &pb.ListScheduledChangesResponse{
+		Changes: pbChanges,
+		Total:   int32(total),
+	}, nil
+}
+
+func (h *ScheduledChangeHandler) CancelScheduledChange(ctx context.Context, req *pb.CancelScheduledChangeRequest) (*pb.ScheduledChangeResponse, error) {
+	change, err := h.service.CancelScheduledChange(ctx, req.Id)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.ScheduledChangeResponse{
+		Change: toScheduledChangeProto(change),
+	}, nil
+}
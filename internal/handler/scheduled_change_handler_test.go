@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	pb "github.com/microservice-go/product-service/proto/schedule"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type MockScheduledChangeService struct {
+	mock.Mock
+}
+
+func (m *MockScheduledChangeService) ScheduleProductChange(ctx context.Context, productID string, change models.ProductChange, effectiveAt time.Time) (*models.ScheduledChange, error) {
+	args := m.Called(productID, change, effectiveAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ScheduledChange), args.Error(1)
+}
+
+func (m *MockScheduledChangeService) SchedulePlanChange(ctx context.Context, planID string, change models.SubscriptionPlanChange, effectiveAt time.Time) (*models.ScheduledChange, error) {
+	args := m.Called(planID, change, effectiveAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ScheduledChange), args.Error(1)
+}
+
+func (m *MockScheduledChangeService) ListScheduledChanges(ctx context.Context, resourceID, status string, page, pageSize int) ([]models.ScheduledChange, int64, error) {
+	args := m.Called(resourceID, status, page, pageSize)
+	return args.Get(0).([]models.ScheduledChange), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockScheduledChangeService) CancelScheduledChange(ctx context.Context, id string) (*models.ScheduledChange, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ScheduledChange), args.Error(1)
+}
+
+func (m *MockScheduledChangeService) ApplyDueChanges(ctx context.Context, now time.Time, limit int) (int, error) {
+	args := m.Called(now, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func TestScheduledChangeHandler_SchedulePlanChange(t *testing.T) {
+	mockService := new(MockScheduledChangeService)
+	handler := NewScheduledChangeHandler(mockService)
+
+	planID := uuid.New()
+	effectiveAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	duration := 12
+	price := 99.0
+	mockService.On("SchedulePlanChange", planID.String(), models.SubscriptionPlanChange{Duration: &duration, Price: &price}, effectiveAt).
+		Return(&models.ScheduledChange{
+			ID:           uuid.New(),
+			ResourceType: models.AuditResourceSubscriptionPlan,
+			ResourceID:   planID,
+			Changes:      `{"duration":12,"price":99}`,
+			EffectiveAt:  effectiveAt,
+			Status:       models.ScheduledChangePending,
+		}, nil)
+
+	durationPb := int32(12)
+	resp, err := handler.SchedulePlanChange(context.Background(), &pb.SchedulePlanChangeRequest{
+		PlanId:      planID.String(),
+		EffectiveAt: timestamppb.New(effectiveAt),
+		Duration:    &durationPb,
+		Price:       &price,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, planID.String(), resp.Change.ResourceId)
+	assert.Equal(t, "pending", resp.Change.Status)
+	assert.Nil(t, resp.Change.AppliedAt)
+	mockService.AssertExpectations(t)
+}
+
+func TestScheduledChangeHandler_CancelScheduledChange_NotPending(t *testing.T) {
+	mockService := new(MockScheduledChangeService)
+	handler := NewScheduledChangeHandler(mockService)
+
+	id := uuid.New().String()
+	mockService.On("CancelScheduledChange", id).
+		Return(nil, apperrors.NewValidationError("status", "only pending changes can be cancelled"))
+
+	_, err := handler.CancelScheduledChange(context.Background(), &pb.CancelScheduledChangeRequest{Id: id})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	mockService.AssertExpectations(t)
+}
@@ -2,7 +2,9 @@ package handler
 
 import (
 	"context"
+	"time"
 
+	"github.com/microservice-go/product-service/internal/models"
 	"github.com/microservice-go/product-service/internal/service"
 	pb "github.com/microservice-go/product-service/proto/subscription"
 )
@@ -17,7 +19,8 @@ func NewSubscriptionHandler(service service.SubscriptionService) *SubscriptionHa
 }
 
 func (h *SubscriptionHandler) CreateSubscriptionPlan(ctx context.Context, req *pb.CreateSubscriptionPlanRequest) (*pb.SubscriptionPlanResponse, error) {
-	plan, err := h.service.CreateSubscriptionPlan(req.ProductId, req.PlanName, int(req.Duration), req.Price)
+	plan, err := h.service.CreateSubscriptionPlan(ctx, req.ProductId, req.PlanName, fromBillingIntervalProto(req.Interval, req.Duration), req.Price,
+		req.PricingModel, fromPriceTiersProto(req.Tiers), fromMeterProto(req.Meter), int(req.TrialDays), fromIntroPhasesProto(req.IntroPhases))
 	if err != nil {
 		return nil, mapServiceError(err)
 	}
@@ -28,7 +31,13 @@ func (h *SubscriptionHandler) CreateSubscriptionPlan(ctx context.Context, req *p
 }
 
 func (h *SubscriptionHandler) GetSubscriptionPlan(ctx context.Context, req *pb.GetSubscriptionPlanRequest) (*pb.SubscriptionPlanResponse, error) {
-	plan, err := h.service.GetSubscriptionPlan(req.Id)
+	var plan *models.SubscriptionPlan
+	var err error
+	if req.AsOf != nil {
+		plan, err = h.service.GetSubscriptionPlanAsOf(ctx, req.Id, req.AsOf.AsTime())
+	} else {
+		plan, err = h.service.GetSubscriptionPlan(ctx, req.Id)
+	}
 	if err != nil {
 		return nil, mapServiceError(err)
 	}
@@ -39,7 +48,8 @@ func (h *SubscriptionHandler) GetSubscriptionPlan(ctx context.Context, req *pb.G
 }
 
 func (h *SubscriptionHandler) UpdateSubscriptionPlan(ctx context.Context, req *pb.UpdateSubscriptionPlanRequest) (*pb.SubscriptionPlanResponse, error) {
-	plan, err := h.service.UpdateSubscriptionPlan(req.Id, req.ProductId, req.PlanName, int(req.Duration), req.Price)
+	plan, err := h.service.UpdateSubscriptionPlan(ctx, req.Id, req.ProductId, req.PlanName, fromBillingIntervalProto(req.Interval, req.Duration), req.Price,
+		req.PricingModel, fromPriceTiersProto(req.Tiers), fromMeterProto(req.Meter), int(req.TrialDays), fromIntroPhasesProto(req.IntroPhases))
 	if err != nil {
 		return nil, mapServiceError(err)
 	}
@@ -50,7 +60,7 @@ func (h *SubscriptionHandler) UpdateSubscriptionPlan(ctx context.Context, req *p
 }
 
 func (h *SubscriptionHandler) DeleteSubscriptionPlan(ctx context.Context, req *pb.DeleteSubscriptionPlanRequest) (*pb.DeleteSubscriptionPlanResponse, error) {
-	err := h.service.DeleteSubscriptionPlan(req.Id)
+	err := h.service.DeleteSubscriptionPlan(ctx, req.Id)
 	if err != nil {
 		return &pb.DeleteSubscriptionPlanResponse{
 			Success: false,
@@ -65,7 +75,13 @@ func (h *SubscriptionHandler) DeleteSubscriptionPlan(ctx context.Context, req *p
 }
 
 func (h *SubscriptionHandler) ListSubscriptionPlans(ctx context.Context, req *pb.ListSubscriptionPlansRequest) (*pb.ListSubscriptionPlansResponse, error) {
-	plans, err := h.service.ListSubscriptionPlans(req.ProductId)
+	var plans []models.SubscriptionPlan
+	var err error
+	if req.AsOf != nil {
+		plans, err = h.service.ListSubscriptionPlansAsOf(ctx, req.ProductId, req.AsOf.AsTime())
+	} else {
+		plans, err = h.service.ListSubscriptionPlans(ctx, req.ProductId)
+	}
 	if err != nil {
 		return nil, mapServiceError(err)
 	}
@@ -80,3 +96,124 @@ func (h *SubscriptionHandler) ListSubscriptionPlans(ctx context.Context, req *pb
 		Total: int32(len(plans)),
 	}, nil
 }
+
+func (h *SubscriptionHandler) BatchGetSubscriptionPlans(ctx context.Context, req *pb.BatchGetSubscriptionPlansRequest) (*pb.BatchSubscriptionPlansResponse, error) {
+	results, err := h.service.BatchGetSubscriptionPlans(ctx, req.Ids)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.BatchSubscriptionPlansResponse{
+		Results: toSubscriptionPlanResultsProto(results),
+	}, nil
+}
+
+func (h *SubscriptionHandler) BatchCreateSubscriptionPlans(ctx context.Context, req *pb.BatchCreateSubscriptionPlansRequest) (*pb.BatchSubscriptionPlansResponse, error) {
+	inputs := make([]service.SubscriptionPlanInput, len(req.Items))
+	for i, item := range req.Items {
+		inputs[i] = service.SubscriptionPlanInput{
+			ProductID:    item.ProductId,
+			PlanName:     item.PlanName,
+			Interval:     fromBillingIntervalProto(item.Interval, item.Duration),
+			Price:        item.Price,
+			PricingModel: item.PricingModel,
+			Tiers:        fromPriceTiersProto(item.Tiers),
+			Meter:        fromMeterProto(item.Meter),
+			TrialDays:    int(item.TrialDays),
+			IntroPhases:  fromIntroPhasesProto(item.IntroPhases),
+		}
+	}
+
+	results, err := h.service.BatchCreateSubscriptionPlans(ctx, inputs, req.Atomic)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.BatchSubscriptionPlansResponse{
+		Results: toSubscriptionPlanResultsProto(results),
+	}, nil
+}
+
+func (h *SubscriptionHandler) BatchUpdateSubscriptionPlans(ctx context.Context, req *pb.BatchUpdateSubscriptionPlansRequest) (*pb.BatchSubscriptionPlansResponse, error) {
+	inputs := make([]service.SubscriptionPlanInput, len(req.Items))
+	for i, item := range req.Items {
+		inputs[i] = service.SubscriptionPlanInput{
+			ID:           item.Id,
+			ProductID:    item.ProductId,
+			PlanName:     item.PlanName,
+			Interval:     fromBillingIntervalProto(item.Interval, item.Duration),
+			Price:        item.Price,
+			PricingModel: item.PricingModel,
+			Tiers:        fromPriceTiersProto(item.Tiers),
+			Meter:        fromMeterProto(item.Meter),
+			TrialDays:    int(item.TrialDays),
+			IntroPhases:  fromIntroPhasesProto(item.IntroPhases),
+		}
+	}
+
+	results, err := h.service.BatchUpdateSubscriptionPlans(ctx, inputs, req.Atomic)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.BatchSubscriptionPlansResponse{
+		Results: toSubscriptionPlanResultsProto(results),
+	}, nil
+}
+
+func (h *SubscriptionHandler) BatchDeleteSubscriptionPlans(ctx context.Context, req *pb.BatchDeleteSubscriptionPlansRequest) (*pb.BatchSubscriptionPlansResponse, error) {
+	results, err := h.service.BatchDeleteSubscriptionPlans(ctx, req.Ids, req.Atomic)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.BatchSubscriptionPlansResponse{
+		Results: toSubscriptionPlanResultsProto(results),
+	}, nil
+}
+
+func (h *SubscriptionHandler) GetPriceSchedule(ctx context.Context, req *pb.GetPriceScheduleRequest) (*pb.GetPriceScheduleResponse, error) {
+	start := time.Now()
+	if req.StartTime != nil {
+		start = req.StartTime.AsTime()
+	}
+
+	schedule, err := h.service.GetPriceSchedule(ctx, req.PlanId, start, int(req.Cycles))
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	periods := make([]*pb.PricePeriod, len(schedule))
+	for i := range schedule {
+		periods[i] = toPricePeriodProto(&schedule[i])
+	}
+	return &pb.GetPriceScheduleResponse{Periods: periods}, nil
+}
+
+func (h *SubscriptionHandler) CalculatePrice(ctx context.Context, req *pb.CalculatePriceRequest) (*pb.CalculatePriceResponse, error) {
+	calculation, err := h.service.CalculatePrice(ctx, req.PlanId, int(req.Quantity), service.TaxLocation{Country: req.Country, Region: req.Region})
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return toCalculatePriceProto(calculation), nil
+}
+
+func (h *SubscriptionHandler) WatchSubscriptionPlans(req *pb.WatchSubscriptionPlansRequest, stream pb.SubscriptionService_WatchSubscriptionPlansServer) error {
+	ctx := stream.Context()
+	sub, err := h.service.WatchSubscriptionPlans(ctx, req.ProductType, req.ProductId, req.ResumeFromSequence)
+	if err != nil {
+		return mapServiceError(err)
+	}
+	defer sub.Close()
+
+	for {
+		event, err := sub.Next(ctx)
+		if err != nil {
+			return mapServiceError(err)
+		}
+		if err := stream.Send(toSubscriptionPlanEventProto(event)); err != nil {
+			return err
+		}
+	}
+}
@@ -3,27 +3,37 @@ package handler
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/events"
 	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/service"
+	"github.com/microservice-go/product-service/internal/tax"
 	pb "github.com/microservice-go/product-service/proto/subscription"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+var monthly = models.BillingInterval{Unit: models.IntervalMonth, Count: 1}
+
 type MockSubscriptionService struct {
 	mock.Mock
 }
 
-func (m *MockSubscriptionService) CreateSubscriptionPlan(productID, planName string, duration int, price float64) (*models.SubscriptionPlan, error) {
-	args := m.Called(productID, planName, duration, price)
+func (m *MockSubscriptionService) CreateSubscriptionPlan(ctx context.Context, productID, planName string, interval models.BillingInterval, price float64, pricingModel string, tiers []models.PriceTier, meter *models.Meter, trialDays int, introPhases []models.IntroPhase) (*models.SubscriptionPlan, error) {
+	args := m.Called(productID, planName, interval, price, pricingModel, tiers, meter, trialDays, introPhases)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.SubscriptionPlan), args.Error(1)
 }
 
-func (m *MockSubscriptionService) GetSubscriptionPlan(id string) (*models.SubscriptionPlan, error) {
+func (m *MockSubscriptionService) GetSubscriptionPlan(ctx context.Context, id string) (*models.SubscriptionPlan, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -31,20 +41,28 @@ func (m *MockSubscriptionService) GetSubscriptionPlan(id string) (*models.Subscr
 	return args.Get(0).(*models.SubscriptionPlan), args.Error(1)
 }
 
-func (m *MockSubscriptionService) UpdateSubscriptionPlan(id, productID, planName string, duration int, price float64) (*models.SubscriptionPlan, error) {
-	args := m.Called(id, productID, planName, duration, price)
+func (m *MockSubscriptionService) GetSubscriptionPlanAsOf(ctx context.Context, id string, asOf time.Time) (*models.SubscriptionPlan, error) {
+	args := m.Called(id, asOf)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SubscriptionPlan), args.Error(1)
+}
+
+func (m *MockSubscriptionService) UpdateSubscriptionPlan(ctx context.Context, id, productID, planName string, interval models.BillingInterval, price float64, pricingModel string, tiers []models.PriceTier, meter *models.Meter, trialDays int, introPhases []models.IntroPhase) (*models.SubscriptionPlan, error) {
+	args := m.Called(id, productID, planName, interval, price, pricingModel, tiers, meter, trialDays, introPhases)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.SubscriptionPlan), args.Error(1)
 }
 
-func (m *MockSubscriptionService) DeleteSubscriptionPlan(id string) error {
+func (m *MockSubscriptionService) DeleteSubscriptionPlan(ctx context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *MockSubscriptionService) ListSubscriptionPlans(productID string) ([]models.SubscriptionPlan, error) {
+func (m *MockSubscriptionService) ListSubscriptionPlans(ctx context.Context, productID string) ([]models.SubscriptionPlan, error) {
 	args := m.Called(productID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -52,6 +70,67 @@ func (m *MockSubscriptionService) ListSubscriptionPlans(productID string) ([]mod
 	return args.Get(0).([]models.SubscriptionPlan), args.Error(1)
 }
 
+func (m *MockSubscriptionService) ListSubscriptionPlansAsOf(ctx context.Context, productID string, asOf time.Time) ([]models.SubscriptionPlan, error) {
+	args := m.Called(productID, asOf)
+	return args.Get(0).([]models.SubscriptionPlan), args.Error(1)
+}
+
+func (m *MockSubscriptionService) GetPriceSchedule(ctx context.Context, id string, start time.Time, cycles int) ([]models.PricePeriod, error) {
+	args := m.Called(id, start, cycles)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.PricePeriod), args.Error(1)
+}
+
+func (m *MockSubscriptionService) CalculatePrice(ctx context.Context, id string, quantity int, location service.TaxLocation) (*service.PriceCalculation, error) {
+	args := m.Called(id, quantity, location)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.PriceCalculation), args.Error(1)
+}
+
+func (m *MockSubscriptionService) BatchGetSubscriptionPlans(ctx context.Context, ids []string) ([]service.SubscriptionPlanResult, error) {
+	args := m.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]service.SubscriptionPlanResult), args.Error(1)
+}
+
+func (m *MockSubscriptionService) BatchCreateSubscriptionPlans(ctx context.Context, inputs []service.SubscriptionPlanInput, atomic bool) ([]service.SubscriptionPlanResult, error) {
+	args := m.Called(inputs, atomic)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]service.SubscriptionPlanResult), args.Error(1)
+}
+
+func (m *MockSubscriptionService) BatchUpdateSubscriptionPlans(ctx context.Context, inputs []service.SubscriptionPlanInput, atomic bool) ([]service.SubscriptionPlanResult, error) {
+	args := m.Called(inputs, atomic)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]service.SubscriptionPlanResult), args.Error(1)
+}
+
+func (m *MockSubscriptionService) BatchDeleteSubscriptionPlans(ctx context.Context, ids []string, atomic bool) ([]service.SubscriptionPlanResult, error) {
+	args := m.Called(ids, atomic)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]service.SubscriptionPlanResult), args.Error(1)
+}
+
+func (m *MockSubscriptionService) WatchSubscriptionPlans(ctx context.Context, productType, productID string, fromSequence uint64) (*events.Subscription, error) {
+	args := m.Called(productType, productID, fromSequence)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*events.Subscription), args.Error(1)
+}
+
 func TestSubscriptionHandler_CreateSubscriptionPlan(t *testing.T) {
 	mockService := new(MockSubscriptionService)
 	handler := NewSubscriptionHandler(mockService)
@@ -66,7 +145,7 @@ func TestSubscriptionHandler_CreateSubscriptionPlan(t *testing.T) {
 		Price:     29.99,
 	}
 
-	mockService.On("CreateSubscriptionPlan", productID.String(), "Monthly Plan", 30, 29.99).
+	mockService.On("CreateSubscriptionPlan", productID.String(), "Monthly Plan", monthly, 29.99, "", []models.PriceTier(nil), (*models.Meter)(nil), 0, []models.IntroPhase(nil)).
 		Return(expectedPlan, nil)
 
 	req := &pb.CreateSubscriptionPlanRequest{
@@ -120,6 +199,158 @@ func TestSubscriptionHandler_GetSubscriptionPlan(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestSubscriptionHandler_CreateSubscriptionPlan_Interval(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	quarterly := models.BillingInterval{Unit: models.IntervalMonth, Count: 3}
+	productID := uuid.New()
+	mockService.On("CreateSubscriptionPlan", productID.String(), "Quarterly", quarterly, 79.0, "", []models.PriceTier(nil), (*models.Meter)(nil), 0, []models.IntroPhase(nil)).
+		Return(&models.SubscriptionPlan{ID: uuid.New(), ProductID: productID, PlanName: "Quarterly", Interval: quarterly, Duration: 90, Price: 79}, nil)
+
+	resp, err := handler.CreateSubscriptionPlan(context.Background(), &pb.CreateSubscriptionPlanRequest{
+		ProductId: productID.String(),
+		PlanName:  "Quarterly",
+		Duration:  30, // ignored in favour of the interval
+		Interval:  &pb.BillingInterval{Unit: pb.IntervalUnit_INTERVAL_UNIT_MONTH, Count: 3},
+		Price:     79,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, pb.IntervalUnit_INTERVAL_UNIT_MONTH, resp.Plan.Interval.Unit)
+	assert.Equal(t, int32(3), resp.Plan.Interval.Count)
+	assert.Equal(t, int32(90), resp.Plan.Duration)
+	mockService.AssertExpectations(t)
+}
+
+func TestSubscriptionHandler_CreateSubscriptionPlan_IntroPricing(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	productID := uuid.New()
+	phases := []models.IntroPhase{{Price: 14.99, Cycles: 3}}
+	mockService.On("CreateSubscriptionPlan", productID.String(), "Monthly Plan", monthly, 29.99, "", []models.PriceTier(nil), (*models.Meter)(nil), 14, phases).
+		Return(&models.SubscriptionPlan{ID: uuid.New(), ProductID: productID, PlanName: "Monthly Plan", Interval: monthly, Price: 29.99, TrialDays: 14, IntroPhases: phases}, nil)
+
+	resp, err := handler.CreateSubscriptionPlan(context.Background(), &pb.CreateSubscriptionPlanRequest{
+		ProductId:   productID.String(),
+		PlanName:    "Monthly Plan",
+		Interval:    &pb.BillingInterval{Unit: pb.IntervalUnit_INTERVAL_UNIT_MONTH, Count: 1},
+		Price:       29.99,
+		TrialDays:   14,
+		IntroPhases: []*pb.IntroPhase{{Price: 14.99, Cycles: 3}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(14), resp.Plan.TrialDays)
+	assert.Len(t, resp.Plan.IntroPhases, 1)
+	assert.Equal(t, int32(3), resp.Plan.IntroPhases[0].Cycles)
+	mockService.AssertExpectations(t)
+}
+
+func TestSubscriptionHandler_GetPriceSchedule(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	planID := uuid.New()
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	trialEnd := start.AddDate(0, 0, 14)
+	mockService.On("GetPriceSchedule", planID.String(), start, 1).Return([]models.PricePeriod{
+		{Trial: true, Start: start, End: trialEnd},
+		{Cycle: 1, Start: trialEnd, End: trialEnd.AddDate(0, 1, 0), Price: 14.99},
+	}, nil)
+
+	resp, err := handler.GetPriceSchedule(context.Background(), &pb.GetPriceScheduleRequest{
+		PlanId:    planID.String(),
+		Cycles:    1,
+		StartTime: timestamppb.New(start),
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Periods, 2)
+	assert.True(t, resp.Periods[0].Trial)
+	assert.Equal(t, int32(1), resp.Periods[1].Cycle)
+	assert.Equal(t, 14.99, resp.Periods[1].Price)
+	assert.Equal(t, trialEnd, resp.Periods[1].StartTime.AsTime())
+	mockService.AssertExpectations(t)
+}
+
+func TestSubscriptionHandler_CreateSubscriptionPlan_Tiered(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	productID := uuid.New()
+	tiers := []models.PriceTier{{UpTo: 10, UnitPrice: 10}, {UpTo: 100, UnitPrice: 8}, {UnitPrice: 5}}
+	mockService.On("CreateSubscriptionPlan", productID.String(), "Seats", monthly, 0.0, models.PricingGraduated, tiers, (*models.Meter)(nil), 0, []models.IntroPhase(nil)).
+		Return(&models.SubscriptionPlan{ID: uuid.New(), ProductID: productID, PlanName: "Seats", Interval: monthly, PricingModel: models.PricingGraduated, Tiers: tiers}, nil)
+
+	resp, err := handler.CreateSubscriptionPlan(context.Background(), &pb.CreateSubscriptionPlanRequest{
+		ProductId:    productID.String(),
+		PlanName:     "Seats",
+		Interval:     &pb.BillingInterval{Unit: pb.IntervalUnit_INTERVAL_UNIT_MONTH, Count: 1},
+		PricingModel: models.PricingGraduated,
+		Tiers: []*pb.PriceTier{
+			{UpTo: 10, UnitPrice: 10},
+			{UpTo: 100, UnitPrice: 8},
+			{UnitPrice: 5},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.PricingGraduated, resp.Plan.PricingModel)
+	assert.Len(t, resp.Plan.Tiers, 3)
+	assert.Equal(t, int32(100), resp.Plan.Tiers[1].UpTo)
+	mockService.AssertExpectations(t)
+}
+
+func TestSubscriptionHandler_CalculatePrice(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	planID := uuid.New()
+	plan := &models.SubscriptionPlan{ID: planID, PricingModel: models.PricingGraduated}
+	location := service.TaxLocation{Country: "US", Region: "CA"}
+	mockService.On("CalculatePrice", planID.String(), 12, location).Return(&service.PriceCalculation{
+		Plan: plan,
+		Breakdown: models.PriceBreakdown{
+			Quantity: 12,
+			Total:    116,
+			Tiers: []models.TierCharge{
+				{Tier: 0, FromUnit: 1, ToUnit: 10, Quantity: 10, UnitPrice: 10, Amount: 100},
+				{Tier: 1, FromUnit: 11, ToUnit: 100, Quantity: 2, UnitPrice: 8, Amount: 16},
+			},
+		},
+		Tax: tax.Result{Net: 116, Tax: 8.41, Gross: 124.41, Rate: 0.0725},
+	}, nil)
+
+	resp, err := handler.CalculatePrice(context.Background(), &pb.CalculatePriceRequest{PlanId: planID.String(), Quantity: 12, Country: "US", Region: "CA"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, planID.String(), resp.PlanId)
+	assert.Equal(t, models.PricingGraduated, resp.PricingModel)
+	assert.Equal(t, 116.0, resp.Total)
+	assert.Len(t, resp.Tiers, 2)
+	assert.Equal(t, int32(11), resp.Tiers[1].FromUnit)
+	assert.Equal(t, 16.0, resp.Tiers[1].Amount)
+	assert.Equal(t, 8.41, resp.Tax)
+	assert.Equal(t, 124.41, resp.Gross)
+	mockService.AssertExpectations(t)
+}
+
+func TestSubscriptionHandler_CalculatePrice_InvalidQuantity(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	planID := uuid.New()
+	mockService.On("CalculatePrice", planID.String(), 0, service.TaxLocation{}).
+		Return(nil, apperrors.NewValidationError("quantity", "quantity must be between 1 and 1000000"))
+
+	resp, err := handler.CalculatePrice(context.Background(), &pb.CalculatePriceRequest{PlanId: planID.String()})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
 func TestSubscriptionHandler_UpdateSubscriptionPlan(t *testing.T) {
 	mockService := new(MockSubscriptionService)
 	handler := NewSubscriptionHandler(mockService)
@@ -134,7 +365,7 @@ func TestSubscriptionHandler_UpdateSubscriptionPlan(t *testing.T) {
 		Price:     49.99,
 	}
 
-	mockService.On("UpdateSubscriptionPlan", planID.String(), productID.String(), "Updated Plan", 60, 49.99).
+	mockService.On("UpdateSubscriptionPlan", planID.String(), productID.String(), "Updated Plan", models.BillingInterval{Unit: models.IntervalMonth, Count: 2}, 49.99, "", []models.PriceTier(nil), (*models.Meter)(nil), 0, []models.IntroPhase(nil)).
 		Return(expectedPlan, nil)
 
 	req := &pb.UpdateSubscriptionPlanRequest{
@@ -209,7 +440,7 @@ func TestSubscriptionHandler_CreateSubscriptionPlan_ServiceError(t *testing.T) {
 	handler := NewSubscriptionHandler(mockService)
 
 	productID := uuid.New()
-	mockService.On("CreateSubscriptionPlan", productID.String(), "Monthly Plan", 30, 29.99).
+	mockService.On("CreateSubscriptionPlan", productID.String(), "Monthly Plan", monthly, 29.99, "", []models.PriceTier(nil), (*models.Meter)(nil), 0, []models.IntroPhase(nil)).
 		Return(nil, assert.AnError)
 
 	req := &pb.CreateSubscriptionPlanRequest{
@@ -226,6 +457,25 @@ func TestSubscriptionHandler_CreateSubscriptionPlan_ServiceError(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestSubscriptionHandler_GetSubscriptionPlan_AsOf(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	planID := uuid.New()
+	asOf := time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)
+	mockService.On("GetSubscriptionPlanAsOf", planID.String(), asOf).
+		Return(&models.SubscriptionPlan{ID: planID, PlanName: "Annual Plan", Price: 299.99}, nil)
+
+	resp, err := handler.GetSubscriptionPlan(context.Background(), &pb.GetSubscriptionPlanRequest{
+		Id:   planID.String(),
+		AsOf: timestamppb.New(asOf),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 299.99, resp.Plan.Price)
+	mockService.AssertExpectations(t)
+}
+
 func TestSubscriptionHandler_GetSubscriptionPlan_ServiceError(t *testing.T) {
 	mockService := new(MockSubscriptionService)
 	handler := NewSubscriptionHandler(mockService)
@@ -250,7 +500,7 @@ func TestSubscriptionHandler_UpdateSubscriptionPlan_ServiceError(t *testing.T) {
 
 	planID := uuid.New()
 	productID := uuid.New()
-	mockService.On("UpdateSubscriptionPlan", planID.String(), productID.String(), "Updated Plan", 60, 49.99).
+	mockService.On("UpdateSubscriptionPlan", planID.String(), productID.String(), "Updated Plan", models.BillingInterval{Unit: models.IntervalMonth, Count: 2}, 49.99, "", []models.PriceTier(nil), (*models.Meter)(nil), 0, []models.IntroPhase(nil)).
 		Return(nil, assert.AnError)
 
 	req := &pb.UpdateSubscriptionPlanRequest{
@@ -305,3 +555,24 @@ func TestSubscriptionHandler_ListSubscriptionPlans_ServiceError(t *testing.T) {
 	assert.Nil(t, resp)
 	mockService.AssertExpectations(t)
 }
+
+func TestSubscriptionHandler_BatchGetSubscriptionPlans(t *testing.T) {
+	mockService := new(MockSubscriptionService)
+	handler := NewSubscriptionHandler(mockService)
+
+	planID := uuid.New()
+	missingID := uuid.New().String()
+	ids := []string{planID.String(), missingID}
+	mockService.On("BatchGetSubscriptionPlans", ids).Return([]service.SubscriptionPlanResult{
+		{Plan: &models.SubscriptionPlan{ID: planID, PlanName: "Monthly Plan"}},
+		{Err: apperrors.NewNotFoundError("SubscriptionPlan", missingID)},
+	}, nil)
+
+	resp, err := handler.BatchGetSubscriptionPlans(context.Background(), &pb.BatchGetSubscriptionPlansRequest{Ids: ids})
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Results, 2)
+	assert.Equal(t, "Monthly Plan", resp.Results[0].Plan.PlanName)
+	assert.Equal(t, int32(codes.NotFound), resp.Results[1].Code)
+	mockService.AssertExpectations(t)
+}
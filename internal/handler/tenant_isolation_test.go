@@ -0,0 +1,252 @@
+//go:build cgo
+// +build cgo
+
+package handler
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/events"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/repository"
+	"github.com/microservice-go/product-service/internal/service"
+	"github.com/microservice-go/product-service/internal/tenant"
+	pb "github.com/microservice-go/product-service/proto/product"
+	subscriptionpb "github.com/microservice-go/product-service/proto/subscription"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type tenantFixture struct {
+	products      *ProductHandler
+	subscriptions *SubscriptionHandler
+	productRepo   repository.ProductRepository
+	planRepo      repository.SubscriptionRepository
+	broker        *events.Broker
+	acme          context.Context
+	globex        context.Context
+	product       *pb.Product
+	plan          *subscriptionpb.SubscriptionPlan
+}
+
+// setupTenants wires real repositories, services and handlers to a SQLite
+// database holding one product and plan in tenant "acme".
+func setupTenants(t *testing.T) *tenantFixture {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "tenants.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Product{}, &models.SubscriptionPlan{}, &models.OutboxEvent{},
+		&models.AuditEvent{}, &models.ProductVersion{}, &models.SubscriptionPlanVersion{}))
+
+	f := &tenantFixture{
+		productRepo: repository.NewProductRepository(db),
+		planRepo:    repository.NewSubscriptionRepository(db),
+		broker:      events.NewBroker(10),
+		acme:        tenant.WithTenant(context.Background(), "acme"),
+		globex:      tenant.WithTenant(context.Background(), "globex"),
+	}
+	f.products = NewProductHandler(service.NewProductService(f.productRepo, service.WithEventBroker(f.broker)))
+	f.subscriptions = NewSubscriptionHandler(service.NewSubscriptionService(f.planRepo, f.productRepo, service.WithEventBroker(f.broker)))
+
+	created, err := f.products.CreateProduct(f.acme, &pb.CreateProductRequest{
+		Name: "Anvil", Description: "Heavy", Price: 10, ProductType: "physical",
+	})
+	require.NoError(t, err)
+	f.product = created.Product
+
+	plan, err := f.subscriptions.CreateSubscriptionPlan(f.acme, &subscriptionpb.CreateSubscriptionPlanRequest{
+		ProductId: f.product.Id, PlanName: "Monthly", Duration: 30, Price: 5,
+	})
+	require.NoError(t, err)
+	f.plan = plan.Plan
+	return f
+}
+
+type mockSubscriptionPlanEventStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan *subscriptionpb.SubscriptionPlanEvent
+}
+
+func (s *mockSubscriptionPlanEventStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *mockSubscriptionPlanEventStream) Send(event *subscriptionpb.SubscriptionPlanEvent) error {
+	s.sent <- event
+	return nil
+}
+
+func assertNotFound(t *testing.T, err error) {
+	t.Helper()
+	assert.Equal(t, codes.NotFound, status.Code(err), "error: %v", err)
+}
+
+func TestTenantIsolation_ProductService(t *testing.T) {
+	f := setupTenants(t)
+	id := f.product.Id
+
+	_, err := f.products.GetProduct(f.globex, &pb.GetProductRequest{Id: id})
+	assertNotFound(t, err)
+
+	_, err = f.products.GetProduct(f.globex, &pb.GetProductRequest{Id: id, AsOf: timestamppb.Now()})
+	assertNotFound(t, err)
+
+	_, err = f.products.UpdateProduct(f.globex, &pb.UpdateProductRequest{Id: id, Name: "Stolen", Price: 1, ProductType: "physical"})
+	assertNotFound(t, err)
+
+	_, err = f.products.DeleteProduct(f.globex, &pb.DeleteProductRequest{Id: id})
+	assertNotFound(t, err)
+
+	list, err := f.products.ListProducts(f.globex, &pb.ListProductsRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, list.Products)
+	assert.Zero(t, list.Total)
+
+	list, err = f.products.ListProducts(f.globex, &pb.ListProductsRequest{AsOf: timestamppb.Now()})
+	require.NoError(t, err)
+	assert.Empty(t, list.Products)
+
+	batch, err := f.products.BatchGetProducts(f.globex, &pb.BatchGetProductsRequest{Ids: []string{id}})
+	require.NoError(t, err)
+	require.Len(t, batch.Results, 1)
+	assert.Equal(t, int32(codes.NotFound), batch.Results[0].Code)
+
+	batch, err = f.products.BatchUpdateProducts(f.globex, &pb.BatchUpdateProductsRequest{
+		Items: []*pb.UpdateProductRequest{{Id: id, Name: "Stolen", Price: 1, ProductType: "physical"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(codes.NotFound), batch.Results[0].Code)
+
+	batch, err = f.products.BatchDeleteProducts(f.globex, &pb.BatchDeleteProductsRequest{Ids: []string{id}})
+	require.NoError(t, err)
+	assert.Equal(t, int32(codes.NotFound), batch.Results[0].Code)
+
+	// Products created by another tenant stay in that tenant.
+	batch, err = f.products.BatchCreateProducts(f.globex, &pb.BatchCreateProductsRequest{
+		Items: []*pb.CreateProductRequest{{Name: "Widget", Price: 3, ProductType: "digital"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(codes.OK), batch.Results[0].Code)
+
+	list, err = f.products.ListProducts(f.acme, &pb.ListProductsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Products, 1)
+	assert.Equal(t, "Anvil", list.Products[0].Name)
+
+	got, err := f.products.GetProduct(f.acme, &pb.GetProductRequest{Id: id})
+	require.NoError(t, err)
+	assert.Equal(t, "Anvil", got.Product.Name)
+	assert.Equal(t, 10.0, got.Product.Price)
+}
+
+func TestTenantIsolation_SubscriptionService(t *testing.T) {
+	f := setupTenants(t)
+	id := f.plan.Id
+
+	_, err := f.subscriptions.GetSubscriptionPlan(f.globex, &subscriptionpb.GetSubscriptionPlanRequest{Id: id})
+	assertNotFound(t, err)
+
+	_, err = f.subscriptions.GetSubscriptionPlan(f.globex, &subscriptionpb.GetSubscriptionPlanRequest{Id: id, AsOf: timestamppb.Now()})
+	assertNotFound(t, err)
+
+	_, err = f.subscriptions.UpdateSubscriptionPlan(f.globex, &subscriptionpb.UpdateSubscriptionPlanRequest{
+		Id: id, ProductId: f.product.Id, PlanName: "Stolen", Duration: 30, Price: 1,
+	})
+	assertNotFound(t, err)
+
+	_, err = f.subscriptions.DeleteSubscriptionPlan(f.globex, &subscriptionpb.DeleteSubscriptionPlanRequest{Id: id})
+	assertNotFound(t, err)
+
+	// Plans cannot be attached to another tenant's product.
+	_, err = f.subscriptions.CreateSubscriptionPlan(f.globex, &subscriptionpb.CreateSubscriptionPlanRequest{
+		ProductId: f.product.Id, PlanName: "Annual", Duration: 365, Price: 50,
+	})
+	assertNotFound(t, err)
+
+	list, err := f.subscriptions.ListSubscriptionPlans(f.globex, &subscriptionpb.ListSubscriptionPlansRequest{ProductId: f.product.Id})
+	require.NoError(t, err)
+	assert.Empty(t, list.Plans)
+
+	list, err = f.subscriptions.ListSubscriptionPlans(f.globex, &subscriptionpb.ListSubscriptionPlansRequest{ProductId: f.product.Id, AsOf: timestamppb.Now()})
+	require.NoError(t, err)
+	assert.Empty(t, list.Plans)
+
+	batch, err := f.subscriptions.BatchGetSubscriptionPlans(f.globex, &subscriptionpb.BatchGetSubscriptionPlansRequest{Ids: []string{id}})
+	require.NoError(t, err)
+	require.Len(t, batch.Results, 1)
+	assert.Equal(t, int32(codes.NotFound), batch.Results[0].Code)
+
+	batch, err = f.subscriptions.BatchCreateSubscriptionPlans(f.globex, &subscriptionpb.BatchCreateSubscriptionPlansRequest{
+		Items: []*subscriptionpb.CreateSubscriptionPlanRequest{{ProductId: f.product.Id, PlanName: "Annual", Duration: 365, Price: 50}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(codes.NotFound), batch.Results[0].Code)
+
+	batch, err = f.subscriptions.BatchUpdateSubscriptionPlans(f.globex, &subscriptionpb.BatchUpdateSubscriptionPlansRequest{
+		Items: []*subscriptionpb.UpdateSubscriptionPlanRequest{{Id: id, ProductId: f.product.Id, PlanName: "Stolen", Duration: 30, Price: 1}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(codes.NotFound), batch.Results[0].Code)
+
+	batch, err = f.subscriptions.BatchDeleteSubscriptionPlans(f.globex, &subscriptionpb.BatchDeleteSubscriptionPlansRequest{Ids: []string{id}})
+	require.NoError(t, err)
+	assert.Equal(t, int32(codes.NotFound), batch.Results[0].Code)
+
+	got, err := f.subscriptions.GetSubscriptionPlan(f.acme, &subscriptionpb.GetSubscriptionPlanRequest{Id: id})
+	require.NoError(t, err)
+	assert.Equal(t, "Monthly", got.Plan.PlanName)
+	assert.Equal(t, 5.0, got.Plan.Price)
+
+	list, err = f.subscriptions.ListSubscriptionPlans(f.acme, &subscriptionpb.ListSubscriptionPlansRequest{ProductId: f.product.Id})
+	require.NoError(t, err)
+	assert.Len(t, list.Plans, 1)
+}
+
+func TestTenantIsolation_Watch(t *testing.T) {
+	f := setupTenants(t)
+
+	product, err := f.productRepo.WithContext(f.acme).GetByID(uuid.MustParse(f.product.Id))
+	require.NoError(t, err)
+	plan, err := f.planRepo.WithContext(f.acme).GetByID(uuid.MustParse(f.plan.Id))
+	require.NoError(t, err)
+
+	// Sequence 1 is skipped by resuming from it; the acme events that follow
+	// must not reach globex watchers.
+	require.NoError(t, f.broker.Publish(context.Background(), events.NewProductEvent(events.TypeCreated, product)))
+	require.NoError(t, f.broker.Publish(context.Background(), events.NewProductEvent(events.TypeUpdated, product)))
+	require.NoError(t, f.broker.Publish(context.Background(), events.NewPlanEvent(events.TypeUpdated, plan)))
+	globexProduct := &models.Product{Name: "Widget", TenantID: "globex"}
+	require.NoError(t, f.broker.Publish(context.Background(), events.NewProductEvent(events.TypeCreated, globexProduct)))
+	require.NoError(t, f.broker.Publish(context.Background(), events.NewPlanEvent(events.TypeCreated, &models.SubscriptionPlan{PlanName: "Basic", TenantID: "globex"})))
+
+	ctx, cancel := context.WithCancel(f.globex)
+	defer cancel()
+
+	productStream := &mockProductEventStream{ctx: ctx, sent: make(chan *pb.ProductEvent, 10)}
+	go f.products.WatchProducts(&pb.WatchProductsRequest{ResumeFromSequence: 1}, productStream)
+	select {
+	case event := <-productStream.sent:
+		assert.Equal(t, "Widget", event.Product.Name)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for product event")
+	}
+
+	planStream := &mockSubscriptionPlanEventStream{ctx: ctx, sent: make(chan *subscriptionpb.SubscriptionPlanEvent, 10)}
+	go f.subscriptions.WatchSubscriptionPlans(&subscriptionpb.WatchSubscriptionPlansRequest{ResumeFromSequence: 1}, planStream)
+	select {
+	case event := <-planStream.sent:
+		assert.Equal(t, "Basic", event.Plan.PlanName)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for plan event")
+	}
+}
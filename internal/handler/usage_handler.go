@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/microservice-go/product-service/internal/service"
+	pb "github.com/microservice-go/product-service/proto/usage"
+)
+
+type UsageHandler struct {
+	pb.UnimplementedUsageServiceServer
+	service service.UsageService
+}
+
+func NewUsageHandler(service service.UsageService) *UsageHandler {
+	return &UsageHandler{service: service}
+}
+
+func (h *UsageHandler) RecordUsage(ctx context.Context, req *pb.RecordUsageRequest) (*pb.RecordUsageResponse, error) {
+	var timestamp time.Time
+	if req.Timestamp != nil {
+		timestamp = req.Timestamp.AsTime()
+	}
+
+	record, duplicate, err := h.service.RecordUsage(ctx, req.SubscriptionId, req.EventId, req.Quantity, timestamp)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.RecordUsageResponse{
+		Record:    toUsageRecordProto(record),
+		Duplicate: duplicate,
+	}, nil
+}
+
+func (h *UsageHandler) GetUsageSummary(ctx context.Context, req *pb.GetUsageSummaryRequest) (*pb.UsageSummary, error) {
+	summary, err := h.service.GetUsageSummary(ctx, req.SubscriptionId)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return toUsageSummaryProto(summary), nil
+}
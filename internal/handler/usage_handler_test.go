@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/service"
+	pb "github.com/microservice-go/product-service/proto/usage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type MockUsageService struct {
+	mock.Mock
+}
+
+func (m *MockUsageService) RecordUsage(ctx context.Context, subscriptionID, eventID string, quantity int64, timestamp time.Time) (*models.UsageRecord, bool, error) {
+	args := m.Called(subscriptionID, eventID, quantity, timestamp)
+	if args.Get(0) == nil {
+		return nil, false, args.Error(2)
+	}
+	return args.Get(0).(*models.UsageRecord), args.Bool(1), args.Error(2)
+}
+
+func (m *MockUsageService) GetUsageSummary(ctx context.Context, subscriptionID string) (*service.UsageSummary, error) {
+	args := m.Called(subscriptionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*service.UsageSummary), args.Error(1)
+}
+
+func TestUsageHandler_RecordUsage(t *testing.T) {
+	mockService := new(MockUsageService)
+	handler := NewUsageHandler(mockService)
+
+	subID := uuid.New()
+	at := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	mockService.On("RecordUsage", subID.String(), "evt_1", int64(25), at).
+		Return(&models.UsageRecord{ID: uuid.New(), EventID: "evt_1", SubscriptionID: subID, Quantity: 25, Timestamp: at}, true, nil)
+
+	resp, err := handler.RecordUsage(context.Background(), &pb.RecordUsageRequest{
+		SubscriptionId: subID.String(),
+		EventId:        "evt_1",
+		Quantity:       25,
+		Timestamp:      timestamppb.New(at),
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, resp.Duplicate)
+	assert.Equal(t, "evt_1", resp.Record.EventId)
+	assert.Equal(t, int64(25), resp.Record.Quantity)
+	mockService.AssertExpectations(t)
+}
+
+func TestUsageHandler_RecordUsage_NotMetered(t *testing.T) {
+	mockService := new(MockUsageService)
+	handler := NewUsageHandler(mockService)
+
+	subID := uuid.New()
+	mockService.On("RecordUsage", subID.String(), "evt_1", int64(1), time.Time{}).
+		Return(nil, false, apperrors.NewValidationError("subscriptionId", "the subscription's plan is not metered"))
+
+	resp, err := handler.RecordUsage(context.Background(), &pb.RecordUsageRequest{SubscriptionId: subID.String(), EventId: "evt_1", Quantity: 1})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestUsageHandler_GetUsageSummary(t *testing.T) {
+	mockService := new(MockUsageService)
+	handler := NewUsageHandler(mockService)
+
+	subID, planID := uuid.New(), uuid.New()
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	mockService.On("GetUsageSummary", subID.String()).Return(&service.UsageSummary{
+		Subscription: &models.Subscription{ID: subID},
+		Plan: &models.SubscriptionPlan{
+			ID:           planID,
+			PricingModel: models.PricingPerUnit,
+			Meter:        &models.Meter{Unit: "gb", Aggregation: models.AggregationMax},
+		},
+		PeriodStart: start,
+		PeriodEnd:   start.AddDate(0, 1, 0),
+		Quantity:    40,
+		Records:     3,
+		Price:       models.PriceBreakdown{Quantity: 40, Total: 8},
+	}, nil)
+
+	resp, err := handler.GetUsageSummary(context.Background(), &pb.GetUsageSummaryRequest{SubscriptionId: subID.String()})
+
+	assert.NoError(t, err)
+	assert.Equal(t, planID.String(), resp.PlanId)
+	assert.Equal(t, "gb", resp.Unit)
+	assert.Equal(t, models.AggregationMax, resp.Aggregation)
+	assert.Equal(t, int64(40), resp.Quantity)
+	assert.Equal(t, 8.0, resp.Amount)
+	assert.Equal(t, start, resp.PeriodStart.AsTime())
+	assert.Empty(t, resp.Tiers)
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/microservice-go/product-service/internal/service"
+	pb "github.com/microservice-go/product-service/proto/webhook"
+)
+
+type WebhookHandler struct {
+	pb.UnimplementedWebhookServiceServer
+	service service.WebhookService
+}
+
+func NewWebhookHandler(service service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+func (h *WebhookHandler) CreateWebhook(ctx context.Context, req *pb.CreateWebhookRequest) (*pb.CreateWebhookResponse, error) {
+	webhook, err := h.service.CreateWebhook(ctx, req.Url, req.EventTypes, req.Secret)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.CreateWebhookResponse{
+		Webhook: toWebhookProto(webhook),
+		Secret:  webhook.Secret,
+	}, nil
+}
+
+func (h *WebhookHandler) GetWebhook(ctx context.Context, req *pb.GetWebhookRequest) (*pb.WebhookResponse, error) {
+	webhook, err := h.service.GetWebhook(ctx, req.Id)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.WebhookResponse{
+		Webhook: toWebhookProto(webhook),
+	}, nil
+}
+
+func (h *WebhookHandler) UpdateWebhook(ctx context.Context, req *pb.UpdateWebhookRequest) (*pb.WebhookResponse, error) {
+	webhook, err := h.service.UpdateWebhook(ctx, req.Id, req.Url, req.EventTypes, req.Active)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.WebhookResponse{
+		Webhook: toWebhookProto(webhook),
+	}, nil
+}
+
+func (h *WebhookHandler) DeleteWebhook(ctx context.Context, req *pb.DeleteWebhookRequest) (*pb.DeleteWebhookResponse, error) {
+	err := h.service.DeleteWebhook(ctx, req.Id)
+	if err != nil {
+		return &pb.DeleteWebhookResponse{
+			Success: false,
+			Message: err.Error(),
+		}, mapServiceError(err)
+	}
+
+	return &pb.DeleteWebhookResponse{
+		Success: true,
+		Message: "Webhook deleted successfully",
+	}, nil
+}
+
+func (h *WebhookHandler) ListWebhooks(ctx context.Context, req *pb.ListWebhooksRequest) (*pb.ListWebhooksResponse, error) {
+	webhooks, err := h.service.ListWebhooks(ctx)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	pbWebhooks := make([]*pb.Webhook, len(webhooks))
+	for i := range webhooks {
+		pbWebhooks[i] = toWebhookProto(&webhooks[i])
+	}
+
+	return &pb.ListWebhooksResponse{
+		Webhooks: pbWebhooks,
+		Total:    int32(len(webhooks)),
+	}, nil
+}
+
+func (h *WebhookHandler) ListWebhookDeliveries(ctx context.Context, req *pb.ListWebhookDeliveriesRequest) (*pb.ListWebhookDeliveriesResponse, error) {
+	deliveries, err := h.service.ListWebhookDeliveries(ctx, req.WebhookId, int(req.Limit))
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	pbDeliveries := make([]*pb.WebhookDelivery, len(deliveries))
+	for i := range deliveries {
+		pbDeliveries[i] = toWebhookDeliveryProto(&deliveries[i])
+	}
+
+	return &pb.ListWebhookDeliveriesResponse{
+		Deliveries: pbDeliveries,
+	}, nil
+}
+
+func (h *WebhookHandler) RedeliverWebhook(ctx context.Context, req *pb.RedeliverWebhookRequest) (*pb.WebhookDeliveryResponse, error) {
+	delivery, err := h.service.RedeliverWebhook(ctx, req.DeliveryId)
+	if err != nil {
+		return nil, mapServiceError(err)
+	}
+
+	return &pb.WebhookDeliveryResponse{
+		Delivery: toWebhookDeliveryProto(delivery),
+	}, nil
+}
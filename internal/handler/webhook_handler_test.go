@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	pb "github.com/microservice-go/product-service/proto/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type MockWebhookService struct {
+	mock.Mock
+}
+
+func (m *MockWebhookService) CreateWebhook(ctx context.Context, url string, eventTypes []string, secret string) (*models.Webhook, error) {
+	args := m.Called(url, eventTypes, secret)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookService) GetWebhook(ctx context.Context, id string) (*models.Webhook, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookService) UpdateWebhook(ctx context.Context, id, url string, eventTypes []string, active bool) (*models.Webhook, error) {
+	args := m.Called(id, url, eventTypes, active)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookService) DeleteWebhook(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookService) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookService) ListWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]models.WebhookDelivery, error) {
+	args := m.Called(webhookID, limit)
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookService) RedeliverWebhook(ctx context.Context, deliveryID string) (*models.WebhookDelivery, error) {
+	args := m.Called(deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookDelivery), args.Error(1)
+}
+
+func TestWebhookHandler_CreateWebhook(t *testing.T) {
+	mockService := new(MockWebhookService)
+	handler := NewWebhookHandler(mockService)
+
+	webhookID := uuid.New()
+	mockService.On("CreateWebhook", "https://example.com/hooks", []string{"product.created"}, "").
+		Return(&models.Webhook{
+			ID:         webhookID,
+			URL:        "https://example.com/hooks",
+			EventTypes: "product.created",
+			Secret:     "generated-secret-value",
+			Active:     true,
+		}, nil)
+
+	resp, err := handler.CreateWebhook(context.Background(), &pb.CreateWebhookRequest{
+		Url:        "https://example.com/hooks",
+		EventTypes: []string{"product.created"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, webhookID.String(), resp.Webhook.Id)
+	assert.Equal(t, []string{"product.created"}, resp.Webhook.EventTypes)
+	assert.Equal(t, "generated-secret-value", resp.Secret)
+	mockService.AssertExpectations(t)
+}
+
+func TestWebhookHandler_GetWebhook_NotFound(t *testing.T) {
+	mockService := new(MockWebhookService)
+	handler := NewWebhookHandler(mockService)
+
+	webhookID := uuid.New().String()
+	mockService.On("GetWebhook", webhookID).Return(nil, apperrors.NewNotFoundError("Webhook", webhookID))
+
+	resp, err := handler.GetWebhook(context.Background(), &pb.GetWebhookRequest{Id: webhookID})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	mockService.AssertExpectations(t)
+}
+
+func TestWebhookHandler_ListWebhookDeliveries(t *testing.T) {
+	mockService := new(MockWebhookService)
+	handler := NewWebhookHandler(mockService)
+
+	webhookID := uuid.New()
+	mockService.On("ListWebhookDeliveries", webhookID.String(), 20).Return([]models.WebhookDelivery{
+		{ID: uuid.New(), WebhookID: webhookID, EventSequence: 3, EventType: "product.updated", Status: models.WebhookDeliveryFailed, Attempts: 10, ResponseStatus: 503},
+	}, nil)
+
+	resp, err := handler.ListWebhookDeliveries(context.Background(), &pb.ListWebhookDeliveriesRequest{
+		WebhookId: webhookID.String(),
+		Limit:     20,
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Deliveries, 1)
+	assert.Equal(t, uint64(3), resp.Deliveries[0].EventSequence)
+	assert.Equal(t, "failed", resp.Deliveries[0].Status)
+	assert.Equal(t, int32(503), resp.Deliveries[0].ResponseStatus)
+	assert.Nil(t, resp.Deliveries[0].DeliveredAt)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,72 @@
+package invoice
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"time"
+
+	"github.com/microservice-go/product-service/internal/models"
+)
+
+const ContentTypeHTML = "text/html; charset=utf-8"
+
+var funcs = template.FuncMap{
+	"money": func(amount float64, currency string) string {
+		return fmt.Sprintf("%.2f %s", amount, currency)
+	},
+	"date": func(t time.Time) string {
+		return t.UTC().Format("2006-01-02")
+	},
+	"percent": func(rate float64) string {
+		return fmt.Sprintf("%g%%", math.Round(rate*10000)/100)
+	},
+}
+
+var page = template.Must(template.New("invoice").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Invoice {{with .Number}}{{.}}{{else}}(draft){{end}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-top: 1.5em; }
+th, td { padding: 0.4em 0.6em; border-bottom: 1px solid #ddd; text-align: left; }
+td.amount, th.amount { text-align: right; }
+tfoot td { font-weight: bold; border-bottom: none; }
+.status { text-transform: uppercase; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Invoice {{with .Number}}{{.}}{{else}}(draft){{end}}</h1>
+<p class="status">{{.Status}}</p>
+<dl>
+<dt>Customer</dt><dd>{{.CustomerID}}</dd>
+<dt>Subscription</dt><dd>{{.SubscriptionID}}</dd>
+<dt>Period</dt><dd>{{date .PeriodStart}} to {{date .PeriodEnd}}</dd>
+{{with .IssuedAt}}<dt>Issued</dt><dd>{{date .}}</dd>{{end}}
+{{with .PaidAt}}<dt>Paid</dt><dd>{{date .}}</dd>{{end}}
+{{with .VoidedAt}}<dt>Voided</dt><dd>{{date .}}</dd>{{end}}
+{{with .TaxCountry}}<dt>Tax location</dt><dd>{{.}}{{with $.TaxRegion}}, {{.}}{{end}}</dd>{{end}}
+</dl>
+<table>
+<thead>
+<tr><th>Description</th><th>Period</th><th class="amount">Amount</th></tr>
+</thead>
+<tbody>
+{{range .LineItems}}<tr><td>{{.Description}}</td><td>{{date .PeriodStart}} to {{date .PeriodEnd}}</td><td class="amount">{{money .Amount $.Currency}}</td></tr>
+{{end}}</tbody>
+<tfoot>
+<tr><td colspan="2">Total</td><td class="amount">{{money .Total .Currency}}</td></tr>
+{{if .TaxInclusive}}<tr><td colspan="2">Includes tax at {{percent .TaxRate}}</td><td class="amount">{{money .Tax .Currency}}</td></tr>
+{{end}}</tfoot>
+</table>
+</body>
+</html>
+`))
+
+// RenderHTML writes inv as a standalone HTML page.
+func RenderHTML(w io.Writer, inv *models.Invoice) error {
+	return page.Execute(w, inv)
+}
@@ -0,0 +1,41 @@
+package invoice
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderHTML(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	inv := &models.Invoice{
+		ID:           uuid.New(),
+		Number:       "INV-000001",
+		CustomerID:   "<cus_1>",
+		Status:       models.InvoiceOpen,
+		Currency:     "EUR",
+		PeriodStart:  start,
+		PeriodEnd:    start.AddDate(0, 1, 0),
+		LineItems:    []models.LineItem{{Kind: models.LineItemPlan, Description: "Monthly", Amount: 24, PeriodStart: start, PeriodEnd: start.AddDate(0, 1, 0)}},
+		Tax:          4,
+		TaxRate:      0.2,
+		TaxInclusive: true,
+		TaxCountry:   "DE",
+		Total:        24,
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, RenderHTML(&buf, inv))
+
+	page := buf.String()
+	assert.Contains(t, page, "Invoice INV-000001")
+	assert.Contains(t, page, "2024-01-01 to 2024-02-01")
+	assert.Contains(t, page, "24.00 EUR")
+	assert.Contains(t, page, "Includes tax at 20%")
+	assert.Contains(t, page, "&lt;cus_1&gt;", "values are escaped")
+	assert.NotContains(t, page, "Paid</dt>")
+}
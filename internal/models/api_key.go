@@ -0,0 +1,47 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// APIKey authenticates a service-to-service caller. Only a SHA-256 hash of
+// the key is stored; Prefix keeps its first characters so operators can tell
+// keys apart.
+type APIKey struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	TenantID   string     `gorm:"not null;default:'default';index" json:"tenant_id"`
+	Name       string     `gorm:"not null" json:"name"`
+	Prefix     string     `gorm:"not null" json:"prefix"`
+	KeyHash    string     `gorm:"not null;uniqueIndex" json:"-"`
+	Scopes     string     `gorm:"not null" json:"scopes"` // comma-separated, e.g. "catalog.read,catalog.write"
+	LastUsedAt *time.Time `json:"-"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func (k *APIKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+func (k *APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return nil
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+func (k *APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
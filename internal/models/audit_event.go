@@ -0,0 +1,61 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+const (
+	AuditResourceProduct          = "product"
+	AuditResourceSubscriptionPlan = "subscription_plan"
+	AuditResourceWebhook          = "webhook"
+	AuditResourceAPIKey           = "api_key"
+	AuditResourceSubscription     = "subscription"
+	AuditResourceCoupon           = "coupon"
+	AuditResourceInvoice          = "invoice"
+)
+
+var ErrAuditEventImmutable = errors.New("audit events cannot be modified or deleted")
+
+// AuditEvent records one create, update or delete together with who made it
+// and the resource state before and after. Rows are append-only.
+type AuditEvent struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key"`
+	TenantID     string    `gorm:"not null;default:'default';index"`
+	Actor        string    `gorm:"not null;index"`
+	Action       string    `gorm:"not null"`
+	ResourceType string    `gorm:"not null"`
+	ResourceID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	Before       string    `gorm:"type:text"`
+	After        string    `gorm:"type:text"`
+	Diff         string    `gorm:"type:text"`
+	OccurredAt   time.Time `gorm:"not null;index"`
+}
+
+func (a *AuditEvent) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+func (a *AuditEvent) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditEventImmutable
+}
+
+func (a *AuditEvent) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditEventImmutable
+}
+
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
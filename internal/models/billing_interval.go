@@ -0,0 +1,101 @@
+package models
+
+import "time"
+
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+	IntervalYear  = "year"
+)
+
+// BillingInterval is the length of a billing period as a count of calendar
+// units, such as 1 month or 2 weeks.
+type BillingInterval struct {
+	Unit  string `gorm:"not null;default:''" json:"unit"`
+	Count int    `gorm:"not null;default:0" json:"count"`
+}
+
+// IntervalFromDays converts a length in days to the largest unit dividing it
+// exactly, treating 365 days as a year and 30 days as a month as plans did
+// before intervals.
+func IntervalFromDays(days int) BillingInterval {
+	switch {
+	case days > 0 && days%365 == 0:
+		return BillingInterval{Unit: IntervalYear, Count: days / 365}
+	case days > 0 && days%30 == 0:
+		return BillingInterval{Unit: IntervalMonth, Count: days / 30}
+	case days > 0 && days%7 == 0:
+		return BillingInterval{Unit: IntervalWeek, Count: days / 7}
+	default:
+		return BillingInterval{Unit: IntervalDay, Count: days}
+	}
+}
+
+func (i BillingInterval) IsZero() bool {
+	return i.Unit == "" && i.Count == 0
+}
+
+// ValidUnit reports whether Unit is one of the interval units.
+func (i BillingInterval) ValidUnit() bool {
+	switch i.Unit {
+	case IntervalDay, IntervalWeek, IntervalMonth, IntervalYear:
+		return true
+	}
+	return false
+}
+
+// NominalDays approximates the interval in days, with 30-day months and
+// 365-day years. Use PeriodStart for actual billing dates.
+func (i BillingInterval) NominalDays() int {
+	switch i.Unit {
+	case IntervalWeek:
+		return i.Count * 7
+	case IntervalMonth:
+		return i.Count * 30
+	case IntervalYear:
+		return i.Count * 365
+	default:
+		return i.Count
+	}
+}
+
+// PeriodStart returns the start of the n-th billing period of a subscription
+// anchored at anchor, where period 0 starts at anchor. Dates are computed
+// from the anchor rather than the previous period, so a subscription started
+// on January 31 renews on February 28 (or 29), then March 31. Days past the
+// end of a shorter month are clamped to its last day, and February 29 anchors
+// renew yearly on February 28 outside leap years.
+func (i BillingInterval) PeriodStart(anchor time.Time, n int) time.Time {
+	switch i.Unit {
+	case IntervalWeek:
+		return anchor.AddDate(0, 0, 7*i.Count*n)
+	case IntervalMonth:
+		return addMonths(anchor, i.Count*n)
+	case IntervalYear:
+		return addMonths(anchor, 12*i.Count*n)
+	default:
+		return anchor.AddDate(0, 0, i.Count*n)
+	}
+}
+
+// PeriodEnd returns the end of the n-th billing period, which is the start of
+// the next one.
+func (i BillingInterval) PeriodEnd(anchor time.Time, n int) time.Time {
+	return i.PeriodStart(anchor, n+1)
+}
+
+// addMonths adds months to t, clamping the day to the length of the resulting
+// month instead of overflowing into the next one as time.AddDate does.
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := daysIn(first.Year(), first.Month()); day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 30, 0, 0, time.UTC)
+}
+
+func TestBillingInterval_PeriodStart(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval BillingInterval
+		anchor   time.Time
+		n        int
+		want     time.Time
+	}{
+		{"monthly from mid-month", BillingInterval{IntervalMonth, 1}, date(2023, 1, 15), 1, date(2023, 2, 15)},
+		{"month end clamps to February", BillingInterval{IntervalMonth, 1}, date(2023, 1, 31), 1, date(2023, 2, 28)},
+		{"month end clamps to leap February", BillingInterval{IntervalMonth, 1}, date(2024, 1, 31), 1, date(2024, 2, 29)},
+		{"month end recovers after February", BillingInterval{IntervalMonth, 1}, date(2023, 1, 31), 2, date(2023, 3, 31)},
+		{"month end clamps to 30-day month", BillingInterval{IntervalMonth, 1}, date(2023, 1, 31), 3, date(2023, 4, 30)},
+		{"quarterly across a year", BillingInterval{IntervalMonth, 3}, date(2023, 11, 30), 1, date(2024, 2, 29)},
+		{"leap day yearly", BillingInterval{IntervalYear, 1}, date(2024, 2, 29), 1, date(2025, 2, 28)},
+		{"leap day returns in leap years", BillingInterval{IntervalYear, 1}, date(2024, 2, 29), 4, date(2028, 2, 29)},
+		{"weekly", BillingInterval{IntervalWeek, 2}, date(2023, 12, 25), 1, date(2024, 1, 8)},
+		{"daily", BillingInterval{IntervalDay, 10}, date(2023, 2, 25), 1, date(2023, 3, 7)},
+		{"period zero is the anchor", BillingInterval{IntervalMonth, 1}, date(2023, 1, 31), 0, date(2023, 1, 31)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.interval.PeriodStart(tt.anchor, tt.n))
+		})
+	}
+}
+
+func TestBillingInterval_PeriodEnd(t *testing.T) {
+	monthly := BillingInterval{IntervalMonth, 1}
+	anchor := date(2023, 1, 31)
+	for n := 0; n < 24; n++ {
+		assert.Equal(t, monthly.PeriodStart(anchor, n+1), monthly.PeriodEnd(anchor, n))
+		assert.True(t, monthly.PeriodEnd(anchor, n).After(monthly.PeriodStart(anchor, n)))
+	}
+	assert.Equal(t, date(2025, 1, 31), monthly.PeriodStart(anchor, 24), "no drift after two years")
+}
+
+func TestBillingInterval_KeepsLocalTimeAcrossDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	anchor := time.Date(2023, 3, 1, 9, 0, 0, 0, ny)
+	next := BillingInterval{IntervalMonth, 1}.PeriodStart(anchor, 1)
+	assert.Equal(t, time.Date(2023, 4, 1, 9, 0, 0, 0, ny), next)
+}
+
+func TestIntervalFromDays(t *testing.T) {
+	tests := map[int]BillingInterval{
+		30:  {IntervalMonth, 1},
+		90:  {IntervalMonth, 3},
+		365: {IntervalYear, 1},
+		730: {IntervalYear, 2},
+		14:  {IntervalWeek, 2},
+		10:  {IntervalDay, 10},
+		0:   {IntervalDay, 0},
+	}
+	for days, want := range tests {
+		got := IntervalFromDays(days)
+		assert.Equal(t, want, got, "%d days", days)
+		assert.Equal(t, days, got.NominalDays(), "%d days round-trips", days)
+	}
+}
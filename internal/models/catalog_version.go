@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProductVersion is one historical state of a product. It was current from
+// ValidFrom until ValidTo; ValidTo is nil for the live version.
+type ProductVersion struct {
+	ID               uint64    `gorm:"primaryKey;autoIncrement"`
+	TenantID         string    `gorm:"not null;default:'default';index"`
+	ProductID        uuid.UUID `gorm:"type:uuid;not null;index"`
+	Name             string    `gorm:"not null"`
+	Description      string    `gorm:"type:text"`
+	Price            float64   `gorm:"not null"`
+	ProductType      string    `gorm:"not null"`
+	TaxInclusive     bool      `gorm:"not null;default:false"`
+	ProductCreatedAt time.Time
+	ValidFrom        time.Time  `gorm:"not null;index"`
+	ValidTo          *time.Time `gorm:"index"`
+}
+
+func (ProductVersion) TableName() string {
+	return "product_versions"
+}
+
+// Product returns the product as it looked while this version was current.
+func (v *ProductVersion) Product() Product {
+	return Product{
+		ID:           v.ProductID,
+		TenantID:     v.TenantID,
+		Name:         v.Name,
+		Description:  v.Description,
+		Price:        v.Price,
+		ProductType:  v.ProductType,
+		TaxInclusive: v.TaxInclusive,
+		CreatedAt:    v.ProductCreatedAt,
+		UpdatedAt:    v.ValidFrom,
+	}
+}
+
+// SubscriptionPlanVersion is one historical state of a subscription plan,
+// with the same validity semantics as ProductVersion.
+type SubscriptionPlanVersion struct {
+	ID            uint64          `gorm:"primaryKey;autoIncrement"`
+	TenantID      string          `gorm:"not null;default:'default';index"`
+	PlanID        uuid.UUID       `gorm:"type:uuid;not null;index"`
+	ProductID     uuid.UUID       `gorm:"type:uuid;not null;index"`
+	PlanName      string          `gorm:"not null"`
+	Interval      BillingInterval `gorm:"embedded;embeddedPrefix:interval_"`
+	Duration      int             `gorm:"not null"`
+	Price         float64         `gorm:"not null"`
+	PricingModel  string          `gorm:"not null;default:'flat'"`
+	Tiers         []PriceTier     `gorm:"serializer:json;type:text"`
+	Meter         *Meter          `gorm:"serializer:json;type:text"`
+	TrialDays     int             `gorm:"not null;default:0"`
+	IntroPhases   []IntroPhase    `gorm:"serializer:json;type:text"`
+	PlanCreatedAt time.Time
+	ValidFrom     time.Time  `gorm:"not null;index"`
+	ValidTo       *time.Time `gorm:"index"`
+}
+
+func (SubscriptionPlanVersion) TableName() string {
+	return "subscription_plan_versions"
+}
+
+func (v *SubscriptionPlanVersion) Plan() SubscriptionPlan {
+	return SubscriptionPlan{
+		ID:           v.PlanID,
+		TenantID:     v.TenantID,
+		ProductID:    v.ProductID,
+		PlanName:     v.PlanName,
+		Interval:     v.Interval,
+		Duration:     v.Duration,
+		Price:        v.Price,
+		PricingModel: v.PricingModel,
+		Tiers:        v.Tiers,
+		Meter:        v.Meter,
+		TrialDays:    v.TrialDays,
+		IntroPhases:  v.IntroPhases,
+		CreatedAt:    v.PlanCreatedAt,
+		UpdatedAt:    v.ValidFrom,
+	}
+}
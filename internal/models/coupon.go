@@ -0,0 +1,102 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	CouponOnce      = "once"
+	CouponRepeating = "repeating"
+	CouponForever   = "forever"
+)
+
+// Coupon discounts a subscription's price by PercentOff, or by AmountOff in
+// Currency. Duration says which billing cycles of a subscription get the
+// discount: the first only, the first DurationCycles, or all of them. A coupon
+// restricted to ProductIDs or PlanIDs only applies to those plans and to the
+// plans of those products. A code is unique among a tenant's coupons that are
+// not deleted, so the code of a retired coupon can be issued again.
+type Coupon struct {
+	ID             uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
+	TenantID       string         `gorm:"not null;default:'default';uniqueIndex:idx_coupons_tenant_code" json:"tenant_id"`
+	Code           string         `gorm:"not null;uniqueIndex:idx_coupons_tenant_code,where:deleted_at IS NULL" json:"code"`
+	PercentOff     float64        `gorm:"not null;default:0" json:"percent_off"`
+	AmountOff      float64        `gorm:"not null;default:0" json:"amount_off"`
+	Currency       string         `gorm:"not null;default:''" json:"currency"`
+	Duration       string         `gorm:"not null" json:"duration"`
+	DurationCycles int            `gorm:"not null;default:0" json:"duration_cycles"`
+	MaxRedemptions int            `gorm:"not null;default:0" json:"max_redemptions"` // 0 is unlimited
+	TimesRedeemed  int            `gorm:"not null;default:0" json:"times_redeemed"`
+	ExpiresAt      *time.Time     `json:"expires_at"`
+	ProductIDs     []uuid.UUID    `gorm:"serializer:json;type:text" json:"product_ids"`
+	PlanIDs        []uuid.UUID    `gorm:"serializer:json;type:text" json:"plan_ids"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (c *Coupon) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+func (Coupon) TableName() string {
+	return "coupons"
+}
+
+// Discount returns how much the coupon takes off amount. Fixed amounts are
+// capped at amount, so a discounted price never goes below zero.
+func (c *Coupon) Discount(amount float64) float64 {
+	if c.PercentOff > 0 {
+		return RoundCents(amount * c.PercentOff / 100)
+	}
+	if c.AmountOff > amount {
+		return amount
+	}
+	return c.AmountOff
+}
+
+// Cycles returns how many billing cycles the discount lasts, or 0 if it lasts
+// for the life of the subscription.
+func (c *Coupon) Cycles() int {
+	switch c.Duration {
+	case CouponOnce:
+		return 1
+	case CouponRepeating:
+		return c.DurationCycles
+	default:
+		return 0
+	}
+}
+
+// AppliesTo reports whether the coupon's product and plan restrictions allow
+// it on plan.
+func (c *Coupon) AppliesTo(plan *SubscriptionPlan) bool {
+	if len(c.ProductIDs) == 0 && len(c.PlanIDs) == 0 {
+		return true
+	}
+	for _, id := range c.PlanIDs {
+		if id == plan.ID {
+			return true
+		}
+	}
+	for _, id := range c.ProductIDs {
+		if id == plan.ProductID {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Coupon) IsExpired(at time.Time) bool {
+	return c.ExpiresAt != nil && !at.Before(*c.ExpiresAt)
+}
+
+func (c *Coupon) IsExhausted() bool {
+	return c.MaxRedemptions > 0 && c.TimesRedeemed >= c.MaxRedemptions
+}
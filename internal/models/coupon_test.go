@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoupon_Discount(t *testing.T) {
+	assert.Equal(t, 7.5, (&Coupon{PercentOff: 25}).Discount(29.99))
+	assert.Equal(t, 5.0, (&Coupon{AmountOff: 5}).Discount(29.99))
+	assert.Equal(t, 3.0, (&Coupon{AmountOff: 5}).Discount(3), "fixed amounts are capped at the price")
+}
+
+func TestCoupon_AppliesTo(t *testing.T) {
+	plan := &SubscriptionPlan{ID: uuid.New(), ProductID: uuid.New()}
+
+	assert.True(t, (&Coupon{}).AppliesTo(plan), "unrestricted")
+	assert.True(t, (&Coupon{PlanIDs: []uuid.UUID{plan.ID}}).AppliesTo(plan))
+	assert.True(t, (&Coupon{ProductIDs: []uuid.UUID{plan.ProductID}}).AppliesTo(plan))
+	assert.False(t, (&Coupon{PlanIDs: []uuid.UUID{uuid.New()}, ProductIDs: []uuid.UUID{uuid.New()}}).AppliesTo(plan))
+}
+
+func TestCoupon_Cycles(t *testing.T) {
+	assert.Equal(t, 1, (&Coupon{Duration: CouponOnce}).Cycles())
+	assert.Equal(t, 3, (&Coupon{Duration: CouponRepeating, DurationCycles: 3}).Cycles())
+	assert.Equal(t, 0, (&Coupon{Duration: CouponForever}).Cycles())
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	InvoiceDraft = "draft"
+	InvoiceOpen  = "open"
+	InvoicePaid  = "paid"
+	InvoiceVoid  = "void"
+)
+
+// Invoice bills a subscription for what starting, renewing or changing it
+// charged. Drafts have no number yet; finalizing one gives it the next number
+// of its tenant and opens it for payment, or marks it paid straight away when
+// there is nothing to collect. The line items always add up to Total. With
+// TaxInclusive the prices already contain Tax, so it has no line of its own.
+type Invoice struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	TenantID       string     `gorm:"not null;default:'default';index;uniqueIndex:idx_invoices_tenant_number,where:number <> ''" json:"tenant_id"`
+	Number         string     `gorm:"not null;default:'';uniqueIndex:idx_invoices_tenant_number,where:number <> ''" json:"number"`
+	SubscriptionID uuid.UUID  `gorm:"type:uuid;not null;index" json:"subscription_id"`
+	CustomerID     string     `gorm:"not null;index" json:"customer_id"`
+	Status         string     `gorm:"not null;index" json:"status"`
+	Currency       string     `gorm:"not null" json:"currency"`
+	PeriodStart    time.Time  `gorm:"not null" json:"period_start"`
+	PeriodEnd      time.Time  `gorm:"not null" json:"period_end"`
+	LineItems      []LineItem `gorm:"serializer:json;type:text" json:"line_items"`
+	Subtotal       float64    `gorm:"not null" json:"subtotal"`
+	Discount       float64    `gorm:"not null;default:0" json:"discount"`
+	Tax            float64    `gorm:"not null;default:0" json:"tax"`
+	TaxRate        float64    `gorm:"not null;default:0" json:"tax_rate"`
+	TaxInclusive   bool       `gorm:"not null;default:false" json:"tax_inclusive"`
+	TaxCountry     string     `gorm:"not null;default:''" json:"tax_country"`
+	TaxRegion      string     `gorm:"not null;default:''" json:"tax_region"`
+	Total          float64    `gorm:"not null" json:"total"`
+	IssuedAt       *time.Time `json:"issued_at"`
+	PaidAt         *time.Time `json:"paid_at"`
+	VoidedAt       *time.Time `json:"voided_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (i *Invoice) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+func (Invoice) TableName() string {
+	return "invoices"
+}
+
+// InvoiceSequence holds the last invoice number given out to a tenant.
+type InvoiceSequence struct {
+	TenantID string `gorm:"primaryKey"`
+	Value    int64  `gorm:"not null"`
+}
+
+func (InvoiceSequence) TableName() string {
+	return "invoice_sequences"
+}
+
+// FormatInvoiceNumber turns the nth number of a tenant into an invoice number.
+func FormatInvoiceNumber(n int64) string {
+	return fmt.Sprintf("INV-%06d", n)
+}
+
+// NewInvoice returns a draft invoice for items charged on sub in its current
+// period.
+func NewInvoice(sub *Subscription, currency string, items []LineItem) *Invoice {
+	return &Invoice{
+		SubscriptionID: sub.ID,
+		CustomerID:     sub.CustomerID,
+		Status:         InvoiceDraft,
+		Currency:       currency,
+		PeriodStart:    sub.CurrentPeriodStart,
+		PeriodEnd:      sub.CurrentPeriodEnd,
+		LineItems:      items,
+		Subtotal:       LineItemsTotal(items),
+		Total:          LineItemsTotal(items),
+		TaxCountry:     sub.TaxCountry,
+		TaxRegion:      sub.TaxRegion,
+	}
+}
+
+// AddDiscount takes amount off the invoice with a discount line.
+func (i *Invoice) AddDiscount(description string, amount float64) {
+	if amount <= 0 {
+		return
+	}
+	i.Discount = RoundCents(i.Discount + amount)
+	i.LineItems = append(i.LineItems, LineItem{
+		Kind:        LineItemDiscount,
+		Description: description,
+		Amount:      -amount,
+		PeriodStart: i.PeriodStart,
+		PeriodEnd:   i.PeriodEnd,
+	})
+	i.Total = LineItemsTotal(i.LineItems)
+}
+
+// SetTax records the tax on the invoice at rate. Tax added on top of the
+// prices gets a line of its own; tax included in them is only reported.
+func (i *Invoice) SetTax(amount, rate float64, inclusive bool) {
+	i.Tax = amount
+	i.TaxRate = rate
+	i.TaxInclusive = inclusive
+	if !inclusive && amount != 0 {
+		i.LineItems = append(i.LineItems, LineItem{
+			Kind:        LineItemTax,
+			Description: fmt.Sprintf("Tax (%g%%)", math.Round(rate*10000)/100),
+			Amount:      amount,
+			PeriodStart: i.PeriodStart,
+			PeriodEnd:   i.PeriodEnd,
+		})
+	}
+	i.Total = LineItemsTotal(i.LineItems)
+}
+
+// Finalize issues a draft at at. Invoices with nothing to collect are paid at
+// once.
+func (i *Invoice) Finalize(at time.Time) {
+	i.Status = InvoiceOpen
+	i.IssuedAt = &at
+	if i.Total <= 0 {
+		i.Pay(at)
+	}
+}
+
+func (i *Invoice) Pay(at time.Time) {
+	i.Status = InvoicePaid
+	i.PaidAt = &at
+}
+
+func (i *Invoice) Void(at time.Time) {
+	i.Status = InvoiceVoid
+	i.VoidedAt = &at
+}
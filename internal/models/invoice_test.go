@@ -0,0 +1,54 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvoice_Totals(t *testing.T) {
+	plan := &SubscriptionPlan{PlanName: "Monthly", Interval: BillingInterval{IntervalMonth, 1}, Price: 29.99}
+	sub := NewSubscription("cus_1", plan, date(2024, 1, 1))
+	sub.TaxCountry = "GB"
+
+	inv := NewInvoice(sub, "USD", []LineItem{sub.PeriodCharge(plan)})
+	assert.Equal(t, InvoiceDraft, inv.Status)
+	assert.Equal(t, "GB", inv.TaxCountry)
+	assert.Equal(t, date(2024, 2, 1), inv.PeriodEnd)
+
+	inv.AddDiscount("Coupon LAUNCH25", 7.5)
+	inv.SetTax(4.5, 0.2, false)
+	assert.Equal(t, 29.99, inv.Subtotal)
+	assert.Equal(t, 7.5, inv.Discount)
+	assert.Equal(t, 26.99, inv.Total)
+	assert.Len(t, inv.LineItems, 3)
+	assert.Equal(t, -7.5, inv.LineItems[1].Amount)
+	assert.Equal(t, "Tax (20%)", inv.LineItems[2].Description)
+
+	inclusive := NewInvoice(sub, "USD", []LineItem{sub.PeriodCharge(plan)})
+	inclusive.SetTax(5, 0.2, true)
+	assert.Len(t, inclusive.LineItems, 1, "included tax has no line of its own")
+	assert.Equal(t, 29.99, inclusive.Total)
+	assert.Equal(t, 5.0, inclusive.Tax)
+}
+
+func TestInvoice_Finalize(t *testing.T) {
+	plan := &SubscriptionPlan{Interval: BillingInterval{IntervalMonth, 1}, Price: 10}
+	sub := NewSubscription("cus_1", plan, date(2024, 1, 1))
+
+	inv := NewInvoice(sub, "USD", []LineItem{sub.PeriodCharge(plan)})
+	inv.Finalize(date(2024, 1, 2))
+	assert.Equal(t, InvoiceOpen, inv.Status)
+	assert.Equal(t, date(2024, 1, 2), *inv.IssuedAt)
+	assert.Nil(t, inv.PaidAt)
+
+	credit := NewInvoice(sub, "USD", []LineItem{{Kind: LineItemProration, Amount: -5}})
+	credit.Finalize(date(2024, 1, 2))
+	assert.Equal(t, InvoicePaid, credit.Status, "nothing to collect")
+	assert.Equal(t, date(2024, 1, 2), *credit.PaidAt)
+}
+
+func TestFormatInvoiceNumber(t *testing.T) {
+	assert.Equal(t, "INV-000042", FormatInvoiceNumber(42))
+	assert.Equal(t, "INV-1234567", FormatInvoiceNumber(1234567))
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	LineItemPlan      = "plan"
+	LineItemProration = "proration"
+	LineItemUsage     = "usage"
+	LineItemDiscount  = "discount"
+	LineItemTax       = "tax"
+)
+
+// LineItem is one amount charged, or credited when negative, for a period of
+// a subscription.
+type LineItem struct {
+	Kind        string    `json:"kind"`
+	Description string    `json:"description"`
+	PlanID      uuid.UUID `json:"plan_id"`
+	Amount      float64   `json:"amount"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+}
+
+// LineItemsTotal sums the amounts of items.
+func LineItemsTotal(items []LineItem) float64 {
+	var total float64
+	for _, item := range items {
+		total += item.Amount
+	}
+	return RoundCents(total)
+}
+
+// RoundCents rounds amount to the nearest cent.
+func RoundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
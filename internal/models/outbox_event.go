@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxEvent is a domain event recorded in the same transaction as the
+// mutation that produced it. The auto-increment ID doubles as the change
+// feed sequence number.
+type OutboxEvent struct {
+	ID            uint64    `gorm:"primaryKey;autoIncrement"`
+	TenantID      string    `gorm:"not null;default:'default';index"`
+	EventType     string    `gorm:"not null"`
+	Aggregate     string    `gorm:"not null"`
+	AggregateID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	ProductID     uuid.UUID `gorm:"type:uuid;not null"`
+	ProductType   string
+	Payload       string `gorm:"type:text;not null"`
+	OccurredAt    time.Time
+	Attempts      int `gorm:"not null;default:0"`
+	NextAttemptAt time.Time
+	LastError     string     `gorm:"type:text"`
+	DeliveredAt   *time.Time `gorm:"index"`
+	FailedAt      *time.Time
+	CreatedAt     time.Time
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox"
+}
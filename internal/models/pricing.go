@@ -0,0 +1,102 @@
+package models
+
+const (
+	PricingFlat      = "flat"      // Price per cycle whatever the quantity
+	PricingPerUnit   = "per_unit"  // Price for each unit
+	PricingGraduated = "graduated" // each unit priced by the tier it falls in
+	PricingVolume    = "volume"    // every unit priced by the tier the total falls in
+)
+
+// PriceTier prices the units up to and including UpTo. UpTo is 0 on the last
+// tier, which has no upper bound. FlatFee is charged once when any unit falls
+// in the tier.
+type PriceTier struct {
+	UpTo      int     `json:"up_to"`
+	UnitPrice float64 `json:"unit_price"`
+	FlatFee   float64 `json:"flat_fee"`
+}
+
+// TierCharge is what one tier contributes to a price. Tier is the index of the
+// tier in the plan and ToUnit is 0 for an unbounded tier.
+type TierCharge struct {
+	Tier      int
+	FromUnit  int
+	ToUnit    int
+	Quantity  int
+	UnitPrice float64
+	FlatFee   float64
+	Amount    float64
+}
+
+// PriceBreakdown is the price of a quantity of a plan with the tiers that make
+// it up. Flat and per-unit plans have no tiers.
+type PriceBreakdown struct {
+	Quantity int
+	Total    float64
+	Tiers    []TierCharge
+}
+
+// PricingModelOrDefault returns the plan's pricing model, treating plans
+// stored before pricing models existed as flat.
+func (s *SubscriptionPlan) PricingModelOrDefault() string {
+	if s.PricingModel == "" {
+		return PricingFlat
+	}
+	return s.PricingModel
+}
+
+// Calculate prices quantity units of the plan for one billing cycle at its
+// regular price, leaving introductory phases aside.
+func (s *SubscriptionPlan) Calculate(quantity int) PriceBreakdown {
+	breakdown := PriceBreakdown{Quantity: quantity}
+	switch s.PricingModelOrDefault() {
+	case PricingPerUnit:
+		breakdown.Total = RoundCents(s.Price * float64(quantity))
+	case PricingGraduated:
+		from := 1
+		for i, tier := range s.Tiers {
+			if quantity < from {
+				break
+			}
+			to := quantity
+			if tier.UpTo > 0 && tier.UpTo < quantity {
+				to = tier.UpTo
+			}
+			breakdown.Tiers = append(breakdown.Tiers, tierCharge(i, from, tier, to-from+1))
+			from = tier.UpTo + 1
+			if tier.UpTo == 0 {
+				break
+			}
+		}
+	case PricingVolume:
+		from := 1
+		for i, tier := range s.Tiers {
+			if tier.UpTo == 0 || quantity <= tier.UpTo {
+				if quantity > 0 {
+					breakdown.Tiers = append(breakdown.Tiers, tierCharge(i, from, tier, quantity))
+				}
+				break
+			}
+			from = tier.UpTo + 1
+		}
+	default:
+		breakdown.Total = s.Price
+	}
+	for _, charge := range breakdown.Tiers {
+		breakdown.Total += charge.Amount
+	}
+	breakdown.Total = RoundCents(breakdown.Total)
+	return breakdown
+}
+
+func tierCharge(index, from int, tier PriceTier, quantity int) TierCharge {
+	return TierCharge{
+		Tier:      index,
+		FromUnit:  from,
+		ToUnit:    tier.UpTo,
+		Quantity:  quantity,
+		UnitPrice: tier.UnitPrice,
+		FlatFee:   tier.FlatFee,
+		Amount:    RoundCents(tier.UnitPrice*float64(quantity) + tier.FlatFee),
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var seatTiers = []PriceTier{{UpTo: 10, UnitPrice: 10}, {UpTo: 100, UnitPrice: 8}, {UnitPrice: 5, FlatFee: 50}}
+
+func TestSubscriptionPlan_Calculate_FlatAndPerUnit(t *testing.T) {
+	flat := SubscriptionPlan{Price: 29.99}
+	assert.Equal(t, 29.99, flat.Calculate(5).Total, "flat plans ignore the quantity")
+	assert.Empty(t, flat.Calculate(5).Tiers)
+
+	perUnit := SubscriptionPlan{PricingModel: PricingPerUnit, Price: 4.99}
+	assert.Equal(t, 49.9, perUnit.Calculate(10).Total)
+	assert.Equal(t, 4.99, perUnit.CyclePrice(1))
+}
+
+func TestSubscriptionPlan_Calculate_Graduated(t *testing.T) {
+	plan := SubscriptionPlan{PricingModel: PricingGraduated, Tiers: seatTiers}
+
+	breakdown := plan.Calculate(120)
+
+	assert.Equal(t, []TierCharge{
+		{Tier: 0, FromUnit: 1, ToUnit: 10, Quantity: 10, UnitPrice: 10, Amount: 100},
+		{Tier: 1, FromUnit: 11, ToUnit: 100, Quantity: 90, UnitPrice: 8, Amount: 720},
+		{Tier: 2, FromUnit: 101, ToUnit: 0, Quantity: 20, UnitPrice: 5, FlatFee: 50, Amount: 150},
+	}, breakdown.Tiers)
+	assert.Equal(t, 970.0, breakdown.Total)
+
+	breakdown = plan.Calculate(10)
+	assert.Len(t, breakdown.Tiers, 1, "tiers above the quantity are not charged")
+	assert.Equal(t, 100.0, breakdown.Total)
+	assert.Equal(t, 10.0, plan.CyclePrice(1))
+}
+
+func TestSubscriptionPlan_Calculate_Volume(t *testing.T) {
+	plan := SubscriptionPlan{PricingModel: PricingVolume, Tiers: seatTiers}
+
+	assert.Equal(t, 100.0, plan.Calculate(10).Total)
+	assert.Equal(t, 88.0, plan.Calculate(11).Total, "every unit moves to the cheaper tier")
+	assert.Equal(t, []TierCharge{
+		{Tier: 2, FromUnit: 101, ToUnit: 0, Quantity: 120, UnitPrice: 5, FlatFee: 50, Amount: 650},
+	}, plan.Calculate(120).Tiers)
+}
+
+func TestSubscriptionPlan_CyclePrice_Metered(t *testing.T) {
+	plan := SubscriptionPlan{PricingModel: PricingPerUnit, Price: 0.02, Meter: &Meter{Unit: "gb", Aggregation: AggregationMax}}
+
+	assert.Zero(t, plan.CyclePrice(1), "usage is charged after the period")
+	assert.Equal(t, 2.0, plan.Calculate(100).Total)
+}
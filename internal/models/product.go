@@ -7,18 +7,21 @@ import (
 	"gorm.io/gorm"
 )
 
-
 type Product struct {
-	ID          uuid.UUID `gorm:"type:uuid;primary_key"`
-	Name        string    `gorm:"not null"`
-	Description string    `gorm:"type:text"`
-	Price       float64   `gorm:"not null"`
-	ProductType string    `gorm:"not null;index"` 
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	DeletedAt   gorm.DeletedAt `gorm:"index"`
-
-	SubscriptionPlans []SubscriptionPlan `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE"`
+	ID          uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	TenantID    string    `gorm:"not null;default:'default';index" json:"tenant_id"`
+	Name        string    `gorm:"not null" json:"name"`
+	Description string    `gorm:"type:text" json:"description"`
+	Price       float64   `gorm:"not null" json:"price"`
+	ProductType string    `gorm:"not null;index" json:"product_type"`
+	// TaxInclusive marks prices of the product and its plans as including
+	// tax; otherwise tax is added on top.
+	TaxInclusive bool           `gorm:"not null;default:false" json:"tax_inclusive"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+
+	SubscriptionPlans []SubscriptionPlan `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE" json:"subscription_plans,omitempty"`
 }
 
 func (p *Product) BeforeCreate(tx *gorm.DB) error {
@@ -28,7 +31,6 @@ func (p *Product) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
-
 func (Product) TableName() string {
 	return "products"
 }
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ScheduledChangePending   = "pending"
+	ScheduledChangeApplied   = "applied"
+	ScheduledChangeCancelled = "cancelled"
+	ScheduledChangeFailed    = "failed"
+)
+
+// ScheduledChange is an update to a product or subscription plan that takes
+// effect at EffectiveAt. Changes holds a JSON-encoded ProductChange or
+// SubscriptionPlanChange depending on ResourceType.
+type ScheduledChange struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key"`
+	TenantID     string    `gorm:"not null;default:'default';index"`
+	ResourceType string    `gorm:"not null"`
+	ResourceID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	Changes      string    `gorm:"type:text;not null"`
+	EffectiveAt  time.Time `gorm:"not null;index"`
+	Status       string    `gorm:"not null;index"`
+	Actor        string    `gorm:"not null"`
+	AppliedAt    *time.Time
+	LastError    string `gorm:"type:text"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (c *ScheduledChange) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+func (ScheduledChange) TableName() string {
+	return "scheduled_changes"
+}
+
+// ProductChange lists the product fields a scheduled change sets; nil fields
+// are left as they are.
+type ProductChange struct {
+	Name        *string  `json:"name,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Price       *float64 `json:"price,omitempty"`
+	ProductType *string  `json:"product_type,omitempty"`
+}
+
+func (c ProductChange) IsEmpty() bool {
+	return c.Name == nil && c.Description == nil && c.Price == nil && c.ProductType == nil
+}
+
+// ApplyTo returns a copy of product with the change applied.
+func (c ProductChange) ApplyTo(product Product) Product {
+	if c.Name != nil {
+		product.Name = *c.Name
+	}
+	if c.Description != nil {
+		product.Description = *c.Description
+	}
+	if c.Price != nil {
+		product.Price = *c.Price
+	}
+	if c.ProductType != nil {
+		product.ProductType = *c.ProductType
+	}
+	return product
+}
+
+type SubscriptionPlanChange struct {
+	PlanName *string          `json:"plan_name,omitempty"`
+	Interval *BillingInterval `json:"interval,omitempty"`
+	// Duration is a length in days, from changes scheduled before intervals.
+	Duration *int     `json:"duration,omitempty"`
+	Price    *float64 `json:"price,omitempty"`
+}
+
+func (c SubscriptionPlanChange) IsEmpty() bool {
+	return c.PlanName == nil && c.Interval == nil && c.Duration == nil && c.Price == nil
+}
+
+func (c SubscriptionPlanChange) ApplyTo(plan SubscriptionPlan) SubscriptionPlan {
+	if c.PlanName != nil {
+		plan.PlanName = *c.PlanName
+	}
+	if c.Interval != nil {
+		plan.Interval = *c.Interval
+		plan.Duration = plan.Interval.NominalDays()
+	} else if c.Duration != nil {
+		plan.Interval = IntervalFromDays(*c.Duration)
+		plan.Duration = *c.Duration
+	}
+	if c.Price != nil {
+		plan.Price = *c.Price
+	}
+	return plan
+}
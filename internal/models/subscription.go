@@ -0,0 +1,193 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	SubscriptionTrialing = "trialing"
+	SubscriptionActive   = "active"
+	SubscriptionCanceled = "canceled"
+)
+
+// Subscription records a customer subscribed to a plan. Billing cycles are
+// counted from BillingAnchor, the end of the trial, so period dates follow the
+// calendar rules of the plan's interval. Cycle is 0 during the trial.
+// DiscountedCycles counts the periods the coupon has been applied to and
+// PeriodDiscount is what it took off the current period's plan charge.
+// TaxCountry and TaxRegion say where the customer's invoices are taxed.
+// UsageStart is set when the plan changes mid-period, since usage before the
+// change is billed with it. Version goes up with every write, so a write based
+// on a stale read fails.
+type Subscription struct {
+	ID                 uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	TenantID           string     `gorm:"not null;default:'default';index" json:"tenant_id"`
+	CustomerID         string     `gorm:"not null;index" json:"customer_id"`
+	PlanID             uuid.UUID  `gorm:"type:uuid;not null;index" json:"plan_id"`
+	Status             string     `gorm:"not null;index" json:"status"`
+	BillingAnchor      time.Time  `gorm:"not null" json:"billing_anchor"`
+	Cycle              int        `gorm:"not null;default:0" json:"cycle"`
+	CurrentPeriodStart time.Time  `gorm:"not null" json:"current_period_start"`
+	CurrentPeriodEnd   time.Time  `gorm:"not null;index" json:"current_period_end"`
+	PendingPlanID      *uuid.UUID `gorm:"type:uuid" json:"pending_plan_id"` // plan to switch to at the end of the period
+	CouponID           *uuid.UUID `gorm:"type:uuid;index" json:"coupon_id"`
+	UsageStart         *time.Time `json:"usage_start"`
+	DiscountedCycles   int        `gorm:"not null;default:0" json:"discounted_cycles"`
+	PeriodDiscount     float64    `gorm:"not null;default:0" json:"period_discount"`
+	TaxCountry         string     `gorm:"not null;default:''" json:"tax_country"`
+	TaxRegion          string     `gorm:"not null;default:''" json:"tax_region"`
+	CancelAtPeriodEnd  bool       `gorm:"not null;default:false" json:"cancel_at_period_end"`
+	Version            int        `gorm:"not null;default:0" json:"version"`
+	CanceledAt         *time.Time `json:"canceled_at"`
+	EndedAt            *time.Time `json:"ended_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+func (s *Subscription) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+func (Subscription) TableName() string {
+	return "subscriptions"
+}
+
+// NewSubscription starts customerID on plan at start, in the plan's trial if
+// it has one and otherwise in its first billing cycle.
+func NewSubscription(customerID string, plan *SubscriptionPlan, start time.Time) *Subscription {
+	sub := &Subscription{
+		CustomerID:    customerID,
+		PlanID:        plan.ID,
+		BillingAnchor: start.AddDate(0, 0, plan.TrialDays),
+	}
+	if plan.TrialDays > 0 {
+		sub.Status = SubscriptionTrialing
+		sub.CurrentPeriodStart = start
+		sub.CurrentPeriodEnd = sub.BillingAnchor
+		return sub
+	}
+	sub.Status = SubscriptionActive
+	sub.Cycle = 1
+	sub.CurrentPeriodStart = plan.Interval.PeriodStart(sub.BillingAnchor, 0)
+	sub.CurrentPeriodEnd = plan.Interval.PeriodEnd(sub.BillingAnchor, 0)
+	return sub
+}
+
+// UsagePeriodStart returns when the usage still to be billed for the current
+// period began: the start of the period, or the last immediate plan change
+// within it.
+func (s *Subscription) UsagePeriodStart() time.Time {
+	if s.UsageStart != nil && s.UsageStart.After(s.CurrentPeriodStart) {
+		return *s.UsageStart
+	}
+	return s.CurrentPeriodStart
+}
+
+// IsEnded reports whether the subscription has been cancelled for good.
+func (s *Subscription) IsEnded() bool {
+	return s.Status == SubscriptionCanceled
+}
+
+// ChangePlan moves the subscription from plan from to plan to at time at and
+// returns the proration: a credit for the unused part of what was paid for the
+// current period, net of its discount, and a charge for the new plan. When
+// both plans bill on the same interval the current period is kept and the new
+// plan is charged for its remainder; otherwise a new period starts at at and
+// the new plan is charged in full. Nothing has been paid during a trial, so the
+// trial simply carries on under the new plan. Usage from at on counts towards
+// the new plan; the caller bills the usage before it.
+func (s *Subscription) ChangePlan(from, to *SubscriptionPlan, at time.Time) []LineItem {
+	s.PlanID = to.ID
+	s.PendingPlanID = nil
+	if s.Status == SubscriptionTrialing {
+		return nil
+	}
+
+	s.UsageStart = &at
+	var items []LineItem
+	unused := remainingFraction(s.CurrentPeriodStart, s.CurrentPeriodEnd, at)
+	paid := from.CyclePrice(s.Cycle) - s.PeriodDiscount
+	s.PeriodDiscount = 0
+	if credit := RoundCents(paid * unused); credit > 0 {
+		items = append(items, LineItem{
+			Kind:        LineItemProration,
+			Description: "Unused time on " + from.PlanName,
+			PlanID:      from.ID,
+			Amount:      -credit,
+			PeriodStart: at,
+			PeriodEnd:   s.CurrentPeriodEnd,
+		})
+	}
+
+	if from.Interval == to.Interval {
+		return append(items, LineItem{
+			Kind:        LineItemProration,
+			Description: "Remaining time on " + to.PlanName,
+			PlanID:      to.ID,
+			Amount:      RoundCents(to.CyclePrice(s.Cycle) * unused),
+			PeriodStart: at,
+			PeriodEnd:   s.CurrentPeriodEnd,
+		})
+	}
+
+	s.BillingAnchor = at
+	s.Cycle = 1
+	s.CurrentPeriodStart = at
+	s.CurrentPeriodEnd = to.Interval.PeriodEnd(at, 0)
+	return append(items, s.PeriodCharge(to))
+}
+
+// Renew moves the subscription into the period following the current one,
+// billed on plan to, and returns the charge for it. from is the plan of the
+// current period; to differs from it when a plan change was scheduled for the
+// end of the period. A change of interval re-anchors billing at the renewal.
+func (s *Subscription) Renew(from, to *SubscriptionPlan) LineItem {
+	start := s.CurrentPeriodEnd
+	switch {
+	case s.Status == SubscriptionTrialing:
+		s.Cycle = 1
+	case from.Interval != to.Interval:
+		s.BillingAnchor = start
+		s.Cycle = 1
+	default:
+		s.Cycle++
+	}
+	s.Status = SubscriptionActive
+	s.PlanID = to.ID
+	s.PendingPlanID = nil
+	s.PeriodDiscount = 0
+	s.CurrentPeriodStart = start
+	s.CurrentPeriodEnd = to.Interval.PeriodEnd(s.BillingAnchor, s.Cycle-1)
+	return s.PeriodCharge(to)
+}
+
+// PeriodCharge returns the charge for the current period on plan.
+func (s *Subscription) PeriodCharge(plan *SubscriptionPlan) LineItem {
+	return LineItem{
+		Kind:        LineItemPlan,
+		Description: plan.PlanName,
+		PlanID:      plan.ID,
+		Amount:      plan.CyclePrice(s.Cycle),
+		PeriodStart: s.CurrentPeriodStart,
+		PeriodEnd:   s.CurrentPeriodEnd,
+	}
+}
+
+// remainingFraction returns the share of the period [start, end) that is left
+// at at.
+func remainingFraction(start, end, at time.Time) float64 {
+	total := end.Sub(start)
+	if total <= 0 || !at.Before(end) {
+		return 0
+	}
+	if at.Before(start) {
+		return 1
+	}
+	return float64(end.Sub(at)) / float64(total)
+}
@@ -8,16 +8,25 @@ import (
 )
 
 type SubscriptionPlan struct {
-	ID        uuid.UUID `gorm:"type:uuid;primary_key"`
-	ProductID uuid.UUID `gorm:"type:uuid;not null;index"`
-	PlanName  string    `gorm:"not null"`
-	Duration  int       `gorm:"not null"` 
-	Price     float64   `gorm:"not null"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	DeletedAt gorm.DeletedAt `gorm:"index"`
-
-	Product Product `gorm:"foreignKey:ProductID;references:ID;constraint:OnDelete:CASCADE"`
+	ID        uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	TenantID  string          `gorm:"not null;default:'default';index" json:"tenant_id"`
+	ProductID uuid.UUID       `gorm:"type:uuid;not null;index" json:"product_id"`
+	PlanName  string          `gorm:"not null" json:"plan_name"`
+	Interval  BillingInterval `gorm:"embedded;embeddedPrefix:interval_" json:"interval"`
+	// Duration is Interval in nominal days, kept for clients that predate
+	// intervals.
+	Duration     int            `gorm:"not null" json:"duration"`
+	Price        float64        `gorm:"not null" json:"price"`
+	PricingModel string         `gorm:"not null;default:'flat'" json:"pricing_model"`
+	Tiers        []PriceTier    `gorm:"serializer:json;type:text" json:"tiers"` // graduated and volume pricing only
+	Meter        *Meter         `gorm:"serializer:json;type:text" json:"meter"` // set on pay-as-you-go plans
+	TrialDays    int            `gorm:"not null;default:0" json:"trial_days"`   // free days before the first billing cycle
+	IntroPhases  []IntroPhase   `gorm:"serializer:json;type:text" json:"intro_phases"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Product Product `gorm:"foreignKey:ProductID;references:ID;constraint:OnDelete:CASCADE" json:"product"`
 }
 
 func (s *SubscriptionPlan) BeforeCreate(tx *gorm.DB) error {
@@ -30,3 +39,58 @@ func (s *SubscriptionPlan) BeforeCreate(tx *gorm.DB) error {
 func (SubscriptionPlan) TableName() string {
 	return "subscription_plans"
 }
+
+// IntroPhase charges Price instead of the plan price for Cycles billing
+// cycles. A plan's phases run one after another, starting with the first
+// cycle after the trial.
+type IntroPhase struct {
+	Price  float64 `json:"price"`
+	Cycles int     `json:"cycles"`
+}
+
+// PricePeriod is one period of a subscription and what is charged for it.
+// Cycle is 0 for the trial and counts billing cycles from 1.
+type PricePeriod struct {
+	Cycle int
+	Trial bool
+	Start time.Time
+	End   time.Time
+	Price float64
+}
+
+// CyclePrice returns the price of one unit for the given billing cycle,
+// counted from 1. Metered plans charge for usage after the period instead, so
+// nothing is due up front.
+func (s *SubscriptionPlan) CyclePrice(cycle int) float64 {
+	if s.Meter != nil {
+		return 0
+	}
+	for _, phase := range s.IntroPhases {
+		if cycle <= phase.Cycles {
+			return phase.Price
+		}
+		cycle -= phase.Cycles
+	}
+	return s.Calculate(1).Total
+}
+
+// PriceSchedule returns the trial, if the plan has one, followed by the first
+// cycles billing cycles of a subscription started at start. Billing cycles are
+// anchored at the end of the trial.
+func (s *SubscriptionPlan) PriceSchedule(start time.Time, cycles int) []PricePeriod {
+	var schedule []PricePeriod
+	anchor := start
+	if s.TrialDays > 0 {
+		anchor = start.AddDate(0, 0, s.TrialDays)
+		schedule = append(schedule, PricePeriod{Trial: true, Start: start, End: anchor})
+	}
+	for n := 0; n < cycles; n++ {
+		schedule = append(schedule, PricePeriod{
+			Cycle: n + 1,
+			Start: s.Interval.PeriodStart(anchor, n),
+			End:   s.Interval.PeriodEnd(anchor, n),
+			Price: s.CyclePrice(n + 1),
+		})
+	}
+	return schedule
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionPlan_PriceSchedule_Trial(t *testing.T) {
+	plan := SubscriptionPlan{Interval: BillingInterval{IntervalMonth, 1}, Price: 29.99, TrialDays: 14}
+
+	schedule := plan.PriceSchedule(date(2024, 1, 17), 2)
+
+	assert.Equal(t, []PricePeriod{
+		{Cycle: 0, Trial: true, Start: date(2024, 1, 17), End: date(2024, 1, 31), Price: 0},
+		{Cycle: 1, Start: date(2024, 1, 31), End: date(2024, 2, 29), Price: 29.99},
+		{Cycle: 2, Start: date(2024, 2, 29), End: date(2024, 3, 31), Price: 29.99},
+	}, schedule, "billing is anchored at the end of the trial")
+}
+
+func TestSubscriptionPlan_PriceSchedule_IntroPhases(t *testing.T) {
+	plan := SubscriptionPlan{
+		Interval:    BillingInterval{IntervalMonth, 1},
+		Price:       30,
+		IntroPhases: []IntroPhase{{Price: 15, Cycles: 3}, {Price: 20, Cycles: 1}},
+	}
+
+	schedule := plan.PriceSchedule(date(2024, 1, 1), 6)
+
+	prices := make([]float64, len(schedule))
+	for i, period := range schedule {
+		prices[i] = period.Price
+	}
+	assert.Equal(t, []float64{15, 15, 15, 20, 30, 30}, prices)
+	assert.Equal(t, 1, schedule[0].Cycle, "no trial period without trial days")
+	assert.Equal(t, date(2024, 6, 1), schedule[5].Start)
+}
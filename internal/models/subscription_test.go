@@ -0,0 +1,89 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSubscription(t *testing.T) {
+	monthly := BillingInterval{IntervalMonth, 1}
+
+	sub := NewSubscription("cus_1", &SubscriptionPlan{Interval: monthly}, date(2024, 1, 31))
+	assert.Equal(t, SubscriptionActive, sub.Status)
+	assert.Equal(t, 1, sub.Cycle)
+	assert.Equal(t, date(2024, 1, 31), sub.CurrentPeriodStart)
+	assert.Equal(t, date(2024, 2, 29), sub.CurrentPeriodEnd)
+
+	trial := NewSubscription("cus_1", &SubscriptionPlan{Interval: monthly, TrialDays: 14}, date(2024, 1, 17))
+	assert.Equal(t, SubscriptionTrialing, trial.Status)
+	assert.Equal(t, 0, trial.Cycle)
+	assert.Equal(t, date(2024, 1, 17), trial.CurrentPeriodStart)
+	assert.Equal(t, date(2024, 1, 31), trial.CurrentPeriodEnd)
+	assert.Equal(t, date(2024, 1, 31), trial.BillingAnchor)
+}
+
+func TestSubscription_ChangePlan(t *testing.T) {
+	monthly := BillingInterval{IntervalMonth, 1}
+	basic := &SubscriptionPlan{PlanName: "Basic", Interval: monthly, Price: 30}
+	pro := &SubscriptionPlan{PlanName: "Pro", Interval: monthly, Price: 60}
+	annual := &SubscriptionPlan{PlanName: "Annual", Interval: BillingInterval{IntervalYear, 1}, Price: 600}
+
+	// April has 30 days, so the 11th leaves two thirds of the period unused.
+	sub := NewSubscription("cus_1", basic, date(2024, 4, 1))
+	items := sub.ChangePlan(basic, pro, date(2024, 4, 11))
+	assert.Len(t, items, 2)
+	assert.Equal(t, -20.0, items[0].Amount)
+	assert.Equal(t, 40.0, items[1].Amount)
+	assert.Equal(t, 20.0, LineItemsTotal(items))
+	assert.Equal(t, date(2024, 5, 1), sub.CurrentPeriodEnd, "same interval keeps the period")
+	assert.Equal(t, date(2024, 4, 11), sub.UsagePeriodStart(), "usage before the change is billed with it")
+
+	sub = NewSubscription("cus_1", basic, date(2024, 4, 1))
+	items = sub.ChangePlan(basic, annual, date(2024, 4, 11))
+	assert.Len(t, items, 2)
+	assert.Equal(t, -20.0, items[0].Amount)
+	assert.Equal(t, LineItemPlan, items[1].Kind)
+	assert.Equal(t, 600.0, items[1].Amount)
+	assert.Equal(t, date(2024, 4, 11), sub.BillingAnchor)
+	assert.Equal(t, date(2025, 4, 11), sub.CurrentPeriodEnd)
+	assert.Equal(t, 1, sub.Cycle)
+
+	// Only what was paid for the period is credited back.
+	sub = NewSubscription("cus_1", basic, date(2024, 4, 1))
+	sub.PeriodDiscount = 15
+	items = sub.ChangePlan(basic, pro, date(2024, 4, 11))
+	assert.Equal(t, -10.0, items[0].Amount)
+	assert.Equal(t, 40.0, items[1].Amount)
+	assert.Zero(t, sub.PeriodDiscount, "the new plan's remaining time is charged in full")
+
+	trial := NewSubscription("cus_1", &SubscriptionPlan{Interval: monthly, TrialDays: 14}, date(2024, 4, 1))
+	assert.Empty(t, trial.ChangePlan(basic, annual, date(2024, 4, 5)))
+	assert.Equal(t, date(2024, 4, 15), trial.CurrentPeriodEnd, "the trial carries on")
+}
+
+func TestSubscription_Renew(t *testing.T) {
+	monthly := BillingInterval{IntervalMonth, 1}
+	plan := &SubscriptionPlan{Interval: monthly, Price: 30, IntroPhases: []IntroPhase{{Price: 10, Cycles: 1}}}
+
+	sub := NewSubscription("cus_1", plan, date(2024, 1, 31))
+	charge := sub.Renew(plan, plan)
+	assert.Equal(t, 2, sub.Cycle)
+	assert.Equal(t, date(2024, 2, 29), sub.CurrentPeriodStart)
+	assert.Equal(t, date(2024, 3, 31), sub.CurrentPeriodEnd)
+	assert.Equal(t, 30.0, charge.Amount)
+
+	annual := &SubscriptionPlan{Interval: BillingInterval{IntervalYear, 1}, Price: 300}
+	charge = sub.Renew(plan, annual)
+	assert.Equal(t, 1, sub.Cycle)
+	assert.Equal(t, date(2024, 3, 31), sub.BillingAnchor)
+	assert.Equal(t, date(2025, 3, 31), sub.CurrentPeriodEnd)
+	assert.Equal(t, 300.0, charge.Amount)
+
+	trial := NewSubscription("cus_1", &SubscriptionPlan{Interval: monthly, TrialDays: 14}, date(2024, 1, 17))
+	charge = trial.Renew(plan, plan)
+	assert.Equal(t, SubscriptionActive, trial.Status)
+	assert.Equal(t, 1, trial.Cycle)
+	assert.Equal(t, date(2024, 2, 29), trial.CurrentPeriodEnd)
+	assert.Equal(t, 10.0, charge.Amount, "the first cycle gets the introductory price")
+}
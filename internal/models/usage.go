@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	AggregationSum  = "sum"  // total of every record in the period
+	AggregationMax  = "max"  // largest single record in the period
+	AggregationLast = "last" // most recent record in the period
+)
+
+// Meter makes a plan pay-as-you-go: usage recorded against its subscriptions
+// is aggregated over each billing period and priced with the plan's pricing
+// model, instead of charging for the period up front.
+type Meter struct {
+	Unit        string `json:"unit"` // what is counted, e.g. "api_call"
+	Aggregation string `json:"aggregation"`
+}
+
+// UsageRecord is a quantity of a metered plan's unit used by a subscription at
+// Timestamp. EventID is chosen by the caller and unique per tenant, so a
+// retried record is only counted once.
+type UsageRecord struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	TenantID       string    `gorm:"not null;default:'default';uniqueIndex:idx_usage_records_tenant_event" json:"tenant_id"`
+	EventID        string    `gorm:"not null;uniqueIndex:idx_usage_records_tenant_event" json:"event_id"`
+	SubscriptionID uuid.UUID `gorm:"type:uuid;not null;index:idx_usage_records_subscription_time" json:"subscription_id"`
+	Quantity       int64     `gorm:"not null" json:"quantity"`
+	Timestamp      time.Time `gorm:"not null;index:idx_usage_records_subscription_time" json:"timestamp"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (u *UsageRecord) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	return nil
+}
+
+func (UsageRecord) TableName() string {
+	return "usage_records"
+}
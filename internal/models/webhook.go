@@ -0,0 +1,90 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WildcardEventType subscribes a webhook to every event type.
+const WildcardEventType = "*"
+
+type Webhook struct {
+	ID         uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
+	TenantID   string         `gorm:"not null;default:'default';index" json:"tenant_id"`
+	URL        string         `gorm:"not null" json:"url"`
+	EventTypes string         `gorm:"not null" json:"event_types"` // comma-separated, e.g. "product.updated,subscription_plan.updated"
+	Secret     string         `gorm:"not null" json:"-"`
+	Active     bool           `gorm:"not null;default:true" json:"active"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (w *Webhook) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
+
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+func (w *Webhook) EventTypeList() []string {
+	if w.EventTypes == "" {
+		return nil
+	}
+	return strings.Split(w.EventTypes, ",")
+}
+
+func (w *Webhook) Subscribes(eventType string) bool {
+	for _, t := range w.EventTypeList() {
+		if t == WildcardEventType || t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery records sending one event to one webhook, including its
+// retry state, and doubles as the delivery log. DedupeKey is set for
+// deliveries created from the outbox so redelivered outbox events do not fan
+// out twice; manual redeliveries leave it empty.
+type WebhookDelivery struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key"`
+	TenantID       string    `gorm:"not null;default:'default';index"`
+	WebhookID      uuid.UUID `gorm:"type:uuid;not null;index"`
+	EventSequence  uint64    `gorm:"not null"`
+	EventType      string    `gorm:"not null"`
+	Payload        string    `gorm:"type:text;not null"`
+	Status         string    `gorm:"not null;index"`
+	Attempts       int       `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time `gorm:"index"`
+	LastError      string    `gorm:"type:text"`
+	ResponseStatus int
+	DeliveredAt    *time.Time
+	DedupeKey      *string `gorm:"uniqueIndex"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
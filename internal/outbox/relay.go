@@ -0,0 +1,141 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/constants"
+	"github.com/microservice-go/product-service/internal/events"
+	"github.com/microservice-go/product-service/internal/repository"
+	"github.com/microservice-go/product-service/internal/retry"
+)
+
+type Config struct {
+	PollInterval time.Duration
+	BatchSize    int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	// MaxAttempts parks an event as failed after this many deliveries have
+	// failed, unblocking later events for the same aggregate. Zero retries forever.
+	MaxAttempts int
+	// Lease is how long a relay holds the events it has claimed. Events
+	// claimed by a replica that stops are delivered once it runs out.
+	Lease time.Duration
+	Now   func() time.Time
+}
+
+func (c Config) withDefaults() Config {
+	if c.PollInterval <= 0 {
+		c.PollInterval = time.Second
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = constants.DefaultOutboxBatch
+	}
+	if c.BaseBackoff <= 0 {
+		c.BaseBackoff = time.Second
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 5 * time.Minute
+	}
+	if c.Lease <= 0 {
+		c.Lease = 5 * time.Minute
+	}
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+	return c
+}
+
+// Relay delivers committed outbox events to a publisher. Events are handled in
+// the order they were recorded; when delivery of an event fails, later events
+// for the same aggregate are held back until it succeeds so per-aggregate
+// ordering is preserved while other aggregates keep flowing. Every replica can
+// run a relay: events are claimed before they are delivered, so each is
+// delivered by one relay at a time.
+type Relay struct {
+	repo      repository.OutboxRepository
+	publisher events.Publisher
+	config    Config
+}
+
+func NewRelay(repo repository.OutboxRepository, publisher events.Publisher, config Config) *Relay {
+	return &Relay{
+		repo:      repo,
+		publisher: publisher,
+		config:    config.withDefaults(),
+	}
+}
+
+// Run polls the outbox until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.ProcessOnce(ctx); err != nil {
+			log.Printf("Outbox relay: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessOnce claims one batch of due events, attempts their delivery and
+// returns how many were delivered.
+func (r *Relay) ProcessOnce(ctx context.Context) (int, error) {
+	now := r.config.Now()
+	rows, err := r.repo.Claim(now, now.Add(r.config.Lease), r.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	blocked := make(map[uuid.UUID]bool)
+	delivered := 0
+
+	for _, row := range rows {
+		if ctx.Err() != nil {
+			return delivered, ctx.Err()
+		}
+		if blocked[row.AggregateID] {
+			// Hand the event back rather than holding it for the whole
+			// lease; the failed event before it keeps it waiting.
+			if err := r.repo.MarkRetry(row.ID, row.Attempts, now, row.LastError); err != nil {
+				return delivered, err
+			}
+			continue
+		}
+
+		event, err := events.FromOutbox(row)
+		if err == nil {
+			err = r.publisher.Publish(ctx, event)
+		}
+		if err != nil {
+			attempts := row.Attempts + 1
+			if r.config.MaxAttempts > 0 && attempts >= r.config.MaxAttempts {
+				log.Printf("Outbox relay: giving up on event %d after %d attempts: %v", row.ID, attempts, err)
+				if markErr := r.repo.MarkFailed(row.ID, attempts, now, err.Error()); markErr != nil {
+					return delivered, markErr
+				}
+				continue
+			}
+			blocked[row.AggregateID] = true
+			if markErr := r.repo.MarkRetry(row.ID, attempts, now.Add(retry.Backoff(r.config.BaseBackoff, r.config.MaxBackoff, attempts)), err.Error()); markErr != nil {
+				return delivered, markErr
+			}
+			continue
+		}
+
+		if err := r.repo.MarkDelivered(row.ID, now); err != nil {
+			return delivered, err
+		}
+		delivered++
+	}
+
+	return delivered, nil
+}
@@ -0,0 +1,215 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/events"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeOutboxRepository struct {
+	rows []*models.OutboxEvent
+}
+
+func (r *fakeOutboxRepository) add(t *testing.T, event events.Event) *models.OutboxEvent {
+	t.Helper()
+	row, err := events.ToOutbox(event)
+	require.NoError(t, err)
+	row.ID = uint64(len(r.rows) + 1)
+	r.rows = append(r.rows, row)
+	return row
+}
+
+func (r *fakeOutboxRepository) find(id uint64) *models.OutboxEvent {
+	return r.rows[id-1]
+}
+
+func (r *fakeOutboxRepository) Claim(now, until time.Time, limit int) ([]models.OutboxEvent, error) {
+	var claimed []models.OutboxEvent
+	waiting := make(map[uuid.UUID]bool)
+	for _, row := range r.rows {
+		if row.DeliveredAt != nil || row.FailedAt != nil {
+			continue
+		}
+		if row.NextAttemptAt.After(now) {
+			waiting[row.AggregateID] = true
+			continue
+		}
+		if waiting[row.AggregateID] || len(claimed) == limit {
+			continue
+		}
+		claimed = append(claimed, *row)
+		row.NextAttemptAt = until
+	}
+	return claimed, nil
+}
+
+func (r *fakeOutboxRepository) MarkDelivered(id uint64, at time.Time) error {
+	r.find(id).DeliveredAt = &at
+	return nil
+}
+
+func (r *fakeOutboxRepository) MarkRetry(id uint64, attempts int, nextAttemptAt time.Time, lastError string) error {
+	row := r.find(id)
+	row.Attempts = attempts
+	row.NextAttemptAt = nextAttemptAt
+	row.LastError = lastError
+	return nil
+}
+
+func (r *fakeOutboxRepository) MarkFailed(id uint64, attempts int, at time.Time, lastError string) error {
+	row := r.find(id)
+	row.Attempts = attempts
+	row.FailedAt = &at
+	row.LastError = lastError
+	return nil
+}
+
+type recordingPublisher struct {
+	failFor   map[uuid.UUID]bool
+	published []uint64
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event events.Event) error {
+	if p.failFor[event.AggregateID] {
+		return errors.New("publisher unavailable")
+	}
+	p.published = append(p.published, event.Sequence)
+	return nil
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func productEvent(id uuid.UUID, eventType events.Type, at time.Time) events.Event {
+	event := events.NewProductEvent(eventType, &models.Product{ID: id, Name: "Product"})
+	event.OccurredAt = at
+	return event
+}
+
+func TestRelay_DeliversInOrder(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 3, 12, 0, 0, 0, time.UTC)}
+	repo := &fakeOutboxRepository{}
+	publisher := &recordingPublisher{}
+	relay := NewRelay(repo, publisher, Config{Now: clock.Now})
+
+	id := uuid.New()
+	repo.add(t, productEvent(id, events.TypeCreated, clock.now))
+	repo.add(t, productEvent(id, events.TypeUpdated, clock.now))
+
+	delivered, err := relay.ProcessOnce(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, delivered)
+	assert.Equal(t, []uint64{1, 2}, publisher.published)
+	assert.NotNil(t, repo.find(1).DeliveredAt)
+}
+
+func TestRelay_FailureHoldsBackSameAggregateOnly(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 3, 12, 0, 0, 0, time.UTC)}
+	repo := &fakeOutboxRepository{}
+	failing := uuid.New()
+	healthy := uuid.New()
+	publisher := &recordingPublisher{failFor: map[uuid.UUID]bool{failing: true}}
+	relay := NewRelay(repo, publisher, Config{Now: clock.Now, BaseBackoff: time.Second, MaxBackoff: 4 * time.Second})
+
+	repo.add(t, productEvent(failing, events.TypeCreated, clock.now))
+	repo.add(t, productEvent(healthy, events.TypeCreated, clock.now))
+	repo.add(t, productEvent(failing, events.TypeUpdated, clock.now))
+
+	delivered, err := relay.ProcessOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, []uint64{2}, publisher.published)
+	assert.Equal(t, 1, repo.find(1).Attempts)
+	assert.Equal(t, clock.now.Add(time.Second), repo.find(1).NextAttemptAt)
+	assert.Equal(t, "publisher unavailable", repo.find(1).LastError)
+	assert.Zero(t, repo.find(3).Attempts)
+
+	// Still inside the backoff window: nothing is retried.
+	delivered, err = relay.ProcessOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+	assert.Equal(t, 1, repo.find(1).Attempts)
+
+	clock.now = clock.now.Add(time.Second)
+	_, err = relay.ProcessOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, repo.find(1).Attempts)
+	assert.Equal(t, clock.now.Add(2*time.Second), repo.find(1).NextAttemptAt)
+
+	publisher.failFor = nil
+	clock.now = clock.now.Add(2 * time.Second)
+	delivered, err = relay.ProcessOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, delivered)
+	assert.Equal(t, []uint64{2, 1, 3}, publisher.published)
+}
+
+func TestRelay_MaxAttemptsParksEvent(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 3, 12, 0, 0, 0, time.UTC)}
+	repo := &fakeOutboxRepository{}
+	id := uuid.New()
+	publisher := &recordingPublisher{failFor: map[uuid.UUID]bool{id: true}}
+	relay := NewRelay(repo, publisher, Config{Now: clock.Now, MaxAttempts: 1})
+
+	repo.add(t, productEvent(id, events.TypeCreated, clock.now))
+
+	_, err := relay.ProcessOnce(context.Background())
+
+	require.NoError(t, err)
+	assert.NotNil(t, repo.find(1).FailedAt)
+	pending, _ := repo.Claim(clock.now.Add(time.Hour), clock.now.Add(2*time.Hour), 10)
+	assert.Empty(t, pending)
+}
+
+func TestRelay_ClaimedEventsAreDeliveredOnce(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 3, 12, 0, 0, 0, time.UTC)}
+	repo := &fakeOutboxRepository{}
+	first := &recordingPublisher{}
+	second := &recordingPublisher{}
+	config := Config{Now: clock.Now, Lease: time.Minute}
+
+	repo.add(t, productEvent(uuid.New(), events.TypeCreated, clock.now))
+	// Another replica claimed the event and is still delivering it.
+	_, err := repo.Claim(clock.now, clock.now.Add(time.Minute), 10)
+	require.NoError(t, err)
+
+	delivered, err := NewRelay(repo, first, config).ProcessOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+
+	// The replica stopped: the event is delivered once its lease runs out.
+	clock.now = clock.now.Add(time.Minute)
+	delivered, err = NewRelay(repo, second, config).ProcessOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Empty(t, first.published)
+	assert.Equal(t, []uint64{1}, second.published)
+}
+
+func TestRelay_PublishesToBroker(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 3, 3, 12, 0, 0, 0, time.UTC)}
+	repo := &fakeOutboxRepository{}
+	broker := events.NewBroker(10)
+	relay := NewRelay(repo, broker, Config{Now: clock.Now})
+
+	repo.add(t, productEvent(uuid.New(), events.TypeCreated, clock.now))
+	repo.add(t, productEvent(uuid.New(), events.TypeCreated, clock.now))
+
+	_, err := relay.ProcessOnce(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), broker.LastSequence())
+}
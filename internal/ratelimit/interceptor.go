@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/microservice-go/product-service/internal/auth"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// RetryAfterKey is the response header telling a rejected client how many
+// seconds to wait before retrying.
+const RetryAfterKey = "retry-after"
+
+// inFlightRetryDelay is suggested to clients turned away because the server is
+// at its concurrency limit, which has no precise refill time.
+const inFlightRetryDelay = time.Second
+
+// UnaryServerInterceptor rejects calls over the client's rate limit or over
+// the server's in-flight limit with RESOURCE_EXHAUSTED. It must run after
+// authentication so clients are keyed by principal.
+func UnaryServerInterceptor(l *Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if ok, wait := l.Allow(ClientKey(ctx), info.FullMethod); !ok {
+			return nil, exhausted(ctx, wait, "rate limit exceeded for %s", info.FullMethod)
+		}
+		release, ok := l.Acquire(info.FullMethod)
+		if !ok {
+			return nil, exhausted(ctx, inFlightRetryDelay, "server is at its limit of %d concurrent requests", l.config.MaxInFlight)
+		}
+		defer release()
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor rate limits opening streams. Streams are long-lived,
+// so they do not count against the in-flight limit.
+func StreamServerInterceptor(l *Limiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := stream.Context()
+		if ok, wait := l.Allow(ClientKey(ctx), info.FullMethod); !ok {
+			return exhausted(ctx, wait, "rate limit exceeded for %s", info.FullMethod)
+		}
+		return handler(srv, stream)
+	}
+}
+
+// ClientKey identifies the caller: its principal when authenticated,
+// otherwise its peer host.
+func ClientKey(ctx context.Context) string {
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		return "principal:" + principal.Subject
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr := p.Addr.String()
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			addr = host
+		}
+		return "peer:" + addr
+	}
+	return "unknown"
+}
+
+func exhausted(ctx context.Context, wait time.Duration, format string, args ...interface{}) error {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	// Fails only outside a real RPC, as in tests; the status detail below
+	// still carries the delay.
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RetryAfterKey, strconv.Itoa(seconds)))
+
+	st := status.Newf(codes.ResourceExhausted, format, args...)
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
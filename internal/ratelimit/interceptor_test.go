@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/microservice-go/product-service/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestClientKey(t *testing.T) {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.7"), Port: 53211}})
+	assert.Equal(t, "peer:10.0.0.7", ClientKey(ctx))
+
+	ctx = auth.WithPrincipal(ctx, &auth.Principal{Subject: "storefront"})
+	assert.Equal(t, "principal:storefront", ClientKey(ctx))
+}
+
+func TestUnaryServerInterceptor_RateLimit(t *testing.T) {
+	l, _ := newTestLimiter(Config{Default: Limit{Rate: 0.5, Burst: 1}})
+	interceptor := UnaryServerInterceptor(l)
+	info := &grpc.UnaryServerInfo{FullMethod: listProducts}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "storefront"})
+
+	resp, err := interceptor(ctx, nil, info, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+
+	_, err = interceptor(ctx, nil, info, handler)
+	st := status.Convert(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 1)
+	retry, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	assert.Equal(t, 2*time.Second, retry.RetryDelay.AsDuration())
+}
+
+func TestUnaryServerInterceptor_InFlight(t *testing.T) {
+	l, _ := newTestLimiter(Config{MaxInFlight: 1})
+	interceptor := UnaryServerInterceptor(l)
+	info := &grpc.UnaryServerInfo{FullMethod: listProducts}
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		_, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			close(started)
+			<-unblock
+			return nil, nil
+		})
+		done <- err
+	}()
+	<-started
+
+	_, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	close(unblock)
+	require.NoError(t, <-done)
+	_, err = interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	assert.NoError(t, err)
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	l, _ := newTestLimiter(Config{Default: Limit{Rate: 1, Burst: 1}, MaxInFlight: 1})
+	interceptor := StreamServerInterceptor(l)
+	info := &grpc.StreamServerInfo{FullMethod: "/product.ProductService/WatchProducts"}
+	stream := &fakeServerStream{ctx: context.Background()}
+
+	// An open stream does not hold an in-flight slot.
+	release, ok := l.Acquire(listProducts)
+	require.True(t, ok)
+	defer release()
+
+	assert.NoError(t, interceptor(nil, stream, info, func(srv interface{}, stream grpc.ServerStream) error { return nil }))
+	err := interceptor(nil, stream, info, func(srv interface{}, stream grpc.ServerStream) error { return nil })
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
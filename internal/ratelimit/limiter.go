@@ -0,0 +1,193 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often buckets that have refilled completely, and so
+// hold no state worth keeping, are dropped.
+const sweepInterval = time.Minute
+
+// Limit is a token bucket refilled at Rate tokens per second up to Burst. A
+// zero Rate means unlimited.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l Limit) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return math.Max(1, math.Ceil(l.Rate))
+}
+
+type Config struct {
+	// Default applies to every method without an override. Each client has
+	// one bucket shared by those methods.
+	Default Limit
+	// Methods overrides Default for full method names or service names, the
+	// method winning. Each client has a separate bucket per override.
+	Methods map[string]Limit
+	// MaxInFlight caps concurrent unary calls across all clients; zero means
+	// unlimited.
+	MaxInFlight int
+	// Exempt lists full method or service names that are never limited.
+	Exempt []string
+}
+
+type bucketKey struct {
+	client string
+	rule   string
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+type Limiter struct {
+	config Config
+	now    func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+
+	inFlight chan struct{}
+}
+
+func NewLimiter(config Config) *Limiter {
+	l := &Limiter{
+		config:  config,
+		now:     time.Now,
+		buckets: make(map[bucketKey]*bucket),
+	}
+	if config.MaxInFlight > 0 {
+		l.inFlight = make(chan struct{}, config.MaxInFlight)
+	}
+	return l
+}
+
+// Allow takes a token from the client's bucket for fullMethod. When none is
+// left it reports how long until one is.
+func (l *Limiter) Allow(client, fullMethod string) (bool, time.Duration) {
+	rule, limit := l.limitFor(fullMethod)
+	if limit.Rate <= 0 || l.exempt(fullMethod) {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	key := bucketKey{client: client, rule: rule}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit.burst(), updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(limit.burst(), b.tokens+now.Sub(b.updated).Seconds()*limit.Rate)
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	return false, wait
+}
+
+// Acquire claims an in-flight slot without waiting. The returned function
+// releases it.
+func (l *Limiter) Acquire(fullMethod string) (func(), bool) {
+	if l.inFlight == nil || l.exempt(fullMethod) {
+		return func() {}, true
+	}
+	select {
+	case l.inFlight <- struct{}{}:
+		return func() { <-l.inFlight }, true
+	default:
+		return nil, false
+	}
+}
+
+func (l *Limiter) limitFor(fullMethod string) (string, Limit) {
+	if limit, ok := l.config.Methods[fullMethod]; ok {
+		return fullMethod, limit
+	}
+	if service := serviceName(fullMethod); service != "" {
+		if limit, ok := l.config.Methods[service]; ok {
+			return service, limit
+		}
+	}
+	return "", l.config.Default
+}
+
+func (l *Limiter) exempt(fullMethod string) bool {
+	service := serviceName(fullMethod)
+	for _, method := range l.config.Exempt {
+		if method == fullMethod || method == service {
+			return true
+		}
+	}
+	return false
+}
+
+// sweep drops full buckets; a new bucket starts full, so this loses nothing.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		_, limit := l.limitFor(key.rule)
+		if b.tokens+now.Sub(b.updated).Seconds()*limit.Rate >= limit.burst() {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func serviceName(fullMethod string) string {
+	service := strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(service, "/"); i >= 0 {
+		return service[:i]
+	}
+	return ""
+}
+
+// ParseMethodLimits parses comma-separated "method=rate[:burst]" overrides,
+// for example "/product.ProductService/ListProducts=5:10,grpc.health.v1.Health=0".
+func ParseMethodLimits(spec string) (map[string]Limit, error) {
+	limits := make(map[string]Limit)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		method, value, ok := strings.Cut(entry, "=")
+		if !ok || method == "" {
+			return nil, fmt.Errorf("invalid rate limit %q: want method=rate[:burst]", entry)
+		}
+		rate, burst, hasBurst := strings.Cut(value, ":")
+		var limit Limit
+		var err error
+		if limit.Rate, err = strconv.ParseFloat(rate, 64); err != nil || limit.Rate < 0 {
+			return nil, fmt.Errorf("invalid rate in %q", entry)
+		}
+		if hasBurst {
+			if limit.Burst, err = strconv.Atoi(burst); err != nil || limit.Burst < 1 {
+				return nil, fmt.Errorf("invalid burst in %q", entry)
+			}
+		}
+		limits[method] = limit
+	}
+	return limits, nil
+}
@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const listProducts = "/product.ProductService/ListProducts"
+
+func newTestLimiter(config Config) (*Limiter, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewLimiter(config)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestLimiter_TokenBucket(t *testing.T) {
+	l, now := newTestLimiter(Config{Default: Limit{Rate: 2, Burst: 3}})
+
+	for i := 0; i < 3; i++ {
+		ok, _ := l.Allow("alice", listProducts)
+		assert.True(t, ok, "call %d is within the burst", i)
+	}
+	ok, wait := l.Allow("alice", listProducts)
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// Other clients have their own bucket.
+	ok, _ = l.Allow("bob", listProducts)
+	assert.True(t, ok)
+
+	*now = now.Add(500 * time.Millisecond)
+	ok, _ = l.Allow("alice", listProducts)
+	assert.True(t, ok)
+	ok, _ = l.Allow("alice", listProducts)
+	assert.False(t, ok)
+}
+
+func TestLimiter_MethodOverrides(t *testing.T) {
+	l, _ := newTestLimiter(Config{
+		Default: Limit{Rate: 1, Burst: 1},
+		Methods: map[string]Limit{
+			listProducts:             {Rate: 1, Burst: 2},
+			"grpc.health.v1.Health":  {Rate: 0},
+			"webhook.WebhookService": {Rate: 1, Burst: 1},
+		},
+	})
+
+	ok, _ := l.Allow("alice", "/product.ProductService/GetProduct")
+	assert.True(t, ok)
+	ok, _ = l.Allow("alice", "/subscription.SubscriptionService/GetSubscriptionPlan")
+	assert.False(t, ok, "methods without an override share the default bucket")
+
+	ok, _ = l.Allow("alice", listProducts)
+	assert.True(t, ok)
+	ok, _ = l.Allow("alice", listProducts)
+	assert.True(t, ok)
+	ok, _ = l.Allow("alice", listProducts)
+	assert.False(t, ok)
+
+	ok, _ = l.Allow("alice", "/webhook.WebhookService/ListWebhooks")
+	assert.True(t, ok)
+	ok, _ = l.Allow("alice", "/webhook.WebhookService/GetWebhook")
+	assert.False(t, ok, "a service override is one bucket for the service")
+
+	for i := 0; i < 10; i++ {
+		ok, _ = l.Allow("alice", "/grpc.health.v1.Health/Check")
+		assert.True(t, ok)
+	}
+}
+
+func TestLimiter_SweepsFullBuckets(t *testing.T) {
+	l, now := newTestLimiter(Config{Default: Limit{Rate: 1, Burst: 1}})
+
+	l.Allow("alice", listProducts)
+	*now = now.Add(2 * sweepInterval)
+	l.Allow("bob", listProducts)
+
+	assert.Len(t, l.buckets, 1)
+}
+
+func TestLimiter_InFlight(t *testing.T) {
+	l, _ := newTestLimiter(Config{MaxInFlight: 1, Exempt: []string{"grpc.health.v1.Health"}})
+
+	release, ok := l.Acquire(listProducts)
+	require.True(t, ok)
+	_, ok = l.Acquire(listProducts)
+	assert.False(t, ok)
+
+	_, ok = l.Acquire("/grpc.health.v1.Health/Check")
+	assert.True(t, ok)
+
+	release()
+	_, ok = l.Acquire(listProducts)
+	assert.True(t, ok)
+}
+
+func TestParseMethodLimits(t *testing.T) {
+	limits, err := ParseMethodLimits(" /product.ProductService/ListProducts=5:10, grpc.health.v1.Health=0,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Limit{
+		listProducts:            {Rate: 5, Burst: 10},
+		"grpc.health.v1.Health": {Rate: 0},
+	}, limits)
+
+	for _, spec := range []string{"ListProducts", "=5", "x=fast", "x=-1", "x=5:0"} {
+		_, err := ParseMethodLimits(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,75 @@
+package renewal
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+type Config struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Now          func() time.Time
+}
+
+func (c Config) withDefaults() Config {
+	if c.PollInterval <= 0 {
+		c.PollInterval = time.Minute
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 100
+	}
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+	return c
+}
+
+// Renewer moves subscriptions whose period has ended into their next one.
+// service.BillingService implements it.
+type Renewer interface {
+	RenewDue(ctx context.Context, now time.Time, limit int) (int, error)
+}
+
+// Worker periodically renews subscriptions whose period has ended and ends
+// those cancelled at the period end. Every replica may run one; the
+// repository lets only one of them move a subscription on.
+type Worker struct {
+	renewer Renewer
+	config  Config
+}
+
+func New(renewer Renewer, config Config) *Worker {
+	return &Worker{renewer: renewer, config: config.withDefaults()}
+}
+
+// Run renews due subscriptions until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := w.ProcessOnce(ctx); err != nil {
+			log.Printf("Renewal worker: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessOnce renews the subscriptions that are currently due, draining
+// batches until none remain, and returns how many were renewed.
+func (w *Worker) ProcessOnce(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		renewed, err := w.renewer.RenewDue(ctx, w.config.Now(), w.config.BatchSize)
+		total += renewed
+		if err != nil || renewed < w.config.BatchSize || ctx.Err() != nil {
+			return total, err
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package renewal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRenewer struct {
+	batches []int
+	err     error
+	calls   []time.Time
+}
+
+func (r *fakeRenewer) RenewDue(ctx context.Context, now time.Time, limit int) (int, error) {
+	r.calls = append(r.calls, now)
+	if len(r.batches) == 0 {
+		return 0, r.err
+	}
+	renewed := r.batches[0]
+	r.batches = r.batches[1:]
+	return renewed, nil
+}
+
+func TestWorker_ProcessOnceDrainsFullBatches(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	renewer := &fakeRenewer{batches: []int{2, 2, 1}}
+	w := New(renewer, Config{BatchSize: 2, Now: func() time.Time { return now }})
+
+	renewed, err := w.ProcessOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, renewed)
+	assert.Equal(t, []time.Time{now, now, now}, renewer.calls)
+}
+
+func TestWorker_ProcessOnceStopsOnError(t *testing.T) {
+	renewer := &fakeRenewer{batches: []int{2}, err: errors.New("database is locked")}
+	w := New(renewer, Config{BatchSize: 2})
+
+	renewed, err := w.ProcessOnce(context.Background())
+
+	assert.EqualError(t, err, "database is locked")
+	assert.Equal(t, 2, renewed)
+	assert.Len(t, renewer.calls, 2)
+}
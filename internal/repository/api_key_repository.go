@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/models"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	Create(key *models.APIKey) error
+	GetByID(id uuid.UUID) (*models.APIKey, error)
+	GetByHash(keyHash string) (*models.APIKey, error)
+	List() ([]models.APIKey, error)
+	Rotate(id uuid.UUID, prefix, keyHash string) error
+	Revoke(id uuid.UUID, at time.Time) error
+	TouchLastUsed(id uuid.UUID, at time.Time) error
+	WithContext(ctx context.Context) APIKeyRepository
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(key *models.APIKey) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		key.TenantID = tenantOf(tx)
+		if err := tx.Create(key).Error; err != nil {
+			return err
+		}
+		return appendAudit(tx, models.AuditActionCreate, models.AuditResourceAPIKey, key.ID, nil, key)
+	})
+}
+
+func (r *apiKeyRepository) GetByID(id uuid.UUID) (*models.APIKey, error) {
+	return r.first(r.db.Scopes(forTenant), "id = ?", id)
+}
+
+// GetByHash looks a key up across all tenants; it is how callers are
+// authenticated, before their tenant is known.
+func (r *apiKeyRepository) GetByHash(keyHash string) (*models.APIKey, error) {
+	return r.first(r.db, "key_hash = ?", keyHash)
+}
+
+func (r *apiKeyRepository) first(db *gorm.DB, query string, args ...interface{}) (*models.APIKey, error) {
+	var key models.APIKey
+	err := db.Where(query, args...).First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("API key not found")
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) List() ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := r.db.Scopes(forTenant).Order("created_at").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Rotate replaces the key's secret; the previous one stops working at once.
+func (r *apiKeyRepository) Rotate(id uuid.UUID, prefix, keyHash string) error {
+	return r.update(id, map[string]interface{}{
+		"prefix":   prefix,
+		"key_hash": keyHash,
+	})
+}
+
+func (r *apiKeyRepository) Revoke(id uuid.UUID, at time.Time) error {
+	return r.update(id, map[string]interface{}{"revoked_at": at})
+}
+
+func (r *apiKeyRepository) update(id uuid.UUID, fields map[string]interface{}) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var before models.APIKey
+		if err := tx.Scopes(forTenant).First(&before, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("API key not found")
+			}
+			return err
+		}
+
+		if err := tx.Model(&models.APIKey{}).Scopes(forTenant).Where("id = ?", id).Updates(fields).Error; err != nil {
+			return err
+		}
+
+		var updated models.APIKey
+		if err := tx.First(&updated, "id = ?", id).Error; err != nil {
+			return err
+		}
+		return appendAudit(tx, models.AuditActionUpdate, models.AuditResourceAPIKey, id, &before, &updated)
+	})
+}
+
+// TouchLastUsed records use of a key. It is not audited.
+func (r *apiKeyRepository) TouchLastUsed(id uuid.UUID, at time.Time) error {
+	return r.db.Model(&models.APIKey{}).Where("id = ?", id).
+		UpdateColumn("last_used_at", at).Error
+}
+
+func (r *apiKeyRepository) WithContext(ctx context.Context) APIKeyRepository {
+	return &apiKeyRepository{db: r.db.WithContext(ctx)}
+}
@@ -0,0 +1,90 @@
+//go:build cgo
+// +build cgo
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupAPIKeyTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("test_api_key.db"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.APIKey{}, &models.AuditEvent{})
+	if err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	db.Exec("DELETE FROM api_keys")
+	db.Exec("DELETE FROM audit_events")
+
+	return db
+}
+
+func TestAPIKeyRepository_Lifecycle(t *testing.T) {
+	db := setupAPIKeyTestDB(t)
+	repo := NewAPIKeyRepository(db)
+
+	key := &models.APIKey{Name: "nightly-import", Prefix: "psk_aaaaaaaa", KeyHash: "hash-1", Scopes: "catalog.write"}
+	assert.NoError(t, repo.Create(key))
+
+	found, err := repo.GetByHash("hash-1")
+	assert.NoError(t, err)
+	assert.Equal(t, key.ID, found.ID)
+
+	assert.NoError(t, repo.Rotate(key.ID, "psk_bbbbbbbb", "hash-2"))
+	_, err = repo.GetByHash("hash-1")
+	assert.EqualError(t, err, "API key not found")
+
+	usedAt := time.Now()
+	assert.NoError(t, repo.TouchLastUsed(key.ID, usedAt))
+	assert.NoError(t, repo.Revoke(key.ID, usedAt))
+
+	found, err = repo.GetByHash("hash-2")
+	assert.NoError(t, err)
+	assert.Equal(t, "psk_bbbbbbbb", found.Prefix)
+	assert.NotNil(t, found.LastUsedAt)
+	assert.True(t, found.Revoked())
+
+	var auditEvents []models.AuditEvent
+	assert.NoError(t, db.Where("resource_id = ?", key.ID).Order("occurred_at").Find(&auditEvents).Error)
+	assert.Len(t, auditEvents, 3, "create, rotate and revoke are audited; use is not")
+	for _, event := range auditEvents {
+		assert.NotContains(t, event.After, "hash-")
+	}
+}
+
+func TestAPIKeyRepository_TenantScoped(t *testing.T) {
+	db := setupAPIKeyTestDB(t)
+	acme := NewAPIKeyRepository(db).WithContext(tenant.WithTenant(context.Background(), "acme"))
+	globex := NewAPIKeyRepository(db).WithContext(tenant.WithTenant(context.Background(), "globex"))
+
+	key := &models.APIKey{Name: "storefront", Prefix: "psk_cccccccc", KeyHash: "hash-3", Scopes: "catalog.read"}
+	assert.NoError(t, acme.Create(key))
+
+	_, err := globex.GetByID(key.ID)
+	assert.EqualError(t, err, "API key not found")
+	assert.EqualError(t, globex.Revoke(key.ID, time.Now()), "API key not found")
+
+	keys, err := globex.List()
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	// Keys are found by hash in any tenant, since that is how the caller's
+	// tenant is learned.
+	found, err := globex.GetByHash("hash-3")
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", found.TenantID)
+	assert.False(t, found.Revoked())
+}
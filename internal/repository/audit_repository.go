@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/audit"
+	"github.com/microservice-go/product-service/internal/models"
+	"gorm.io/gorm"
+)
+
+type AuditFilter struct {
+	ResourceID uuid.UUID
+	Actor      string
+	From       time.Time
+	To         time.Time
+}
+
+type AuditRepository interface {
+	List(filter AuditFilter, page, pageSize int) ([]models.AuditEvent, int64, error)
+	WithContext(ctx context.Context) AuditRepository
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+// List returns matching audit events, newest first. Zero-valued filter fields
+// are ignored; From is inclusive and To is exclusive.
+func (r *auditRepository) List(filter AuditFilter, page, pageSize int) ([]models.AuditEvent, int64, error) {
+	var auditEvents []models.AuditEvent
+	var total int64
+
+	query := r.db.Model(&models.AuditEvent{}).Scopes(forTenant)
+	if filter.ResourceID != uuid.Nil {
+		query = query.Where("resource_id = ?", filter.ResourceID)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("occurred_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("occurred_at < ?", filter.To)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if page > 0 && pageSize > 0 {
+		query = query.Offset((page - 1) * pageSize).Limit(pageSize)
+	}
+
+	if err := query.Order("occurred_at DESC").Find(&auditEvents).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return auditEvents, total, nil
+}
+
+func (r *auditRepository) WithContext(ctx context.Context) AuditRepository {
+	return &auditRepository{db: r.db.WithContext(ctx)}
+}
+
+// appendAudit records a change on tx, attributed to the actor and tenant
+// carried by the transaction's context. before is nil for creates and after is nil for
+// deletes. Fields named in omit (typically preloaded associations) are left
+// out of the snapshots.
+func appendAudit(tx *gorm.DB, action, resourceType string, resourceID uuid.UUID, before, after interface{}, omit ...string) error {
+	beforeJSON, err := snapshot(before, omit)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := snapshot(after, omit)
+	if err != nil {
+		return err
+	}
+	changes, err := audit.Diff(beforeJSON, afterJSON)
+	if err != nil {
+		return err
+	}
+	diff, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.AuditEvent{
+		TenantID:     tenantOf(tx),
+		Actor:        audit.ActorFromContext(tx.Statement.Context),
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Before:       string(beforeJSON),
+		After:        string(afterJSON),
+		Diff:         string(diff),
+		OccurredAt:   time.Now(),
+	}).Error
+}
+
+func snapshot(value interface{}, omit []string) ([]byte, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(omit) == 0 {
+		return data, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, name := range omit {
+		delete(fields, name)
+	}
+	return json.Marshal(fields)
+}
@@ -0,0 +1,112 @@
+//go:build cgo
+// +build cgo
+
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/microservice-go/product-service/internal/audit"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditRepository_RecordsPlanChanges(t *testing.T) {
+	db := setupSubscriptionTestDB(t)
+	ctx := audit.WithActor(context.Background(), "pricing-admin")
+	productRepo := NewProductRepository(db).WithContext(ctx)
+	planRepo := NewSubscriptionRepository(db).WithContext(ctx)
+	auditRepo := NewAuditRepository(db)
+
+	product := &models.Product{Name: "Product", Price: 10, ProductType: "digital"}
+	require.NoError(t, productRepo.Create(product))
+	plan := &models.SubscriptionPlan{ProductID: product.ID, PlanName: "Monthly", Duration: 30, Price: 29.99}
+	require.NoError(t, planRepo.Create(plan))
+	require.NoError(t, planRepo.Update(&models.SubscriptionPlan{ID: plan.ID, Price: 39.99}))
+	require.NoError(t, planRepo.Delete(plan.ID))
+
+	auditEvents, total, err := auditRepo.List(AuditFilter{ResourceID: plan.ID}, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+
+	actions := map[string]models.AuditEvent{}
+	for _, auditEvent := range auditEvents {
+		assert.Equal(t, "pricing-admin", auditEvent.Actor)
+		assert.Equal(t, models.AuditResourceSubscriptionPlan, auditEvent.ResourceType)
+		actions[auditEvent.Action] = auditEvent
+	}
+
+	update := actions[models.AuditActionUpdate]
+	var diff map[string]audit.Change
+	require.NoError(t, json.Unmarshal([]byte(update.Diff), &diff))
+	assert.Equal(t, json.RawMessage("29.99"), diff["price"].Before)
+	assert.Equal(t, json.RawMessage("39.99"), diff["price"].After)
+	assert.NotContains(t, diff, "plan_name")
+	assert.NotContains(t, update.Before, `"product"`)
+
+	assert.Empty(t, actions[models.AuditActionCreate].Before)
+	assert.Empty(t, actions[models.AuditActionDelete].After)
+}
+
+func TestAuditRepository_ListFilters(t *testing.T) {
+	db := setupTestDB(t)
+	auditRepo := NewAuditRepository(db)
+
+	alice := NewProductRepository(db).WithContext(audit.WithActor(context.Background(), "alice"))
+	bob := NewProductRepository(db).WithContext(audit.WithActor(context.Background(), "bob"))
+	require.NoError(t, alice.Create(&models.Product{Name: "A", Price: 1, ProductType: "digital"}))
+	require.NoError(t, bob.Create(&models.Product{Name: "B", Price: 2, ProductType: "digital"}))
+	require.NoError(t, NewProductRepository(db).Create(&models.Product{Name: "C", Price: 3, ProductType: "digital"}))
+
+	_, total, err := auditRepo.List(AuditFilter{Actor: "alice"}, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	_, total, err = auditRepo.List(AuditFilter{Actor: audit.AnonymousActor}, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	_, total, err = auditRepo.List(AuditFilter{From: time.Now().Add(-time.Minute)}, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+
+	_, total, err = auditRepo.List(AuditFilter{To: time.Now().Add(-time.Minute)}, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+}
+
+func TestAuditRepository_EventsAreImmutable(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, NewProductRepository(db).Create(&models.Product{Name: "A", Price: 1, ProductType: "digital"}))
+
+	auditEvents, _, err := NewAuditRepository(db).List(AuditFilter{}, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, auditEvents, 1)
+
+	auditEvent := auditEvents[0]
+	auditEvent.Actor = "someone-else"
+	assert.ErrorIs(t, db.Save(&auditEvent).Error, models.ErrAuditEventImmutable)
+	assert.ErrorIs(t, db.Delete(&auditEvent).Error, models.ErrAuditEventImmutable)
+}
+
+func TestAuditRepository_TenantScoped(t *testing.T) {
+	db := setupTestDB(t)
+	acme := tenant.WithTenant(context.Background(), "acme")
+	globex := tenant.WithTenant(context.Background(), "globex")
+
+	require.NoError(t, NewProductRepository(db).WithContext(acme).Create(&models.Product{Name: "A", Price: 1, ProductType: "digital"}))
+
+	_, total, err := NewAuditRepository(db).WithContext(globex).List(AuditFilter{}, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+
+	auditEvents, total, err := NewAuditRepository(db).WithContext(acme).List(AuditFilter{}, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "acme", auditEvents[0].TenantID)
+}
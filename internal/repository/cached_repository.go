@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/cache"
+	"github.com/microservice-go/product-service/internal/database"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/tenant"
+	"golang.org/x/sync/singleflight"
+)
+
+// Invalidator is implemented by repositories that cache reads. Writers that
+// bypass them, such as scheduled changes applied in their own transaction,
+// call Invalidate after committing.
+type Invalidator interface {
+	Invalidate(ids ...uuid.UUID)
+}
+
+func productKey(ctx context.Context, id uuid.UUID) string {
+	return "product:" + tenant.FromContext(ctx) + ":" + id.String()
+}
+
+func planKey(ctx context.Context, id uuid.UUID) string {
+	return "plan:" + tenant.FromContext(ctx) + ":" + id.String()
+}
+
+// pendingKeys collects the keys written inside a transaction so they are
+// evicted only once it commits; evicting earlier would let a concurrent read
+// cache the pre-commit row again.
+type pendingKeys struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+func (p *pendingKeys) add(keys ...string) {
+	p.mu.Lock()
+	p.keys = append(p.keys, keys...)
+	p.mu.Unlock()
+}
+
+// readThrough returns the cached value under key, loading and caching it on a
+// miss. Concurrent misses for the same key share a single load.
+func readThrough[T any](ctx context.Context, c cache.Cache, group *singleflight.Group, key string, ttl time.Duration, load func(ctx context.Context) (*T, error)) (*T, error) {
+	data, ok := c.Get(ctx, key)
+	if !ok {
+		v, err, _ := group.Do(key, func() (interface{}, error) {
+			// The load is shared, so one caller giving up must not fail the rest.
+			// Cached rows outlive replica lag, so they come from the primary.
+			value, err := load(database.WithPrimary(context.WithoutCancel(ctx)))
+			if err != nil {
+				return nil, err
+			}
+			data, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			c.Set(ctx, key, data, ttl)
+			return data, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		data = v.([]byte)
+	}
+
+	// Every caller decodes its own copy, so mutating a result never leaks
+	// into the cache or into another caller's result.
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+type cachedProductRepository struct {
+	ProductRepository
+	cache   cache.Cache
+	ttl     time.Duration
+	group   *singleflight.Group
+	ctx     context.Context
+	pending *pendingKeys
+}
+
+// NewCachedProductRepository caches GetByID results of repo. Writes through
+// the returned repository evict the product and the plans embedding it.
+func NewCachedProductRepository(repo ProductRepository, c cache.Cache, ttl time.Duration) ProductRepository {
+	return &cachedProductRepository{
+		ProductRepository: repo,
+		cache:             c,
+		ttl:               ttl,
+		group:             &singleflight.Group{},
+		ctx:               context.Background(),
+	}
+}
+
+func (r *cachedProductRepository) GetByID(id uuid.UUID) (*models.Product, error) {
+	if r.pending != nil {
+		// Reads inside a transaction must see its uncommitted writes.
+		return r.ProductRepository.GetByID(id)
+	}
+	return readThrough(r.ctx, r.cache, r.group, productKey(r.ctx, id), r.ttl, func(ctx context.Context) (*models.Product, error) {
+		return r.ProductRepository.WithContext(ctx).GetByID(id)
+	})
+}
+
+func (r *cachedProductRepository) Update(product *models.Product) error {
+	keys := r.keysFor(product.ID)
+	if err := r.ProductRepository.Update(product); err != nil {
+		return err
+	}
+	r.evict(keys...)
+	return nil
+}
+
+func (r *cachedProductRepository) Delete(id uuid.UUID) error {
+	keys := r.keysFor(id)
+	if err := r.ProductRepository.Delete(id); err != nil {
+		return err
+	}
+	r.evict(keys...)
+	return nil
+}
+
+func (r *cachedProductRepository) Invalidate(ids ...uuid.UUID) {
+	for _, id := range ids {
+		r.evict(r.keysFor(id)...)
+	}
+}
+
+// keysFor returns the keys of the product and of its plans, which embed it.
+func (r *cachedProductRepository) keysFor(id uuid.UUID) []string {
+	keys := []string{productKey(r.ctx, id)}
+	if product, err := r.ProductRepository.GetByID(id); err == nil {
+		for _, plan := range product.SubscriptionPlans {
+			keys = append(keys, planKey(r.ctx, plan.ID))
+		}
+	}
+	return keys
+}
+
+func (r *cachedProductRepository) evict(keys ...string) {
+	if r.pending != nil {
+		r.pending.add(keys...)
+		return
+	}
+	r.cache.Delete(r.ctx, keys...)
+}
+
+func (r *cachedProductRepository) Transaction(fn func(repo ProductRepository) error) error {
+	if r.pending != nil {
+		return r.ProductRepository.Transaction(func(repo ProductRepository) error {
+			return fn(r.withRepo(repo, r.pending))
+		})
+	}
+
+	pending := &pendingKeys{}
+	err := r.ProductRepository.Transaction(func(repo ProductRepository) error {
+		return fn(r.withRepo(repo, pending))
+	})
+	// A failed transaction changed nothing, but its keys are cheap to drop.
+	r.cache.Delete(r.ctx, pending.keys...)
+	return err
+}
+
+func (r *cachedProductRepository) WithContext(ctx context.Context) ProductRepository {
+	clone := *r
+	clone.ProductRepository = r.ProductRepository.WithContext(ctx)
+	clone.ctx = ctx
+	return &clone
+}
+
+func (r *cachedProductRepository) withRepo(repo ProductRepository, pending *pendingKeys) *cachedProductRepository {
+	clone := *r
+	clone.ProductRepository = repo
+	clone.pending = pending
+	return &clone
+}
+
+type cachedSubscriptionRepository struct {
+	SubscriptionRepository
+	cache   cache.Cache
+	ttl     time.Duration
+	group   *singleflight.Group
+	ctx     context.Context
+	pending *pendingKeys
+}
+
+// NewCachedSubscriptionRepository caches GetByID results of repo. Writes
+// through the returned repository evict the plan and the products embedding
+// it.
+func NewCachedSubscriptionRepository(repo SubscriptionRepository, c cache.Cache, ttl time.Duration) SubscriptionRepository {
+	return &cachedSubscriptionRepository{
+		SubscriptionRepository: repo,
+		cache:                  c,
+		ttl:                    ttl,
+		group:                  &singleflight.Group{},
+		ctx:                    context.Background(),
+	}
+}
+
+func (r *cachedSubscriptionRepository) GetByID(id uuid.UUID) (*models.SubscriptionPlan, error) {
+	if r.pending != nil {
+		return r.SubscriptionRepository.GetByID(id)
+	}
+	return readThrough(r.ctx, r.cache, r.group, planKey(r.ctx, id), r.ttl, func(ctx context.Context) (*models.SubscriptionPlan, error) {
+		return r.SubscriptionRepository.WithContext(ctx).GetByID(id)
+	})
+}
+
+func (r *cachedSubscriptionRepository) Create(plan *models.SubscriptionPlan) error {
+	if err := r.SubscriptionRepository.Create(plan); err != nil {
+		return err
+	}
+	r.evict(productKey(r.ctx, plan.ProductID))
+	return nil
+}
+
+func (r *cachedSubscriptionRepository) Update(plan *models.SubscriptionPlan) error {
+	// The plan may move to another product, so both lose their entry.
+	keys := append(r.keysFor(plan.ID), productKey(r.ctx, plan.ProductID))
+	if err := r.SubscriptionRepository.Update(plan); err != nil {
+		return err
+	}
+	r.evict(keys...)
+	return nil
+}
+
+func (r *cachedSubscriptionRepository) Delete(id uuid.UUID) error {
+	keys := r.keysFor(id)
+	if err := r.SubscriptionRepository.Delete(id); err != nil {
+		return err
+	}
+	r.evict(keys...)
+	return nil
+}
+
+func (r *cachedSubscriptionRepository) Invalidate(ids ...uuid.UUID) {
+	for _, id := range ids {
+		r.evict(r.keysFor(id)...)
+	}
+}
+
+// keysFor returns the keys of the plan and of its product, which embeds it.
+func (r *cachedSubscriptionRepository) keysFor(id uuid.UUID) []string {
+	keys := []string{planKey(r.ctx, id)}
+	if plan, err := r.SubscriptionRepository.GetByID(id); err == nil {
+		keys = append(keys, productKey(r.ctx, plan.ProductID))
+	}
+	return keys
+}
+
+func (r *cachedSubscriptionRepository) evict(keys ...string) {
+	if r.pending != nil {
+		r.pending.add(keys...)
+		return
+	}
+	r.cache.Delete(r.ctx, keys...)
+}
+
+func (r *cachedSubscriptionRepository) Transaction(fn func(repo SubscriptionRepository) error) error {
+	if r.pending != nil {
+		return r.SubscriptionRepository.Transaction(func(repo SubscriptionRepository) error {
+			return fn(r.withRepo(repo, r.pending))
+		})
+	}
+
+	pending := &pendingKeys{}
+	err := r.SubscriptionRepository.Transaction(func(repo SubscriptionRepository) error {
+		return fn(r.withRepo(repo, pending))
+	})
+	r.cache.Delete(r.ctx, pending.keys...)
+	return err
+}
+
+func (r *cachedSubscriptionRepository) WithContext(ctx context.Context) SubscriptionRepository {
+	clone := *r
+	clone.SubscriptionRepository = r.SubscriptionRepository.WithContext(ctx)
+	clone.ctx = ctx
+	return &clone
+}
+
+func (r *cachedSubscriptionRepository) withRepo(repo SubscriptionRepository, pending *pendingKeys) *cachedSubscriptionRepository {
+	clone := *r
+	clone.SubscriptionRepository = repo
+	clone.pending = pending
+	return &clone
+}
@@ -0,0 +1,167 @@
+//go:build cgo
+// +build cgo
+
+package repository
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/cache"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedRepositories_ReadThroughAndInvalidate(t *testing.T) {
+	db := setupSubscriptionTestDB(t)
+	c := cache.NewLRU(100)
+	products := NewCachedProductRepository(NewProductRepository(db), c, time.Minute)
+	plans := NewCachedSubscriptionRepository(NewSubscriptionRepository(db), c, time.Minute)
+
+	product := &models.Product{Name: "Streaming", Price: 10, ProductType: "digital"}
+	require.NoError(t, products.Create(product))
+	plan := &models.SubscriptionPlan{ProductID: product.ID, PlanName: "Monthly", Duration: 30, Price: 9.99}
+	require.NoError(t, plans.Create(plan))
+
+	cached, err := products.GetByID(product.ID)
+	require.NoError(t, err)
+	assert.Len(t, cached.SubscriptionPlans, 1)
+	cachedPlan, err := plans.GetByID(plan.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Streaming", cachedPlan.Product.Name)
+
+	// Writes behind the cache's back are not seen until evicted.
+	require.NoError(t, db.Model(&models.Product{}).Where("id = ?", product.ID).Update("name", "Sneaky").Error)
+	cached, err = products.GetByID(product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Streaming", cached.Name)
+
+	// Mutating a result does not touch the cached copy.
+	cached.Name = "Mutated"
+	cached, _ = products.GetByID(product.ID)
+	assert.Equal(t, "Streaming", cached.Name)
+
+	// Updating the product evicts it and the plans embedding it.
+	require.NoError(t, products.Update(&models.Product{ID: product.ID, Name: "Video"}))
+	cached, err = products.GetByID(product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Video", cached.Name)
+	cachedPlan, err = plans.GetByID(plan.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Video", cachedPlan.Product.Name)
+
+	// Plan writes evict the product embedding them.
+	require.NoError(t, plans.Update(&models.SubscriptionPlan{ID: plan.ID, ProductID: product.ID, PlanName: "Monthly", Duration: 30, Price: 12}))
+	cached, _ = products.GetByID(product.ID)
+	assert.Equal(t, 12.0, cached.SubscriptionPlans[0].Price)
+
+	second := &models.SubscriptionPlan{ProductID: product.ID, PlanName: "Yearly", Duration: 365, Price: 99}
+	require.NoError(t, plans.Create(second))
+	cached, _ = products.GetByID(product.ID)
+	assert.Len(t, cached.SubscriptionPlans, 2)
+
+	require.NoError(t, plans.Delete(second.ID))
+	cached, _ = products.GetByID(product.ID)
+	assert.Len(t, cached.SubscriptionPlans, 1)
+
+	require.NoError(t, products.Delete(product.ID))
+	_, err = products.GetByID(product.ID)
+	assert.Error(t, err)
+}
+
+func TestCachedRepositories_Transaction(t *testing.T) {
+	db := setupSubscriptionTestDB(t)
+	c := cache.NewLRU(100)
+	products := NewCachedProductRepository(NewProductRepository(db), c, time.Minute)
+
+	product := &models.Product{Name: "Streaming", Price: 10, ProductType: "digital"}
+	require.NoError(t, products.Create(product))
+	_, err := products.GetByID(product.ID)
+	require.NoError(t, err)
+
+	err = products.Transaction(func(repo ProductRepository) error {
+		if err := repo.Update(&models.Product{ID: product.ID, Name: "Video"}); err != nil {
+			return err
+		}
+		inside, err := repo.GetByID(product.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Video", inside.Name, "reads inside the transaction see its writes")
+
+		outside, err := products.GetByID(product.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Streaming", outside.Name, "the entry is evicted only on commit")
+		return nil
+	})
+	require.NoError(t, err)
+
+	cached, err := products.GetByID(product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Video", cached.Name)
+}
+
+func TestCachedRepositories_TenantsAndInvalidate(t *testing.T) {
+	db := setupSubscriptionTestDB(t)
+	c := cache.NewLRU(100)
+	products := NewCachedProductRepository(NewProductRepository(db), c, time.Minute)
+	acme := tenant.WithTenant(context.Background(), "acme")
+	globex := tenant.WithTenant(context.Background(), "globex")
+
+	product := &models.Product{Name: "Streaming", Price: 10, ProductType: "digital"}
+	require.NoError(t, products.WithContext(acme).Create(product))
+	_, err := products.WithContext(acme).GetByID(product.ID)
+	require.NoError(t, err)
+
+	_, err = products.WithContext(globex).GetByID(product.ID)
+	assert.Error(t, err, "another tenant must not be served the cached entry")
+
+	require.NoError(t, db.Model(&models.Product{}).Where("id = ?", product.ID).Update("name", "Video").Error)
+	products.WithContext(acme).(Invalidator).Invalidate(product.ID)
+	cached, err := products.WithContext(acme).GetByID(product.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Video", cached.Name)
+}
+
+type slowProductRepository struct {
+	ProductRepository
+	loads   atomic.Int32
+	release chan struct{}
+}
+
+func (r *slowProductRepository) GetByID(id uuid.UUID) (*models.Product, error) {
+	r.loads.Add(1)
+	<-r.release
+	return &models.Product{ID: id, Name: "Streaming"}, nil
+}
+
+func (r *slowProductRepository) WithContext(ctx context.Context) ProductRepository {
+	return r
+}
+
+func TestCachedProductRepository_CollapsesConcurrentMisses(t *testing.T) {
+	inner := &slowProductRepository{release: make(chan struct{})}
+	products := NewCachedProductRepository(inner, cache.NewLRU(100), time.Minute)
+	id := uuid.New()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			product, err := products.GetByID(id)
+			assert.NoError(t, err)
+			assert.Equal(t, "Streaming", product.Name)
+		}()
+	}
+	assert.Eventually(t, func() bool { return inner.loads.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(inner.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), inner.loads.Load())
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"gorm.io/gorm"
+)
+
+type CouponRepository interface {
+	Create(coupon *models.Coupon) error
+	GetByID(id uuid.UUID) (*models.Coupon, error)
+	GetByIDWithDeleted(id uuid.UUID) (*models.Coupon, error)
+	GetByCode(code string) (*models.Coupon, error)
+	List(page, pageSize int) ([]models.Coupon, int64, error)
+	Update(coupon *models.Coupon) error
+	Delete(id uuid.UUID) error
+	WithContext(ctx context.Context) CouponRepository
+}
+
+type couponRepository struct {
+	db *gorm.DB
+}
+
+func NewCouponRepository(db *gorm.DB) CouponRepository {
+	return &couponRepository{db: db}
+}
+
+func (r *couponRepository) Create(coupon *models.Coupon) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		coupon.TenantID = tenantOf(tx)
+		if err := tx.Create(coupon).Error; err != nil {
+			return err
+		}
+		return appendAudit(tx, models.AuditActionCreate, models.AuditResourceCoupon, coupon.ID, nil, coupon)
+	})
+}
+
+func (r *couponRepository) GetByID(id uuid.UUID) (*models.Coupon, error) {
+	return r.first("id = ?", id)
+}
+
+// GetByIDWithDeleted also finds coupons that have been deleted since, which
+// still discount the subscriptions that redeemed them.
+func (r *couponRepository) GetByIDWithDeleted(id uuid.UUID) (*models.Coupon, error) {
+	var coupon models.Coupon
+	err := r.db.Unscoped().Scopes(forTenant).First(&coupon, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("coupon not found")
+		}
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func (r *couponRepository) GetByCode(code string) (*models.Coupon, error) {
+	return r.first("code = ?", code)
+}
+
+func (r *couponRepository) first(query string, arg interface{}) (*models.Coupon, error) {
+	var coupon models.Coupon
+	err := r.db.Scopes(forTenant).First(&coupon, query, arg).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("coupon not found")
+		}
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func (r *couponRepository) List(page, pageSize int) ([]models.Coupon, int64, error) {
+	var coupons []models.Coupon
+	var total int64
+
+	query := r.db.Model(&models.Coupon{}).Scopes(forTenant)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if page > 0 && pageSize > 0 {
+		offset := (page - 1) * pageSize
+		query = query.Offset(offset).Limit(pageSize)
+	}
+
+	if err := query.Order("created_at DESC, id").Find(&coupons).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return coupons, total, nil
+}
+
+// Update writes the discount terms, limits and restrictions of coupon. The
+// code and redemption count are left alone.
+func (r *couponRepository) Update(coupon *models.Coupon) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Coupon
+		if err := tx.Scopes(forTenant).First(&before, "id = ?", coupon.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("coupon not found")
+			}
+			return err
+		}
+
+		err := tx.Model(coupon).Scopes(forTenant).
+			Select("percent_off", "amount_off", "currency", "duration", "duration_cycles", "max_redemptions",
+				"expires_at", "product_ids", "plan_ids").
+			Updates(coupon).Error
+		if err != nil {
+			return err
+		}
+
+		var updated models.Coupon
+		if err := tx.First(&updated, "id = ?", coupon.ID).Error; err != nil {
+			return err
+		}
+		*coupon = updated
+		return appendAudit(tx, models.AuditActionUpdate, models.AuditResourceCoupon, coupon.ID, &before, &updated)
+	})
+}
+
+// Delete retires a coupon. Subscriptions that already redeemed it keep their
+// discount.
+func (r *couponRepository) Delete(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var coupon models.Coupon
+		if err := tx.Scopes(forTenant).First(&coupon, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("coupon not found")
+			}
+			return err
+		}
+
+		if err := tx.Scopes(forTenant).Delete(&models.Coupon{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		return appendAudit(tx, models.AuditActionDelete, models.AuditResourceCoupon, id, &coupon, nil)
+	})
+}
+
+func (r *couponRepository) WithContext(ctx context.Context) CouponRepository {
+	return &couponRepository{db: r.db.WithContext(ctx)}
+}
+
+// redeemCoupon counts a redemption of the coupon on tx, failing with
+// ErrCouponExhausted when none are left. The conditional increment keeps
+// concurrent redemptions from overshooting the limit.
+func redeemCoupon(tx *gorm.DB, id uuid.UUID) error {
+	result := tx.Model(&models.Coupon{}).Scopes(forTenant).
+		Where("id = ? AND (max_redemptions = 0 OR times_redeemed < max_redemptions)", id).
+		Update("times_redeemed", gorm.Expr("times_redeemed + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.ErrCouponExhausted
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+//go:build cgo
+// +build cgo
+
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupCouponTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("test_coupon.db"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.Coupon{}, &models.Subscription{}, &models.AuditEvent{})
+	if err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	db.Exec("DELETE FROM coupons")
+	db.Exec("DELETE FROM subscriptions")
+	db.Exec("DELETE FROM audit_events")
+
+	return db
+}
+
+func TestCouponRepository_CRUD(t *testing.T) {
+	db := setupCouponTestDB(t)
+	repo := NewCouponRepository(db)
+
+	expiresAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	planID := uuid.New()
+	coupon := &models.Coupon{Code: "LAUNCH25", PercentOff: 25, Duration: models.CouponOnce, ExpiresAt: &expiresAt, PlanIDs: []uuid.UUID{planID}}
+	require.NoError(t, repo.Create(coupon))
+
+	stored, err := repo.GetByCode("LAUNCH25")
+	require.NoError(t, err)
+	assert.Equal(t, coupon.ID, stored.ID)
+	assert.Equal(t, []uuid.UUID{planID}, stored.PlanIDs)
+
+	stored.ExpiresAt = nil
+	stored.PlanIDs = nil
+	require.NoError(t, repo.Update(stored))
+
+	stored, err = repo.GetByID(coupon.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored.ExpiresAt, "the expiry can be cleared")
+	assert.Empty(t, stored.PlanIDs)
+
+	require.NoError(t, repo.Delete(coupon.ID))
+	_, err = repo.GetByCode("LAUNCH25")
+	assert.Error(t, err)
+
+	reissued := &models.Coupon{Code: "LAUNCH25", PercentOff: 10, Duration: models.CouponOnce}
+	require.NoError(t, repo.Create(reissued), "a deleted coupon's code can be reused")
+	stored, err = repo.GetByCode("LAUNCH25")
+	require.NoError(t, err)
+	assert.Equal(t, reissued.ID, stored.ID)
+	assert.Error(t, repo.Create(&models.Coupon{Code: "LAUNCH25", PercentOff: 5, Duration: models.CouponOnce}))
+
+	deleted, err := repo.GetByIDWithDeleted(coupon.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 25.0, deleted.PercentOff)
+}
+
+func TestCouponRepository_RedeemOnSubscribe(t *testing.T) {
+	db := setupCouponTestDB(t)
+	repo := NewCouponRepository(db)
+	subs := NewCustomerSubscriptionRepository(db)
+
+	coupon := &models.Coupon{Code: "ONCE", PercentOff: 10, Duration: models.CouponOnce, MaxRedemptions: 1}
+	require.NoError(t, repo.Create(coupon))
+
+	plan := &models.SubscriptionPlan{ID: uuid.New(), Interval: models.BillingInterval{Unit: models.IntervalMonth, Count: 1}}
+	first := models.NewSubscription("cus_1", plan, time.Now())
+	first.CouponID = &coupon.ID
+	require.NoError(t, subs.Create(first, nil))
+
+	second := models.NewSubscription("cus_2", plan, time.Now())
+	second.CouponID = &coupon.ID
+	assert.ErrorIs(t, subs.Create(second, nil), apperrors.ErrCouponExhausted)
+
+	stored, err := repo.GetByID(coupon.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.TimesRedeemed)
+
+	var count int64
+	db.Model(&models.Subscription{}).Count(&count)
+	assert.Equal(t, int64(1), count, "the rejected subscription was not created")
+}
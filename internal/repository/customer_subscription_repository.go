@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/events"
+	"github.com/microservice-go/product-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// CustomerSubscriptionRepository stores the subscriptions of customers to
+// plans; SubscriptionRepository stores the plans themselves. The invoice
+// passed to Create, Update and Renew, if any, is saved in the same
+// transaction as the change it bills.
+type CustomerSubscriptionRepository interface {
+	Create(sub *models.Subscription, invoice *models.Invoice) error
+	GetByID(id uuid.UUID) (*models.Subscription, error)
+	List(customerID string, planID uuid.UUID, status string, page, pageSize int) ([]models.Subscription, int64, error)
+	Update(sub *models.Subscription, invoice *models.Invoice) error
+	ListDue(now time.Time, limit int) ([]models.Subscription, error)
+	Renew(sub *models.Subscription, periodEnd time.Time, plan *models.SubscriptionPlan, invoice *models.Invoice) (bool, error)
+	WithContext(ctx context.Context) CustomerSubscriptionRepository
+}
+
+type customerSubscriptionRepository struct {
+	db *gorm.DB
+}
+
+func NewCustomerSubscriptionRepository(db *gorm.DB) CustomerSubscriptionRepository {
+	return &customerSubscriptionRepository{db: db}
+}
+
+func (r *customerSubscriptionRepository) Create(sub *models.Subscription, invoice *models.Invoice) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		sub.TenantID = tenantOf(tx)
+		if sub.CouponID != nil {
+			if err := redeemCoupon(tx, *sub.CouponID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(sub).Error; err != nil {
+			return err
+		}
+		if invoice != nil {
+			invoice.SubscriptionID = sub.ID
+			if err := createInvoice(tx, invoice); err != nil {
+				return err
+			}
+		}
+		return appendAudit(tx, models.AuditActionCreate, models.AuditResourceSubscription, sub.ID, nil, sub)
+	})
+}
+
+func (r *customerSubscriptionRepository) GetByID(id uuid.UUID) (*models.Subscription, error) {
+	var sub models.Subscription
+	err := r.db.Scopes(forTenant).First(&sub, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("subscription not found")
+		}
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// List returns subscriptions, newest first. An empty customerID or status, or
+// a nil planID, matches everything.
+func (r *customerSubscriptionRepository) List(customerID string, planID uuid.UUID, status string, page, pageSize int) ([]models.Subscription, int64, error) {
+	var subs []models.Subscription
+	var total int64
+
+	query := r.db.Model(&models.Subscription{}).Scopes(forTenant)
+	if customerID != "" {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	if planID != uuid.Nil {
+		query = query.Where("plan_id = ?", planID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if page > 0 && pageSize > 0 {
+		query = query.Offset((page - 1) * pageSize).Limit(pageSize)
+	}
+
+	if err := query.Order("created_at DESC, id").Find(&subs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return subs, total, nil
+}
+
+// Update writes the lifecycle state of sub: its plan, status, billing period,
+// discount and cancellation. The update only matches the version of sub that
+// was read, so a renewal or another update that got there in between is not
+// overwritten; Update returns ErrSubscriptionChanged instead.
+func (r *customerSubscriptionRepository) Update(sub *models.Subscription, invoice *models.Invoice) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Subscription
+		if err := tx.Scopes(forTenant).First(&before, "id = ?", sub.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("subscription not found")
+			}
+			return err
+		}
+
+		ok, err := updateVersion(tx, sub,
+			"plan_id", "status", "billing_anchor", "cycle", "current_period_start", "current_period_end",
+			"pending_plan_id", "usage_start", "discounted_cycles", "period_discount", "cancel_at_period_end",
+			"canceled_at", "ended_at")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return apperrors.ErrSubscriptionChanged
+		}
+		if invoice != nil {
+			if err := createInvoice(tx, invoice); err != nil {
+				return err
+			}
+		}
+
+		var updated models.Subscription
+		if err := tx.First(&updated, "id = ?", sub.ID).Error; err != nil {
+			return err
+		}
+		*sub = updated
+		return appendAudit(tx, models.AuditActionUpdate, models.AuditResourceSubscription, sub.ID, &before, &updated)
+	})
+}
+
+// ListDue returns up to limit running subscriptions of every tenant whose
+// current period has ended by now, longest overdue first.
+func (r *customerSubscriptionRepository) ListDue(now time.Time, limit int) ([]models.Subscription, error) {
+	var subs []models.Subscription
+	err := r.db.Where("status <> ? AND current_period_end <= ?", models.SubscriptionCanceled, now).
+		Order("current_period_end, id").
+		Limit(limit).
+		Find(&subs).Error
+	if err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// Renew saves sub after it has moved on from the period ending at periodEnd,
+// either into its next period on plan or to its end, and records the matching
+// subscription event in the same transaction. The update only matches while
+// the subscription is still in that period, so when several replicas race for
+// the same renewal the row lock it takes lets one of them through and the
+// others find nothing left to update. It reports false when the subscription
+// had already moved on or was changed since it was read.
+func (r *customerSubscriptionRepository) Renew(sub *models.Subscription, periodEnd time.Time, plan *models.SubscriptionPlan, invoice *models.Invoice) (bool, error) {
+	renewed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Subscription
+		if err := tx.Scopes(forTenant).First(&before, "id = ?", sub.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("subscription not found")
+			}
+			return err
+		}
+
+		ok, err := updateVersion(tx.Where("status <> ? AND current_period_end = ?", models.SubscriptionCanceled, periodEnd), sub,
+			"plan_id", "status", "billing_anchor", "cycle", "current_period_start", "current_period_end",
+			"pending_plan_id", "discounted_cycles", "period_discount", "ended_at")
+		if err != nil || !ok {
+			return err
+		}
+		if invoice != nil {
+			if err := createInvoice(tx, invoice); err != nil {
+				return err
+			}
+		}
+
+		var updated models.Subscription
+		if err := tx.First(&updated, "id = ?", sub.ID).Error; err != nil {
+			return err
+		}
+		*sub = updated
+		if err := appendAudit(tx, models.AuditActionUpdate, models.AuditResourceSubscription, sub.ID, &before, &updated); err != nil {
+			return err
+		}
+
+		eventType := events.TypeRenewed
+		if sub.IsEnded() {
+			eventType = events.TypeEnded
+		}
+		if err := appendOutbox(tx, events.NewSubscriptionEvent(eventType, sub, plan)); err != nil {
+			return err
+		}
+		renewed = true
+		return nil
+	})
+	return renewed, err
+}
+
+// updateVersion writes columns of sub if the stored subscription is still at
+// the version sub was read at, and moves it to the next version. It reports
+// false when nothing matched.
+func updateVersion(tx *gorm.DB, sub *models.Subscription, columns ...string) (bool, error) {
+	version := sub.Version
+	sub.Version++
+	result := tx.Model(sub).Scopes(forTenant).
+		Where("version = ?", version).
+		Select(append(columns, "version")).
+		Updates(sub)
+	if result.Error != nil || result.RowsAffected == 0 {
+		sub.Version = version
+		return false, result.Error
+	}
+	return true, nil
+}
+
+func (r *customerSubscriptionRepository) WithContext(ctx context.Context) CustomerSubscriptionRepository {
+	return &customerSubscriptionRepository{db: r.db.WithContext(ctx)}
+}
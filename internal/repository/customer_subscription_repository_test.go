@@ -0,0 +1,200 @@
+//go:build cgo
+// +build cgo
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupCustomerSubscriptionTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("test_customer_subscription.db"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.Subscription{}, &models.Invoice{}, &models.InvoiceSequence{}, &models.AuditEvent{}, &models.OutboxEvent{})
+	if err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	db.Exec("DELETE FROM subscriptions")
+	db.Exec("DELETE FROM invoices")
+	db.Exec("DELETE FROM invoice_sequences")
+	db.Exec("DELETE FROM audit_events")
+	db.Exec("DELETE FROM outbox")
+
+	return db
+}
+
+func TestCustomerSubscriptionRepository_CreateAndUpdate(t *testing.T) {
+	db := setupCustomerSubscriptionTestDB(t)
+	repo := NewCustomerSubscriptionRepository(db)
+
+	start := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	plan := &models.SubscriptionPlan{ID: uuid.New(), Interval: models.BillingInterval{Unit: models.IntervalMonth, Count: 1}}
+	sub := models.NewSubscription("cus_1", plan, start)
+	require.NoError(t, repo.Create(sub, nil))
+
+	canceledAt := start.Add(time.Hour)
+	pendingPlanID := uuid.New()
+	sub.CancelAtPeriodEnd = true
+	sub.CanceledAt = &canceledAt
+	sub.PendingPlanID = &pendingPlanID
+	require.NoError(t, repo.Update(sub, nil))
+
+	stored, err := repo.GetByID(sub.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.CancelAtPeriodEnd)
+	assert.True(t, canceledAt.Equal(*stored.CanceledAt))
+	assert.Equal(t, pendingPlanID, *stored.PendingPlanID)
+
+	sub.CancelAtPeriodEnd = false
+	sub.CanceledAt = nil
+	sub.PendingPlanID = nil
+	require.NoError(t, repo.Update(sub, nil))
+
+	stored, err = repo.GetByID(sub.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.CancelAtPeriodEnd, "zero values are written")
+	assert.Nil(t, stored.CanceledAt)
+	assert.Nil(t, stored.PendingPlanID)
+	assert.Equal(t, models.SubscriptionActive, stored.Status)
+
+	var audits int64
+	db.Model(&models.AuditEvent{}).Where("resource_id = ?", sub.ID).Count(&audits)
+	assert.Equal(t, int64(3), audits)
+}
+
+func TestCustomerSubscriptionRepository_List(t *testing.T) {
+	db := setupCustomerSubscriptionTestDB(t)
+	repo := NewCustomerSubscriptionRepository(db)
+
+	plan := &models.SubscriptionPlan{ID: uuid.New(), Interval: models.BillingInterval{Unit: models.IntervalMonth, Count: 1}}
+	other := &models.SubscriptionPlan{ID: uuid.New(), Interval: models.BillingInterval{Unit: models.IntervalYear, Count: 1}}
+	for _, sub := range []*models.Subscription{
+		models.NewSubscription("cus_1", plan, time.Now()),
+		models.NewSubscription("cus_1", other, time.Now()),
+		models.NewSubscription("cus_2", plan, time.Now()),
+	} {
+		require.NoError(t, repo.Create(sub, nil))
+	}
+
+	subs, total, err := repo.List("cus_1", uuid.Nil, "", 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, subs, 2)
+
+	_, total, err = repo.List("", plan.ID, models.SubscriptionActive, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+
+	globex := repo.WithContext(tenant.WithTenant(context.Background(), "globex"))
+	_, total, err = globex.List("cus_1", uuid.Nil, "", 1, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total, "subscriptions are scoped to their tenant")
+}
+
+func TestCustomerSubscriptionRepository_Renew(t *testing.T) {
+	db := setupCustomerSubscriptionTestDB(t)
+	repo := NewCustomerSubscriptionRepository(db)
+
+	start := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	plan := &models.SubscriptionPlan{ID: uuid.New(), ProductID: uuid.New(), Interval: models.BillingInterval{Unit: models.IntervalMonth, Count: 1}}
+	sub := models.NewSubscription("cus_1", plan, start)
+	require.NoError(t, repo.Create(sub, nil))
+	globex := models.NewSubscription("cus_2", plan, start)
+	require.NoError(t, repo.WithContext(tenant.WithTenant(context.Background(), "globex")).Create(globex, nil))
+
+	due, err := repo.ListDue(sub.CurrentPeriodEnd, 10)
+	require.NoError(t, err)
+	assert.Len(t, due, 2, "renewals cover every tenant")
+
+	// Two workers picked up the same subscription.
+	first, second := due[0], due[0]
+	if first.ID != sub.ID {
+		first, second = due[1], due[1]
+	}
+	periodEnd := first.CurrentPeriodEnd
+	first.Renew(plan, plan)
+	second.Renew(plan, plan)
+	firstInvoice := models.NewInvoice(&first, "USD", []models.LineItem{first.PeriodCharge(plan)})
+	firstInvoice.Finalize(periodEnd)
+	secondInvoice := models.NewInvoice(&second, "USD", []models.LineItem{second.PeriodCharge(plan)})
+	secondInvoice.Finalize(periodEnd)
+
+	ok, err := repo.Renew(&first, periodEnd, plan, firstInvoice)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = repo.Renew(&second, periodEnd, plan, secondInvoice)
+	require.NoError(t, err)
+	assert.False(t, ok, "the period was already renewed")
+
+	var invoices int64
+	db.Model(&models.Invoice{}).Where("subscription_id = ?", sub.ID).Count(&invoices)
+	assert.Equal(t, int64(1), invoices, "only the renewal that went through is invoiced")
+
+	stored, err := repo.GetByID(sub.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.Cycle)
+	assert.True(t, time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC).Equal(stored.CurrentPeriodEnd))
+
+	var outbox []models.OutboxEvent
+	require.NoError(t, db.Find(&outbox).Error)
+	require.Len(t, outbox, 1)
+	assert.Equal(t, "subscription", outbox[0].Aggregate)
+	assert.Equal(t, "renewed", outbox[0].EventType)
+	assert.Equal(t, plan.ProductID, outbox[0].ProductID)
+
+	due, err = repo.ListDue(sub.CurrentPeriodEnd, 10)
+	require.NoError(t, err)
+	assert.Len(t, due, 1)
+}
+
+func TestCustomerSubscriptionRepository_UpdateAfterRenewal(t *testing.T) {
+	db := setupCustomerSubscriptionTestDB(t)
+	repo := NewCustomerSubscriptionRepository(db)
+
+	start := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	plan := &models.SubscriptionPlan{ID: uuid.New(), Interval: models.BillingInterval{Unit: models.IntervalMonth, Count: 1}}
+	sub := models.NewSubscription("cus_1", plan, start)
+	require.NoError(t, repo.Create(sub, nil))
+
+	// A cancellation read the subscription just before the worker renewed it.
+	stale, err := repo.GetByID(sub.ID)
+	require.NoError(t, err)
+	renewed := *stale
+	periodEnd := renewed.CurrentPeriodEnd
+	renewed.Renew(plan, plan)
+	ok, err := repo.Renew(&renewed, periodEnd, plan, nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	stale.CancelAtPeriodEnd = true
+	err = repo.Update(stale, nil)
+	assert.ErrorIs(t, err, apperrors.ErrSubscriptionChanged)
+
+	stored, err := repo.GetByID(sub.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.Cycle, "the renewal is not rolled back")
+	assert.False(t, stored.CancelAtPeriodEnd)
+
+	// A resume that read the subscription before it was cancelled loses too.
+	first, err := repo.GetByID(sub.ID)
+	require.NoError(t, err)
+	second := *first
+	first.CancelAtPeriodEnd = true
+	require.NoError(t, repo.Update(first, nil))
+	assert.ErrorIs(t, repo.Update(&second, nil), apperrors.ErrSubscriptionChanged)
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/models"
+	"gorm.io/gorm"
+)
+
+// recordProductVersion closes the product's current version and opens a new
+// one holding its state as of product.UpdatedAt.
+func recordProductVersion(tx *gorm.DB, product *models.Product) error {
+	if err := closeProductVersion(tx, product.ID, product.UpdatedAt); err != nil {
+		return err
+	}
+	return tx.Create(&models.ProductVersion{
+		TenantID:         product.TenantID,
+		ProductID:        product.ID,
+		Name:             product.Name,
+		Description:      product.Description,
+		Price:            product.Price,
+		ProductType:      product.ProductType,
+		TaxInclusive:     product.TaxInclusive,
+		ProductCreatedAt: product.CreatedAt,
+		ValidFrom:        product.UpdatedAt,
+	}).Error
+}
+
+func closeProductVersion(tx *gorm.DB, productID uuid.UUID, at time.Time) error {
+	return tx.Model(&models.ProductVersion{}).
+		Where("product_id = ? AND valid_to IS NULL", productID).
+		Update("valid_to", at).Error
+}
+
+func recordPlanVersion(tx *gorm.DB, plan *models.SubscriptionPlan) error {
+	if err := closePlanVersion(tx, plan.ID, plan.UpdatedAt); err != nil {
+		return err
+	}
+	return tx.Create(&models.SubscriptionPlanVersion{
+		TenantID:      plan.TenantID,
+		PlanID:        plan.ID,
+		ProductID:     plan.ProductID,
+		PlanName:      plan.PlanName,
+		Interval:      plan.Interval,
+		Duration:      plan.Duration,
+		Price:         plan.Price,
+		PricingModel:  plan.PricingModel,
+		Tiers:         plan.Tiers,
+		Meter:         plan.Meter,
+		TrialDays:     plan.TrialDays,
+		IntroPhases:   plan.IntroPhases,
+		PlanCreatedAt: plan.CreatedAt,
+		ValidFrom:     plan.UpdatedAt,
+	}).Error
+}
+
+func closePlanVersion(tx *gorm.DB, planID uuid.UUID, at time.Time) error {
+	return tx.Model(&models.SubscriptionPlanVersion{}).
+		Where("plan_id = ? AND valid_to IS NULL", planID).
+		Update("valid_to", at).Error
+}
+
+// validAt restricts a version query to rows that were current at t.
+func validAt(t time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("valid_from <= ? AND (valid_to IS NULL OR valid_to > ?)", t, t)
+	}
+}
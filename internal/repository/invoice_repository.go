@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// InvoiceRepository reads invoices and moves them through their statuses.
+// Invoices are created by CustomerSubscriptionRepository, in the same
+// transaction as the subscription change they bill.
+type InvoiceRepository interface {
+	GetByID(id uuid.UUID) (*models.Invoice, error)
+	List(customerID string, subscriptionID uuid.UUID, status string, page, pageSize int) ([]models.Invoice, int64, error)
+	UpdateStatus(invoice *models.Invoice, from string) (bool, error)
+	WithContext(ctx context.Context) InvoiceRepository
+}
+
+type invoiceRepository struct {
+	db *gorm.DB
+}
+
+func NewInvoiceRepository(db *gorm.DB) InvoiceRepository {
+	return &invoiceRepository{db: db}
+}
+
+func (r *invoiceRepository) GetByID(id uuid.UUID) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := r.db.Scopes(forTenant).First(&invoice, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invoice not found")
+		}
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// List returns invoices, newest first. An empty customerID or status, or a
+// nil subscriptionID, matches everything.
+func (r *invoiceRepository) List(customerID string, subscriptionID uuid.UUID, status string, page, pageSize int) ([]models.Invoice, int64, error) {
+	var invoices []models.Invoice
+	var total int64
+
+	query := r.db.Model(&models.Invoice{}).Scopes(forTenant)
+	if customerID != "" {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	if subscriptionID != uuid.Nil {
+		query = query.Where("subscription_id = ?", subscriptionID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if page > 0 && pageSize > 0 {
+		query = query.Offset((page - 1) * pageSize).Limit(pageSize)
+	}
+
+	if err := query.Order("created_at DESC, id").Find(&invoices).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return invoices, total, nil
+}
+
+// UpdateStatus saves the status of invoice and when it was issued, paid or
+// voided, provided it still has status from. An invoice leaving draft is
+// given the next number in the same transaction. It reports false when the
+// invoice had already moved on.
+func (r *invoiceRepository) UpdateStatus(invoice *models.Invoice, from string) (bool, error) {
+	updated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Invoice
+		if err := tx.Scopes(forTenant).First(&before, "id = ?", invoice.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("invoice not found")
+			}
+			return err
+		}
+
+		result := tx.Model(invoice).Scopes(forTenant).
+			Where("status = ?", from).
+			Select("status", "issued_at", "paid_at", "voided_at").
+			Updates(invoice)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if invoice.Status != models.InvoiceDraft && before.Number == "" {
+			number, err := nextInvoiceNumber(tx)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(invoice).Update("number", number).Error; err != nil {
+				return err
+			}
+		}
+
+		var after models.Invoice
+		if err := tx.First(&after, "id = ?", invoice.ID).Error; err != nil {
+			return err
+		}
+		*invoice = after
+		updated = true
+		return appendAudit(tx, models.AuditActionUpdate, models.AuditResourceInvoice, invoice.ID, &before, &after)
+	})
+	return updated, err
+}
+
+func (r *invoiceRepository) WithContext(ctx context.Context) InvoiceRepository {
+	return &invoiceRepository{db: r.db.WithContext(ctx)}
+}
+
+// createInvoice saves invoice on tx, numbering it unless it is a draft.
+func createInvoice(tx *gorm.DB, invoice *models.Invoice) error {
+	invoice.TenantID = tenantOf(tx)
+	if invoice.Status != models.InvoiceDraft {
+		number, err := nextInvoiceNumber(tx)
+		if err != nil {
+			return err
+		}
+		invoice.Number = number
+	}
+	if err := tx.Create(invoice).Error; err != nil {
+		return err
+	}
+	return appendAudit(tx, models.AuditActionCreate, models.AuditResourceInvoice, invoice.ID, nil, invoice)
+}
+
+// nextInvoiceNumber takes the next number of the calling tenant. The upsert
+// locks the tenant's counter row until tx ends, so numbers are handed out in
+// commit order and a rolled back transaction gives its number back.
+func nextInvoiceNumber(tx *gorm.DB) (string, error) {
+	sequence := models.InvoiceSequence{TenantID: tenantOf(tx), Value: 1}
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"value": gorm.Expr("invoice_sequences.value + 1")}),
+	}).Create(&sequence).Error
+	if err != nil {
+		return "", err
+	}
+
+	if err := tx.First(&sequence, "tenant_id = ?", sequence.TenantID).Error; err != nil {
+		return "", err
+	}
+	return models.FormatInvoiceNumber(sequence.Value), nil
+}
@@ -0,0 +1,136 @@
+//go:build cgo
+// +build cgo
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupInvoiceTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("test_invoice.db"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.Subscription{}, &models.Invoice{}, &models.InvoiceSequence{}, &models.AuditEvent{}, &models.OutboxEvent{})
+	if err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	db.Exec("DELETE FROM subscriptions")
+	db.Exec("DELETE FROM invoices")
+	db.Exec("DELETE FROM invoice_sequences")
+	db.Exec("DELETE FROM audit_events")
+	db.Exec("DELETE FROM outbox")
+
+	return db
+}
+
+// subscribeWithInvoice creates a subscription for customerID with an invoice
+// for its first period, finalized unless draft is set.
+func subscribeWithInvoice(t *testing.T, repo CustomerSubscriptionRepository, customerID string, draft bool) *models.Invoice {
+	t.Helper()
+	plan := &models.SubscriptionPlan{ID: uuid.New(), Price: 10, Interval: models.BillingInterval{Unit: models.IntervalMonth, Count: 1}}
+	sub := models.NewSubscription(customerID, plan, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	invoice := models.NewInvoice(sub, "USD", []models.LineItem{sub.PeriodCharge(plan)})
+	if !draft {
+		invoice.Finalize(sub.CurrentPeriodStart)
+	}
+	require.NoError(t, repo.Create(sub, invoice))
+	return invoice
+}
+
+func TestInvoiceRepository_NumbersPerTenant(t *testing.T) {
+	db := setupInvoiceTestDB(t)
+	subs := NewCustomerSubscriptionRepository(db)
+	globex := subs.WithContext(tenant.WithTenant(context.Background(), "globex"))
+
+	first := subscribeWithInvoice(t, subs, "cus_1", false)
+	draft := subscribeWithInvoice(t, subs, "cus_2", true)
+	second := subscribeWithInvoice(t, subs, "cus_3", false)
+	other := subscribeWithInvoice(t, globex, "cus_1", false)
+
+	assert.Equal(t, "INV-000001", first.Number)
+	assert.Empty(t, draft.Number, "drafts are numbered when finalized")
+	assert.Equal(t, "INV-000002", second.Number)
+	assert.Equal(t, "INV-000001", other.Number, "each tenant has its own sequence")
+
+	repo := NewInvoiceRepository(db)
+	stored, err := repo.GetByID(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.InvoiceOpen, stored.Status)
+	require.Len(t, stored.LineItems, 1)
+	assert.Equal(t, 10.0, stored.Total)
+
+	_, err = repo.GetByID(other.ID)
+	assert.Error(t, err, "invoices are scoped to their tenant")
+}
+
+func TestInvoiceRepository_UpdateStatus(t *testing.T) {
+	db := setupInvoiceTestDB(t)
+	subs := NewCustomerSubscriptionRepository(db)
+	repo := NewInvoiceRepository(db)
+
+	subscribeWithInvoice(t, subs, "cus_1", false)
+	draft := subscribeWithInvoice(t, subs, "cus_2", true)
+
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	finalized := *draft
+	finalized.Finalize(now)
+	ok, err := repo.UpdateStatus(&finalized, models.InvoiceDraft)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "INV-000002", finalized.Number, "finalizing takes the next number")
+	assert.Equal(t, models.InvoiceOpen, finalized.Status)
+
+	// A second request still holding the draft loses the race.
+	voided := *draft
+	voided.Void(now)
+	ok, err = repo.UpdateStatus(&voided, models.InvoiceDraft)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	stored, err := repo.GetByID(draft.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.InvoiceOpen, stored.Status)
+	assert.Nil(t, stored.VoidedAt)
+
+	var audits int64
+	db.Model(&models.AuditEvent{}).Where("resource_type = ? AND resource_id = ?", models.AuditResourceInvoice, draft.ID).Count(&audits)
+	assert.Equal(t, int64(2), audits)
+}
+
+func TestInvoiceRepository_List(t *testing.T) {
+	db := setupInvoiceTestDB(t)
+	subs := NewCustomerSubscriptionRepository(db)
+	repo := NewInvoiceRepository(db)
+
+	first := subscribeWithInvoice(t, subs, "cus_1", false)
+	subscribeWithInvoice(t, subs, "cus_1", true)
+	subscribeWithInvoice(t, subs, "cus_2", false)
+
+	invoices, total, err := repo.List("cus_1", uuid.Nil, "", 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, invoices, 2)
+
+	_, total, err = repo.List("", uuid.Nil, models.InvoiceDraft, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	invoices, total, err = repo.List("", first.SubscriptionID, "", 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, first.ID, invoices[0].ID)
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/events"
+	"github.com/microservice-go/product-service/internal/models"
+	"gorm.io/gorm"
+)
+
+type OutboxRepository interface {
+	Claim(now, until time.Time, limit int) ([]models.OutboxEvent, error)
+	MarkDelivered(id uint64, at time.Time) error
+	MarkRetry(id uint64, attempts int, nextAttemptAt time.Time, lastError string) error
+	MarkFailed(id uint64, attempts int, at time.Time, lastError string) error
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// Claim leases up to limit undelivered events that are due by now until
+// until, and returns them in the order they were recorded. An event is left
+// out while an earlier event for the same aggregate is waiting out a retry
+// backoff or is leased to another relay, so each aggregate's events are
+// delivered in order even with a relay in every replica. The lease is taken
+// with a conditional update; when another relay takes an event first, the
+// rest of its aggregate is left to that relay.
+func (r *outboxRepository) Claim(now, until time.Time, limit int) ([]models.OutboxEvent, error) {
+	var rows []models.OutboxEvent
+	err := r.db.Where("delivered_at IS NULL AND failed_at IS NULL AND next_attempt_at <= ?", now).
+		Where(`NOT EXISTS (SELECT 1 FROM outbox earlier WHERE earlier.aggregate_id = outbox.aggregate_id
+			AND earlier.id < outbox.id AND earlier.delivered_at IS NULL AND earlier.failed_at IS NULL
+			AND earlier.next_attempt_at > ?)`, now).
+		Order("id").
+		Limit(limit).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	claimed := rows[:0]
+	taken := make(map[uuid.UUID]bool)
+	for _, row := range rows {
+		if taken[row.AggregateID] {
+			continue
+		}
+		result := r.db.Model(&models.OutboxEvent{}).
+			Where("id = ? AND delivered_at IS NULL AND failed_at IS NULL AND next_attempt_at <= ?", row.ID, now).
+			Update("next_attempt_at", until)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			taken[row.AggregateID] = true
+			continue
+		}
+		claimed = append(claimed, row)
+	}
+	return claimed, nil
+}
+
+func (r *outboxRepository) MarkDelivered(id uint64, at time.Time) error {
+	return r.db.Model(&models.OutboxEvent{}).Where("id = ?", id).
+		Update("delivered_at", at).Error
+}
+
+func (r *outboxRepository) MarkRetry(id uint64, attempts int, nextAttemptAt time.Time, lastError string) error {
+	return r.db.Model(&models.OutboxEvent{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastError,
+		}).Error
+}
+
+func (r *outboxRepository) MarkFailed(id uint64, attempts int, at time.Time, lastError string) error {
+	return r.db.Model(&models.OutboxEvent{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":   attempts,
+			"failed_at":  at,
+			"last_error": lastError,
+		}).Error
+}
+
+// appendOutbox records event on tx so it commits or rolls back together with
+// the mutation that produced it.
+func appendOutbox(tx *gorm.DB, event events.Event) error {
+	row, err := events.ToOutbox(event)
+	if err != nil {
+		return err
+	}
+	return tx.Create(row).Error
+}
@@ -0,0 +1,121 @@
+//go:build cgo
+// +build cgo
+
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/microservice-go/product-service/internal/events"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxRepository_MutationsRecordEvents(t *testing.T) {
+	db := setupTestDB(t)
+	productRepo := NewProductRepository(db)
+	outboxRepo := NewOutboxRepository(db)
+
+	product := &models.Product{Name: "Test Product", Price: 10, ProductType: "digital"}
+	assert.NoError(t, productRepo.Create(product))
+	product.Price = 20
+	assert.NoError(t, productRepo.Update(product))
+	assert.NoError(t, productRepo.Delete(product.ID))
+
+	now := time.Now()
+	rows, err := outboxRepo.Claim(now, now.Add(time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, string(events.TypeCreated), rows[0].EventType)
+	assert.Equal(t, string(events.TypeUpdated), rows[1].EventType)
+	assert.Equal(t, string(events.TypeDeleted), rows[2].EventType)
+	assert.True(t, rows[0].ID < rows[1].ID && rows[1].ID < rows[2].ID)
+
+	updated, err := events.FromOutbox(rows[1])
+	assert.NoError(t, err)
+	assert.Equal(t, product.ID, updated.AggregateID)
+	assert.Equal(t, 20.0, updated.Product.Price)
+}
+
+func TestOutboxRepository_RolledBackMutationLeavesNoEvent(t *testing.T) {
+	db := setupTestDB(t)
+	productRepo := NewProductRepository(db)
+	outboxRepo := NewOutboxRepository(db)
+
+	err := productRepo.Transaction(func(tx ProductRepository) error {
+		if err := tx.Create(&models.Product{Name: "Rolled Back", Price: 10, ProductType: "digital"}); err != nil {
+			return err
+		}
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+
+	now := time.Now()
+	rows, err := outboxRepo.Claim(now, now.Add(time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Empty(t, rows)
+}
+
+func TestOutboxRepository_MarkDeliveredAndFailed(t *testing.T) {
+	db := setupTestDB(t)
+	productRepo := NewProductRepository(db)
+	outboxRepo := NewOutboxRepository(db)
+
+	assert.NoError(t, productRepo.Create(&models.Product{Name: "First", Price: 10, ProductType: "digital"}))
+	assert.NoError(t, productRepo.Create(&models.Product{Name: "Second", Price: 10, ProductType: "digital"}))
+	assert.NoError(t, productRepo.Create(&models.Product{Name: "Third", Price: 10, ProductType: "digital"}))
+
+	now := time.Now().UTC()
+	rows, err := outboxRepo.Claim(now, now.Add(time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+
+	assert.NoError(t, outboxRepo.MarkDelivered(rows[0].ID, now))
+	assert.NoError(t, outboxRepo.MarkFailed(rows[1].ID, 5, now, "gave up"))
+	assert.NoError(t, outboxRepo.MarkRetry(rows[2].ID, 1, now.Add(time.Minute), "timeout"))
+
+	pending, err := outboxRepo.Claim(now, now.Add(time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Empty(t, pending, "the retry is still backing off")
+
+	later := now.Add(time.Minute)
+	pending, err = outboxRepo.Claim(later, later.Add(time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, rows[2].ID, pending[0].ID)
+	assert.Equal(t, 1, pending[0].Attempts)
+	assert.Equal(t, "timeout", pending[0].LastError)
+}
+
+func TestOutboxRepository_ClaimLeasesEventsInAggregateOrder(t *testing.T) {
+	db := setupTestDB(t)
+	productRepo := NewProductRepository(db)
+	outboxRepo := NewOutboxRepository(db)
+
+	first := &models.Product{Name: "First", Price: 10, ProductType: "digital"}
+	assert.NoError(t, productRepo.Create(first))
+	first.Price = 20
+	assert.NoError(t, productRepo.Update(first))
+	assert.NoError(t, productRepo.Create(&models.Product{Name: "Second", Price: 10, ProductType: "digital"}))
+
+	now := time.Now().UTC()
+	claimed, err := outboxRepo.Claim(now, now.Add(time.Minute), 1)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 1)
+	assert.Equal(t, first.ID, claimed[0].AggregateID)
+
+	// Another relay skips the leased event and the update queued behind it.
+	others, err := outboxRepo.Claim(now, now.Add(time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Len(t, others, 1)
+	assert.NotEqual(t, first.ID, others[0].AggregateID)
+
+	// Once the lease runs out the first product's events are claimed again.
+	later := now.Add(time.Minute)
+	claimed, err = outboxRepo.Claim(later, later.Add(time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 3)
+	assert.Equal(t, first.ID, claimed[0].AggregateID)
+	assert.Equal(t, string(events.TypeUpdated), claimed[1].EventType)
+}
@@ -1,9 +1,12 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/events"
 	"github.com/microservice-go/product-service/internal/models"
 	"gorm.io/gorm"
 )
@@ -14,6 +17,11 @@ type ProductRepository interface {
 	Update(product *models.Product) error
 	Delete(id uuid.UUID) error
 	List(productType string, page, pageSize int) ([]models.Product, int64, error)
+	GetByIDs(ids []uuid.UUID) ([]models.Product, error)
+	GetByIDAsOf(id uuid.UUID, asOf time.Time) (*models.Product, error)
+	ListAsOf(productType string, page, pageSize int, asOf time.Time) ([]models.Product, int64, error)
+	Transaction(fn func(repo ProductRepository) error) error
+	WithContext(ctx context.Context) ProductRepository
 }
 
 type productRepository struct {
@@ -25,12 +33,24 @@ func NewProductRepository(db *gorm.DB) ProductRepository {
 }
 
 func (r *productRepository) Create(product *models.Product) error {
-	return r.db.Create(product).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		product.TenantID = tenantOf(tx)
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		if err := recordProductVersion(tx, product); err != nil {
+			return err
+		}
+		if err := appendAudit(tx, models.AuditActionCreate, models.AuditResourceProduct, product.ID, nil, product, "subscription_plans"); err != nil {
+			return err
+		}
+		return appendOutbox(tx, events.NewProductEvent(events.TypeCreated, product))
+	})
 }
 
 func (r *productRepository) GetByID(id uuid.UUID) (*models.Product, error) {
 	var product models.Product
-	err := r.db.Preload("SubscriptionPlans").First(&product, "id = ?", id).Error
+	err := r.db.Scopes(forTenant).Preload("SubscriptionPlans").First(&product, "id = ?", id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("product not found")
@@ -41,32 +61,78 @@ func (r *productRepository) GetByID(id uuid.UUID) (*models.Product, error) {
 }
 
 func (r *productRepository) Update(product *models.Product) error {
-	result := r.db.Model(&models.Product{}).Where("id = ?", product.ID).Updates(product)
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return errors.New("product not found")
-	}
-	return nil
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Product
+		if err := tx.Scopes(forTenant).First(&before, "id = ?", product.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("product not found")
+			}
+			return err
+		}
+
+		result := tx.Model(&models.Product{}).Scopes(forTenant).Where("id = ?", product.ID).
+			Omit("TenantID").
+			Updates(product)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("product not found")
+		}
+		// Updates skips false, so the tax flag is written separately to allow
+		// clearing it.
+		if err := tx.Model(&models.Product{}).Where("id = ?", product.ID).
+			Select("tax_inclusive").
+			Updates(product).Error; err != nil {
+			return err
+		}
+
+		var updated models.Product
+		if err := tx.First(&updated, "id = ?", product.ID).Error; err != nil {
+			return err
+		}
+		if err := recordProductVersion(tx, &updated); err != nil {
+			return err
+		}
+		if err := appendAudit(tx, models.AuditActionUpdate, models.AuditResourceProduct, product.ID, &before, &updated, "subscription_plans"); err != nil {
+			return err
+		}
+		return appendOutbox(tx, events.NewProductEvent(events.TypeUpdated, &updated))
+	})
 }
 
 func (r *productRepository) Delete(id uuid.UUID) error {
-	result := r.db.Delete(&models.Product{}, "id = ?", id)
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return errors.New("product not found")
-	}
-	return nil
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.Scopes(forTenant).First(&product, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("product not found")
+			}
+			return err
+		}
+
+		result := tx.Scopes(forTenant).Delete(&models.Product{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("product not found")
+		}
+		if err := closeProductVersion(tx, id, time.Now()); err != nil {
+			return err
+		}
+		if err := appendAudit(tx, models.AuditActionDelete, models.AuditResourceProduct, id, &product, nil, "subscription_plans"); err != nil {
+			return err
+		}
+		return appendOutbox(tx, events.NewProductEvent(events.TypeDeleted, &product))
+	})
 }
 
 func (r *productRepository) List(productType string, page, pageSize int) ([]models.Product, int64, error) {
 	var products []models.Product
 	var total int64
 
-	query := r.db.Model(&models.Product{})
+	query := r.db.Model(&models.Product{}).Scopes(forTenant)
 
 	if productType != "" {
 		query = query.Where("product_type = ?", productType)
@@ -87,3 +153,75 @@ func (r *productRepository) List(productType string, page, pageSize int) ([]mode
 
 	return products, total, nil
 }
+
+func (r *productRepository) GetByIDs(ids []uuid.UUID) ([]models.Product, error) {
+	var products []models.Product
+	if len(ids) == 0 {
+		return products, nil
+	}
+	err := r.db.Scopes(forTenant).Preload("SubscriptionPlans").Where("id IN ?", ids).Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// GetByIDAsOf returns the product as it was at asOf, including products that
+// have since been deleted.
+func (r *productRepository) GetByIDAsOf(id uuid.UUID, asOf time.Time) (*models.Product, error) {
+	var version models.ProductVersion
+	err := r.db.Scopes(forTenant, validAt(asOf)).First(&version, "product_id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("product not found")
+		}
+		return nil, err
+	}
+	product := version.Product()
+	return &product, nil
+}
+
+func (r *productRepository) ListAsOf(productType string, page, pageSize int, asOf time.Time) ([]models.Product, int64, error) {
+	var versions []models.ProductVersion
+	var total int64
+
+	query := r.db.Model(&models.ProductVersion{}).Scopes(forTenant, validAt(asOf))
+
+	if productType != "" {
+		query = query.Where("product_type = ?", productType)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if page > 0 && pageSize > 0 {
+		offset := (page - 1) * pageSize
+		query = query.Offset(offset).Limit(pageSize)
+	}
+
+	if err := query.Order("product_created_at, product_id").Find(&versions).Error; err != nil {
+		return nil, 0, err
+	}
+
+	products := make([]models.Product, len(versions))
+	for i := range versions {
+		products[i] = versions[i].Product()
+	}
+	return products, total, nil
+}
+
+// Transaction runs fn against a repository bound to a single database
+// transaction. Nested calls use savepoints, so a failing inner call only
+// rolls back its own writes.
+func (r *productRepository) Transaction(fn func(repo ProductRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&productRepository{db: tx})
+	})
+}
+
+// WithContext returns a repository whose queries, and the audit events they
+// record, carry ctx.
+func (r *productRepository) WithContext(ctx context.Context) ProductRepository {
+	return &productRepository{db: r.db.WithContext(ctx)}
+}
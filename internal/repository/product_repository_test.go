@@ -5,6 +5,7 @@ package repository
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/microservice-go/product-service/internal/models"
@@ -20,7 +21,8 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		t.Fatalf("Failed to connect to test database: %v", err)
 	}
 
-	err = db.AutoMigrate(&models.Product{}, &models.SubscriptionPlan{})
+	err = db.AutoMigrate(&models.Product{}, &models.SubscriptionPlan{}, &models.OutboxEvent{}, &models.AuditEvent{},
+		&models.ProductVersion{}, &models.SubscriptionPlanVersion{})
 	if err != nil {
 		t.Fatalf("Failed to migrate test database: %v", err)
 	}
@@ -28,6 +30,10 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	// Clean up test data before each test
 	db.Exec("DELETE FROM subscription_plans")
 	db.Exec("DELETE FROM products")
+	db.Exec("DELETE FROM outbox")
+	db.Exec("DELETE FROM audit_events")
+	db.Exec("DELETE FROM product_versions")
+	db.Exec("DELETE FROM subscription_plan_versions")
 
 	return db
 }
@@ -108,6 +114,21 @@ func TestProductRepository_Update(t *testing.T) {
 	assert.Equal(t, 149.99, updated.Price)
 }
 
+func TestProductRepository_Update_TaxInclusive(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewProductRepository(db)
+
+	product := &models.Product{Name: "Ebook", Price: 9.99, ProductType: "digital", TaxInclusive: true}
+	assert.NoError(t, repo.Create(product))
+
+	product.TaxInclusive = false
+	assert.NoError(t, repo.Update(product))
+
+	updated, err := repo.GetByID(product.ID)
+	assert.NoError(t, err)
+	assert.False(t, updated.TaxInclusive, "the flag can be cleared")
+}
+
 func TestProductRepository_Delete(t *testing.T) {
 	db := setupTestDB(t)
 	repo := NewProductRepository(db)
@@ -180,3 +201,96 @@ func TestProductRepository_List_Pagination(t *testing.T) {
 	assert.Equal(t, int64(5), total)
 }
 
+
+func TestProductRepository_GetByIDs(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewProductRepository(db)
+
+	first := &models.Product{Name: "First", Price: 10, ProductType: "digital"}
+	second := &models.Product{Name: "Second", Price: 20, ProductType: "physical"}
+	assert.NoError(t, repo.Create(first))
+	assert.NoError(t, repo.Create(second))
+
+	products, err := repo.GetByIDs([]uuid.UUID{first.ID, second.ID, uuid.New()})
+
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+}
+
+func TestProductRepository_TransactionRollback(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewProductRepository(db)
+
+	err := repo.Transaction(func(tx ProductRepository) error {
+		if err := tx.Create(&models.Product{Name: "Kept", Price: 10, ProductType: "digital"}); err != nil {
+			return err
+		}
+		_ = tx.Transaction(func(item ProductRepository) error {
+			if err := item.Create(&models.Product{Name: "Discarded", Price: 10, ProductType: "digital"}); err != nil {
+				return err
+			}
+			return assert.AnError
+		})
+		return nil
+	})
+	assert.NoError(t, err)
+
+	products, total, err := repo.List("", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "Kept", products[0].Name)
+
+	err = repo.Transaction(func(tx ProductRepository) error {
+		if err := tx.Create(&models.Product{Name: "Rolled Back", Price: 10, ProductType: "digital"}); err != nil {
+			return err
+		}
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+
+	_, total, err = repo.List("", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+}
+
+func TestProductRepository_AsOf(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewProductRepository(db)
+
+	product := &models.Product{Name: "Widget", Price: 10, ProductType: "digital"}
+	assert.NoError(t, repo.Create(product))
+	other := &models.Product{Name: "Gadget", Price: 20, ProductType: "physical"}
+	assert.NoError(t, repo.Create(other))
+	time.Sleep(10 * time.Millisecond)
+	beforeUpdate := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	assert.NoError(t, repo.Update(&models.Product{ID: product.ID, Name: "Widget Pro", Price: 15}))
+	assert.NoError(t, repo.Delete(other.ID))
+
+	old, err := repo.GetByIDAsOf(product.ID, beforeUpdate)
+	assert.NoError(t, err)
+	assert.Equal(t, "Widget", old.Name)
+	assert.Equal(t, 10.0, old.Price)
+
+	current, err := repo.GetByIDAsOf(product.ID, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "Widget Pro", current.Name)
+	assert.Equal(t, 15.0, current.Price)
+
+	_, err = repo.GetByIDAsOf(other.ID, time.Now())
+	assert.EqualError(t, err, "product not found")
+
+	_, err = repo.GetByIDAsOf(product.ID, product.CreatedAt.Add(-time.Second))
+	assert.EqualError(t, err, "product not found")
+
+	products, total, err := repo.ListAsOf("", 1, 10, beforeUpdate)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, "Widget", products[0].Name)
+
+	products, total, err = repo.ListAsOf("physical", 1, 10, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	assert.Empty(t, products)
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/models"
+	"gorm.io/gorm"
+)
+
+type ScheduledChangeRepository interface {
+	Create(change *models.ScheduledChange) error
+	GetByID(id uuid.UUID) (*models.ScheduledChange, error)
+	List(resourceID uuid.UUID, status string, page, pageSize int) ([]models.ScheduledChange, int64, error)
+	ListDue(now time.Time, limit int) ([]models.ScheduledChange, error)
+	Cancel(id uuid.UUID) (bool, error)
+	Apply(id uuid.UUID, at time.Time, fn func(products ProductRepository, plans SubscriptionRepository) error) (bool, error)
+	MarkFailed(id uuid.UUID, lastError string) error
+	WithContext(ctx context.Context) ScheduledChangeRepository
+}
+
+type scheduledChangeRepository struct {
+	db *gorm.DB
+}
+
+func NewScheduledChangeRepository(db *gorm.DB) ScheduledChangeRepository {
+	return &scheduledChangeRepository{db: db}
+}
+
+func (r *scheduledChangeRepository) Create(change *models.ScheduledChange) error {
+	change.TenantID = tenantOf(r.db)
+	return r.db.Create(change).Error
+}
+
+func (r *scheduledChangeRepository) GetByID(id uuid.UUID) (*models.ScheduledChange, error) {
+	var change models.ScheduledChange
+	err := r.db.Scopes(forTenant).First(&change, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("scheduled change not found")
+		}
+		return nil, err
+	}
+	return &change, nil
+}
+
+// List returns scheduled changes ordered by effective time. A nil resourceID
+// or empty status matches everything.
+func (r *scheduledChangeRepository) List(resourceID uuid.UUID, status string, page, pageSize int) ([]models.ScheduledChange, int64, error) {
+	var changes []models.ScheduledChange
+	var total int64
+
+	query := r.db.Model(&models.ScheduledChange{}).Scopes(forTenant)
+	if resourceID != uuid.Nil {
+		query = query.Where("resource_id = ?", resourceID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if page > 0 && pageSize > 0 {
+		query = query.Offset((page - 1) * pageSize).Limit(pageSize)
+	}
+
+	if err := query.Order("effective_at, created_at").Find(&changes).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return changes, total, nil
+}
+
+func (r *scheduledChangeRepository) ListDue(now time.Time, limit int) ([]models.ScheduledChange, error) {
+	var changes []models.ScheduledChange
+	err := r.db.Where("status = ? AND effective_at <= ?", models.ScheduledChangePending, now).
+		Order("effective_at, created_at").
+		Limit(limit).
+		Find(&changes).Error
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// Cancel moves a pending change to cancelled and reports whether it was still
+// pending.
+func (r *scheduledChangeRepository) Cancel(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.ScheduledChange{}).Scopes(forTenant).
+		Where("id = ? AND status = ?", id, models.ScheduledChangePending).
+		Update("status", models.ScheduledChangeCancelled)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Apply claims a pending change and runs fn in the same transaction, so the
+// change is applied exactly once even when several replicas race for it: the
+// conditional status update only succeeds for one of them, and if fn fails the
+// claim rolls back with it. It reports false when the change was no longer
+// pending.
+func (r *scheduledChangeRepository) Apply(id uuid.UUID, at time.Time, fn func(products ProductRepository, plans SubscriptionRepository) error) (bool, error) {
+	applied := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.ScheduledChange{}).
+			Where("id = ? AND status = ?", id, models.ScheduledChangePending).
+			Updates(map[string]interface{}{
+				"status":     models.ScheduledChangeApplied,
+				"applied_at": at,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := fn(&productRepository{db: tx}, &subscriptionRepository{db: tx}); err != nil {
+			return err
+		}
+		applied = true
+		return nil
+	})
+	return applied, err
+}
+
+func (r *scheduledChangeRepository) MarkFailed(id uuid.UUID, lastError string) error {
+	return r.db.Model(&models.ScheduledChange{}).
+		Where("id = ? AND status = ?", id, models.ScheduledChangePending).
+		Updates(map[string]interface{}{
+			"status":     models.ScheduledChangeFailed,
+			"last_error": lastError,
+		}).Error
+}
+
+func (r *scheduledChangeRepository) WithContext(ctx context.Context) ScheduledChangeRepository {
+	return &scheduledChangeRepository{db: r.db.WithContext(ctx)}
+}
@@ -0,0 +1,188 @@
+//go:build cgo
+// +build cgo
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupScheduledChangeTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("test_scheduled_change.db"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.Product{}, &models.SubscriptionPlan{}, &models.OutboxEvent{}, &models.AuditEvent{},
+		&models.ProductVersion{}, &models.SubscriptionPlanVersion{}, &models.ScheduledChange{})
+	if err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	db.Exec("DELETE FROM scheduled_changes")
+	db.Exec("DELETE FROM subscription_plans")
+	db.Exec("DELETE FROM products")
+	db.Exec("DELETE FROM outbox")
+	db.Exec("DELETE FROM audit_events")
+	db.Exec("DELETE FROM product_versions")
+	db.Exec("DELETE FROM subscription_plan_versions")
+
+	return db
+}
+
+func TestScheduledChangeRepository_ApplyOnce(t *testing.T) {
+	db := setupScheduledChangeTestDB(t)
+	repo := NewScheduledChangeRepository(db)
+	productRepo := NewProductRepository(db)
+
+	product := &models.Product{Name: "Basic", Price: 10, ProductType: "digital"}
+	assert.NoError(t, productRepo.Create(product))
+
+	now := time.Now()
+	change := &models.ScheduledChange{
+		ResourceType: models.AuditResourceProduct,
+		ResourceID:   product.ID,
+		Changes:      `{"price":20}`,
+		EffectiveAt:  now.Add(-time.Minute),
+		Status:       models.ScheduledChangePending,
+	}
+	assert.NoError(t, repo.Create(change))
+
+	due, err := repo.ListDue(now, 10)
+	assert.NoError(t, err)
+	assert.Len(t, due, 1)
+
+	update := func(products ProductRepository, plans SubscriptionRepository) error {
+		return products.Update(&models.Product{ID: product.ID, Name: "Basic", Price: 20, ProductType: "digital"})
+	}
+
+	applied, err := repo.Apply(change.ID, now, update)
+	assert.NoError(t, err)
+	assert.True(t, applied)
+
+	applied, err = repo.Apply(change.ID, now, func(ProductRepository, SubscriptionRepository) error {
+		t.Fatal("change applied twice")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.False(t, applied)
+
+	stored, err := repo.GetByID(change.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ScheduledChangeApplied, stored.Status)
+	assert.NotNil(t, stored.AppliedAt)
+
+	updated, err := productRepo.GetByID(product.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 20.0, updated.Price)
+
+	due, err = repo.ListDue(now, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, due)
+}
+
+func TestScheduledChangeRepository_ApplyRollsBackOnError(t *testing.T) {
+	db := setupScheduledChangeTestDB(t)
+	repo := NewScheduledChangeRepository(db)
+
+	change := &models.ScheduledChange{
+		ResourceType: models.AuditResourceProduct,
+		Changes:      `{"price":20}`,
+		EffectiveAt:  time.Now(),
+		Status:       models.ScheduledChangePending,
+	}
+	assert.NoError(t, repo.Create(change))
+
+	applied, err := repo.Apply(change.ID, time.Now(), func(ProductRepository, SubscriptionRepository) error {
+		return errors.New("boom")
+	})
+	assert.EqualError(t, err, "boom")
+	assert.False(t, applied)
+
+	stored, err := repo.GetByID(change.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ScheduledChangePending, stored.Status)
+	assert.Nil(t, stored.AppliedAt)
+
+	assert.NoError(t, repo.MarkFailed(change.ID, "product not found"))
+	stored, err = repo.GetByID(change.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ScheduledChangeFailed, stored.Status)
+	assert.Equal(t, "product not found", stored.LastError)
+}
+
+func TestScheduledChangeRepository_CancelAndList(t *testing.T) {
+	db := setupScheduledChangeTestDB(t)
+	repo := NewScheduledChangeRepository(db)
+
+	change := &models.ScheduledChange{
+		ResourceType: models.AuditResourceProduct,
+		Changes:      `{"price":20}`,
+		EffectiveAt:  time.Now().Add(-time.Minute),
+		Status:       models.ScheduledChangePending,
+	}
+	assert.NoError(t, repo.Create(change))
+
+	cancelled, err := repo.Cancel(change.ID)
+	assert.NoError(t, err)
+	assert.True(t, cancelled)
+
+	cancelled, err = repo.Cancel(change.ID)
+	assert.NoError(t, err)
+	assert.False(t, cancelled)
+
+	applied, err := repo.Apply(change.ID, time.Now(), func(ProductRepository, SubscriptionRepository) error {
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.False(t, applied)
+
+	changes, total, err := repo.List(change.ResourceID, models.ScheduledChangeCancelled, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, changes, 1)
+
+	_, total, err = repo.List(change.ResourceID, models.ScheduledChangePending, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+}
+
+func TestScheduledChangeRepository_TenantScoped(t *testing.T) {
+	db := setupScheduledChangeTestDB(t)
+	acme := NewScheduledChangeRepository(db).WithContext(tenant.WithTenant(context.Background(), "acme"))
+	globex := NewScheduledChangeRepository(db).WithContext(tenant.WithTenant(context.Background(), "globex"))
+
+	change := &models.ScheduledChange{
+		ResourceType: models.AuditResourceProduct,
+		Changes:      `{"price":20}`,
+		EffectiveAt:  time.Now().Add(-time.Minute),
+		Status:       models.ScheduledChangePending,
+	}
+	assert.NoError(t, acme.Create(change))
+
+	_, err := globex.GetByID(change.ID)
+	assert.EqualError(t, err, "scheduled change not found")
+
+	cancelled, err := globex.Cancel(change.ID)
+	assert.NoError(t, err)
+	assert.False(t, cancelled)
+
+	_, total, err := globex.List(uuid.Nil, "", 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+
+	// The scheduler applies due changes of every tenant.
+	due, err := globex.ListDue(time.Now(), 10)
+	assert.NoError(t, err)
+	assert.Len(t, due, 1)
+}
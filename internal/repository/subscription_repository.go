@@ -1,9 +1,12 @@
 package repository
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/events"
 	"github.com/microservice-go/product-service/internal/models"
 	"gorm.io/gorm"
 )
@@ -14,6 +17,11 @@ type SubscriptionRepository interface {
 	Update(plan *models.SubscriptionPlan) error
 	Delete(id uuid.UUID) error
 	ListByProductID(productID uuid.UUID) ([]models.SubscriptionPlan, error)
+	GetByIDs(ids []uuid.UUID) ([]models.SubscriptionPlan, error)
+	GetByIDAsOf(id uuid.UUID, asOf time.Time) (*models.SubscriptionPlan, error)
+	ListByProductIDAsOf(productID uuid.UUID, asOf time.Time) ([]models.SubscriptionPlan, error)
+	Transaction(fn func(repo SubscriptionRepository) error) error
+	WithContext(ctx context.Context) SubscriptionRepository
 }
 
 type subscriptionRepository struct {
@@ -25,12 +33,29 @@ func NewSubscriptionRepository(db *gorm.DB) SubscriptionRepository {
 }
 
 func (r *subscriptionRepository) Create(plan *models.SubscriptionPlan) error {
-	return r.db.Create(plan).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		plan.TenantID = tenantOf(tx)
+		if err := tx.Create(plan).Error; err != nil {
+			return err
+		}
+
+		var created models.SubscriptionPlan
+		if err := tx.Preload("Product").First(&created, "id = ?", plan.ID).Error; err != nil {
+			return err
+		}
+		if err := recordPlanVersion(tx, &created); err != nil {
+			return err
+		}
+		if err := appendAudit(tx, models.AuditActionCreate, models.AuditResourceSubscriptionPlan, plan.ID, nil, &created, "product"); err != nil {
+			return err
+		}
+		return appendOutbox(tx, events.NewPlanEvent(events.TypeCreated, &created))
+	})
 }
 
 func (r *subscriptionRepository) GetByID(id uuid.UUID) (*models.SubscriptionPlan, error) {
 	var plan models.SubscriptionPlan
-	err := r.db.Preload("Product").First(&plan, "id = ?", id).Error
+	err := r.db.Scopes(forTenant).Preload("Product").First(&plan, "id = ?", id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("subscription plan not found")
@@ -41,32 +66,137 @@ func (r *subscriptionRepository) GetByID(id uuid.UUID) (*models.SubscriptionPlan
 }
 
 func (r *subscriptionRepository) Update(plan *models.SubscriptionPlan) error {
-	result := r.db.Model(&models.SubscriptionPlan{}).Where("id = ?", plan.ID).Updates(plan)
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return errors.New("subscription plan not found")
-	}
-	return nil
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var before models.SubscriptionPlan
+		if err := tx.Scopes(forTenant).First(&before, "id = ?", plan.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("subscription plan not found")
+			}
+			return err
+		}
+
+		result := tx.Model(&models.SubscriptionPlan{}).Scopes(forTenant).Where("id = ?", plan.ID).
+			Omit("TenantID").
+			Updates(plan)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("subscription plan not found")
+		}
+		// Updates skips zero values, so the trial, introductory phases, tiers,
+		// meter and price (zero on tiered plans) are written separately to
+		// allow removing them.
+		if err := tx.Model(&models.SubscriptionPlan{}).Where("id = ?", plan.ID).
+			Select("price", "trial_days", "intro_phases", "tiers", "meter").
+			Updates(plan).Error; err != nil {
+			return err
+		}
+
+		var updated models.SubscriptionPlan
+		if err := tx.Preload("Product").First(&updated, "id = ?", plan.ID).Error; err != nil {
+			return err
+		}
+		if err := recordPlanVersion(tx, &updated); err != nil {
+			return err
+		}
+		if err := appendAudit(tx, models.AuditActionUpdate, models.AuditResourceSubscriptionPlan, plan.ID, &before, &updated, "product"); err != nil {
+			return err
+		}
+		return appendOutbox(tx, events.NewPlanEvent(events.TypeUpdated, &updated))
+	})
 }
 
 func (r *subscriptionRepository) Delete(id uuid.UUID) error {
-	result := r.db.Delete(&models.SubscriptionPlan{}, "id = ?", id)
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return errors.New("subscription plan not found")
-	}
-	return nil
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var plan models.SubscriptionPlan
+		if err := tx.Scopes(forTenant).Preload("Product").First(&plan, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("subscription plan not found")
+			}
+			return err
+		}
+
+		result := tx.Scopes(forTenant).Delete(&models.SubscriptionPlan{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("subscription plan not found")
+		}
+		if err := closePlanVersion(tx, id, time.Now()); err != nil {
+			return err
+		}
+		if err := appendAudit(tx, models.AuditActionDelete, models.AuditResourceSubscriptionPlan, id, &plan, nil, "product"); err != nil {
+			return err
+		}
+		return appendOutbox(tx, events.NewPlanEvent(events.TypeDeleted, &plan))
+	})
 }
 
 func (r *subscriptionRepository) ListByProductID(productID uuid.UUID) ([]models.SubscriptionPlan, error) {
 	var plans []models.SubscriptionPlan
-	err := r.db.Where("product_id = ?", productID).Find(&plans).Error
+	err := r.db.Scopes(forTenant).Where("product_id = ?", productID).Find(&plans).Error
 	if err != nil {
 		return nil, err
 	}
 	return plans, nil
 }
+
+func (r *subscriptionRepository) GetByIDs(ids []uuid.UUID) ([]models.SubscriptionPlan, error) {
+	var plans []models.SubscriptionPlan
+	if len(ids) == 0 {
+		return plans, nil
+	}
+	err := r.db.Scopes(forTenant).Preload("Product").Where("id IN ?", ids).Find(&plans).Error
+	if err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+// GetByIDAsOf returns the plan as it was at asOf, including plans that have
+// since been deleted. The Product association is not loaded.
+func (r *subscriptionRepository) GetByIDAsOf(id uuid.UUID, asOf time.Time) (*models.SubscriptionPlan, error) {
+	var version models.SubscriptionPlanVersion
+	err := r.db.Scopes(forTenant, validAt(asOf)).First(&version, "plan_id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("subscription plan not found")
+		}
+		return nil, err
+	}
+	plan := version.Plan()
+	return &plan, nil
+}
+
+func (r *subscriptionRepository) ListByProductIDAsOf(productID uuid.UUID, asOf time.Time) ([]models.SubscriptionPlan, error) {
+	var versions []models.SubscriptionPlanVersion
+	err := r.db.Scopes(forTenant, validAt(asOf)).
+		Where("product_id = ?", productID).
+		Order("plan_created_at, plan_id").
+		Find(&versions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	plans := make([]models.SubscriptionPlan, len(versions))
+	for i := range versions {
+		plans[i] = versions[i].Plan()
+	}
+	return plans, nil
+}
+
+// Transaction runs fn against a repository bound to a single database
+// transaction. Nested calls use savepoints.
+func (r *subscriptionRepository) Transaction(fn func(repo SubscriptionRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&subscriptionRepository{db: tx})
+	})
+}
+
+// WithContext returns a repository whose queries, and the audit events they
+// record, carry ctx.
+func (r *subscriptionRepository) WithContext(ctx context.Context) SubscriptionRepository {
+	return &subscriptionRepository{db: r.db.WithContext(ctx)}
+}
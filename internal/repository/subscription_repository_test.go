@@ -5,6 +5,7 @@ package repository
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/microservice-go/product-service/internal/models"
@@ -20,7 +21,8 @@ func setupSubscriptionTestDB(t *testing.T) *gorm.DB {
 		t.Fatalf("Failed to connect to test database: %v", err)
 	}
 
-	err = db.AutoMigrate(&models.Product{}, &models.SubscriptionPlan{})
+	err = db.AutoMigrate(&models.Product{}, &models.SubscriptionPlan{}, &models.OutboxEvent{}, &models.AuditEvent{},
+		&models.ProductVersion{}, &models.SubscriptionPlanVersion{})
 	if err != nil {
 		t.Fatalf("Failed to migrate test database: %v", err)
 	}
@@ -28,6 +30,10 @@ func setupSubscriptionTestDB(t *testing.T) *gorm.DB {
 	// Clean up test data before each test
 	db.Exec("DELETE FROM subscription_plans")
 	db.Exec("DELETE FROM products")
+	db.Exec("DELETE FROM outbox")
+	db.Exec("DELETE FROM audit_events")
+	db.Exec("DELETE FROM product_versions")
+	db.Exec("DELETE FROM subscription_plan_versions")
 
 	return db
 }
@@ -160,6 +166,71 @@ func TestSubscriptionRepository_Update_NotFound(t *testing.T) {
 	assert.Equal(t, "subscription plan not found", err.Error())
 }
 
+func TestSubscriptionRepository_Update_IntroPricing(t *testing.T) {
+	db := setupSubscriptionTestDB(t)
+	repo := NewSubscriptionRepository(db)
+
+	product := &models.Product{Name: "Test Product", Price: 99.99, ProductType: "digital"}
+	assert.NoError(t, db.Create(product).Error)
+
+	plan := &models.SubscriptionPlan{
+		ProductID:   product.ID,
+		PlanName:    "Annual Plan",
+		Duration:    365,
+		Price:       299.99,
+		TrialDays:   14,
+		IntroPhases: []models.IntroPhase{{Price: 149.99, Cycles: 1}},
+	}
+	assert.NoError(t, repo.Create(plan))
+
+	created, err := repo.GetByID(plan.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 14, created.TrialDays)
+	assert.Equal(t, []models.IntroPhase{{Price: 149.99, Cycles: 1}}, created.IntroPhases)
+
+	plan.TrialDays = 0
+	plan.IntroPhases = nil
+	assert.NoError(t, repo.Update(plan))
+
+	updated, err := repo.GetByID(plan.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, updated.TrialDays)
+	assert.Empty(t, updated.IntroPhases)
+}
+
+func TestSubscriptionRepository_Update_TieredPricing(t *testing.T) {
+	db := setupSubscriptionTestDB(t)
+	repo := NewSubscriptionRepository(db)
+
+	product := &models.Product{Name: "Test Product", Price: 99.99, ProductType: "digital"}
+	assert.NoError(t, db.Create(product).Error)
+
+	plan := &models.SubscriptionPlan{
+		ProductID:    product.ID,
+		PlanName:     "Seats",
+		Duration:     30,
+		Price:        12,
+		PricingModel: models.PricingPerUnit,
+	}
+	assert.NoError(t, repo.Create(plan))
+
+	tiers := []models.PriceTier{{UpTo: 10, UnitPrice: 12}, {UnitPrice: 9, FlatFee: 20}}
+	plan.Price = 0
+	plan.PricingModel = models.PricingVolume
+	plan.Tiers = tiers
+	assert.NoError(t, repo.Update(plan))
+
+	updated, err := repo.GetByID(plan.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.PricingVolume, updated.PricingModel)
+	assert.Equal(t, tiers, updated.Tiers)
+	assert.Zero(t, updated.Price, "tiered plans clear the unit price")
+
+	asOf, err := repo.GetByIDAsOf(plan.ID, updated.UpdatedAt)
+	assert.NoError(t, err)
+	assert.Equal(t, tiers, asOf.Tiers, "tiers are kept in the plan history")
+}
+
 func TestSubscriptionRepository_Delete(t *testing.T) {
 	db := setupSubscriptionTestDB(t)
 	repo := NewSubscriptionRepository(db)
@@ -336,3 +407,45 @@ func TestSubscriptionRepository_SoftDelete(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), count)
 }
+
+func TestSubscriptionRepository_PriceHistory(t *testing.T) {
+	db := setupSubscriptionTestDB(t)
+	repo := NewSubscriptionRepository(db)
+
+	product := &models.Product{Name: "Test Product", Price: 99.99, ProductType: "digital"}
+	assert.NoError(t, db.Create(product).Error)
+
+	plan := &models.SubscriptionPlan{ProductID: product.ID, PlanName: "Annual Plan", Duration: 365, Price: 299.99}
+	assert.NoError(t, repo.Create(plan))
+	time.Sleep(10 * time.Millisecond)
+	march := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	assert.NoError(t, repo.Update(&models.SubscriptionPlan{ID: plan.ID, Price: 349.99}))
+	time.Sleep(10 * time.Millisecond)
+	april := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	assert.NoError(t, repo.Delete(plan.ID))
+
+	inMarch, err := repo.GetByIDAsOf(plan.ID, march)
+	assert.NoError(t, err)
+	assert.Equal(t, 299.99, inMarch.Price)
+	assert.Equal(t, "Annual Plan", inMarch.PlanName)
+
+	inApril, err := repo.GetByIDAsOf(plan.ID, april)
+	assert.NoError(t, err)
+	assert.Equal(t, 349.99, inApril.Price)
+
+	_, err = repo.GetByIDAsOf(plan.ID, time.Now())
+	assert.EqualError(t, err, "subscription plan not found")
+
+	plans, err := repo.ListByProductIDAsOf(product.ID, april)
+	assert.NoError(t, err)
+	assert.Len(t, plans, 1)
+	assert.Equal(t, plan.ID, plans[0].ID)
+
+	plans, err = repo.ListByProductIDAsOf(product.ID, time.Now())
+	assert.NoError(t, err)
+	assert.Empty(t, plans)
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"github.com/microservice-go/product-service/internal/tenant"
+	"gorm.io/gorm"
+)
+
+// tenantOf returns the tenant the statement runs for, taken from the context
+// the repository was bound to with WithContext.
+func tenantOf(db *gorm.DB) string {
+	return tenant.FromContext(db.Statement.Context)
+}
+
+// forTenant restricts a query to the rows of the calling tenant. Every
+// tenant-owned read and write goes through it; only background workers that
+// serve all tenants, such as the scheduler and webhook deliverer, skip it.
+func forTenant(db *gorm.DB) *gorm.DB {
+	return db.Where("tenant_id = ?", tenantOf(db))
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UsageRepository interface {
+	Create(record *models.UsageRecord) (bool, error)
+	GetByEventID(eventID string) (*models.UsageRecord, error)
+	Aggregate(subscriptionID uuid.UUID, aggregation string, from, to time.Time) (int64, int64, error)
+	WithContext(ctx context.Context) UsageRepository
+}
+
+type usageRepository struct {
+	db *gorm.DB
+}
+
+func NewUsageRepository(db *gorm.DB) UsageRepository {
+	return &usageRepository{db: db}
+}
+
+// Create inserts record and reports whether it was new. A record whose event
+// ID the tenant already used is skipped, so concurrent retries of the same
+// event are only stored once.
+func (r *usageRepository) Create(record *models.UsageRecord) (bool, error) {
+	record.TenantID = tenantOf(r.db)
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *usageRepository) GetByEventID(eventID string) (*models.UsageRecord, error) {
+	var record models.UsageRecord
+	err := r.db.Scopes(forTenant).First(&record, "event_id = ?", eventID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("usage record not found")
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Aggregate combines the usage a subscription recorded in [from, to) and
+// returns the resulting quantity and the number of records it came from.
+func (r *usageRepository) Aggregate(subscriptionID uuid.UUID, aggregation string, from, to time.Time) (int64, int64, error) {
+	query := func() *gorm.DB {
+		return r.db.Model(&models.UsageRecord{}).Scopes(forTenant).
+			Where("subscription_id = ? AND timestamp >= ? AND timestamp < ?", subscriptionID, from, to)
+	}
+
+	var records int64
+	if err := query().Count(&records).Error; err != nil {
+		return 0, 0, err
+	}
+	if records == 0 {
+		return 0, 0, nil
+	}
+
+	var quantity int64
+	var err error
+	switch aggregation {
+	case models.AggregationMax:
+		err = query().Select("MAX(quantity)").Scan(&quantity).Error
+	case models.AggregationLast:
+		err = query().Select("quantity").Order("timestamp DESC, created_at DESC").Limit(1).Scan(&quantity).Error
+	default:
+		err = query().Select("SUM(quantity)").Scan(&quantity).Error
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	return quantity, records, nil
+}
+
+func (r *usageRepository) WithContext(ctx context.Context) UsageRepository {
+	return &usageRepository{db: r.db.WithContext(ctx)}
+}
@@ -0,0 +1,105 @@
+//go:build cgo
+// +build cgo
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupUsageTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("test_usage.db"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.UsageRecord{})
+	if err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	db.Exec("DELETE FROM usage_records")
+
+	return db
+}
+
+func TestUsageRepository_CreateIsIdempotent(t *testing.T) {
+	db := setupUsageTestDB(t)
+	repo := NewUsageRepository(db)
+
+	subID := uuid.New()
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	created, err := repo.Create(&models.UsageRecord{EventID: "evt_1", SubscriptionID: subID, Quantity: 5, Timestamp: at})
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	created, err = repo.Create(&models.UsageRecord{EventID: "evt_1", SubscriptionID: subID, Quantity: 5, Timestamp: at})
+	require.NoError(t, err)
+	assert.False(t, created, "the event was already recorded")
+
+	globex := repo.WithContext(tenant.WithTenant(context.Background(), "globex"))
+	created, err = globex.Create(&models.UsageRecord{EventID: "evt_1", SubscriptionID: uuid.New(), Quantity: 1, Timestamp: at})
+	require.NoError(t, err)
+	assert.True(t, created, "event IDs are unique per tenant")
+
+	record, err := repo.GetByEventID("evt_1")
+	require.NoError(t, err)
+	assert.Equal(t, subID, record.SubscriptionID)
+	assert.Equal(t, int64(5), record.Quantity)
+
+	_, err = repo.GetByEventID("evt_2")
+	assert.Error(t, err)
+}
+
+func TestUsageRepository_Aggregate(t *testing.T) {
+	db := setupUsageTestDB(t)
+	repo := NewUsageRepository(db)
+
+	subID := uuid.New()
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	for i, record := range []models.UsageRecord{
+		{SubscriptionID: subID, Quantity: 3, Timestamp: start.Add(-time.Minute)},
+		{SubscriptionID: subID, Quantity: 7, Timestamp: start},
+		{SubscriptionID: subID, Quantity: 2, Timestamp: start.AddDate(0, 0, 10)},
+		{SubscriptionID: subID, Quantity: 4, Timestamp: start.AddDate(0, 0, 5)},
+		{SubscriptionID: subID, Quantity: 9, Timestamp: end},
+		{SubscriptionID: uuid.New(), Quantity: 100, Timestamp: start.AddDate(0, 0, 1)},
+	} {
+		record.EventID = uuid.NewString()
+		_, err := repo.Create(&record)
+		require.NoError(t, err, "record %d", i)
+	}
+
+	tests := []struct {
+		aggregation string
+		want        int64
+	}{
+		{models.AggregationSum, 13},
+		{models.AggregationMax, 7},
+		{models.AggregationLast, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.aggregation, func(t *testing.T) {
+			quantity, records, err := repo.Aggregate(subID, tt.aggregation, start, end)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, quantity)
+			assert.Equal(t, int64(3), records, "only the period's records count")
+		})
+	}
+
+	quantity, records, err := repo.Aggregate(uuid.New(), models.AggregationMax, start, end)
+	require.NoError(t, err)
+	assert.Zero(t, quantity)
+	assert.Zero(t, records)
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository interface {
+	Create(webhook *models.Webhook) error
+	GetByID(id uuid.UUID) (*models.Webhook, error)
+	Update(webhook *models.Webhook) error
+	Delete(id uuid.UUID) error
+	List() ([]models.Webhook, error)
+	ListActive() ([]models.Webhook, error)
+
+	CreateDeliveries(deliveries []models.WebhookDelivery) error
+	GetDelivery(id uuid.UUID) (*models.WebhookDelivery, error)
+	UpdateDelivery(delivery *models.WebhookDelivery) error
+	ListDeliveries(webhookID uuid.UUID, limit int) ([]models.WebhookDelivery, error)
+	ListDueDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error)
+	ClaimDelivery(id uuid.UUID, now, until time.Time) (bool, error)
+
+	WithContext(ctx context.Context) WebhookRepository
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) Create(webhook *models.Webhook) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		webhook.TenantID = tenantOf(tx)
+		if err := tx.Create(webhook).Error; err != nil {
+			return err
+		}
+		return appendAudit(tx, models.AuditActionCreate, models.AuditResourceWebhook, webhook.ID, nil, webhook)
+	})
+}
+
+func (r *webhookRepository) GetByID(id uuid.UUID) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := r.db.Scopes(forTenant).First(&webhook, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("webhook not found")
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *webhookRepository) Update(webhook *models.Webhook) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var before models.Webhook
+		if err := tx.Scopes(forTenant).First(&before, "id = ?", webhook.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("webhook not found")
+			}
+			return err
+		}
+
+		result := tx.Model(&models.Webhook{}).Scopes(forTenant).Where("id = ?", webhook.ID).
+			Select("URL", "EventTypes", "Active").
+			Updates(webhook)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("webhook not found")
+		}
+
+		var updated models.Webhook
+		if err := tx.First(&updated, "id = ?", webhook.ID).Error; err != nil {
+			return err
+		}
+		return appendAudit(tx, models.AuditActionUpdate, models.AuditResourceWebhook, webhook.ID, &before, &updated)
+	})
+}
+
+func (r *webhookRepository) Delete(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var webhook models.Webhook
+		if err := tx.Scopes(forTenant).First(&webhook, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("webhook not found")
+			}
+			return err
+		}
+
+		result := tx.Scopes(forTenant).Delete(&models.Webhook{}, "id = ?", id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("webhook not found")
+		}
+		return appendAudit(tx, models.AuditActionDelete, models.AuditResourceWebhook, id, &webhook, nil)
+	})
+}
+
+func (r *webhookRepository) List() ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	if err := r.db.Scopes(forTenant).Order("created_at").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *webhookRepository) ListActive() ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	if err := r.db.Scopes(forTenant).Where("active = ?", true).Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// CreateDeliveries inserts deliveries, skipping any whose dedupe key already
+// exists.
+func (r *webhookRepository) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+func (r *webhookRepository) GetDelivery(id uuid.UUID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.Scopes(forTenant).First(&delivery, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("webhook delivery not found")
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *webhookRepository) UpdateDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Save(delivery).Error
+}
+
+func (r *webhookRepository) ListDeliveries(webhookID uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.Scopes(forTenant).Where("webhook_id = ?", webhookID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *webhookRepository) ListDueDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ClaimDelivery leases a pending delivery that is due by now until until, so
+// that only one deliverer sends it. It reports false when another deliverer
+// claimed it first or it is no longer due.
+func (r *webhookRepository) ClaimDelivery(id uuid.UUID, now, until time.Time) (bool, error) {
+	result := r.db.Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", id, models.WebhookDeliveryPending, now).
+		Update("next_attempt_at", until)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// WithContext returns a repository whose queries, and the audit events they
+// record, carry ctx.
+func (r *webhookRepository) WithContext(ctx context.Context) WebhookRepository {
+	return &webhookRepository{db: r.db.WithContext(ctx)}
+}
@@ -0,0 +1,166 @@
+//go:build cgo
+// +build cgo
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupWebhookTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("test_webhook.db"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.Webhook{}, &models.WebhookDelivery{}, &models.AuditEvent{})
+	if err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	db.Exec("DELETE FROM webhook_deliveries")
+	db.Exec("DELETE FROM webhooks")
+	db.Exec("DELETE FROM audit_events")
+
+	return db
+}
+
+func TestWebhookRepository_UpdateAndListActive(t *testing.T) {
+	db := setupWebhookTestDB(t)
+	repo := NewWebhookRepository(db)
+
+	active := &models.Webhook{URL: "https://example.com/a", EventTypes: "*", Secret: "0123456789abcdef", Active: true}
+	paused := &models.Webhook{URL: "https://example.com/b", EventTypes: "*", Secret: "0123456789abcdef", Active: true}
+	assert.NoError(t, repo.Create(active))
+	assert.NoError(t, repo.Create(paused))
+
+	err := repo.Update(&models.Webhook{ID: paused.ID, URL: "https://example.com/c", EventTypes: "product.created", Active: false})
+	assert.NoError(t, err)
+
+	updated, err := repo.GetByID(paused.ID)
+	assert.NoError(t, err)
+	assert.False(t, updated.Active)
+	assert.Equal(t, "https://example.com/c", updated.URL)
+	assert.Equal(t, "0123456789abcdef", updated.Secret)
+
+	webhooks, err := repo.ListActive()
+	assert.NoError(t, err)
+	assert.Len(t, webhooks, 1)
+	assert.Equal(t, active.ID, webhooks[0].ID)
+
+	err = repo.Update(&models.Webhook{ID: uuid.New(), URL: "https://example.com", EventTypes: "*"})
+	assert.EqualError(t, err, "webhook not found")
+}
+
+func TestWebhookRepository_CreateDeliveriesDedupes(t *testing.T) {
+	db := setupWebhookTestDB(t)
+	repo := NewWebhookRepository(db)
+
+	webhookID := uuid.New()
+	key := webhookID.String() + ":1"
+	now := time.Now()
+	delivery := models.WebhookDelivery{
+		WebhookID:     webhookID,
+		EventSequence: 1,
+		EventType:     "product.created",
+		Payload:       "{}",
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: now,
+		DedupeKey:     &key,
+	}
+
+	assert.NoError(t, repo.CreateDeliveries([]models.WebhookDelivery{delivery}))
+	assert.NoError(t, repo.CreateDeliveries([]models.WebhookDelivery{delivery}))
+
+	// Manual redeliveries have no dedupe key and are always recorded.
+	delivery.DedupeKey = nil
+	assert.NoError(t, repo.CreateDeliveries([]models.WebhookDelivery{delivery}))
+
+	deliveries, err := repo.ListDeliveries(webhookID, 10)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 2)
+
+	due, err := repo.ListDueDeliveries(now.Add(time.Second), 10)
+	assert.NoError(t, err)
+	assert.Len(t, due, 2)
+
+	due[0].Status = models.WebhookDeliverySucceeded
+	assert.NoError(t, repo.UpdateDelivery(&due[0]))
+
+	due, err = repo.ListDueDeliveries(now.Add(time.Second), 10)
+	assert.NoError(t, err)
+	assert.Len(t, due, 1)
+
+	notYetDue, err := repo.ListDueDeliveries(now.Add(-time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Empty(t, notYetDue)
+}
+
+func TestWebhookRepository_ClaimDelivery(t *testing.T) {
+	db := setupWebhookTestDB(t)
+	repo := NewWebhookRepository(db)
+
+	now := time.Now()
+	delivery := models.WebhookDelivery{
+		WebhookID:     uuid.New(),
+		EventSequence: 1,
+		EventType:     "product.created",
+		Payload:       "{}",
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: now,
+	}
+	assert.NoError(t, repo.CreateDeliveries([]models.WebhookDelivery{delivery}))
+	due, err := repo.ListDueDeliveries(now, 10)
+	assert.NoError(t, err)
+	assert.Len(t, due, 1)
+
+	claimed, err := repo.ClaimDelivery(due[0].ID, now, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	// A second deliverer that listed the delivery at the same time loses.
+	claimed, err = repo.ClaimDelivery(due[0].ID, now, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+
+	due, err = repo.ListDueDeliveries(now, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, due)
+
+	// Once the lease runs out the delivery is due again.
+	due, err = repo.ListDueDeliveries(now.Add(2*time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Len(t, due, 1)
+}
+
+func TestWebhookRepository_TenantScoped(t *testing.T) {
+	db := setupWebhookTestDB(t)
+	acme := NewWebhookRepository(db).WithContext(tenant.WithTenant(context.Background(), "acme"))
+	globex := NewWebhookRepository(db).WithContext(tenant.WithTenant(context.Background(), "globex"))
+
+	webhook := &models.Webhook{URL: "https://example.com/a", EventTypes: "*", Secret: "0123456789abcdef", Active: true}
+	assert.NoError(t, acme.Create(webhook))
+	assert.Equal(t, "acme", webhook.TenantID)
+
+	_, err := globex.GetByID(webhook.ID)
+	assert.EqualError(t, err, "webhook not found")
+	assert.EqualError(t, globex.Update(&models.Webhook{ID: webhook.ID, URL: "https://evil.example.com"}), "webhook not found")
+	assert.EqualError(t, globex.Delete(webhook.ID), "webhook not found")
+
+	active, err := globex.ListActive()
+	assert.NoError(t, err)
+	assert.Empty(t, active)
+
+	active, err = acme.ListActive()
+	assert.NoError(t, err)
+	assert.Len(t, active, 1)
+}
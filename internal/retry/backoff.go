@@ -0,0 +1,16 @@
+package retry
+
+import "time"
+
+// Backoff returns the exponential delay before the given attempt (1-based),
+// starting at base and doubling until it reaches max.
+func Backoff(base, max time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return delay
+}
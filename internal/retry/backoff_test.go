@@ -0,0 +1,16 @@
+package retry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, Backoff(time.Second, 5*time.Second, 1))
+	assert.Equal(t, 2*time.Second, Backoff(time.Second, 5*time.Second, 2))
+	assert.Equal(t, 4*time.Second, Backoff(time.Second, 5*time.Second, 3))
+	assert.Equal(t, 5*time.Second, Backoff(time.Second, 5*time.Second, 4))
+	assert.Equal(t, 5*time.Second, Backoff(time.Second, 5*time.Second, 40))
+}
@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/microservice-go/product-service/internal/service"
+)
+
+type Config struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Now          func() time.Time
+}
+
+func (c Config) withDefaults() Config {
+	if c.PollInterval <= 0 {
+		c.PollInterval = 10 * time.Second
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 100
+	}
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+	return c
+}
+
+// Scheduler periodically applies scheduled catalog changes that have become
+// effective. Every replica may run one; the service guarantees each change is
+// applied once.
+type Scheduler struct {
+	service service.ScheduledChangeService
+	config  Config
+}
+
+func New(service service.ScheduledChangeService, config Config) *Scheduler {
+	return &Scheduler{service: service, config: config.withDefaults()}
+}
+
+// Run applies due changes until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ProcessOnce(ctx); err != nil {
+			log.Printf("Scheduler: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessOnce applies the changes that are currently due, draining batches
+// until none remain, and returns how many were applied.
+func (s *Scheduler) ProcessOnce(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		applied, err := s.service.ApplyDueChanges(ctx, s.config.Now(), s.config.BatchSize)
+		total += applied
+		if err != nil || applied < s.config.BatchSize || ctx.Err() != nil {
+			return total, err
+		}
+	}
+}
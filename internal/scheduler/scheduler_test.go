@@ -0,0 +1,65 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeScheduledChangeService struct {
+	batches []int
+	err     error
+	calls   int
+}
+
+func (s *fakeScheduledChangeService) ScheduleProductChange(ctx context.Context, productID string, change models.ProductChange, effectiveAt time.Time) (*models.ScheduledChange, error) {
+	return nil, nil
+}
+
+func (s *fakeScheduledChangeService) SchedulePlanChange(ctx context.Context, planID string, change models.SubscriptionPlanChange, effectiveAt time.Time) (*models.ScheduledChange, error) {
+	return nil, nil
+}
+
+func (s *fakeScheduledChangeService) ListScheduledChanges(ctx context.Context, resourceID, status string, page, pageSize int) ([]models.ScheduledChange, int64, error) {
+	return nil, 0, nil
+}
+
+func (s *fakeScheduledChangeService) CancelScheduledChange(ctx context.Context, id string) (*models.ScheduledChange, error) {
+	return nil, nil
+}
+
+func (s *fakeScheduledChangeService) ApplyDueChanges(ctx context.Context, now time.Time, limit int) (int, error) {
+	s.calls++
+	if len(s.batches) == 0 {
+		return 0, s.err
+	}
+	applied := s.batches[0]
+	s.batches = s.batches[1:]
+	return applied, nil
+}
+
+func TestScheduler_ProcessOnceDrainsFullBatches(t *testing.T) {
+	svc := &fakeScheduledChangeService{batches: []int{2, 2, 1}}
+	s := New(svc, Config{BatchSize: 2})
+
+	applied, err := s.ProcessOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, applied)
+	assert.Equal(t, 3, svc.calls)
+}
+
+func TestScheduler_ProcessOnceStopsOnError(t *testing.T) {
+	svc := &fakeScheduledChangeService{batches: []int{2}, err: errors.New("database is locked")}
+	s := New(svc, Config{BatchSize: 2})
+
+	applied, err := s.ProcessOnce(context.Background())
+
+	assert.EqualError(t, err, "database is locked")
+	assert.Equal(t, 2, applied)
+	assert.Equal(t, 2, svc.calls)
+}
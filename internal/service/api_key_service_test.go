@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/auth"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) Create(key *models.APIKey) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) GetByID(id uuid.UUID) (*models.APIKey, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) GetByHash(keyHash string) (*models.APIKey, error) {
+	args := m.Called(keyHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) List() ([]models.APIKey, error) {
+	args := m.Called()
+	return args.Get(0).([]models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) Rotate(id uuid.UUID, prefix, keyHash string) error {
+	args := m.Called(id, prefix, keyHash)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) Revoke(id uuid.UUID, at time.Time) error {
+	args := m.Called(id, at)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) TouchLastUsed(id uuid.UUID, at time.Time) error {
+	args := m.Called(id, at)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) WithContext(ctx context.Context) repository.APIKeyRepository {
+	return m
+}
+
+func TestAPIKeyService_CreateAPIKey(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepository)
+	svc := NewAPIKeyService(mockRepo, auth.DefaultPolicy())
+
+	var stored *models.APIKey
+	mockRepo.On("Create", mock.AnythingOfType("*models.APIKey")).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(*models.APIKey)
+	}).Return(nil)
+
+	key, plaintext, err := svc.CreateAPIKey(context.Background(), "nightly-import", []string{"catalog.read", "catalog.write"})
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(plaintext, apiKeyPrefix))
+	assert.Equal(t, plaintext[:apiKeyPrefixLength], key.Prefix)
+	assert.Equal(t, hashAPIKey(plaintext), stored.KeyHash)
+	assert.NotContains(t, stored.KeyHash, plaintext)
+	assert.Equal(t, "catalog.read,catalog.write", stored.Scopes)
+}
+
+func TestAPIKeyService_CreateAPIKey_Validation(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepository)
+	svc := NewAPIKeyService(mockRepo, auth.DefaultPolicy())
+
+	_, _, err := svc.CreateAPIKey(context.Background(), "", []string{"catalog.read"})
+	assert.True(t, apperrors.IsValidationError(err))
+
+	_, _, err = svc.CreateAPIKey(context.Background(), "job", nil)
+	assert.True(t, apperrors.IsValidationError(err))
+
+	_, _, err = svc.CreateAPIKey(context.Background(), "job", []string{"catalog.read,catalog.admin"})
+	assert.True(t, apperrors.IsValidationError(err))
+
+	_, _, err = svc.CreateAPIKey(context.Background(), "job", []string{"catalog.everything"})
+	assert.True(t, apperrors.IsValidationError(err), "scopes the policy does not define are rejected")
+
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestAPIKeyService_CreateAPIKey_LimitedToCallerPermissions(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepository)
+	svc := NewAPIKeyService(mockRepo, auth.DefaultPolicy())
+	mockRepo.On("Create", mock.AnythingOfType("*models.APIKey")).Return(nil)
+
+	admin := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "ops", Roles: []string{"admin"}})
+	_, _, err := svc.CreateAPIKey(admin, "importer", []string{auth.PermissionCatalogWrite})
+	assert.NoError(t, err)
+
+	_, _, err = svc.CreateAPIKey(admin, "everywhere", []string{auth.PermissionAllTenants})
+	assert.ErrorIs(t, err, apperrors.ErrScopeNotHeld, "no role grants access to every tenant")
+
+	// A key holding only the admin scope cannot mint keys that also write.
+	key := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "apikey:ops", Scopes: []string{auth.PermissionCatalogAdmin}})
+	_, _, err = svc.CreateAPIKey(key, "importer", []string{auth.PermissionCatalogAdmin, auth.PermissionCatalogWrite})
+	assert.ErrorIs(t, err, apperrors.ErrScopeNotHeld)
+
+	mockRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestAPIKeyService_RotateAPIKey(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepository)
+	svc := NewAPIKeyService(mockRepo, auth.DefaultPolicy())
+
+	keyID := uuid.New()
+	mockRepo.On("GetByID", keyID).Return(&models.APIKey{ID: keyID, Name: "job", Scopes: "catalog.read"}, nil)
+	mockRepo.On("Rotate", keyID, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
+
+	_, plaintext, err := svc.RotateAPIKey(context.Background(), keyID.String())
+
+	assert.NoError(t, err)
+	mockRepo.AssertCalled(t, "Rotate", keyID, plaintext[:apiKeyPrefixLength], hashAPIKey(plaintext))
+
+	revokedAt := time.Now()
+	revokedID := uuid.New()
+	mockRepo.On("GetByID", revokedID).Return(&models.APIKey{ID: revokedID, RevokedAt: &revokedAt}, nil)
+	_, _, err = svc.RotateAPIKey(context.Background(), revokedID.String())
+	assert.True(t, apperrors.IsValidationError(err))
+}
+
+func TestAPIKeyService_RevokeAPIKey_NotFound(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepository)
+	svc := NewAPIKeyService(mockRepo, auth.DefaultPolicy())
+
+	keyID := uuid.New()
+	mockRepo.On("GetByID", keyID).Return(nil, errors.New("API key not found"))
+
+	_, err := svc.RevokeAPIKey(context.Background(), keyID.String())
+	assert.True(t, apperrors.IsNotFoundError(err))
+}
+
+func TestAPIKeyService_VerifyAPIKey(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepository)
+	svc := NewAPIKeyService(mockRepo, auth.DefaultPolicy())
+
+	plaintext := apiKeyPrefix + strings.Repeat("a", 64)
+	keyID := uuid.New()
+	mockRepo.On("GetByHash", hashAPIKey(plaintext)).Return(&models.APIKey{ID: keyID, Scopes: "catalog.read"}, nil).Once()
+	mockRepo.On("TouchLastUsed", keyID, mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+	key, err := svc.VerifyAPIKey(context.Background(), plaintext)
+	assert.NoError(t, err)
+	assert.Equal(t, keyID, key.ID)
+	assert.NotNil(t, key.LastUsedAt)
+
+	// Recently used keys are not touched again.
+	recently := time.Now().Add(-time.Second)
+	mockRepo.On("GetByHash", hashAPIKey(plaintext)).Return(&models.APIKey{ID: keyID, LastUsedAt: &recently}, nil).Once()
+	_, err = svc.VerifyAPIKey(context.Background(), plaintext)
+	assert.NoError(t, err)
+	mockRepo.AssertNumberOfCalls(t, "TouchLastUsed", 1)
+
+	mockRepo.On("GetByHash", hashAPIKey(plaintext)).Return(&models.APIKey{ID: keyID, RevokedAt: &recently}, nil).Once()
+	_, err = svc.VerifyAPIKey(context.Background(), plaintext)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidAPIKey)
+
+	_, err = svc.VerifyAPIKey(context.Background(), "not-a-key")
+	assert.ErrorIs(t, err, apperrors.ErrInvalidAPIKey)
+}
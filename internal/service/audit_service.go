@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/repository"
+)
+
+type AuditService interface {
+	ListAuditEvents(ctx context.Context, resourceID, actor string, from, to time.Time, page, pageSize int) ([]models.AuditEvent, int64, error)
+}
+
+type auditService struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditService(repo repository.AuditRepository) AuditService {
+	return &auditService{repo: repo}
+}
+
+func (s *auditService) ListAuditEvents(ctx context.Context, resourceID, actor string, from, to time.Time, page, pageSize int) ([]models.AuditEvent, int64, error) {
+	filter := repository.AuditFilter{
+		Actor: actor,
+		From:  from,
+		To:    to,
+	}
+	if resourceID != "" {
+		id, err := uuid.Parse(resourceID)
+		if err != nil {
+			return nil, 0, apperrors.NewValidationError("resourceId", "invalid resource ID format")
+		}
+		filter.ResourceID = id
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return nil, 0, apperrors.NewValidationError("endTime", "end time must be after start time")
+	}
+
+	auditEvents, total, err := s.repo.WithContext(ctx).List(filter, normalizePage(page), normalizePageSize(pageSize))
+	if err != nil {
+		return nil, 0, apperrors.NewDatabaseError("list audit events", err)
+	}
+
+	return auditEvents, total, nil
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepository) List(filter repository.AuditFilter, page, pageSize int) ([]models.AuditEvent, int64, error) {
+	args := m.Called(filter, page, pageSize)
+	return args.Get(0).([]models.AuditEvent), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockAuditRepository) WithContext(ctx context.Context) repository.AuditRepository {
+	return m
+}
+
+func TestListAuditEvents_Success(t *testing.T) {
+	mockRepo := new(MockAuditRepository)
+	service := NewAuditService(mockRepo)
+
+	resourceID := uuid.New()
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	expected := []models.AuditEvent{{ID: uuid.New(), Actor: "alice", ResourceID: resourceID}}
+	mockRepo.On("List", repository.AuditFilter{ResourceID: resourceID, Actor: "alice", From: from, To: to}, 1, 10).
+		Return(expected, int64(1), nil)
+
+	auditEvents, total, err := service.ListAuditEvents(context.Background(), resourceID.String(), "alice", from, to, 0, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, auditEvents)
+	assert.Equal(t, int64(1), total)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListAuditEvents_InvalidInput(t *testing.T) {
+	service := NewAuditService(new(MockAuditRepository))
+	now := time.Now()
+
+	_, _, err := service.ListAuditEvents(context.Background(), "not-a-uuid", "", time.Time{}, time.Time{}, 1, 10)
+	assert.True(t, apperrors.IsValidationError(err))
+
+	_, _, err = service.ListAuditEvents(context.Background(), "", "", now, now.Add(-time.Hour), 1, 10)
+	assert.True(t, apperrors.IsValidationError(err))
+}
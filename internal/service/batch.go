@@ -0,0 +1,75 @@
+package service
+
+import (
+	"fmt"
+
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+)
+
+type ProductInput struct {
+	ID           string
+	Name         string
+	Description  string
+	Price        float64
+	ProductType  string
+	TaxInclusive bool
+}
+
+type ProductResult struct {
+	Product *models.Product
+	Err     error
+}
+
+type SubscriptionPlanInput struct {
+	ID           string
+	ProductID    string
+	PlanName     string
+	Interval     models.BillingInterval
+	Price        float64
+	PricingModel string // flat when empty
+	Tiers        []models.PriceTier
+	Meter        *models.Meter // nil for plans charged up front
+	TrialDays    int
+	IntroPhases  []models.IntroPhase
+}
+
+type SubscriptionPlanResult struct {
+	Plan *models.SubscriptionPlan
+	Err  error
+}
+
+type transactional[R any] interface {
+	Transaction(fn func(repo R) error) error
+}
+
+// runBatch executes fn for every item inside one transaction. In atomic mode
+// the first failure rolls back the whole batch; otherwise each item runs in its
+// own savepoint so only the failing item's writes are discarded.
+func runBatch[R transactional[R]](repo R, n int, atomic bool, fn func(repo R, i int) error) error {
+	return repo.Transaction(func(tx R) error {
+		for i := 0; i < n; i++ {
+			if atomic {
+				if err := fn(tx, i); err != nil {
+					return apperrors.NewBatchItemError(i, err)
+				}
+				continue
+			}
+			index := i
+			_ = tx.Transaction(func(item R) error {
+				return fn(item, index)
+			})
+		}
+		return nil
+	})
+}
+
+func validateBatchSize(size, max int) error {
+	if size == 0 {
+		return apperrors.NewValidationError("items", "batch must contain at least one item")
+	}
+	if size > max {
+		return apperrors.NewValidationError("items", fmt.Sprintf("batch cannot exceed %d items", max))
+	}
+	return nil
+}
@@ -0,0 +1,465 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/audit"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/repository"
+	"github.com/microservice-go/product-service/internal/tenant"
+)
+
+// BillingService manages customers' subscriptions to plans.
+type BillingService interface {
+	Subscribe(ctx context.Context, customerID, planID, couponCode string, location TaxLocation) (*models.Subscription, error)
+	GetSubscription(ctx context.Context, id string) (*models.Subscription, error)
+	ListSubscriptions(ctx context.Context, customerID, planID, status string, page, pageSize int) ([]models.Subscription, int64, error)
+	CancelSubscription(ctx context.Context, id string, atPeriodEnd bool) (*models.Subscription, error)
+	ResumeSubscription(ctx context.Context, id string) (*models.Subscription, error)
+	ChangeSubscriptionPlan(ctx context.Context, id, planID string, atPeriodEnd bool) (*PlanChange, error)
+	PreviewPlanChange(ctx context.Context, id, planID string, atPeriodEnd bool) (*PlanChange, error)
+	RenewDue(ctx context.Context, now time.Time, limit int) (int, error)
+}
+
+// PlanChange is a subscription moved to another plan, as it is after the
+// change, with what the change charges and credits. EffectiveAt is when the
+// new plan takes over. Invoice is set once an immediate change has been
+// invoiced.
+type PlanChange struct {
+	Subscription *models.Subscription
+	LineItems    []models.LineItem
+	EffectiveAt  time.Time
+	Invoice      *models.Invoice
+}
+
+func (c *PlanChange) Total() float64 {
+	return models.LineItemsTotal(c.LineItems)
+}
+
+type billingService struct {
+	repo        repository.CustomerSubscriptionRepository
+	planRepo    repository.SubscriptionRepository
+	productRepo repository.ProductRepository
+	couponRepo  repository.CouponRepository
+	invoices    invoiceBuilder
+	opts        options
+}
+
+func NewBillingService(repo repository.CustomerSubscriptionRepository, planRepo repository.SubscriptionRepository, productRepo repository.ProductRepository, couponRepo repository.CouponRepository, usageRepo repository.UsageRepository, opts ...Option) BillingService {
+	o := newOptions(opts)
+	return &billingService{
+		repo:        repo,
+		planRepo:    planRepo,
+		productRepo: productRepo,
+		couponRepo:  couponRepo,
+		invoices: invoiceBuilder{
+			usageRepo:   usageRepo,
+			couponRepo:  couponRepo,
+			productRepo: productRepo,
+			opts:        o,
+		},
+		opts: o,
+	}
+}
+
+// Subscribe starts a subscription to the plan now, in its trial if it has
+// one, redeeming the coupon if a code is given. A customer can hold only one
+// subscription to a plan at a time. The first period is invoiced at once,
+// taxed at location; trials and metered plans are invoiced when they renew.
+func (s *billingService) Subscribe(ctx context.Context, customerID, planID, couponCode string, location TaxLocation) (*models.Subscription, error) {
+	if err := validateCustomerID(customerID); err != nil {
+		return nil, err
+	}
+	location, err := normalizeTaxLocation(location)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := s.activePlan(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := s.repo.WithContext(ctx)
+	existing, _, err := repo.List(customerID, plan.ID, "", 0, 0)
+	if err != nil {
+		return nil, apperrors.NewDatabaseError("list subscriptions", err)
+	}
+	for _, sub := range existing {
+		if !sub.IsEnded() {
+			return nil, apperrors.NewValidationError("planId", "customer is already subscribed to this plan")
+		}
+	}
+
+	sub := models.NewSubscription(customerID, plan, s.opts.now())
+	sub.TaxCountry = location.Country
+	sub.TaxRegion = location.Region
+	if code := normalizeCouponCode(couponCode); code != "" {
+		coupon, err := s.couponRepo.WithContext(ctx).GetByCode(code)
+		if err != nil {
+			return nil, apperrors.NewValidationError("couponCode", "coupon code is not valid")
+		}
+		if reasons := couponRejections(coupon, plan, s.opts); len(reasons) > 0 {
+			return nil, apperrors.NewValidationError("couponCode", strings.Join(reasons, "; "))
+		}
+		sub.CouponID = &coupon.ID
+	}
+
+	var invoice *models.Invoice
+	if sub.Status == models.SubscriptionActive && plan.Meter == nil {
+		invoice, err = s.invoices.build(ctx, sub, plan, []models.LineItem{sub.PeriodCharge(plan)})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := repo.Create(sub, invoice); err != nil {
+		if errors.Is(err, apperrors.ErrCouponExhausted) {
+			return nil, apperrors.NewValidationError("couponCode", err.Error())
+		}
+		return nil, apperrors.NewDatabaseError("create subscription", err)
+	}
+
+	return sub, nil
+}
+
+func (s *billingService) GetSubscription(ctx context.Context, id string) (*models.Subscription, error) {
+	subID, err := parseSubscriptionID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := s.repo.WithContext(ctx).GetByID(subID)
+	if err != nil {
+		return nil, apperrors.NewNotFoundError("Subscription", id)
+	}
+
+	return sub, nil
+}
+
+func (s *billingService) ListSubscriptions(ctx context.Context, customerID, planID, status string, page, pageSize int) ([]models.Subscription, int64, error) {
+	var id uuid.UUID
+	if planID != "" {
+		parsed, err := uuid.Parse(planID)
+		if err != nil {
+			return nil, 0, apperrors.NewValidationError("planId", "invalid subscription plan ID format")
+		}
+		id = parsed
+	}
+
+	subs, total, err := s.repo.WithContext(ctx).List(customerID, id, status, normalizePage(page), normalizePageSize(pageSize))
+	if err != nil {
+		return nil, 0, apperrors.NewDatabaseError("list subscriptions", err)
+	}
+
+	return subs, total, nil
+}
+
+// CancelSubscription ends the subscription now, or with atPeriodEnd lets it
+// run until the end of the period already paid for.
+func (s *billingService) CancelSubscription(ctx context.Context, id string, atPeriodEnd bool) (*models.Subscription, error) {
+	sub, err := s.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub.IsEnded() {
+		return nil, apperrors.NewValidationError("status", "subscription is already canceled")
+	}
+
+	now := s.opts.now()
+	sub.CanceledAt = &now
+	if atPeriodEnd {
+		sub.CancelAtPeriodEnd = true
+	} else {
+		sub.Status = models.SubscriptionCanceled
+		sub.EndedAt = &now
+	}
+
+	if err := s.repo.WithContext(ctx).Update(sub, nil); err != nil {
+		return nil, updateError("cancel subscription", err)
+	}
+
+	return sub, nil
+}
+
+// ResumeSubscription withdraws a cancellation scheduled for the end of the
+// period. Subscriptions that have already ended cannot be resumed.
+func (s *billingService) ResumeSubscription(ctx context.Context, id string) (*models.Subscription, error) {
+	sub, err := s.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub.IsEnded() {
+		return nil, apperrors.NewValidationError("status", "canceled subscriptions cannot be resumed")
+	}
+	if !sub.CancelAtPeriodEnd {
+		return nil, apperrors.NewValidationError("status", "subscription is not scheduled to cancel")
+	}
+
+	sub.CancelAtPeriodEnd = false
+	sub.CanceledAt = nil
+
+	if err := s.repo.WithContext(ctx).Update(sub, nil); err != nil {
+		return nil, updateError("resume subscription", err)
+	}
+
+	return sub, nil
+}
+
+// ChangeSubscriptionPlan moves the subscription to another plan. An
+// immediate change is prorated: the customer is credited for the unused part
+// of the current period and charged for the new plan, and usage so far on a
+// metered plan is billed at that plan's rates. With atPeriodEnd the
+// change waits for the next renewal and the new plan is billed from then on.
+// Asking for the current plan withdraws a change scheduled for the period end.
+// Immediate changes are invoiced straight away.
+func (s *billingService) ChangeSubscriptionPlan(ctx context.Context, id, planID string, atPeriodEnd bool) (*PlanChange, error) {
+	change, to, err := s.planChange(ctx, id, planID, atPeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	if !atPeriodEnd && len(change.LineItems) > 0 {
+		change.Invoice, err = s.invoices.build(ctx, change.Subscription, to, change.LineItems)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.WithContext(ctx).Update(change.Subscription, change.Invoice); err != nil {
+		return nil, updateError("change subscription plan", err)
+	}
+
+	return change, nil
+}
+
+// PreviewPlanChange works out a plan change as ChangeSubscriptionPlan would
+// without applying it.
+func (s *billingService) PreviewPlanChange(ctx context.Context, id, planID string, atPeriodEnd bool) (*PlanChange, error) {
+	change, _, err := s.planChange(ctx, id, planID, atPeriodEnd)
+	return change, err
+}
+
+// planChange works out a plan change and returns it with the plan the
+// subscription is changing to.
+func (s *billingService) planChange(ctx context.Context, id, planID string, atPeriodEnd bool) (*PlanChange, *models.SubscriptionPlan, error) {
+	sub, err := s.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sub.IsEnded() {
+		return nil, nil, apperrors.NewValidationError("status", "canceled subscriptions cannot change plan")
+	}
+
+	to, err := s.activePlan(ctx, planID)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := s.opts.now()
+	if to.ID == sub.PlanID {
+		if !atPeriodEnd || sub.PendingPlanID == nil {
+			return nil, nil, apperrors.NewValidationError("planId", "subscription is already on this plan")
+		}
+		sub.PendingPlanID = nil
+		return &PlanChange{Subscription: sub, EffectiveAt: now}, to, nil
+	}
+
+	from, err := currentPlan(ctx, s.planRepo, sub)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if atPeriodEnd {
+		if sub.CancelAtPeriodEnd {
+			return nil, nil, apperrors.NewValidationError("status", "subscription is scheduled to cancel at the end of the period")
+		}
+		// The renewal itself is what the change will bill, so preview it on
+		// a copy and keep the subscription in its current period.
+		renewed := *sub
+		charge := renewed.Renew(from, to)
+		sub.PendingPlanID = &to.ID
+		return &PlanChange{Subscription: sub, LineItems: []models.LineItem{charge}, EffectiveAt: sub.CurrentPeriodEnd}, to, nil
+	}
+
+	// Usage so far is billed on the plan it was recorded under, before the
+	// change moves the usage window on. Usage during a trial is free.
+	var items []models.LineItem
+	if sub.Status != models.SubscriptionTrialing {
+		if items, err = s.invoices.usage(ctx, sub, from, now); err != nil {
+			return nil, nil, err
+		}
+	}
+	items = append(items, sub.ChangePlan(from, to, now)...)
+	return &PlanChange{Subscription: sub, LineItems: items, EffectiveAt: now}, to, nil
+}
+
+// RenewDue moves up to limit subscriptions whose period has ended by now into
+// their next period, or ends them if they were cancelled at the period end,
+// and returns how many it moved on. A subscription that is several periods
+// behind is renewed once for each. Renewals that another replica got to first
+// are skipped. A subscription that fails to renew does not hold up the others;
+// the failures are returned together once the rest have been renewed.
+func (s *billingService) RenewDue(ctx context.Context, now time.Time, limit int) (int, error) {
+	due, err := s.repo.WithContext(ctx).ListDue(now, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	renewed := 0
+	var failures []error
+	for i := range due {
+		sub := &due[i]
+		subCtx := tenant.WithTenant(audit.WithActor(ctx, audit.SystemActor), sub.TenantID)
+		ok, err := s.renew(subCtx, sub, now)
+		if err != nil {
+			if !apperrors.IsNotFoundError(err) {
+				failures = append(failures, fmt.Errorf("subscription %s: %w", sub.ID, err))
+			}
+			continue
+		}
+		if ok {
+			renewed++
+		}
+	}
+
+	return renewed, errors.Join(failures...)
+}
+
+func (s *billingService) renew(ctx context.Context, sub *models.Subscription, now time.Time) (bool, error) {
+	repo := s.repo.WithContext(ctx)
+	renewed := false
+	for !sub.IsEnded() && !sub.CurrentPeriodEnd.After(now) {
+		periodEnd := sub.CurrentPeriodEnd
+		from, err := currentPlan(ctx, s.planRepo, sub)
+		if err != nil {
+			return renewed, err
+		}
+
+		// Usage is billed for the period that is ending, so it has to be
+		// counted before the subscription moves on.
+		items, err := s.invoices.usage(ctx, sub, from, periodEnd)
+		if err != nil {
+			return renewed, err
+		}
+
+		to := from
+		if sub.CancelAtPeriodEnd {
+			sub.Status = models.SubscriptionCanceled
+			sub.EndedAt = &periodEnd
+		} else {
+			to = s.nextPlan(ctx, sub, from)
+			if charge := sub.Renew(from, to); to.Meter == nil {
+				items = append(items, charge)
+			}
+		}
+
+		var invoice *models.Invoice
+		if len(items) > 0 {
+			invoice, err = s.invoices.build(ctx, sub, to, items)
+			if err != nil {
+				return renewed, err
+			}
+		}
+
+		ok, err := repo.Renew(sub, periodEnd, to, invoice)
+		if err != nil {
+			return renewed, apperrors.NewDatabaseError("renew subscription", err)
+		}
+		if !ok {
+			return renewed, nil
+		}
+		renewed = true
+	}
+	return renewed, nil
+}
+
+// nextPlan returns the plan the subscription renews on: the plan it is
+// switching to if a change is pending, otherwise its current plan at today's
+// price. Deleted plans keep renewing at the price they had.
+func (s *billingService) nextPlan(ctx context.Context, sub *models.Subscription, current *models.SubscriptionPlan) *models.SubscriptionPlan {
+	repo := s.planRepo.WithContext(ctx)
+	if sub.PendingPlanID != nil {
+		if plan, err := repo.GetByID(*sub.PendingPlanID); err == nil {
+			return plan
+		}
+	}
+	if plan, err := repo.GetByID(sub.PlanID); err == nil {
+		return plan
+	}
+	return current
+}
+
+// currentPlan returns the subscription's plan as it was when the current
+// period began, which is what the customer paid for it. Plans deleted since
+// are still found.
+func currentPlan(ctx context.Context, planRepo repository.SubscriptionRepository, sub *models.Subscription) (*models.SubscriptionPlan, error) {
+	repo := planRepo.WithContext(ctx)
+	if plan, err := repo.GetByIDAsOf(sub.PlanID, sub.CurrentPeriodStart); err == nil {
+		return plan, nil
+	}
+
+	plan, err := repo.GetByID(sub.PlanID)
+	if err != nil {
+		return nil, apperrors.NewNotFoundError("SubscriptionPlan", sub.PlanID.String())
+	}
+	return plan, nil
+}
+
+// activePlan returns the plan with the given ID if it and its product still
+// exist.
+func (s *billingService) activePlan(ctx context.Context, planID string) (*models.SubscriptionPlan, error) {
+	id, err := parsePlanID(planID)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := s.planRepo.WithContext(ctx).GetByID(id)
+	if err != nil {
+		return nil, apperrors.NewNotFoundError("SubscriptionPlan", planID)
+	}
+
+	if _, err := s.productRepo.WithContext(ctx).GetByID(plan.ProductID); err != nil {
+		return nil, apperrors.NewNotFoundError("Product", plan.ProductID.String())
+	}
+
+	return plan, nil
+}
+
+// updateError reports a failed subscription update. Losing a race with
+// another write is not a database failure; the caller can read the
+// subscription again and retry.
+func updateError(operation string, err error) error {
+	if errors.Is(err, apperrors.ErrSubscriptionChanged) {
+		return err
+	}
+	return apperrors.NewDatabaseError(operation, err)
+}
+
+func parseSubscriptionID(id string) (uuid.UUID, error) {
+	if id == "" {
+		return uuid.Nil, apperrors.NewValidationError("id", "subscription ID is required")
+	}
+
+	subID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, apperrors.NewValidationError("id", "invalid subscription ID format")
+	}
+
+	return subID, nil
+}
+
+func validateCustomerID(customerID string) error {
+	if customerID == "" {
+		return apperrors.NewValidationError("customerId", "customer ID is required")
+	}
+	if len(customerID) > 255 {
+		return apperrors.NewValidationError("customerId", "customer ID must be less than 255 characters")
+	}
+	return nil
+}
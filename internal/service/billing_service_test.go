@@ -0,0 +1,626 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/repository"
+	"github.com/microservice-go/product-service/internal/tax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCustomerSubscriptionRepository struct {
+	mock.Mock
+}
+
+func (m *MockCustomerSubscriptionRepository) Create(sub *models.Subscription, invoice *models.Invoice) error {
+	args := m.Called(sub, invoice)
+	return args.Error(0)
+}
+
+func (m *MockCustomerSubscriptionRepository) GetByID(id uuid.UUID) (*models.Subscription, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Subscription), args.Error(1)
+}
+
+func (m *MockCustomerSubscriptionRepository) List(customerID string, planID uuid.UUID, status string, page, pageSize int) ([]models.Subscription, int64, error) {
+	args := m.Called(customerID, planID, status, page, pageSize)
+	return args.Get(0).([]models.Subscription), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockCustomerSubscriptionRepository) Update(sub *models.Subscription, invoice *models.Invoice) error {
+	args := m.Called(sub, invoice)
+	return args.Error(0)
+}
+
+func (m *MockCustomerSubscriptionRepository) ListDue(now time.Time, limit int) ([]models.Subscription, error) {
+	args := m.Called(now, limit)
+	return args.Get(0).([]models.Subscription), args.Error(1)
+}
+
+func (m *MockCustomerSubscriptionRepository) Renew(sub *models.Subscription, periodEnd time.Time, plan *models.SubscriptionPlan, invoice *models.Invoice) (bool, error) {
+	args := m.Called(sub, periodEnd, plan, invoice)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockCustomerSubscriptionRepository) WithContext(ctx context.Context) repository.CustomerSubscriptionRepository {
+	return m
+}
+
+type billingFixture struct {
+	subs     *MockCustomerSubscriptionRepository
+	plans    *MockSubscriptionRepository
+	products *MockProductRepositoryForSubscription
+	coupons  *MockCouponRepository
+	usage    *MockUsageRepository
+	service  BillingService
+	now      time.Time
+}
+
+func newBillingFixture() *billingFixture {
+	f := &billingFixture{
+		subs:     new(MockCustomerSubscriptionRepository),
+		plans:    new(MockSubscriptionRepository),
+		products: new(MockProductRepositoryForSubscription),
+		coupons:  new(MockCouponRepository),
+		usage:    new(MockUsageRepository),
+		now:      time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC),
+	}
+	f.service = NewBillingService(f.subs, f.plans, f.products, f.coupons, f.usage, WithClock(func() time.Time { return f.now }))
+	return f
+}
+
+func (f *billingFixture) plan(trialDays int) *models.SubscriptionPlan {
+	plan := &models.SubscriptionPlan{ID: uuid.New(), ProductID: uuid.New(), PlanName: "Monthly Plan", Interval: monthly, Price: 29.99, TrialDays: trialDays}
+	f.plans.On("GetByID", plan.ID).Return(plan, nil)
+	f.products.On("GetByID", plan.ProductID).Return(&models.Product{ID: plan.ProductID}, nil)
+	return plan
+}
+
+func TestBillingService_Subscribe(t *testing.T) {
+	f := newBillingFixture()
+	plan := f.plan(0)
+	f.subs.On("List", "cus_1", plan.ID, "", 0, 0).Return([]models.Subscription{}, int64(0), nil)
+	f.subs.On("Create", mock.AnythingOfType("*models.Subscription"), mock.Anything).Return(nil)
+
+	sub, err := f.service.Subscribe(context.Background(), "cus_1", plan.ID.String(), "", TaxLocation{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.SubscriptionActive, sub.Status)
+	assert.Equal(t, 1, sub.Cycle)
+	assert.Equal(t, f.now, sub.CurrentPeriodStart)
+	assert.Equal(t, time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC), sub.CurrentPeriodEnd)
+	f.subs.AssertExpectations(t)
+}
+
+func TestBillingService_Subscribe_Trial(t *testing.T) {
+	f := newBillingFixture()
+	plan := f.plan(14)
+	f.subs.On("List", "cus_1", plan.ID, "", 0, 0).Return([]models.Subscription{}, int64(0), nil)
+	f.subs.On("Create", mock.AnythingOfType("*models.Subscription"), mock.Anything).Return(nil)
+
+	sub, err := f.service.Subscribe(context.Background(), "cus_1", plan.ID.String(), "", TaxLocation{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.SubscriptionTrialing, sub.Status)
+	assert.Equal(t, 0, sub.Cycle)
+	assert.Equal(t, f.now.AddDate(0, 0, 14), sub.CurrentPeriodEnd)
+	assert.Equal(t, sub.CurrentPeriodEnd, sub.BillingAnchor)
+}
+
+func TestBillingService_Subscribe_AlreadySubscribed(t *testing.T) {
+	f := newBillingFixture()
+	plan := f.plan(0)
+	f.subs.On("List", "cus_1", plan.ID, "", 0, 0).
+		Return([]models.Subscription{{Status: models.SubscriptionCanceled}, {Status: models.SubscriptionActive}}, int64(2), nil)
+
+	sub, err := f.service.Subscribe(context.Background(), "cus_1", plan.ID.String(), "", TaxLocation{})
+
+	assert.Nil(t, sub)
+	assert.Contains(t, err.Error(), "customer is already subscribed to this plan")
+	f.subs.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestBillingService_Subscribe_Validation(t *testing.T) {
+	f := newBillingFixture()
+
+	_, err := f.service.Subscribe(context.Background(), "", uuid.New().String(), "", TaxLocation{})
+	assert.Contains(t, err.Error(), "customer ID is required")
+
+	_, err = f.service.Subscribe(context.Background(), "cus_1", "invalid-uuid", "", TaxLocation{})
+	assert.Contains(t, err.Error(), "invalid subscription plan ID format")
+
+	planID := uuid.New()
+	f.plans.On("GetByID", planID).Return(nil, errors.New("subscription plan not found"))
+	_, err = f.service.Subscribe(context.Background(), "cus_1", planID.String(), "", TaxLocation{})
+	assert.Contains(t, err.Error(), "SubscriptionPlan with ID")
+}
+
+func TestBillingService_CancelSubscription(t *testing.T) {
+	tests := []struct {
+		name        string
+		atPeriodEnd bool
+		wantStatus  string
+		wantEnded   bool
+	}{
+		{"immediately", false, models.SubscriptionCanceled, true},
+		{"at period end", true, models.SubscriptionActive, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newBillingFixture()
+			sub := &models.Subscription{ID: uuid.New(), Status: models.SubscriptionActive}
+			f.subs.On("GetByID", sub.ID).Return(sub, nil)
+			f.subs.On("Update", sub, (*models.Invoice)(nil)).Return(nil)
+
+			canceled, err := f.service.CancelSubscription(context.Background(), sub.ID.String(), tt.atPeriodEnd)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, canceled.Status)
+			assert.Equal(t, tt.atPeriodEnd, canceled.CancelAtPeriodEnd)
+			assert.Equal(t, f.now, *canceled.CanceledAt)
+			assert.Equal(t, tt.wantEnded, canceled.EndedAt != nil)
+		})
+	}
+}
+
+func TestBillingService_CancelSubscription_AlreadyCanceled(t *testing.T) {
+	f := newBillingFixture()
+	sub := &models.Subscription{ID: uuid.New(), Status: models.SubscriptionCanceled}
+	f.subs.On("GetByID", sub.ID).Return(sub, nil)
+
+	_, err := f.service.CancelSubscription(context.Background(), sub.ID.String(), false)
+
+	assert.Contains(t, err.Error(), "subscription is already canceled")
+}
+
+func TestBillingService_ResumeSubscription(t *testing.T) {
+	f := newBillingFixture()
+	canceledAt := f.now
+	sub := &models.Subscription{ID: uuid.New(), Status: models.SubscriptionActive, CancelAtPeriodEnd: true, CanceledAt: &canceledAt}
+	f.subs.On("GetByID", sub.ID).Return(sub, nil)
+	f.subs.On("Update", sub, (*models.Invoice)(nil)).Return(nil)
+
+	resumed, err := f.service.ResumeSubscription(context.Background(), sub.ID.String())
+
+	assert.NoError(t, err)
+	assert.False(t, resumed.CancelAtPeriodEnd)
+	assert.Nil(t, resumed.CanceledAt)
+}
+
+func TestBillingService_ResumeSubscription_Invalid(t *testing.T) {
+	f := newBillingFixture()
+	ended := &models.Subscription{ID: uuid.New(), Status: models.SubscriptionCanceled}
+	running := &models.Subscription{ID: uuid.New(), Status: models.SubscriptionActive}
+	f.subs.On("GetByID", ended.ID).Return(ended, nil)
+	f.subs.On("GetByID", running.ID).Return(running, nil)
+
+	_, err := f.service.ResumeSubscription(context.Background(), ended.ID.String())
+	assert.Contains(t, err.Error(), "canceled subscriptions cannot be resumed")
+
+	_, err = f.service.ResumeSubscription(context.Background(), running.ID.String())
+	assert.Contains(t, err.Error(), "subscription is not scheduled to cancel")
+	f.subs.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestBillingService_ListSubscriptions(t *testing.T) {
+	f := newBillingFixture()
+	planID := uuid.New()
+	f.subs.On("List", "cus_1", planID, models.SubscriptionActive, 1, 10).
+		Return([]models.Subscription{{ID: uuid.New()}}, int64(1), nil)
+
+	subs, total, err := f.service.ListSubscriptions(context.Background(), "cus_1", planID.String(), models.SubscriptionActive, 0, 0)
+
+	assert.NoError(t, err)
+	assert.Len(t, subs, 1)
+	assert.Equal(t, int64(1), total)
+
+	_, _, err = f.service.ListSubscriptions(context.Background(), "", "invalid-uuid", "", 1, 10)
+	assert.Contains(t, err.Error(), "invalid subscription plan ID format")
+}
+
+func (f *billingFixture) currentPlan(sub *models.Subscription, plan *models.SubscriptionPlan) {
+	f.plans.On("GetByIDAsOf", plan.ID, sub.CurrentPeriodStart).Return(plan, nil)
+}
+
+func TestBillingService_ChangeSubscriptionPlan(t *testing.T) {
+	f := newBillingFixture()
+	f.now = time.Date(2024, 4, 11, 0, 0, 0, 0, time.UTC)
+	basic := f.plan(0)
+	basic.Price = 30
+	pro := f.plan(0)
+	pro.Price = 60
+	sub := models.NewSubscription("cus_1", basic, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC))
+	sub.ID = uuid.New()
+	f.subs.On("GetByID", sub.ID).Return(sub, nil)
+	f.subs.On("Update", sub, mock.AnythingOfType("*models.Invoice")).Return(nil)
+	f.currentPlan(sub, basic)
+
+	change, err := f.service.ChangeSubscriptionPlan(context.Background(), sub.ID.String(), pro.ID.String(), false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, pro.ID, change.Subscription.PlanID)
+	assert.Equal(t, f.now, change.EffectiveAt)
+	assert.Len(t, change.LineItems, 2)
+	assert.Equal(t, -20.0, change.LineItems[0].Amount)
+	assert.Equal(t, 40.0, change.LineItems[1].Amount)
+	assert.Equal(t, 20.0, change.Total())
+	f.subs.AssertExpectations(t)
+}
+
+func TestBillingService_ChangeSubscriptionPlan_BillsUsageSoFar(t *testing.T) {
+	f := newBillingFixture()
+	f.now = time.Date(2024, 4, 11, 0, 0, 0, 0, time.UTC)
+	metered := meteredPlan(models.AggregationSum)
+	metered.ProductID = uuid.New()
+	f.products.On("GetByID", metered.ProductID).Return(&models.Product{ID: metered.ProductID}, nil)
+	flat := f.plan(0)
+	flat.Price = 30
+	sub := models.NewSubscription("cus_1", metered, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC))
+	sub.ID = uuid.New()
+	periodStart := sub.CurrentPeriodStart
+	f.subs.On("GetByID", sub.ID).Return(sub, nil)
+	f.subs.On("Update", sub, mock.AnythingOfType("*models.Invoice")).Return(nil)
+	f.currentPlan(sub, metered)
+	f.usage.On("Aggregate", sub.ID, models.AggregationSum, periodStart, f.now).Return(int64(1500), int64(3), nil)
+
+	change, err := f.service.ChangeSubscriptionPlan(context.Background(), sub.ID.String(), flat.ID.String(), false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{models.LineItemUsage, models.LineItemProration}, lineItemKinds(change.LineItems))
+	assert.Equal(t, 12.5, change.LineItems[0].Amount, "usage so far is billed at the metered plan's rates")
+	assert.Equal(t, f.now, change.LineItems[0].PeriodEnd)
+	assert.Equal(t, 32.5, change.Invoice.Total)
+	assert.Equal(t, f.now, change.Subscription.UsagePeriodStart(), "later usage counts towards the new plan")
+	f.usage.AssertExpectations(t)
+}
+
+func TestBillingService_PreviewPlanChange(t *testing.T) {
+	f := newBillingFixture()
+	basic := f.plan(0)
+	pro := f.plan(0)
+	sub := models.NewSubscription("cus_1", basic, f.now)
+	sub.ID = uuid.New()
+	f.subs.On("GetByID", sub.ID).Return(sub, nil)
+	f.currentPlan(sub, basic)
+
+	change, err := f.service.PreviewPlanChange(context.Background(), sub.ID.String(), pro.ID.String(), false)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, change.LineItems)
+	f.subs.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestBillingService_ChangeSubscriptionPlan_AtPeriodEnd(t *testing.T) {
+	f := newBillingFixture()
+	monthlyPlan := f.plan(0)
+	annualPlan := f.plan(0)
+	annualPlan.Interval = models.BillingInterval{Unit: models.IntervalYear, Count: 1}
+	annualPlan.Price = 299.99
+	sub := models.NewSubscription("cus_1", monthlyPlan, f.now)
+	sub.ID = uuid.New()
+	periodEnd := sub.CurrentPeriodEnd
+	f.subs.On("GetByID", sub.ID).Return(sub, nil)
+	f.subs.On("Update", sub, (*models.Invoice)(nil)).Return(nil)
+	f.currentPlan(sub, monthlyPlan)
+
+	change, err := f.service.ChangeSubscriptionPlan(context.Background(), sub.ID.String(), annualPlan.ID.String(), true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, monthlyPlan.ID, change.Subscription.PlanID, "the current period is unchanged")
+	assert.Equal(t, annualPlan.ID, *change.Subscription.PendingPlanID)
+	assert.Equal(t, periodEnd, change.Subscription.CurrentPeriodEnd)
+	assert.Equal(t, periodEnd, change.EffectiveAt)
+	assert.Len(t, change.LineItems, 1)
+	assert.Equal(t, 299.99, change.LineItems[0].Amount)
+	assert.Equal(t, time.Date(2025, 2, 28, 12, 0, 0, 0, time.UTC), change.LineItems[0].PeriodEnd)
+
+	// Asking for the current plan withdraws the scheduled change.
+	change, err = f.service.ChangeSubscriptionPlan(context.Background(), sub.ID.String(), monthlyPlan.ID.String(), true)
+
+	assert.NoError(t, err)
+	assert.Nil(t, change.Subscription.PendingPlanID)
+	assert.Empty(t, change.LineItems)
+}
+
+func TestBillingService_ChangeSubscriptionPlan_Invalid(t *testing.T) {
+	f := newBillingFixture()
+	plan := f.plan(0)
+	other := f.plan(0)
+	canceledAt := f.now
+	sub := &models.Subscription{ID: uuid.New(), PlanID: plan.ID, Status: models.SubscriptionActive}
+	ended := &models.Subscription{ID: uuid.New(), PlanID: plan.ID, Status: models.SubscriptionCanceled}
+	canceling := &models.Subscription{ID: uuid.New(), PlanID: plan.ID, Status: models.SubscriptionActive, CancelAtPeriodEnd: true, CanceledAt: &canceledAt}
+	for _, s := range []*models.Subscription{sub, ended, canceling} {
+		f.subs.On("GetByID", s.ID).Return(s, nil)
+	}
+	f.currentPlan(canceling, plan)
+
+	_, err := f.service.ChangeSubscriptionPlan(context.Background(), sub.ID.String(), plan.ID.String(), false)
+	assert.Contains(t, err.Error(), "subscription is already on this plan")
+
+	_, err = f.service.ChangeSubscriptionPlan(context.Background(), ended.ID.String(), other.ID.String(), false)
+	assert.Contains(t, err.Error(), "canceled subscriptions cannot change plan")
+
+	_, err = f.service.ChangeSubscriptionPlan(context.Background(), canceling.ID.String(), other.ID.String(), true)
+	assert.Contains(t, err.Error(), "subscription is scheduled to cancel at the end of the period")
+
+	f.subs.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestBillingService_RenewDue(t *testing.T) {
+	f := newBillingFixture()
+	plan := f.plan(0)
+	// Two periods behind: Jan 31 - Feb 29 and Feb 29 - Mar 31 have both ended.
+	sub := *models.NewSubscription("cus_1", plan, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC))
+	f.now = time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC)
+	f.subs.On("ListDue", f.now, 10).Return([]models.Subscription{sub}, nil)
+	f.plans.On("GetByIDAsOf", plan.ID, mock.AnythingOfType("time.Time")).Return(plan, nil)
+	f.subs.On("Renew", mock.AnythingOfType("*models.Subscription"), mock.AnythingOfType("time.Time"), plan, mock.AnythingOfType("*models.Invoice")).Return(true, nil)
+
+	renewed, err := f.service.RenewDue(context.Background(), f.now, 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, renewed)
+	f.subs.AssertNumberOfCalls(t, "Renew", 2)
+	last := f.subs.Calls[len(f.subs.Calls)-1].Arguments
+	renewedSub := last.Get(0).(*models.Subscription)
+	assert.Equal(t, 3, renewedSub.Cycle)
+	assert.Equal(t, time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC), last.Get(1))
+	assert.Equal(t, time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC), renewedSub.CurrentPeriodEnd)
+}
+
+func TestBillingService_RenewDue_EndsCanceled(t *testing.T) {
+	f := newBillingFixture()
+	plan := f.plan(0)
+	sub := *models.NewSubscription("cus_1", plan, f.now)
+	periodEnd := sub.CurrentPeriodEnd
+	sub.CancelAtPeriodEnd = true
+	f.now = periodEnd.Add(time.Minute)
+	f.subs.On("ListDue", f.now, 10).Return([]models.Subscription{sub}, nil)
+	f.plans.On("GetByIDAsOf", plan.ID, sub.CurrentPeriodStart).Return(plan, nil)
+	f.subs.On("Renew", mock.AnythingOfType("*models.Subscription"), periodEnd, plan, (*models.Invoice)(nil)).Return(true, nil)
+
+	renewed, err := f.service.RenewDue(context.Background(), f.now, 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, renewed)
+	ended := f.subs.Calls[len(f.subs.Calls)-1].Arguments.Get(0).(*models.Subscription)
+	assert.Equal(t, models.SubscriptionCanceled, ended.Status)
+	assert.Equal(t, periodEnd, *ended.EndedAt, "the subscription ends when its paid period does")
+	f.subs.AssertNumberOfCalls(t, "Renew", 1)
+}
+
+func TestBillingService_RenewDue_PendingPlan(t *testing.T) {
+	f := newBillingFixture()
+	plan := f.plan(0)
+	annualPlan := f.plan(0)
+	annualPlan.Interval = models.BillingInterval{Unit: models.IntervalYear, Count: 1}
+	sub := *models.NewSubscription("cus_1", plan, f.now)
+	sub.PendingPlanID = &annualPlan.ID
+	periodEnd := sub.CurrentPeriodEnd
+	f.now = periodEnd
+	f.subs.On("ListDue", f.now, 10).Return([]models.Subscription{sub}, nil)
+	f.plans.On("GetByIDAsOf", plan.ID, sub.CurrentPeriodStart).Return(plan, nil)
+	f.subs.On("Renew", mock.AnythingOfType("*models.Subscription"), periodEnd, annualPlan, mock.AnythingOfType("*models.Invoice")).Return(true, nil)
+
+	_, err := f.service.RenewDue(context.Background(), f.now, 10)
+
+	assert.NoError(t, err)
+	switched := f.subs.Calls[len(f.subs.Calls)-1].Arguments.Get(0).(*models.Subscription)
+	assert.Equal(t, annualPlan.ID, switched.PlanID)
+	assert.Nil(t, switched.PendingPlanID)
+	assert.Equal(t, time.Date(2025, 2, 28, 12, 0, 0, 0, time.UTC), switched.CurrentPeriodEnd)
+}
+
+func TestBillingService_RenewDue_LostRace(t *testing.T) {
+	f := newBillingFixture()
+	plan := f.plan(0)
+	sub := *models.NewSubscription("cus_1", plan, f.now)
+	f.now = sub.CurrentPeriodEnd
+	f.subs.On("ListDue", f.now, 10).Return([]models.Subscription{sub}, nil)
+	f.plans.On("GetByIDAsOf", plan.ID, sub.CurrentPeriodStart).Return(plan, nil)
+	f.subs.On("Renew", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+
+	renewed, err := f.service.RenewDue(context.Background(), f.now, 10)
+
+	assert.NoError(t, err)
+	assert.Zero(t, renewed, "another replica renewed it first")
+	f.subs.AssertNumberOfCalls(t, "Renew", 1)
+}
+
+func TestBillingService_RenewDue_FailureDoesNotBlockOthers(t *testing.T) {
+	f := newBillingFixture()
+	plan := f.plan(0)
+	broken := *models.NewSubscription("cus_1", plan, f.now)
+	broken.ID = uuid.New()
+	sub := *models.NewSubscription("cus_2", plan, f.now)
+	sub.ID = uuid.New()
+	f.now = sub.CurrentPeriodEnd
+	f.subs.On("ListDue", f.now, 10).Return([]models.Subscription{broken, sub}, nil)
+	f.plans.On("GetByIDAsOf", plan.ID, sub.CurrentPeriodStart).Return(plan, nil)
+	f.subs.On("Renew", mock.MatchedBy(func(s *models.Subscription) bool { return s.ID == broken.ID }), mock.Anything, mock.Anything, mock.Anything).
+		Return(false, errors.New("database is locked"))
+	f.subs.On("Renew", mock.MatchedBy(func(s *models.Subscription) bool { return s.ID == sub.ID }), mock.Anything, mock.Anything, mock.Anything).
+		Return(true, nil)
+
+	renewed, err := f.service.RenewDue(context.Background(), f.now, 10)
+
+	assert.Equal(t, 1, renewed, "the failing subscription does not hold up the next one")
+	assert.ErrorContains(t, err, broken.ID.String())
+	assert.ErrorContains(t, err, "database is locked")
+	f.subs.AssertNumberOfCalls(t, "Renew", 2)
+}
+
+func TestBillingService_Subscribe_Coupon(t *testing.T) {
+	f := newBillingFixture()
+	plan := f.plan(0)
+	coupon := &models.Coupon{ID: uuid.New(), Code: "LAUNCH25", PercentOff: 25, Duration: models.CouponOnce}
+	f.coupons.On("GetByCode", "LAUNCH25").Return(coupon, nil)
+	f.coupons.On("GetByIDWithDeleted", coupon.ID).Return(coupon, nil)
+	f.subs.On("List", "cus_1", plan.ID, "", 0, 0).Return([]models.Subscription{}, int64(0), nil)
+	f.subs.On("Create", mock.AnythingOfType("*models.Subscription"), mock.Anything).Return(nil).Once()
+
+	sub, err := f.service.Subscribe(context.Background(), "cus_1", plan.ID.String(), "launch25", TaxLocation{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, coupon.ID, *sub.CouponID)
+
+	// Someone else took the last redemption between the check and the insert.
+	f.subs.On("Create", mock.AnythingOfType("*models.Subscription"), mock.Anything).Return(apperrors.ErrCouponExhausted)
+	_, err = f.service.Subscribe(context.Background(), "cus_1", plan.ID.String(), "LAUNCH25", TaxLocation{})
+	assert.True(t, apperrors.IsValidationError(err))
+	assert.Contains(t, err.Error(), "coupon has reached its maximum redemptions")
+}
+
+func TestBillingService_Subscribe_RejectedCoupon(t *testing.T) {
+	f := newBillingFixture()
+	plan := f.plan(0)
+	f.coupons.On("GetByCode", "OTHERPLAN").Return(&models.Coupon{ID: uuid.New(), PercentOff: 25, Duration: models.CouponOnce, PlanIDs: []uuid.UUID{uuid.New()}}, nil)
+	f.subs.On("List", "cus_1", plan.ID, "", 0, 0).Return([]models.Subscription{}, int64(0), nil)
+
+	_, err := f.service.Subscribe(context.Background(), "cus_1", plan.ID.String(), "OTHERPLAN", TaxLocation{})
+
+	assert.Contains(t, err.Error(), "coupon does not apply to this plan")
+	f.subs.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestBillingService_Subscribe_Invoice(t *testing.T) {
+	f := newBillingFixture()
+	rates, err := tax.NewTable([]tax.Rate{{Country: "GB", Rate: 0.2}})
+	assert.NoError(t, err)
+	f.service = NewBillingService(f.subs, f.plans, f.products, f.coupons, f.usage, WithClock(func() time.Time { return f.now }), WithTaxCalculator(rates))
+	plan := f.plan(0)
+	coupon := &models.Coupon{ID: uuid.New(), Code: "LAUNCH25", PercentOff: 25, Duration: models.CouponOnce}
+	f.coupons.On("GetByCode", "LAUNCH25").Return(coupon, nil)
+	f.coupons.On("GetByIDWithDeleted", coupon.ID).Return(coupon, nil)
+	f.subs.On("List", "cus_1", plan.ID, "", 0, 0).Return([]models.Subscription{}, int64(0), nil)
+	f.subs.On("Create", mock.AnythingOfType("*models.Subscription"), mock.AnythingOfType("*models.Invoice")).Return(nil)
+
+	sub, err := f.service.Subscribe(context.Background(), "cus_1", plan.ID.String(), "LAUNCH25", TaxLocation{Country: "gb"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "GB", sub.TaxCountry)
+	assert.Equal(t, 1, sub.DiscountedCycles)
+	invoice := f.subs.Calls[len(f.subs.Calls)-1].Arguments.Get(1).(*models.Invoice)
+	assert.Equal(t, models.InvoiceOpen, invoice.Status)
+	assert.Equal(t, f.now, *invoice.IssuedAt)
+	assert.Equal(t, []string{models.LineItemPlan, models.LineItemDiscount, models.LineItemTax}, lineItemKinds(invoice.LineItems))
+	assert.Equal(t, 29.99, invoice.Subtotal)
+	assert.Equal(t, 7.5, invoice.Discount)
+	assert.Equal(t, 4.5, invoice.Tax)
+	assert.Equal(t, 26.99, invoice.Total)
+}
+
+func TestBillingService_Subscribe_TrialIsNotInvoiced(t *testing.T) {
+	f := newBillingFixture()
+	plan := f.plan(14)
+	f.subs.On("List", "cus_1", plan.ID, "", 0, 0).Return([]models.Subscription{}, int64(0), nil)
+	f.subs.On("Create", mock.AnythingOfType("*models.Subscription"), (*models.Invoice)(nil)).Return(nil)
+
+	_, err := f.service.Subscribe(context.Background(), "cus_1", plan.ID.String(), "", TaxLocation{})
+
+	assert.NoError(t, err)
+	f.subs.AssertExpectations(t)
+
+	_, err = f.service.Subscribe(context.Background(), "cus_1", plan.ID.String(), "", TaxLocation{Region: "CA"})
+	assert.Contains(t, err.Error(), "country is required with a region")
+}
+
+func TestBillingService_RenewDue_InvoicesUsage(t *testing.T) {
+	f := newBillingFixture()
+	plan := meteredPlan(models.AggregationSum)
+	sub := *models.NewSubscription("cus_1", plan, f.now)
+	periodStart, periodEnd := sub.CurrentPeriodStart, sub.CurrentPeriodEnd
+	f.now = periodEnd
+	f.subs.On("ListDue", f.now, 10).Return([]models.Subscription{sub}, nil)
+	f.plans.On("GetByIDAsOf", plan.ID, periodStart).Return(plan, nil)
+	f.plans.On("GetByID", plan.ID).Return(plan, nil)
+	f.products.On("GetByID", plan.ProductID).Return(&models.Product{ID: plan.ProductID}, nil)
+	f.usage.On("Aggregate", sub.ID, models.AggregationSum, periodStart, periodEnd).Return(int64(1500), int64(3), nil)
+	f.subs.On("Renew", mock.AnythingOfType("*models.Subscription"), periodEnd, plan, mock.AnythingOfType("*models.Invoice")).Return(true, nil)
+
+	renewed, err := f.service.RenewDue(context.Background(), f.now, 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, renewed)
+	invoice := f.subs.Calls[len(f.subs.Calls)-1].Arguments.Get(3).(*models.Invoice)
+	assert.Equal(t, []string{models.LineItemUsage}, lineItemKinds(invoice.LineItems), "metered plans are billed in arrears only")
+	assert.Contains(t, invoice.LineItems[0].Description, "1500 api_call")
+	assert.Equal(t, periodStart, invoice.LineItems[0].PeriodStart)
+	assert.Equal(t, 12.5, invoice.Total)
+}
+
+func TestBillingService_RenewDue_DiscountRunsOut(t *testing.T) {
+	f := newBillingFixture()
+	plan := f.plan(0)
+	coupon := &models.Coupon{ID: uuid.New(), Code: "TWICE", AmountOff: 10, Duration: models.CouponRepeating, DurationCycles: 2}
+	f.coupons.On("GetByIDWithDeleted", coupon.ID).Return(coupon, nil)
+	sub := *models.NewSubscription("cus_1", plan, f.now)
+	sub.CouponID = &coupon.ID
+	sub.DiscountedCycles = 1
+	f.now = time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	f.subs.On("ListDue", f.now, 10).Return([]models.Subscription{sub}, nil)
+	f.plans.On("GetByIDAsOf", plan.ID, mock.AnythingOfType("time.Time")).Return(plan, nil)
+	f.subs.On("Renew", mock.AnythingOfType("*models.Subscription"), mock.AnythingOfType("time.Time"), plan, mock.AnythingOfType("*models.Invoice")).Return(true, nil)
+
+	_, err := f.service.RenewDue(context.Background(), f.now, 10)
+
+	assert.NoError(t, err)
+	f.subs.AssertNumberOfCalls(t, "Renew", 2)
+	var totals []float64
+	for _, call := range f.subs.Calls {
+		if call.Method == "Renew" {
+			totals = append(totals, call.Arguments.Get(3).(*models.Invoice).Total)
+		}
+	}
+	assert.Equal(t, []float64{19.99, 29.99}, totals, "the coupon lasts for two cycles")
+}
+
+func TestBillingService_ChangeSubscriptionPlan_CreditsDiscountedPeriod(t *testing.T) {
+	f := newBillingFixture()
+	f.now = time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	basic := f.plan(0)
+	basic.Price = 100
+	pro := f.plan(0)
+	pro.Price = 100
+	coupon := &models.Coupon{ID: uuid.New(), Code: "HALF", PercentOff: 50, Duration: models.CouponForever}
+	f.coupons.On("GetByCode", "HALF").Return(coupon, nil)
+	f.coupons.On("GetByIDWithDeleted", coupon.ID).Return(coupon, nil)
+	f.subs.On("List", "cus_1", basic.ID, "", 0, 0).Return([]models.Subscription{}, int64(0), nil)
+	f.subs.On("Create", mock.AnythingOfType("*models.Subscription"), mock.AnythingOfType("*models.Invoice")).Return(nil)
+
+	sub, err := f.service.Subscribe(context.Background(), "cus_1", basic.ID.String(), "HALF", TaxLocation{})
+	assert.NoError(t, err)
+	assert.Equal(t, 50.0, sub.PeriodDiscount)
+
+	sub.ID = uuid.New()
+	f.now = time.Date(2024, 4, 16, 0, 0, 0, 0, time.UTC)
+	f.subs.On("GetByID", sub.ID).Return(sub, nil)
+	f.subs.On("Update", sub, mock.AnythingOfType("*models.Invoice")).Return(nil)
+	f.currentPlan(sub, basic)
+
+	change, err := f.service.ChangeSubscriptionPlan(context.Background(), sub.ID.String(), pro.ID.String(), false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, -25.0, change.LineItems[0].Amount, "half of the $50 paid is unused")
+	assert.Equal(t, 50.0, change.LineItems[1].Amount)
+}
+
+func lineItemKinds(items []models.LineItem) []string {
+	kinds := make([]string, len(items))
+	for i, item := range items {
+		kinds[i] = item.Kind
+	}
+	return kinds
+}
@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/repository"
+	"github.com/microservice-go/product-service/internal/tax"
+)
+
+const (
+	maxCouponCodeLength   = 64
+	maxCouponRestrictions = 100
+)
+
+var (
+	couponCodePattern = regexp.MustCompile(`^[A-Z0-9_-]+$`)
+	currencyPattern   = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// CouponService manages discount coupons and quotes plan prices with them.
+type CouponService interface {
+	CreateCoupon(ctx context.Context, coupon models.Coupon) (*models.Coupon, error)
+	GetCoupon(ctx context.Context, id string) (*models.Coupon, error)
+	ListCoupons(ctx context.Context, page, pageSize int) ([]models.Coupon, int64, error)
+	UpdateCoupon(ctx context.Context, id string, coupon models.Coupon) (*models.Coupon, error)
+	DeleteCoupon(ctx context.Context, id string) error
+	QuotePrice(ctx context.Context, planID, couponCode string, location TaxLocation) (*PriceQuote, error)
+}
+
+// PriceQuote is the price of a plan's first billing cycle with a coupon
+// applied. A coupon that cannot be used leaves the price undiscounted and
+// lists why in Rejections. DiscountCycles is how many cycles the discount
+// lasts, 0 meaning all of them. Tax splits Amount into its net, tax and
+// gross parts at the quoted location.
+type PriceQuote struct {
+	PlanID         uuid.UUID
+	CouponCode     string
+	Currency       string
+	Price          float64
+	Discount       float64
+	Amount         float64
+	DiscountCycles int
+	Rejections     []string
+	Tax            tax.Result
+}
+
+type couponService struct {
+	repo        repository.CouponRepository
+	productRepo repository.ProductRepository
+	planRepo    repository.SubscriptionRepository
+	opts        options
+}
+
+func NewCouponService(repo repository.CouponRepository, productRepo repository.ProductRepository, planRepo repository.SubscriptionRepository, opts ...Option) CouponService {
+	return &couponService{
+		repo:        repo,
+		productRepo: productRepo,
+		planRepo:    planRepo,
+		opts:        newOptions(opts),
+	}
+}
+
+func (s *couponService) CreateCoupon(ctx context.Context, coupon models.Coupon) (*models.Coupon, error) {
+	coupon.Code = normalizeCouponCode(coupon.Code)
+	if err := validateCouponCode(coupon.Code); err != nil {
+		return nil, err
+	}
+	if err := s.validateCoupon(ctx, &coupon); err != nil {
+		return nil, err
+	}
+	if coupon.ExpiresAt != nil && !coupon.ExpiresAt.After(s.opts.now()) {
+		return nil, apperrors.NewValidationError("expiresAt", "expiry must be in the future")
+	}
+
+	repo := s.repo.WithContext(ctx)
+	if _, err := repo.GetByCode(coupon.Code); err == nil {
+		return nil, apperrors.NewValidationError("code", "a coupon with this code already exists")
+	}
+
+	coupon.ID = uuid.Nil
+	coupon.TimesRedeemed = 0
+	if err := repo.Create(&coupon); err != nil {
+		return nil, apperrors.NewDatabaseError("create coupon", err)
+	}
+
+	return &coupon, nil
+}
+
+func (s *couponService) GetCoupon(ctx context.Context, id string) (*models.Coupon, error) {
+	couponID, err := parseCouponID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	coupon, err := s.repo.WithContext(ctx).GetByID(couponID)
+	if err != nil {
+		return nil, apperrors.NewNotFoundError("Coupon", id)
+	}
+
+	return coupon, nil
+}
+
+func (s *couponService) ListCoupons(ctx context.Context, page, pageSize int) ([]models.Coupon, int64, error) {
+	coupons, total, err := s.repo.WithContext(ctx).List(normalizePage(page), normalizePageSize(pageSize))
+	if err != nil {
+		return nil, 0, apperrors.NewDatabaseError("list coupons", err)
+	}
+
+	return coupons, total, nil
+}
+
+// UpdateCoupon replaces the terms, limits and restrictions of a coupon; its
+// code cannot change. Once a coupon has been redeemed its discount is fixed,
+// so only the limits and restrictions can still be changed.
+func (s *couponService) UpdateCoupon(ctx context.Context, id string, coupon models.Coupon) (*models.Coupon, error) {
+	existing, err := s.GetCoupon(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateCoupon(ctx, &coupon); err != nil {
+		return nil, err
+	}
+
+	if existing.TimesRedeemed > 0 {
+		if coupon.PercentOff != existing.PercentOff || coupon.AmountOff != existing.AmountOff ||
+			coupon.Currency != existing.Currency || coupon.Duration != existing.Duration ||
+			coupon.DurationCycles != existing.DurationCycles {
+			return nil, apperrors.NewValidationError("coupon", "the discount of a redeemed coupon cannot change")
+		}
+		if coupon.MaxRedemptions > 0 && coupon.MaxRedemptions < existing.TimesRedeemed {
+			return nil, apperrors.NewValidationError("maxRedemptions", "max redemptions cannot be below the times already redeemed")
+		}
+	}
+
+	coupon.ID = existing.ID
+	if err := s.repo.WithContext(ctx).Update(&coupon); err != nil {
+		return nil, apperrors.NewDatabaseError("update coupon", err)
+	}
+
+	return &coupon, nil
+}
+
+func (s *couponService) DeleteCoupon(ctx context.Context, id string) error {
+	couponID, err := parseCouponID(id)
+	if err != nil {
+		return err
+	}
+
+	repo := s.repo.WithContext(ctx)
+	if _, err := repo.GetByID(couponID); err != nil {
+		return apperrors.NewNotFoundError("Coupon", id)
+	}
+
+	if err := repo.Delete(couponID); err != nil {
+		return apperrors.NewDatabaseError("delete coupon", err)
+	}
+
+	return nil
+}
+
+// QuotePrice prices the first billing cycle of plan with the coupon. Problems
+// with the coupon do not fail the quote; they are returned as rejections and
+// the price is left undiscounted.
+func (s *couponService) QuotePrice(ctx context.Context, planID, couponCode string, location TaxLocation) (*PriceQuote, error) {
+	id, err := parsePlanID(planID)
+	if err != nil {
+		return nil, err
+	}
+	location, err = normalizeTaxLocation(location)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := s.planRepo.WithContext(ctx).GetByID(id)
+	if err != nil {
+		return nil, apperrors.NewNotFoundError("SubscriptionPlan", planID)
+	}
+
+	price := plan.CyclePrice(1)
+	quote := &PriceQuote{
+		PlanID:     plan.ID,
+		CouponCode: normalizeCouponCode(couponCode),
+		Currency:   s.opts.currency,
+		Price:      price,
+		Amount:     price,
+	}
+	if quote.CouponCode != "" {
+		s.applyCoupon(ctx, quote, plan)
+	}
+
+	quote.Tax, err = calculateTax(ctx, s.opts, location, plan.Product, quote.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return quote, nil
+}
+
+func (s *couponService) applyCoupon(ctx context.Context, quote *PriceQuote, plan *models.SubscriptionPlan) {
+	coupon, err := s.repo.WithContext(ctx).GetByCode(quote.CouponCode)
+	if err != nil {
+		quote.Rejections = []string{"coupon code is not valid"}
+		return
+	}
+
+	quote.Rejections = couponRejections(coupon, plan, s.opts)
+	if len(quote.Rejections) == 0 {
+		quote.Discount = coupon.Discount(quote.Price)
+		quote.Amount = models.RoundCents(quote.Price - quote.Discount)
+		quote.DiscountCycles = coupon.Cycles()
+	}
+}
+
+// couponRejections lists the reasons coupon cannot be used on plan now.
+func couponRejections(coupon *models.Coupon, plan *models.SubscriptionPlan, opts options) []string {
+	var reasons []string
+	if coupon.IsExpired(opts.now()) {
+		reasons = append(reasons, "coupon has expired")
+	}
+	if coupon.IsExhausted() {
+		reasons = append(reasons, apperrors.ErrCouponExhausted.Error())
+	}
+	if !coupon.AppliesTo(plan) {
+		reasons = append(reasons, "coupon does not apply to this plan")
+	}
+	if coupon.AmountOff > 0 && coupon.Currency != opts.currency {
+		reasons = append(reasons, fmt.Sprintf("coupon currency %s does not match price currency %s", coupon.Currency, opts.currency))
+	}
+	return reasons
+}
+
+func (s *couponService) validateCoupon(ctx context.Context, coupon *models.Coupon) error {
+	coupon.Currency = strings.ToUpper(coupon.Currency)
+
+	switch {
+	case coupon.PercentOff == 0 && coupon.AmountOff == 0,
+		coupon.PercentOff != 0 && coupon.AmountOff != 0:
+		return apperrors.NewValidationError("percentOff", "exactly one of percent off or amount off must be set")
+	case coupon.PercentOff < 0 || coupon.PercentOff > 100:
+		return apperrors.NewValidationError("percentOff", "percent off must be between 0 and 100")
+	case coupon.AmountOff < 0:
+		return apperrors.NewValidationError("amountOff", "amount off cannot be negative")
+	case coupon.AmountOff > 0 && coupon.Currency == "":
+		return apperrors.NewValidationError("currency", "currency is required for amount off coupons")
+	case coupon.PercentOff > 0 && coupon.Currency != "":
+		return apperrors.NewValidationError("currency", "currency only applies to amount off coupons")
+	case coupon.Currency != "" && !currencyPattern.MatchString(coupon.Currency):
+		return apperrors.NewValidationError("currency", "currency must be a three-letter ISO 4217 code")
+	}
+
+	switch coupon.Duration {
+	case models.CouponRepeating:
+		if coupon.DurationCycles <= 0 {
+			return apperrors.NewValidationError("durationCycles", "repeating coupons need a positive number of cycles")
+		}
+	case models.CouponOnce, models.CouponForever:
+		if coupon.DurationCycles != 0 {
+			return apperrors.NewValidationError("durationCycles", "duration cycles only apply to repeating coupons")
+		}
+	default:
+		return apperrors.NewValidationError("duration", "duration must be once, repeating or forever")
+	}
+
+	if coupon.MaxRedemptions < 0 {
+		return apperrors.NewValidationError("maxRedemptions", "max redemptions cannot be negative")
+	}
+	if len(coupon.ProductIDs) > maxCouponRestrictions || len(coupon.PlanIDs) > maxCouponRestrictions {
+		return apperrors.NewValidationError("productIds", fmt.Sprintf("a coupon can be restricted to at most %d products and %d plans", maxCouponRestrictions, maxCouponRestrictions))
+	}
+
+	if len(coupon.ProductIDs) > 0 {
+		products, err := s.productRepo.WithContext(ctx).GetByIDs(coupon.ProductIDs)
+		if err != nil {
+			return apperrors.NewDatabaseError("get products", err)
+		}
+		found := make(map[uuid.UUID]bool, len(products))
+		for _, product := range products {
+			found[product.ID] = true
+		}
+		if err := requireFound("Product", coupon.ProductIDs, found); err != nil {
+			return err
+		}
+	}
+	if len(coupon.PlanIDs) > 0 {
+		plans, err := s.planRepo.WithContext(ctx).GetByIDs(coupon.PlanIDs)
+		if err != nil {
+			return apperrors.NewDatabaseError("get subscription plans", err)
+		}
+		found := make(map[uuid.UUID]bool, len(plans))
+		for _, plan := range plans {
+			found[plan.ID] = true
+		}
+		if err := requireFound("SubscriptionPlan", coupon.PlanIDs, found); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// requireFound reports the first of ids missing from found.
+func requireFound(resource string, ids []uuid.UUID, found map[uuid.UUID]bool) error {
+	for _, id := range ids {
+		if !found[id] {
+			return apperrors.NewNotFoundError(resource, id.String())
+		}
+	}
+	return nil
+}
+
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func validateCouponCode(code string) error {
+	if code == "" {
+		return apperrors.NewValidationError("code", "coupon code is required")
+	}
+	if len(code) > maxCouponCodeLength {
+		return apperrors.NewValidationError("code", fmt.Sprintf("coupon code must be at most %d characters", maxCouponCodeLength))
+	}
+	if !couponCodePattern.MatchString(code) {
+		return apperrors.NewValidationError("code", "coupon code may only contain letters, digits, '-' and '_'")
+	}
+	return nil
+}
+
+func parseCouponID(id string) (uuid.UUID, error) {
+	if id == "" {
+		return uuid.Nil, apperrors.NewValidationError("id", "coupon ID is required")
+	}
+
+	couponID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, apperrors.NewValidationError("id", "invalid coupon ID format")
+	}
+
+	return couponID, nil
+}
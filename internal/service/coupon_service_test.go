@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/repository"
+	"github.com/microservice-go/product-service/internal/tax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCouponRepository struct {
+	mock.Mock
+}
+
+func (m *MockCouponRepository) Create(coupon *models.Coupon) error {
+	args := m.Called(coupon)
+	return args.Error(0)
+}
+
+func (m *MockCouponRepository) GetByID(id uuid.UUID) (*models.Coupon, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Coupon), args.Error(1)
+}
+
+func (m *MockCouponRepository) GetByIDWithDeleted(id uuid.UUID) (*models.Coupon, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Coupon), args.Error(1)
+}
+
+func (m *MockCouponRepository) GetByCode(code string) (*models.Coupon, error) {
+	args := m.Called(code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Coupon), args.Error(1)
+}
+
+func (m *MockCouponRepository) List(page, pageSize int) ([]models.Coupon, int64, error) {
+	args := m.Called(page, pageSize)
+	return args.Get(0).([]models.Coupon), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockCouponRepository) Update(coupon *models.Coupon) error {
+	args := m.Called(coupon)
+	return args.Error(0)
+}
+
+func (m *MockCouponRepository) Delete(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockCouponRepository) WithContext(ctx context.Context) repository.CouponRepository {
+	return m
+}
+
+type couponFixture struct {
+	coupons  *MockCouponRepository
+	plans    *MockSubscriptionRepository
+	products *MockProductRepositoryForSubscription
+	service  CouponService
+	now      time.Time
+}
+
+func newCouponFixture() *couponFixture {
+	f := &couponFixture{
+		coupons:  new(MockCouponRepository),
+		plans:    new(MockSubscriptionRepository),
+		products: new(MockProductRepositoryForSubscription),
+		now:      time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC),
+	}
+	f.service = NewCouponService(f.coupons, f.products, f.plans, WithClock(func() time.Time { return f.now }))
+	return f
+}
+
+func TestCouponService_CreateCoupon(t *testing.T) {
+	f := newCouponFixture()
+	f.coupons.On("GetByCode", "LAUNCH25").Return(nil, errors.New("coupon not found"))
+	f.coupons.On("Create", mock.AnythingOfType("*models.Coupon")).Return(nil)
+
+	coupon, err := f.service.CreateCoupon(context.Background(), models.Coupon{Code: " launch25 ", PercentOff: 25, Duration: models.CouponOnce})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "LAUNCH25", coupon.Code)
+	f.coupons.AssertExpectations(t)
+}
+
+func TestCouponService_CreateCoupon_Validation(t *testing.T) {
+	expired := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		coupon  models.Coupon
+		wantErr string
+	}{
+		{"missing code", models.Coupon{PercentOff: 10, Duration: models.CouponOnce}, "coupon code is required"},
+		{"bad code", models.Coupon{Code: "10% OFF", PercentOff: 10, Duration: models.CouponOnce}, "coupon code may only contain"},
+		{"no discount", models.Coupon{Code: "A", Duration: models.CouponOnce}, "exactly one of percent off or amount off must be set"},
+		{"both discounts", models.Coupon{Code: "A", PercentOff: 10, AmountOff: 5, Currency: "USD", Duration: models.CouponOnce}, "exactly one of percent off or amount off must be set"},
+		{"percent too high", models.Coupon{Code: "A", PercentOff: 120, Duration: models.CouponOnce}, "percent off must be between 0 and 100"},
+		{"amount without currency", models.Coupon{Code: "A", AmountOff: 5, Duration: models.CouponOnce}, "currency is required for amount off coupons"},
+		{"bad currency", models.Coupon{Code: "A", AmountOff: 5, Currency: "dollars", Duration: models.CouponOnce}, "three-letter ISO 4217 code"},
+		{"percent with currency", models.Coupon{Code: "A", PercentOff: 10, Currency: "USD", Duration: models.CouponOnce}, "currency only applies to amount off coupons"},
+		{"bad duration", models.Coupon{Code: "A", PercentOff: 10, Duration: "weekly"}, "duration must be once, repeating or forever"},
+		{"repeating without cycles", models.Coupon{Code: "A", PercentOff: 10, Duration: models.CouponRepeating}, "repeating coupons need a positive number of cycles"},
+		{"cycles on forever", models.Coupon{Code: "A", PercentOff: 10, Duration: models.CouponForever, DurationCycles: 3}, "duration cycles only apply to repeating coupons"},
+		{"negative max", models.Coupon{Code: "A", PercentOff: 10, Duration: models.CouponOnce, MaxRedemptions: -1}, "max redemptions cannot be negative"},
+		{"expired", models.Coupon{Code: "A", PercentOff: 10, Duration: models.CouponOnce, ExpiresAt: &expired}, "expiry must be in the future"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newCouponFixture()
+
+			coupon, err := f.service.CreateCoupon(context.Background(), tt.coupon)
+
+			assert.Nil(t, coupon)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			f.coupons.AssertNotCalled(t, "Create", mock.Anything)
+		})
+	}
+}
+
+func TestCouponService_CreateCoupon_UnknownRestriction(t *testing.T) {
+	f := newCouponFixture()
+	planID := uuid.New()
+	f.plans.On("GetByIDs", []uuid.UUID{planID}).Return([]models.SubscriptionPlan{}, nil)
+
+	_, err := f.service.CreateCoupon(context.Background(), models.Coupon{Code: "A", PercentOff: 10, Duration: models.CouponOnce, PlanIDs: []uuid.UUID{planID}})
+
+	assert.True(t, apperrors.IsNotFoundError(err))
+}
+
+func TestCouponService_UpdateCoupon_Redeemed(t *testing.T) {
+	f := newCouponFixture()
+	existing := &models.Coupon{ID: uuid.New(), Code: "A", PercentOff: 10, Duration: models.CouponOnce, TimesRedeemed: 5}
+	f.coupons.On("GetByID", existing.ID).Return(existing, nil)
+	f.coupons.On("Update", mock.AnythingOfType("*models.Coupon")).Return(nil)
+
+	_, err := f.service.UpdateCoupon(context.Background(), existing.ID.String(), models.Coupon{PercentOff: 50, Duration: models.CouponOnce})
+	assert.Contains(t, err.Error(), "the discount of a redeemed coupon cannot change")
+
+	_, err = f.service.UpdateCoupon(context.Background(), existing.ID.String(), models.Coupon{PercentOff: 10, Duration: models.CouponOnce, MaxRedemptions: 3})
+	assert.Contains(t, err.Error(), "max redemptions cannot be below the times already redeemed")
+
+	updated, err := f.service.UpdateCoupon(context.Background(), existing.ID.String(), models.Coupon{PercentOff: 10, Duration: models.CouponOnce, MaxRedemptions: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, existing.ID, updated.ID)
+	assert.Equal(t, 10, updated.MaxRedemptions)
+	f.coupons.AssertNumberOfCalls(t, "Update", 1)
+}
+
+func TestCouponService_QuotePrice(t *testing.T) {
+	f := newCouponFixture()
+	plan := &models.SubscriptionPlan{ID: uuid.New(), ProductID: uuid.New(), Price: 29.99}
+	f.plans.On("GetByID", plan.ID).Return(plan, nil)
+	f.coupons.On("GetByCode", "LAUNCH25").Return(&models.Coupon{Code: "LAUNCH25", PercentOff: 25, Duration: models.CouponRepeating, DurationCycles: 3}, nil)
+
+	quote, err := f.service.QuotePrice(context.Background(), plan.ID.String(), "launch25", TaxLocation{})
+
+	assert.NoError(t, err)
+	assert.Empty(t, quote.Rejections)
+	assert.Equal(t, "USD", quote.Currency)
+	assert.Equal(t, 29.99, quote.Price)
+	assert.Equal(t, 7.5, quote.Discount)
+	assert.Equal(t, 22.49, quote.Amount)
+	assert.Equal(t, 3, quote.DiscountCycles)
+	assert.Equal(t, tax.Result{Net: 22.49, Gross: 22.49}, quote.Tax, "no tax without a country")
+}
+
+func TestCouponService_QuotePrice_Tax(t *testing.T) {
+	f := newCouponFixture()
+	rates, err := tax.NewTable([]tax.Rate{{Country: "GB", Rate: 0.2}})
+	assert.NoError(t, err)
+	f.service = NewCouponService(f.coupons, f.products, f.plans, WithClock(func() time.Time { return f.now }), WithTaxCalculator(rates))
+	exclusive := &models.SubscriptionPlan{ID: uuid.New(), Price: 29.99, Product: models.Product{ProductType: "digital"}}
+	inclusive := &models.SubscriptionPlan{ID: uuid.New(), Price: 29.99, Product: models.Product{ProductType: "digital", TaxInclusive: true}}
+	f.plans.On("GetByID", exclusive.ID).Return(exclusive, nil)
+	f.plans.On("GetByID", inclusive.ID).Return(inclusive, nil)
+	f.coupons.On("GetByCode", "LAUNCH25").Return(&models.Coupon{Code: "LAUNCH25", PercentOff: 25, Duration: models.CouponOnce}, nil)
+
+	quote, err := f.service.QuotePrice(context.Background(), exclusive.ID.String(), "LAUNCH25", TaxLocation{Country: "gb"})
+	assert.NoError(t, err)
+	assert.Equal(t, tax.Result{Net: 22.49, Tax: 4.5, Gross: 26.99, Rate: 0.2}, quote.Tax, "tax is charged on the discounted amount")
+
+	quote, err = f.service.QuotePrice(context.Background(), inclusive.ID.String(), "", TaxLocation{Country: "GB"})
+	assert.NoError(t, err)
+	assert.Equal(t, tax.Result{Net: 24.99, Tax: 5, Gross: 29.99, Rate: 0.2, Inclusive: true}, quote.Tax)
+
+	_, err = f.service.QuotePrice(context.Background(), exclusive.ID.String(), "", TaxLocation{Country: "GBR"})
+	assert.True(t, apperrors.IsValidationError(err))
+	assert.Contains(t, err.Error(), "country must be an ISO 3166-1 alpha-2 code")
+}
+
+func TestCouponService_QuotePrice_Rejections(t *testing.T) {
+	f := newCouponFixture()
+	plan := &models.SubscriptionPlan{ID: uuid.New(), ProductID: uuid.New(), Price: 29.99}
+	expiredAt := f.now.Add(-time.Hour)
+	f.plans.On("GetByID", plan.ID).Return(plan, nil)
+	f.coupons.On("GetByCode", "SPENT").Return(&models.Coupon{
+		Code:           "SPENT",
+		AmountOff:      5,
+		Currency:       "EUR",
+		Duration:       models.CouponOnce,
+		MaxRedemptions: 10,
+		TimesRedeemed:  10,
+		ExpiresAt:      &expiredAt,
+		PlanIDs:        []uuid.UUID{uuid.New()},
+	}, nil)
+	f.coupons.On("GetByCode", "NOPE").Return(nil, errors.New("coupon not found"))
+
+	quote, err := f.service.QuotePrice(context.Background(), plan.ID.String(), "SPENT", TaxLocation{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"coupon has expired",
+		"coupon has reached its maximum redemptions",
+		"coupon does not apply to this plan",
+		"coupon currency EUR does not match price currency USD",
+	}, quote.Rejections)
+	assert.Zero(t, quote.Discount)
+	assert.Equal(t, 29.99, quote.Amount)
+
+	quote, err = f.service.QuotePrice(context.Background(), plan.ID.String(), "NOPE", TaxLocation{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"coupon code is not valid"}, quote.Rejections)
+}
+
+func TestCouponService_QuotePrice_PlanNotFound(t *testing.T) {
+	f := newCouponFixture()
+	planID := uuid.New()
+	f.plans.On("GetByID", planID).Return(nil, errors.New("subscription plan not found"))
+
+	_, err := f.service.QuotePrice(context.Background(), planID.String(), "", TaxLocation{})
+
+	assert.True(t, apperrors.IsNotFoundError(err))
+}
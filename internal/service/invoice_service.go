@@ -0,0 +1,300 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/invoice"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/repository"
+)
+
+// InvoiceService reads the invoices generated for subscriptions and moves
+// them from draft to open to paid, or voids them.
+type InvoiceService interface {
+	GetInvoice(ctx context.Context, id string) (*models.Invoice, error)
+	ListInvoices(ctx context.Context, customerID, subscriptionID, status string, page, pageSize int) ([]models.Invoice, int64, error)
+	FinalizeInvoice(ctx context.Context, id string) (*models.Invoice, error)
+	PayInvoice(ctx context.Context, id string) (*models.Invoice, error)
+	VoidInvoice(ctx context.Context, id string) (*models.Invoice, error)
+	RenderInvoice(ctx context.Context, id string) (*RenderedInvoice, error)
+}
+
+// RenderedInvoice is an invoice rendered as a document to hand to the
+// customer.
+type RenderedInvoice struct {
+	Invoice     *models.Invoice
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+type invoiceService struct {
+	repo repository.InvoiceRepository
+	opts options
+}
+
+func NewInvoiceService(repo repository.InvoiceRepository, opts ...Option) InvoiceService {
+	return &invoiceService{
+		repo: repo,
+		opts: newOptions(opts),
+	}
+}
+
+func (s *invoiceService) GetInvoice(ctx context.Context, id string) (*models.Invoice, error) {
+	invoiceID, err := parseInvoiceID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice, err := s.repo.WithContext(ctx).GetByID(invoiceID)
+	if err != nil {
+		return nil, apperrors.NewNotFoundError("Invoice", id)
+	}
+
+	return invoice, nil
+}
+
+func (s *invoiceService) ListInvoices(ctx context.Context, customerID, subscriptionID, status string, page, pageSize int) ([]models.Invoice, int64, error) {
+	var subID uuid.UUID
+	if subscriptionID != "" {
+		parsed, err := uuid.Parse(subscriptionID)
+		if err != nil {
+			return nil, 0, apperrors.NewValidationError("subscriptionId", "invalid subscription ID format")
+		}
+		subID = parsed
+	}
+	switch status {
+	case "", models.InvoiceDraft, models.InvoiceOpen, models.InvoicePaid, models.InvoiceVoid:
+	default:
+		return nil, 0, apperrors.NewValidationError("status", "status must be draft, open, paid or void")
+	}
+
+	invoices, total, err := s.repo.WithContext(ctx).List(customerID, subID, status, normalizePage(page), normalizePageSize(pageSize))
+	if err != nil {
+		return nil, 0, apperrors.NewDatabaseError("list invoices", err)
+	}
+
+	return invoices, total, nil
+}
+
+// FinalizeInvoice issues a draft, giving it the next invoice number.
+func (s *invoiceService) FinalizeInvoice(ctx context.Context, id string) (*models.Invoice, error) {
+	return s.transition(ctx, id, "only draft invoices can be finalized", func(invoice *models.Invoice, now time.Time) bool {
+		if invoice.Status != models.InvoiceDraft {
+			return false
+		}
+		invoice.Finalize(now)
+		return true
+	})
+}
+
+// PayInvoice records that an open invoice has been paid.
+func (s *invoiceService) PayInvoice(ctx context.Context, id string) (*models.Invoice, error) {
+	return s.transition(ctx, id, "only open invoices can be paid", func(invoice *models.Invoice, now time.Time) bool {
+		if invoice.Status != models.InvoiceOpen {
+			return false
+		}
+		invoice.Pay(now)
+		return true
+	})
+}
+
+// VoidInvoice cancels an invoice that has not been paid. Voided invoices keep
+// their number, so numbering stays without gaps.
+func (s *invoiceService) VoidInvoice(ctx context.Context, id string) (*models.Invoice, error) {
+	return s.transition(ctx, id, "only draft and open invoices can be voided", func(invoice *models.Invoice, now time.Time) bool {
+		if invoice.Status != models.InvoiceDraft && invoice.Status != models.InvoiceOpen {
+			return false
+		}
+		invoice.Void(now)
+		return true
+	})
+}
+
+// transition applies a status change to the invoice, failing with message
+// when apply reports that the invoice's current status does not allow it.
+func (s *invoiceService) transition(ctx context.Context, id, message string, apply func(invoice *models.Invoice, now time.Time) bool) (*models.Invoice, error) {
+	invoice, err := s.GetInvoice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	from := invoice.Status
+	if !apply(invoice, s.opts.now()) {
+		return nil, apperrors.NewValidationError("status", message)
+	}
+
+	updated, err := s.repo.WithContext(ctx).UpdateStatus(invoice, from)
+	if err != nil {
+		return nil, apperrors.NewDatabaseError("update invoice", err)
+	}
+	if !updated {
+		return nil, apperrors.NewValidationError("status", "invoice is no longer "+from)
+	}
+
+	return invoice, nil
+}
+
+// RenderInvoice renders the invoice as an HTML document.
+func (s *invoiceService) RenderInvoice(ctx context.Context, id string) (*RenderedInvoice, error) {
+	inv, err := s.GetInvoice(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := invoice.RenderHTML(&buf, inv); err != nil {
+		return nil, err
+	}
+
+	name := inv.Number
+	if name == "" {
+		name = "draft-" + inv.ID.String()
+	}
+	return &RenderedInvoice{
+		Invoice:     inv,
+		Filename:    name + ".html",
+		ContentType: invoice.ContentTypeHTML,
+		Content:     buf.Bytes(),
+	}, nil
+}
+
+// invoiceBuilder prices the invoices generated when subscriptions start,
+// renew or change plan.
+type invoiceBuilder struct {
+	usageRepo   repository.UsageRepository
+	couponRepo  repository.CouponRepository
+	productRepo repository.ProductRepository
+	opts        options
+}
+
+// build invoices items charged on sub, which is billed on plan from now on.
+// The subscription's coupon discounts the plan and usage charges of the
+// periods it lasts for, and what is left is taxed where the customer is.
+// Unless drafts are kept for review the invoice is finalized.
+func (b *invoiceBuilder) build(ctx context.Context, sub *models.Subscription, plan *models.SubscriptionPlan, items []models.LineItem) (*models.Invoice, error) {
+	inv := models.NewInvoice(sub, b.opts.currency, items)
+	if err := b.discount(ctx, inv, sub); err != nil {
+		return nil, err
+	}
+	if err := b.tax(ctx, inv, plan); err != nil {
+		return nil, err
+	}
+	if !b.opts.draftInvoices {
+		inv.Finalize(b.opts.now())
+	}
+	return inv, nil
+}
+
+// usage returns the charge for what sub used of the metered plan in its
+// current period up to end, or nothing if the plan is not metered or nothing
+// was used. Metered plans are billed in arrears, so this goes on the renewal
+// invoice, or on the invoice of an immediate change to another plan.
+func (b *invoiceBuilder) usage(ctx context.Context, sub *models.Subscription, plan *models.SubscriptionPlan, end time.Time) ([]models.LineItem, error) {
+	if plan.Meter == nil {
+		return nil, nil
+	}
+	summary, err := summarizeUsage(ctx, b.usageRepo, sub, plan, sub.UsagePeriodStart(), end)
+	if err != nil {
+		return nil, err
+	}
+	if summary.Quantity == 0 {
+		return nil, nil
+	}
+	return []models.LineItem{{
+		Kind:        models.LineItemUsage,
+		Description: fmt.Sprintf("%s: %d %s", plan.PlanName, summary.Quantity, plan.Meter.Unit),
+		PlanID:      plan.ID,
+		Amount:      summary.Price.Total,
+		PeriodStart: summary.PeriodStart,
+		PeriodEnd:   summary.PeriodEnd,
+	}}, nil
+}
+
+// discount applies the subscription's coupon to an invoice that bills a
+// period, counting the period on sub and recording the share of the discount
+// that went to the plan charge so a plan change credits only what was paid.
+// Invoices of prorations alone are not discounted.
+func (b *invoiceBuilder) discount(ctx context.Context, inv *models.Invoice, sub *models.Subscription) error {
+	if sub.CouponID == nil {
+		return nil
+	}
+	var base, planCharge float64
+	billsPeriod := false
+	for _, item := range inv.LineItems {
+		if item.Kind == models.LineItemPlan || item.Kind == models.LineItemUsage {
+			base += item.Amount
+			billsPeriod = true
+		}
+		if item.Kind == models.LineItemPlan {
+			planCharge += item.Amount
+		}
+	}
+	if !billsPeriod {
+		return nil
+	}
+
+	coupon, err := b.couponRepo.WithContext(ctx).GetByIDWithDeleted(*sub.CouponID)
+	if err != nil {
+		return apperrors.NewNotFoundError("Coupon", sub.CouponID.String())
+	}
+	if cycles := coupon.Cycles(); cycles > 0 && sub.DiscountedCycles >= cycles {
+		return nil
+	}
+
+	amount := coupon.Discount(models.RoundCents(base))
+	sub.DiscountedCycles++
+	if base > 0 {
+		sub.PeriodDiscount = models.RoundCents(amount * planCharge / base)
+	}
+	inv.AddDiscount("Coupon "+coupon.Code, amount)
+	return nil
+}
+
+// tax taxes the invoice's total as a sale of plan's product. Credits are
+// taxed like charges, with the tax credited back.
+func (b *invoiceBuilder) tax(ctx context.Context, inv *models.Invoice, plan *models.SubscriptionPlan) error {
+	location := TaxLocation{Country: inv.TaxCountry, Region: inv.TaxRegion}
+	result, err := calculateTax(ctx, b.opts, location, b.product(ctx, plan), math.Abs(inv.Total))
+	if err != nil {
+		return err
+	}
+
+	amount := result.Tax
+	if inv.Total < 0 {
+		amount = -amount
+	}
+	inv.SetTax(amount, result.Rate, result.Inclusive)
+	return nil
+}
+
+// product returns the product plan belongs to, or an empty product if it has
+// been deleted.
+func (b *invoiceBuilder) product(ctx context.Context, plan *models.SubscriptionPlan) models.Product {
+	if plan.Product.ID != uuid.Nil {
+		return plan.Product
+	}
+	if product, err := b.productRepo.WithContext(ctx).GetByID(plan.ProductID); err == nil {
+		return *product
+	}
+	return models.Product{}
+}
+
+func parseInvoiceID(id string) (uuid.UUID, error) {
+	if id == "" {
+		return uuid.Nil, apperrors.NewValidationError("id", "invoice ID is required")
+	}
+
+	invoiceID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, apperrors.NewValidationError("id", "invalid invoice ID format")
+	}
+
+	return invoiceID, nil
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockInvoiceRepository struct {
+	mock.Mock
+}
+
+func (m *MockInvoiceRepository) GetByID(id uuid.UUID) (*models.Invoice, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Invoice), args.Error(1)
+}
+
+func (m *MockInvoiceRepository) List(customerID string, subscriptionID uuid.UUID, status string, page, pageSize int) ([]models.Invoice, int64, error) {
+	args := m.Called(customerID, subscriptionID, status, page, pageSize)
+	return args.Get(0).([]models.Invoice), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockInvoiceRepository) UpdateStatus(invoice *models.Invoice, from string) (bool, error) {
+	args := m.Called(invoice, from)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockInvoiceRepository) WithContext(ctx context.Context) repository.InvoiceRepository {
+	return m
+}
+
+func newInvoiceTestService(repo *MockInvoiceRepository, now time.Time) InvoiceService {
+	return NewInvoiceService(repo, WithClock(func() time.Time { return now }))
+}
+
+func TestInvoiceService_Transitions(t *testing.T) {
+	now := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		status     string
+		total      float64
+		apply      func(s InvoiceService, ctx context.Context, id string) (*models.Invoice, error)
+		wantStatus string
+	}{
+		{"finalize", models.InvoiceDraft, 10, InvoiceService.FinalizeInvoice, models.InvoiceOpen},
+		{"finalize nothing due", models.InvoiceDraft, 0, InvoiceService.FinalizeInvoice, models.InvoicePaid},
+		{"pay", models.InvoiceOpen, 10, InvoiceService.PayInvoice, models.InvoicePaid},
+		{"void draft", models.InvoiceDraft, 10, InvoiceService.VoidInvoice, models.InvoiceVoid},
+		{"void open", models.InvoiceOpen, 10, InvoiceService.VoidInvoice, models.InvoiceVoid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockInvoiceRepository)
+			invoice := &models.Invoice{ID: uuid.New(), Status: tt.status, Total: tt.total}
+			repo.On("GetByID", invoice.ID).Return(invoice, nil)
+			repo.On("UpdateStatus", invoice, tt.status).Return(true, nil)
+
+			updated, err := tt.apply(newInvoiceTestService(repo, now), context.Background(), invoice.ID.String())
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, updated.Status)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestInvoiceService_Transitions_Invalid(t *testing.T) {
+	repo := new(MockInvoiceRepository)
+	service := newInvoiceTestService(repo, time.Now())
+	paid := &models.Invoice{ID: uuid.New(), Status: models.InvoicePaid}
+	draft := &models.Invoice{ID: uuid.New(), Status: models.InvoiceDraft}
+	repo.On("GetByID", paid.ID).Return(paid, nil)
+	repo.On("GetByID", draft.ID).Return(draft, nil)
+
+	_, err := service.VoidInvoice(context.Background(), paid.ID.String())
+	assert.True(t, apperrors.IsValidationError(err))
+	assert.Contains(t, err.Error(), "only draft and open invoices can be voided")
+
+	_, err = service.FinalizeInvoice(context.Background(), paid.ID.String())
+	assert.Contains(t, err.Error(), "only draft invoices can be finalized")
+
+	_, err = service.PayInvoice(context.Background(), draft.ID.String())
+	assert.Contains(t, err.Error(), "only open invoices can be paid")
+
+	repo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+}
+
+func TestInvoiceService_Transitions_LostRace(t *testing.T) {
+	repo := new(MockInvoiceRepository)
+	invoice := &models.Invoice{ID: uuid.New(), Status: models.InvoiceOpen, Total: 10}
+	repo.On("GetByID", invoice.ID).Return(invoice, nil)
+	repo.On("UpdateStatus", invoice, models.InvoiceOpen).Return(false, nil)
+
+	_, err := newInvoiceTestService(repo, time.Now()).PayInvoice(context.Background(), invoice.ID.String())
+
+	assert.True(t, apperrors.IsValidationError(err))
+	assert.Contains(t, err.Error(), "invoice is no longer open")
+}
+
+func TestInvoiceService_GetInvoice_Invalid(t *testing.T) {
+	repo := new(MockInvoiceRepository)
+	service := newInvoiceTestService(repo, time.Now())
+	missing := uuid.New()
+	repo.On("GetByID", missing).Return(nil, errors.New("invoice not found"))
+
+	_, err := service.GetInvoice(context.Background(), "")
+	assert.Contains(t, err.Error(), "invoice ID is required")
+
+	_, err = service.GetInvoice(context.Background(), "invalid-uuid")
+	assert.Contains(t, err.Error(), "invalid invoice ID format")
+
+	_, err = service.GetInvoice(context.Background(), missing.String())
+	assert.True(t, apperrors.IsNotFoundError(err))
+}
+
+func TestInvoiceService_ListInvoices(t *testing.T) {
+	repo := new(MockInvoiceRepository)
+	service := newInvoiceTestService(repo, time.Now())
+	subID := uuid.New()
+	repo.On("List", "cus_1", subID, models.InvoiceOpen, 1, 10).Return([]models.Invoice{{ID: uuid.New()}}, int64(1), nil)
+
+	invoices, total, err := service.ListInvoices(context.Background(), "cus_1", subID.String(), models.InvoiceOpen, 0, 0)
+
+	assert.NoError(t, err)
+	assert.Len(t, invoices, 1)
+	assert.Equal(t, int64(1), total)
+
+	_, _, err = service.ListInvoices(context.Background(), "", "invalid-uuid", "", 1, 10)
+	assert.Contains(t, err.Error(), "invalid subscription ID format")
+
+	_, _, err = service.ListInvoices(context.Background(), "", "", "overdue", 1, 10)
+	assert.Contains(t, err.Error(), "status must be draft, open, paid or void")
+}
+
+func TestInvoiceService_RenderInvoice(t *testing.T) {
+	repo := new(MockInvoiceRepository)
+	service := newInvoiceTestService(repo, time.Now())
+	invoice := &models.Invoice{ID: uuid.New(), Number: "INV-000007", Status: models.InvoiceOpen, Currency: "USD", Total: 29.99}
+	draft := &models.Invoice{ID: uuid.New(), Status: models.InvoiceDraft, Currency: "USD"}
+	repo.On("GetByID", invoice.ID).Return(invoice, nil)
+	repo.On("GetByID", draft.ID).Return(draft, nil)
+
+	rendered, err := service.RenderInvoice(context.Background(), invoice.ID.String())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "INV-000007.html", rendered.Filename)
+	assert.True(t, strings.HasPrefix(rendered.ContentType, "text/html"))
+	assert.Contains(t, string(rendered.Content), "29.99 USD")
+
+	rendered, err = service.RenderInvoice(context.Background(), draft.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "draft-"+draft.ID.String()+".html", rendered.Filename)
+}
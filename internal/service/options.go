@@ -0,0 +1,93 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/microservice-go/product-service/internal/constants"
+	"github.com/microservice-go/product-service/internal/events"
+	"github.com/microservice-go/product-service/internal/tax"
+)
+
+type Option func(*options)
+
+type options struct {
+	maxBatchSize  int
+	broker        *events.Broker
+	now           func() time.Time
+	currency      string
+	tax           tax.Calculator
+	draftInvoices bool
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		maxBatchSize: constants.DefaultMaxBatchSize,
+		currency:     constants.DefaultCurrency,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.broker == nil {
+		o.broker = events.NewBroker(constants.DefaultEventBuffer)
+	}
+	if o.now == nil {
+		o.now = time.Now
+	}
+	if o.tax == nil {
+		o.tax, _ = tax.NewTable(nil)
+	}
+	return o
+}
+
+// WithMaxBatchSize bounds the number of items accepted by a single batch call.
+// Non-positive values keep the default.
+func WithMaxBatchSize(size int) Option {
+	return func(o *options) {
+		if size > 0 {
+			o.maxBatchSize = size
+		}
+	}
+}
+
+// WithEventBroker sets the broker watch streams subscribe from. The outbox
+// relay is responsible for publishing committed changes to it.
+func WithEventBroker(broker *events.Broker) Option {
+	return func(o *options) {
+		o.broker = broker
+	}
+}
+
+// WithClock replaces time.Now, so tests can control when subscriptions start
+// and end.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// WithCurrency sets the ISO 4217 code catalog prices are in, which fixed
+// amount coupons must match. An empty code keeps the default.
+func WithCurrency(code string) Option {
+	return func(o *options) {
+		if code != "" {
+			o.currency = strings.ToUpper(code)
+		}
+	}
+}
+
+// WithTaxCalculator sets how tax is worked out on price quotes. Without one,
+// quotes carry no tax.
+func WithTaxCalculator(calculator tax.Calculator) Option {
+	return func(o *options) {
+		o.tax = calculator
+	}
+}
+
+// WithDraftInvoices leaves the invoices subscriptions generate as drafts to
+// be reviewed and finalized, instead of issuing them at once.
+func WithDraftInvoices(enabled bool) Option {
+	return func(o *options) {
+		o.draftInvoices = enabled
+	}
+}
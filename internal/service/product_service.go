@@ -1,54 +1,79 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
 	"github.com/microservice-go/product-service/internal/constants"
 	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/events"
 	"github.com/microservice-go/product-service/internal/models"
 	"github.com/microservice-go/product-service/internal/repository"
+	"github.com/microservice-go/product-service/internal/tenant"
 )
 
 type ProductService interface {
-	CreateProduct(name, description string, price float64, productType string) (*models.Product, error)
-	GetProduct(id string) (*models.Product, error)
-	UpdateProduct(id, name, description string, price float64, productType string) (*models.Product, error)
-	DeleteProduct(id string) error
-	ListProducts(productType string, page, pageSize int) ([]models.Product, int64, error)
+	CreateProduct(ctx context.Context, name, description string, price float64, productType string, taxInclusive bool) (*models.Product, error)
+	GetProduct(ctx context.Context, id string) (*models.Product, error)
+	GetProductAsOf(ctx context.Context, id string, asOf time.Time) (*models.Product, error)
+	UpdateProduct(ctx context.Context, id, name, description string, price float64, productType string, taxInclusive bool) (*models.Product, error)
+	DeleteProduct(ctx context.Context, id string) error
+	ListProducts(ctx context.Context, productType string, page, pageSize int) ([]models.Product, int64, error)
+	ListProductsAsOf(ctx context.Context, productType string, page, pageSize int, asOf time.Time) ([]models.Product, int64, error)
+	BatchGetProducts(ctx context.Context, ids []string) ([]ProductResult, error)
+	BatchCreateProducts(ctx context.Context, inputs []ProductInput, atomic bool) ([]ProductResult, error)
+	BatchUpdateProducts(ctx context.Context, inputs []ProductInput, atomic bool) ([]ProductResult, error)
+	BatchDeleteProducts(ctx context.Context, ids []string, atomic bool) ([]ProductResult, error)
+	WatchProducts(ctx context.Context, productType, productID string, fromSequence uint64) (*events.Subscription, error)
 }
 
 type productService struct {
 	repo repository.ProductRepository
+	opts options
 }
 
-func NewProductService(repo repository.ProductRepository) ProductService {
-	return &productService{repo: repo}
+func NewProductService(repo repository.ProductRepository, opts ...Option) ProductService {
+	return &productService{
+		repo: repo,
+		opts: newOptions(opts),
+	}
 }
 
-func (s *productService) CreateProduct(name, description string, price float64, productType string) (*models.Product, error) {
-	if err := validateProductInput(name, price, productType); err != nil {
-		return nil, err
-	}
+func (s *productService) CreateProduct(ctx context.Context, name, description string, price float64, productType string, taxInclusive bool) (*models.Product, error) {
+	return s.createProduct(s.repo.WithContext(ctx), ProductInput{
+		Name:         name,
+		Description:  description,
+		Price:        price,
+		ProductType:  productType,
+		TaxInclusive: taxInclusive,
+	})
+}
 
-	product := &models.Product{
-		Name:        name,
-		Description: description,
-		Price:       price,
-		ProductType: productType,
+func (s *productService) GetProduct(ctx context.Context, id string) (*models.Product, error) {
+	productID, err := parseProductID(id)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.repo.Create(product); err != nil {
-		return nil, apperrors.NewDatabaseError("create product", err)
+	product, err := s.repo.WithContext(ctx).GetByID(productID)
+	if err != nil {
+		return nil, apperrors.NewNotFoundError("Product", id)
 	}
 
 	return product, nil
 }
 
-func (s *productService) GetProduct(id string) (*models.Product, error) {
+// GetProductAsOf returns the product as it was at asOf, even if it has since
+// been changed or deleted.
+func (s *productService) GetProductAsOf(ctx context.Context, id string, asOf time.Time) (*models.Product, error) {
 	productID, err := parseProductID(id)
 	if err != nil {
 		return nil, err
 	}
 
-	product, err := s.repo.GetByID(productID)
+	product, err := s.repo.WithContext(ctx).GetByIDAsOf(productID, asOf)
 	if err != nil {
 		return nil, apperrors.NewNotFoundError("Product", id)
 	}
@@ -56,57 +81,207 @@ func (s *productService) GetProduct(id string) (*models.Product, error) {
 	return product, nil
 }
 
-func (s *productService) UpdateProduct(id, name, description string, price float64, productType string) (*models.Product, error) {
-	productID, err := parseProductID(id)
+func (s *productService) UpdateProduct(ctx context.Context, id, name, description string, price float64, productType string, taxInclusive bool) (*models.Product, error) {
+	return s.updateProduct(s.repo.WithContext(ctx), ProductInput{
+		ID:           id,
+		Name:         name,
+		Description:  description,
+		Price:        price,
+		ProductType:  productType,
+		TaxInclusive: taxInclusive,
+	})
+}
+
+func (s *productService) DeleteProduct(ctx context.Context, id string) error {
+	_, err := s.deleteProduct(s.repo.WithContext(ctx), id)
+	return err
+}
+
+func (s *productService) BatchGetProducts(ctx context.Context, ids []string) ([]ProductResult, error) {
+	if err := validateBatchSize(len(ids), s.opts.maxBatchSize); err != nil {
+		return nil, err
+	}
+
+	results := make([]ProductResult, len(ids))
+	productIDs := make([]uuid.UUID, len(ids))
+	lookup := make([]uuid.UUID, 0, len(ids))
+	for i, id := range ids {
+		productID, err := parseProductID(id)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		productIDs[i] = productID
+		lookup = append(lookup, productID)
+	}
+
+	products, err := s.repo.WithContext(ctx).GetByIDs(lookup)
 	if err != nil {
+		return nil, apperrors.NewDatabaseError("batch get products", err)
+	}
+
+	byID := make(map[uuid.UUID]*models.Product, len(products))
+	for i := range products {
+		byID[products[i].ID] = &products[i]
+	}
+
+	for i, id := range ids {
+		if results[i].Err != nil {
+			continue
+		}
+		product, ok := byID[productIDs[i]]
+		if !ok {
+			results[i].Err = apperrors.NewNotFoundError("Product", id)
+			continue
+		}
+		results[i].Product = product
+	}
+
+	return results, nil
+}
+
+func (s *productService) BatchCreateProducts(ctx context.Context, inputs []ProductInput, atomic bool) ([]ProductResult, error) {
+	return s.batchWrite(ctx, len(inputs), atomic, "batch create products", func(repo repository.ProductRepository, i int) (*models.Product, error) {
+		return s.createProduct(repo, inputs[i])
+	})
+}
+
+func (s *productService) BatchUpdateProducts(ctx context.Context, inputs []ProductInput, atomic bool) ([]ProductResult, error) {
+	return s.batchWrite(ctx, len(inputs), atomic, "batch update products", func(repo repository.ProductRepository, i int) (*models.Product, error) {
+		return s.updateProduct(repo, inputs[i])
+	})
+}
+
+func (s *productService) BatchDeleteProducts(ctx context.Context, ids []string, atomic bool) ([]ProductResult, error) {
+	return s.batchWrite(ctx, len(ids), atomic, "batch delete products", func(repo repository.ProductRepository, i int) (*models.Product, error) {
+		return s.deleteProduct(repo, ids[i])
+	})
+}
+
+func (s *productService) batchWrite(ctx context.Context, size int, atomic bool, operation string, fn func(repo repository.ProductRepository, i int) (*models.Product, error)) ([]ProductResult, error) {
+	if err := validateBatchSize(size, s.opts.maxBatchSize); err != nil {
 		return nil, err
 	}
 
-	if _, err := s.repo.GetByID(productID); err != nil {
-		return nil, apperrors.NewNotFoundError("Product", id)
+	results := make([]ProductResult, size)
+	err := runBatch(s.repo.WithContext(ctx), size, atomic, func(repo repository.ProductRepository, i int) error {
+		product, err := fn(repo, i)
+		results[i] = ProductResult{Product: product, Err: err}
+		return err
+	})
+	if err != nil {
+		var itemErr *apperrors.BatchItemError
+		if errors.As(err, &itemErr) {
+			return nil, err
+		}
+		return nil, apperrors.NewDatabaseError(operation, err)
+	}
+
+	return results, nil
+}
+
+func (s *productService) WatchProducts(ctx context.Context, productType, productID string, fromSequence uint64) (*events.Subscription, error) {
+	filter := events.Filter{
+		TenantID:    tenant.FromContext(ctx),
+		Aggregate:   events.AggregateProduct,
+		ProductType: productType,
 	}
+	if productID != "" {
+		id, err := parseProductID(productID)
+		if err != nil {
+			return nil, err
+		}
+		filter.ProductID = id
+	}
+
+	return s.opts.broker.Subscribe(fromSequence, filter)
+}
 
-	if err := validateProductInput(name, price, productType); err != nil {
+func (s *productService) createProduct(repo repository.ProductRepository, in ProductInput) (*models.Product, error) {
+	if err := validateProductInput(in.Name, in.Price, in.ProductType); err != nil {
 		return nil, err
 	}
 
 	product := &models.Product{
-		ID:          productID,
-		Name:        name,
-		Description: description,
-		Price:       price,
-		ProductType: productType,
+		Name:         in.Name,
+		Description:  in.Description,
+		Price:        in.Price,
+		ProductType:  in.ProductType,
+		TaxInclusive: in.TaxInclusive,
+	}
+
+	if err := repo.Create(product); err != nil {
+		return nil, apperrors.NewDatabaseError("create product", err)
+	}
+
+	return product, nil
+}
+
+func (s *productService) updateProduct(repo repository.ProductRepository, in ProductInput) (*models.Product, error) {
+	productID, err := parseProductID(in.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := repo.GetByID(productID); err != nil {
+		return nil, apperrors.NewNotFoundError("Product", in.ID)
+	}
+
+	if err := validateProductInput(in.Name, in.Price, in.ProductType); err != nil {
+		return nil, err
+	}
+
+	product := &models.Product{
+		ID:           productID,
+		Name:         in.Name,
+		Description:  in.Description,
+		Price:        in.Price,
+		ProductType:  in.ProductType,
+		TaxInclusive: in.TaxInclusive,
 	}
 
-	if err := s.repo.Update(product); err != nil {
+	if err := repo.Update(product); err != nil {
 		return nil, apperrors.NewDatabaseError("update product", err)
 	}
 
-	return s.repo.GetByID(productID)
+	return repo.GetByID(productID)
 }
 
-func (s *productService) DeleteProduct(id string) error {
+func (s *productService) deleteProduct(repo repository.ProductRepository, id string) (*models.Product, error) {
 	productID, err := parseProductID(id)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if _, err := s.repo.GetByID(productID); err != nil {
-		return apperrors.NewNotFoundError("Product", id)
+	product, err := repo.GetByID(productID)
+	if err != nil {
+		return nil, apperrors.NewNotFoundError("Product", id)
 	}
 
-	if err := s.repo.Delete(productID); err != nil {
-		return apperrors.NewDatabaseError("delete product", err)
+	if err := repo.Delete(productID); err != nil {
+		return nil, apperrors.NewDatabaseError("delete product", err)
 	}
 
-	return nil
+	return product, nil
 }
 
-func (s *productService) ListProducts(productType string, page, pageSize int) ([]models.Product, int64, error) {
+func (s *productService) ListProducts(ctx context.Context, productType string, page, pageSize int) ([]models.Product, int64, error) {
 	page = normalizePage(page)
 	pageSize = normalizePageSize(pageSize)
 
-	products, total, err := s.repo.List(productType, page, pageSize)
+	products, total, err := s.repo.WithContext(ctx).List(productType, page, pageSize)
+	if err != nil {
+		return nil, 0, apperrors.NewDatabaseError("list products", err)
+	}
+
+	return products, total, nil
+}
+
+func (s *productService) ListProductsAsOf(ctx context.Context, productType string, page, pageSize int, asOf time.Time) ([]models.Product, int64, error) {
+	page = normalizePage(page)
+	pageSize = normalizePageSize(pageSize)
+
+	products, total, err := s.repo.WithContext(ctx).ListAsOf(productType, page, pageSize, asOf)
 	if err != nil {
 		return nil, 0, apperrors.NewDatabaseError("list products", err)
 	}
@@ -130,7 +305,6 @@ func validateProductInput(name string, price float64, productType string) error
 	return nil
 }
 
-
 func normalizePage(page int) int {
 	if page < constants.MinPageSize {
 		return constants.DefaultPage
@@ -1,11 +1,16 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/events"
 	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/repository"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -42,13 +47,42 @@ func (m *MockProductRepository) List(productType string, page, pageSize int) ([]
 	return args.Get(0).([]models.Product), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockProductRepository) GetByIDs(ids []uuid.UUID) ([]models.Product, error) {
+	args := m.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) GetByIDAsOf(id uuid.UUID, asOf time.Time) (*models.Product, error) {
+	args := m.Called(id, asOf)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductRepository) ListAsOf(productType string, page, pageSize int, asOf time.Time) ([]models.Product, int64, error) {
+	args := m.Called(productType, page, pageSize, asOf)
+	return args.Get(0).([]models.Product), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockProductRepository) Transaction(fn func(repo repository.ProductRepository) error) error {
+	return fn(m)
+}
+
+func (m *MockProductRepository) WithContext(ctx context.Context) repository.ProductRepository {
+	return m
+}
+
 func TestCreateProduct_Success(t *testing.T) {
 	mockRepo := new(MockProductRepository)
 	service := NewProductService(mockRepo)
 
 	mockRepo.On("Create", mock.AnythingOfType("*models.Product")).Return(nil)
 
-	product, err := service.CreateProduct("Test Product", "Test Description", 99.99, "digital")
+	product, err := service.CreateProduct(context.Background(), "Test Product", "Test Description", 99.99, "digital", true)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, product)
@@ -56,6 +90,7 @@ func TestCreateProduct_Success(t *testing.T) {
 	assert.Equal(t, "Test Description", product.Description)
 	assert.Equal(t, 99.99, product.Price)
 	assert.Equal(t, "digital", product.ProductType)
+	assert.True(t, product.TaxInclusive)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -63,7 +98,7 @@ func TestCreateProduct_EmptyName(t *testing.T) {
 	mockRepo := new(MockProductRepository)
 	service := NewProductService(mockRepo)
 
-	product, err := service.CreateProduct("", "Test Description", 99.99, "digital")
+	product, err := service.CreateProduct(context.Background(), "", "Test Description", 99.99, "digital", false)
 
 	assert.Error(t, err)
 	assert.Nil(t, product)
@@ -74,7 +109,7 @@ func TestCreateProduct_NegativePrice(t *testing.T) {
 	mockRepo := new(MockProductRepository)
 	service := NewProductService(mockRepo)
 
-	product, err := service.CreateProduct("Test Product", "Test Description", -10.0, "digital")
+	product, err := service.CreateProduct(context.Background(), "Test Product", "Test Description", -10.0, "digital", false)
 
 	assert.Error(t, err)
 	assert.Nil(t, product)
@@ -96,7 +131,7 @@ func TestGetProduct_Success(t *testing.T) {
 
 	mockRepo.On("GetByID", productID).Return(expectedProduct, nil)
 
-	product, err := service.GetProduct(productID.String())
+	product, err := service.GetProduct(context.Background(), productID.String())
 
 	assert.NoError(t, err)
 	assert.NotNil(t, product)
@@ -109,7 +144,7 @@ func TestGetProduct_InvalidID(t *testing.T) {
 	mockRepo := new(MockProductRepository)
 	service := NewProductService(mockRepo)
 
-	product, err := service.GetProduct("invalid-uuid")
+	product, err := service.GetProduct(context.Background(), "invalid-uuid")
 
 	assert.Error(t, err)
 	assert.Nil(t, product)
@@ -123,7 +158,7 @@ func TestGetProduct_NotFound(t *testing.T) {
 	productID := uuid.New()
 	mockRepo.On("GetByID", productID).Return(nil, errors.New("product not found"))
 
-	product, err := service.GetProduct(productID.String())
+	product, err := service.GetProduct(context.Background(), productID.String())
 
 	assert.Error(t, err)
 	assert.Nil(t, product)
@@ -146,7 +181,7 @@ func TestDeleteProduct_Success(t *testing.T) {
 	mockRepo.On("GetByID", productID).Return(expectedProduct, nil)
 	mockRepo.On("Delete", productID).Return(nil)
 
-	err := service.DeleteProduct(productID.String())
+	err := service.DeleteProduct(context.Background(), productID.String())
 
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
@@ -163,7 +198,7 @@ func TestListProducts_Success(t *testing.T) {
 
 	mockRepo.On("List", "digital", 1, 10).Return(expectedProducts, int64(2), nil)
 
-	products, total, err := service.ListProducts("digital", 1, 10)
+	products, total, err := service.ListProducts(context.Background(), "digital", 1, 10)
 
 	assert.NoError(t, err)
 	assert.Equal(t, 2, len(products))
@@ -171,3 +206,121 @@ func TestListProducts_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestBatchGetProducts_MixedResults(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	service := NewProductService(mockRepo)
+
+	foundID := uuid.New()
+	missingID := uuid.New()
+	mockRepo.On("GetByIDs", []uuid.UUID{foundID, missingID}).
+		Return([]models.Product{{ID: foundID, Name: "Found"}}, nil)
+
+	results, err := service.BatchGetProducts(context.Background(), []string{foundID.String(), "invalid-uuid", missingID.String()})
+
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "Found", results[0].Product.Name)
+	assert.True(t, apperrors.IsValidationError(results[1].Err))
+	assert.True(t, apperrors.IsNotFoundError(results[2].Err))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBatchCreateProducts_PartialFailure(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	service := NewProductService(mockRepo)
+
+	mockRepo.On("Create", mock.AnythingOfType("*models.Product")).Return(nil).Once()
+
+	results, err := service.BatchCreateProducts(context.Background(), []ProductInput{
+		{Name: "Valid", Price: 10, ProductType: "digital"},
+		{Name: "", Price: 10, ProductType: "digital"},
+	}, false)
+
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "Valid", results[0].Product.Name)
+	assert.Nil(t, results[1].Product)
+	assert.True(t, apperrors.IsValidationError(results[1].Err))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBatchCreateProducts_AtomicFailure(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	service := NewProductService(mockRepo)
+
+	mockRepo.On("Create", mock.AnythingOfType("*models.Product")).Return(nil).Once()
+
+	results, err := service.BatchCreateProducts(context.Background(), []ProductInput{
+		{Name: "Valid", Price: 10, ProductType: "digital"},
+		{Name: "Invalid", Price: -1, ProductType: "digital"},
+	}, true)
+
+	assert.Error(t, err)
+	assert.Nil(t, results)
+	var itemErr *apperrors.BatchItemError
+	assert.True(t, errors.As(err, &itemErr))
+	assert.Equal(t, 1, itemErr.Index)
+	assert.True(t, apperrors.IsValidationError(err))
+}
+
+func TestBatchCreateProducts_ExceedsMaxBatchSize(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	service := NewProductService(mockRepo, WithMaxBatchSize(1))
+
+	results, err := service.BatchCreateProducts(context.Background(), []ProductInput{
+		{Name: "One", Price: 10, ProductType: "digital"},
+		{Name: "Two", Price: 10, ProductType: "digital"},
+	}, false)
+
+	assert.Error(t, err)
+	assert.Nil(t, results)
+	assert.Contains(t, err.Error(), "batch cannot exceed 1 items")
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestBatchDeleteProducts_Empty(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	service := NewProductService(mockRepo)
+
+	results, err := service.BatchDeleteProducts(context.Background(), nil, false)
+
+	assert.Error(t, err)
+	assert.Nil(t, results)
+	assert.True(t, apperrors.IsValidationError(err))
+}
+
+func TestWatchProducts_ReceivesMatchingEvents(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	broker := events.NewBroker(10)
+	service := NewProductService(mockRepo, WithEventBroker(broker))
+
+	sub, err := service.WatchProducts(context.Background(), "digital", "", 0)
+	assert.NoError(t, err)
+	defer sub.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	productID := uuid.New()
+	assert.NoError(t, broker.Publish(ctx, events.NewPlanEvent(events.TypeCreated, &models.SubscriptionPlan{ID: uuid.New(), Product: models.Product{ProductType: "digital"}})))
+	assert.NoError(t, broker.Publish(ctx, events.NewProductEvent(events.TypeCreated, &models.Product{ID: uuid.New(), ProductType: "physical"})))
+	assert.NoError(t, broker.Publish(ctx, events.NewProductEvent(events.TypeCreated, &models.Product{ID: productID, ProductType: "digital"})))
+
+	event, err := sub.Next(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, events.TypeCreated, event.Type)
+	assert.Equal(t, productID, event.AggregateID)
+	assert.Equal(t, uint64(3), event.Sequence)
+}
+
+func TestWatchProducts_InvalidProductID(t *testing.T) {
+	mockRepo := new(MockProductRepository)
+	service := NewProductService(mockRepo)
+
+	sub, err := service.WatchProducts(context.Background(), "", "invalid-uuid", 0)
+
+	assert.Error(t, err)
+	assert.Nil(t, sub)
+	assert.True(t, apperrors.IsValidationError(err))
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/audit"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/repository"
+	"github.com/microservice-go/product-service/internal/tenant"
+)
+
+type ScheduledChangeService interface {
+	ScheduleProductChange(ctx context.Context, productID string, change models.ProductChange, effectiveAt time.Time) (*models.ScheduledChange, error)
+	SchedulePlanChange(ctx context.Context, planID string, change models.SubscriptionPlanChange, effectiveAt time.Time) (*models.ScheduledChange, error)
+	ListScheduledChanges(ctx context.Context, resourceID, status string, page, pageSize int) ([]models.ScheduledChange, int64, error)
+	CancelScheduledChange(ctx context.Context, id string) (*models.ScheduledChange, error)
+	ApplyDueChanges(ctx context.Context, now time.Time, limit int) (int, error)
+}
+
+type scheduledChangeService struct {
+	repo        repository.ScheduledChangeRepository
+	productRepo repository.ProductRepository
+	planRepo    repository.SubscriptionRepository
+}
+
+func NewScheduledChangeService(repo repository.ScheduledChangeRepository, productRepo repository.ProductRepository, planRepo repository.SubscriptionRepository) ScheduledChangeService {
+	return &scheduledChangeService{
+		repo:        repo,
+		productRepo: productRepo,
+		planRepo:    planRepo,
+	}
+}
+
+func (s *scheduledChangeService) ScheduleProductChange(ctx context.Context, productID string, change models.ProductChange, effectiveAt time.Time) (*models.ScheduledChange, error) {
+	id, err := parseProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if change.IsEmpty() {
+		return nil, apperrors.NewValidationError("changes", "at least one field must be changed")
+	}
+	if err := validateEffectiveAt(effectiveAt); err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepo.WithContext(ctx).GetByID(id)
+	if err != nil {
+		return nil, apperrors.NewNotFoundError("Product", productID)
+	}
+	updated := change.ApplyTo(*product)
+	if err := validateProductInput(updated.Name, updated.Price, updated.ProductType); err != nil {
+		return nil, err
+	}
+
+	return s.schedule(ctx, models.AuditResourceProduct, id, change, effectiveAt)
+}
+
+func (s *scheduledChangeService) SchedulePlanChange(ctx context.Context, planID string, change models.SubscriptionPlanChange, effectiveAt time.Time) (*models.ScheduledChange, error) {
+	id, err := parsePlanID(planID)
+	if err != nil {
+		return nil, err
+	}
+	if change.IsEmpty() {
+		return nil, apperrors.NewValidationError("changes", "at least one field must be changed")
+	}
+	if err := validateEffectiveAt(effectiveAt); err != nil {
+		return nil, err
+	}
+
+	plan, err := s.planRepo.WithContext(ctx).GetByID(id)
+	if err != nil {
+		return nil, apperrors.NewNotFoundError("SubscriptionPlan", planID)
+	}
+	updated := change.ApplyTo(*plan)
+	if err := validateSubscriptionInput(updated.PlanName, updated.Interval, updated.Price, updated.TrialDays, updated.IntroPhases); err != nil {
+		return nil, err
+	}
+	if err := validatePricing(updated.PricingModelOrDefault(), updated.Price, updated.Tiers, updated.IntroPhases); err != nil {
+		return nil, err
+	}
+	if err := validateMeter(updated.Meter, updated.PricingModelOrDefault(), updated.IntroPhases); err != nil {
+		return nil, err
+	}
+
+	return s.schedule(ctx, models.AuditResourceSubscriptionPlan, id, change, effectiveAt)
+}
+
+func (s *scheduledChangeService) schedule(ctx context.Context, resourceType string, resourceID uuid.UUID, change interface{}, effectiveAt time.Time) (*models.ScheduledChange, error) {
+	changes, err := json.Marshal(change)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled := &models.ScheduledChange{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Changes:      string(changes),
+		EffectiveAt:  effectiveAt,
+		Status:       models.ScheduledChangePending,
+		Actor:        audit.ActorFromContext(ctx),
+	}
+
+	if err := s.repo.WithContext(ctx).Create(scheduled); err != nil {
+		return nil, apperrors.NewDatabaseError("schedule change", err)
+	}
+
+	return scheduled, nil
+}
+
+func (s *scheduledChangeService) ListScheduledChanges(ctx context.Context, resourceID, status string, page, pageSize int) ([]models.ScheduledChange, int64, error) {
+	var id uuid.UUID
+	if resourceID != "" {
+		parsed, err := uuid.Parse(resourceID)
+		if err != nil {
+			return nil, 0, apperrors.NewValidationError("resourceId", "invalid resource ID format")
+		}
+		id = parsed
+	}
+
+	changes, total, err := s.repo.WithContext(ctx).List(id, status, normalizePage(page), normalizePageSize(pageSize))
+	if err != nil {
+		return nil, 0, apperrors.NewDatabaseError("list scheduled changes", err)
+	}
+
+	return changes, total, nil
+}
+
+func (s *scheduledChangeService) CancelScheduledChange(ctx context.Context, id string) (*models.ScheduledChange, error) {
+	if id == "" {
+		return nil, apperrors.NewValidationError("id", "scheduled change ID is required")
+	}
+	changeID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, apperrors.NewValidationError("id", "invalid scheduled change ID format")
+	}
+
+	repo := s.repo.WithContext(ctx)
+	if _, err := repo.GetByID(changeID); err != nil {
+		return nil, apperrors.NewNotFoundError("ScheduledChange", id)
+	}
+
+	cancelled, err := repo.Cancel(changeID)
+	if err != nil {
+		return nil, apperrors.NewDatabaseError("cancel scheduled change", err)
+	}
+	if !cancelled {
+		return nil, apperrors.NewValidationError("status", "only pending changes can be cancelled")
+	}
+
+	return repo.GetByID(changeID)
+}
+
+// ApplyDueChanges applies up to limit pending changes whose effective time has
+// passed and returns how many were applied. Changes that can no longer be
+// applied, for example because the resource was deleted, are marked failed.
+// Other errors leave the change pending for the next run; they do not hold up
+// the changes after it and are returned together once the rest are applied.
+func (s *scheduledChangeService) ApplyDueChanges(ctx context.Context, now time.Time, limit int) (int, error) {
+	changes, err := s.repo.WithContext(ctx).ListDue(now, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	var failures []error
+	for i := range changes {
+		change := &changes[i]
+		changeCtx := tenant.WithTenant(audit.WithActor(ctx, change.Actor), change.TenantID)
+		ok, err := s.repo.WithContext(changeCtx).Apply(change.ID, now, func(products repository.ProductRepository, plans repository.SubscriptionRepository) error {
+			return applyScheduledChange(products, plans, change)
+		})
+		if err != nil {
+			if apperrors.IsValidationError(err) || apperrors.IsNotFoundError(err) {
+				err = s.repo.WithContext(ctx).MarkFailed(change.ID, err.Error())
+			}
+			if err != nil {
+				failures = append(failures, fmt.Errorf("scheduled change %s: %w", change.ID, err))
+			}
+			continue
+		}
+		if ok {
+			s.invalidate(changeCtx, change)
+			applied++
+		}
+	}
+
+	return applied, errors.Join(failures...)
+}
+
+// invalidate evicts cached copies of the resource a change was applied to,
+// since the change was written in a transaction of its own.
+func (s *scheduledChangeService) invalidate(ctx context.Context, change *models.ScheduledChange) {
+	var repo interface{} = s.productRepo.WithContext(ctx)
+	if change.ResourceType == models.AuditResourceSubscriptionPlan {
+		repo = s.planRepo.WithContext(ctx)
+	}
+	if invalidator, ok := repo.(repository.Invalidator); ok {
+		invalidator.Invalidate(change.ResourceID)
+	}
+}
+
+func applyScheduledChange(products repository.ProductRepository, plans repository.SubscriptionRepository, change *models.ScheduledChange) error {
+	switch change.ResourceType {
+	case models.AuditResourceProduct:
+		var fields models.ProductChange
+		if err := json.Unmarshal([]byte(change.Changes), &fields); err != nil {
+			return apperrors.NewValidationError("changes", err.Error())
+		}
+		product, err := products.GetByID(change.ResourceID)
+		if err != nil {
+			return apperrors.NewNotFoundError("Product", change.ResourceID.String())
+		}
+		updated := fields.ApplyTo(*product)
+		if err := validateProductInput(updated.Name, updated.Price, updated.ProductType); err != nil {
+			return err
+		}
+		return products.Update(&models.Product{
+			ID:           updated.ID,
+			Name:         updated.Name,
+			Description:  updated.Description,
+			Price:        updated.Price,
+			ProductType:  updated.ProductType,
+			TaxInclusive: updated.TaxInclusive,
+		})
+
+	case models.AuditResourceSubscriptionPlan:
+		var fields models.SubscriptionPlanChange
+		if err := json.Unmarshal([]byte(change.Changes), &fields); err != nil {
+			return apperrors.NewValidationError("changes", err.Error())
+		}
+		plan, err := plans.GetByID(change.ResourceID)
+		if err != nil {
+			return apperrors.NewNotFoundError("SubscriptionPlan", change.ResourceID.String())
+		}
+		updated := fields.ApplyTo(*plan)
+		if err := validateSubscriptionInput(updated.PlanName, updated.Interval, updated.Price, updated.TrialDays, updated.IntroPhases); err != nil {
+			return err
+		}
+		if err := validatePricing(updated.PricingModelOrDefault(), updated.Price, updated.Tiers, updated.IntroPhases); err != nil {
+			return err
+		}
+		if err := validateMeter(updated.Meter, updated.PricingModelOrDefault(), updated.IntroPhases); err != nil {
+			return err
+		}
+		return plans.Update(&models.SubscriptionPlan{
+			ID:           updated.ID,
+			ProductID:    updated.ProductID,
+			PlanName:     updated.PlanName,
+			Interval:     updated.Interval,
+			Duration:     updated.Duration,
+			Price:        updated.Price,
+			PricingModel: updated.PricingModel,
+			Tiers:        updated.Tiers,
+			Meter:        updated.Meter,
+			TrialDays:    updated.TrialDays,
+			IntroPhases:  updated.IntroPhases,
+		})
+
+	default:
+		return apperrors.NewValidationError("resourceType", "unknown resource type: "+change.ResourceType)
+	}
+}
+
+func validateEffectiveAt(effectiveAt time.Time) error {
+	if effectiveAt.IsZero() {
+		return apperrors.NewValidationError("effectiveAt", "effective time is required")
+	}
+	if !effectiveAt.After(time.Now()) {
+		return apperrors.NewValidationError("effectiveAt", "effective time must be in the future")
+	}
+	return nil
+}
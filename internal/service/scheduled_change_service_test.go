@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/audit"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockScheduledChangeRepository struct {
+	mock.Mock
+	products repository.ProductRepository
+	plans    repository.SubscriptionRepository
+}
+
+func (m *MockScheduledChangeRepository) Create(change *models.ScheduledChange) error {
+	args := m.Called(change)
+	return args.Error(0)
+}
+
+func (m *MockScheduledChangeRepository) GetByID(id uuid.UUID) (*models.ScheduledChange, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ScheduledChange), args.Error(1)
+}
+
+func (m *MockScheduledChangeRepository) List(resourceID uuid.UUID, status string, page, pageSize int) ([]models.ScheduledChange, int64, error) {
+	args := m.Called(resourceID, status, page, pageSize)
+	return args.Get(0).([]models.ScheduledChange), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockScheduledChangeRepository) ListDue(now time.Time, limit int) ([]models.ScheduledChange, error) {
+	args := m.Called(now, limit)
+	return args.Get(0).([]models.ScheduledChange), args.Error(1)
+}
+
+func (m *MockScheduledChangeRepository) Cancel(id uuid.UUID) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+// Apply runs fn against the mock product and plan repositories when the
+// expectation reports the change as claimed.
+func (m *MockScheduledChangeRepository) Apply(id uuid.UUID, at time.Time, fn func(products repository.ProductRepository, plans repository.SubscriptionRepository) error) (bool, error) {
+	args := m.Called(id, at)
+	if !args.Bool(0) || args.Error(1) != nil {
+		return args.Bool(0), args.Error(1)
+	}
+	if err := fn(m.products, m.plans); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (m *MockScheduledChangeRepository) MarkFailed(id uuid.UUID, lastError string) error {
+	args := m.Called(id, lastError)
+	return args.Error(0)
+}
+
+func (m *MockScheduledChangeRepository) WithContext(ctx context.Context) repository.ScheduledChangeRepository {
+	return m
+}
+
+func newTestScheduledChangeService() (ScheduledChangeService, *MockScheduledChangeRepository, *MockProductRepository, *MockSubscriptionRepository) {
+	productRepo := new(MockProductRepository)
+	planRepo := new(MockSubscriptionRepository)
+	repo := &MockScheduledChangeRepository{products: productRepo, plans: planRepo}
+	return NewScheduledChangeService(repo, productRepo, planRepo), repo, productRepo, planRepo
+}
+
+func TestScheduledChangeService_ScheduleProductChange(t *testing.T) {
+	svc, repo, productRepo, _ := newTestScheduledChangeService()
+	ctx := audit.WithActor(context.Background(), "alice")
+
+	productID := uuid.New()
+	product := &models.Product{ID: productID, Name: "Basic", Price: 10, ProductType: "digital"}
+	productRepo.On("GetByID", productID).Return(product, nil)
+	repo.On("Create", mock.AnythingOfType("*models.ScheduledChange")).Return(nil)
+
+	price := 20.0
+	effectiveAt := time.Now().Add(time.Hour)
+	change, err := svc.ScheduleProductChange(ctx, productID.String(), models.ProductChange{Price: &price}, effectiveAt)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.AuditResourceProduct, change.ResourceType)
+	assert.Equal(t, productID, change.ResourceID)
+	assert.JSONEq(t, `{"price":20}`, change.Changes)
+	assert.Equal(t, models.ScheduledChangePending, change.Status)
+	assert.Equal(t, "alice", change.Actor)
+	repo.AssertExpectations(t)
+}
+
+func TestScheduledChangeService_ScheduleProductChange_Validation(t *testing.T) {
+	svc, repo, productRepo, _ := newTestScheduledChangeService()
+	ctx := context.Background()
+
+	productID := uuid.New()
+	productRepo.On("GetByID", productID).Return(&models.Product{ID: productID, Name: "Basic", Price: 10, ProductType: "digital"}, nil)
+
+	price := -5.0
+	future := time.Now().Add(time.Hour)
+
+	_, err := svc.ScheduleProductChange(ctx, productID.String(), models.ProductChange{}, future)
+	assert.True(t, apperrors.IsValidationError(err))
+
+	_, err = svc.ScheduleProductChange(ctx, productID.String(), models.ProductChange{Price: &price}, time.Now().Add(-time.Hour))
+	assert.True(t, apperrors.IsValidationError(err))
+
+	_, err = svc.ScheduleProductChange(ctx, productID.String(), models.ProductChange{Price: &price}, future)
+	assert.True(t, apperrors.IsValidationError(err))
+
+	repo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestScheduledChangeService_SchedulePlanChange_NotFound(t *testing.T) {
+	svc, repo, _, planRepo := newTestScheduledChangeService()
+
+	planID := uuid.New()
+	planRepo.On("GetByID", planID).Return(nil, errors.New("subscription plan not found"))
+
+	duration := 12
+	_, err := svc.SchedulePlanChange(context.Background(), planID.String(), models.SubscriptionPlanChange{Duration: &duration}, time.Now().Add(time.Hour))
+
+	assert.True(t, apperrors.IsNotFoundError(err))
+	repo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestScheduledChangeService_CancelScheduledChange(t *testing.T) {
+	svc, repo, _, _ := newTestScheduledChangeService()
+
+	changeID := uuid.New()
+	repo.On("GetByID", changeID).Return(&models.ScheduledChange{ID: changeID, Status: models.ScheduledChangeCancelled}, nil)
+	repo.On("Cancel", changeID).Return(true, nil).Once()
+
+	change, err := svc.CancelScheduledChange(context.Background(), changeID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, models.ScheduledChangeCancelled, change.Status)
+
+	repo.On("Cancel", changeID).Return(false, nil).Once()
+	_, err = svc.CancelScheduledChange(context.Background(), changeID.String())
+	assert.True(t, apperrors.IsValidationError(err))
+}
+
+func TestScheduledChangeService_ApplyDueChanges(t *testing.T) {
+	svc, repo, productRepo, _ := newTestScheduledChangeService()
+
+	now := time.Now()
+	productID := uuid.New()
+	missingID := uuid.New()
+	due := []models.ScheduledChange{
+		{ID: uuid.New(), ResourceType: models.AuditResourceProduct, ResourceID: productID, Changes: `{"price":20}`, Actor: "alice"},
+		{ID: uuid.New(), ResourceType: models.AuditResourceProduct, ResourceID: missingID, Changes: `{"price":30}`, Actor: "bob"},
+	}
+	repo.On("ListDue", now, 10).Return(due, nil)
+	repo.On("Apply", due[0].ID, now).Return(true, nil)
+	repo.On("Apply", due[1].ID, now).Return(true, nil)
+	repo.On("MarkFailed", due[1].ID, mock.AnythingOfType("string")).Return(nil)
+
+	productRepo.On("GetByID", productID).Return(&models.Product{ID: productID, Name: "Basic", Price: 10, ProductType: "digital"}, nil)
+	productRepo.On("GetByID", missingID).Return(nil, errors.New("product not found"))
+	productRepo.On("Update", mock.MatchedBy(func(p *models.Product) bool {
+		return p.ID == productID && p.Price == 20 && p.Name == "Basic"
+	})).Return(nil)
+
+	applied, err := svc.ApplyDueChanges(context.Background(), now, 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, applied)
+	repo.AssertExpectations(t)
+	productRepo.AssertExpectations(t)
+}
+
+func TestScheduledChangeService_ApplyDueChanges_FailureDoesNotBlockOthers(t *testing.T) {
+	svc, repo, productRepo, _ := newTestScheduledChangeService()
+
+	now := time.Now()
+	productID := uuid.New()
+	due := []models.ScheduledChange{
+		{ID: uuid.New(), ResourceType: models.AuditResourceProduct, ResourceID: uuid.New(), Changes: `{"price":20}`},
+		{ID: uuid.New(), ResourceType: models.AuditResourceProduct, ResourceID: productID, Changes: `{"price":30}`},
+	}
+	repo.On("ListDue", now, 10).Return(due, nil)
+	repo.On("Apply", due[0].ID, now).Return(false, errors.New("database is locked"))
+	repo.On("Apply", due[1].ID, now).Return(true, nil)
+	productRepo.On("GetByID", productID).Return(&models.Product{ID: productID, Name: "Basic", Price: 10, ProductType: "digital"}, nil)
+	productRepo.On("Update", mock.Anything).Return(nil)
+
+	applied, err := svc.ApplyDueChanges(context.Background(), now, 10)
+
+	assert.Equal(t, 1, applied, "the failing change does not hold up the next one")
+	assert.ErrorContains(t, err, due[0].ID.String())
+	repo.AssertNotCalled(t, "MarkFailed", due[0].ID, mock.Anything)
+	repo.AssertExpectations(t)
+}
+
+func TestScheduledChangeService_ApplyDueChanges_AlreadyClaimed(t *testing.T) {
+	svc, repo, productRepo, _ := newTestScheduledChangeService()
+
+	now := time.Now()
+	due := []models.ScheduledChange{
+		{ID: uuid.New(), ResourceType: models.AuditResourceProduct, ResourceID: uuid.New(), Changes: `{"price":20}`},
+	}
+	repo.On("ListDue", now, 10).Return(due, nil)
+	repo.On("Apply", due[0].ID, now).Return(false, nil)
+
+	applied, err := svc.ApplyDueChanges(context.Background(), now, 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, applied)
+	productRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+type invalidatingSubscriptionRepository struct {
+	*MockSubscriptionRepository
+	invalidated []uuid.UUID
+}
+
+func (r *invalidatingSubscriptionRepository) Invalidate(ids ...uuid.UUID) {
+	r.invalidated = append(r.invalidated, ids...)
+}
+
+func (r *invalidatingSubscriptionRepository) WithContext(ctx context.Context) repository.SubscriptionRepository {
+	return r
+}
+
+func TestScheduledChangeService_ApplyDueChanges_InvalidatesCache(t *testing.T) {
+	productRepo := new(MockProductRepository)
+	planRepo := &invalidatingSubscriptionRepository{MockSubscriptionRepository: new(MockSubscriptionRepository)}
+	repo := &MockScheduledChangeRepository{products: productRepo, plans: planRepo.MockSubscriptionRepository}
+	svc := NewScheduledChangeService(repo, productRepo, planRepo)
+
+	now := time.Now()
+	planID := uuid.New()
+	due := []models.ScheduledChange{
+		{ID: uuid.New(), ResourceType: models.AuditResourceSubscriptionPlan, ResourceID: planID, Changes: `{"price":20}`},
+	}
+	repo.On("ListDue", now, 10).Return(due, nil)
+	repo.On("Apply", due[0].ID, now).Return(true, nil)
+	planRepo.On("GetByID", planID).Return(&models.SubscriptionPlan{ID: planID, ProductID: uuid.New(), PlanName: "Monthly", Interval: monthly, Duration: 30, Price: 10}, nil)
+	planRepo.On("Update", mock.Anything).Return(nil)
+
+	applied, err := svc.ApplyDueChanges(context.Background(), now, 10)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, applied)
+	assert.Equal(t, []uuid.UUID{planID}, planRepo.invalidated)
+}
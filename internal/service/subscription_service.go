@@ -1,137 +1,367 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/google/uuid"
 	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/events"
 	"github.com/microservice-go/product-service/internal/models"
 	"github.com/microservice-go/product-service/internal/repository"
+	"github.com/microservice-go/product-service/internal/tax"
+	"github.com/microservice-go/product-service/internal/tenant"
+)
+
+const (
+	// maxIntervalDays caps billing intervals at 10 years.
+	maxIntervalDays = 3650
+	maxTrialDays    = 365
+	maxIntroPhases  = 10
+	maxPriceTiers   = 20
+	// maxQuantity caps the units a price is calculated for.
+	maxQuantity = 1000000
+	// maxScheduleCycles caps how many billing cycles a price schedule covers.
+	maxScheduleCycles = 120
 )
 
 type SubscriptionService interface {
-	CreateSubscriptionPlan(productID, planName string, duration int, price float64) (*models.SubscriptionPlan, error)
-	GetSubscriptionPlan(id string) (*models.SubscriptionPlan, error)
-	UpdateSubscriptionPlan(id, productID, planName string, duration int, price float64) (*models.SubscriptionPlan, error)
-	DeleteSubscriptionPlan(id string) error
-	ListSubscriptionPlans(productID string) ([]models.SubscriptionPlan, error)
+	CreateSubscriptionPlan(ctx context.Context, productID, planName string, interval models.BillingInterval, price float64, pricingModel string, tiers []models.PriceTier, meter *models.Meter, trialDays int, introPhases []models.IntroPhase) (*models.SubscriptionPlan, error)
+	GetSubscriptionPlan(ctx context.Context, id string) (*models.SubscriptionPlan, error)
+	GetSubscriptionPlanAsOf(ctx context.Context, id string, asOf time.Time) (*models.SubscriptionPlan, error)
+	UpdateSubscriptionPlan(ctx context.Context, id, productID, planName string, interval models.BillingInterval, price float64, pricingModel string, tiers []models.PriceTier, meter *models.Meter, trialDays int, introPhases []models.IntroPhase) (*models.SubscriptionPlan, error)
+	DeleteSubscriptionPlan(ctx context.Context, id string) error
+	ListSubscriptionPlans(ctx context.Context, productID string) ([]models.SubscriptionPlan, error)
+	ListSubscriptionPlansAsOf(ctx context.Context, productID string, asOf time.Time) ([]models.SubscriptionPlan, error)
+	GetPriceSchedule(ctx context.Context, id string, start time.Time, cycles int) ([]models.PricePeriod, error)
+	CalculatePrice(ctx context.Context, id string, quantity int, location TaxLocation) (*PriceCalculation, error)
+	BatchGetSubscriptionPlans(ctx context.Context, ids []string) ([]SubscriptionPlanResult, error)
+	BatchCreateSubscriptionPlans(ctx context.Context, inputs []SubscriptionPlanInput, atomic bool) ([]SubscriptionPlanResult, error)
+	BatchUpdateSubscriptionPlans(ctx context.Context, inputs []SubscriptionPlanInput, atomic bool) ([]SubscriptionPlanResult, error)
+	BatchDeleteSubscriptionPlans(ctx context.Context, ids []string, atomic bool) ([]SubscriptionPlanResult, error)
+	WatchSubscriptionPlans(ctx context.Context, productType, productID string, fromSequence uint64) (*events.Subscription, error)
+}
+
+// PriceCalculation is what a quantity of a plan costs for one billing cycle
+// at its regular price. Tax splits the breakdown's total into its net, tax
+// and gross parts.
+type PriceCalculation struct {
+	Plan      *models.SubscriptionPlan
+	Breakdown models.PriceBreakdown
+	Tax       tax.Result
 }
 
 type subscriptionService struct {
 	repo        repository.SubscriptionRepository
 	productRepo repository.ProductRepository
+	opts        options
 }
 
-func NewSubscriptionService(repo repository.SubscriptionRepository, productRepo repository.ProductRepository) SubscriptionService {
+func NewSubscriptionService(repo repository.SubscriptionRepository, productRepo repository.ProductRepository, opts ...Option) SubscriptionService {
 	return &subscriptionService{
 		repo:        repo,
 		productRepo: productRepo,
+		opts:        newOptions(opts),
 	}
 }
 
 // CreateSubscriptionPlan creates a new subscription plan with validation
-func (s *subscriptionService) CreateSubscriptionPlan(productID, planName string, duration int, price float64) (*models.SubscriptionPlan, error) {
-	if err := validateSubscriptionInput(planName, duration, price); err != nil {
+func (s *subscriptionService) CreateSubscriptionPlan(ctx context.Context, productID, planName string, interval models.BillingInterval, price float64, pricingModel string, tiers []models.PriceTier, meter *models.Meter, trialDays int, introPhases []models.IntroPhase) (*models.SubscriptionPlan, error) {
+	return s.createPlan(ctx, s.repo.WithContext(ctx), SubscriptionPlanInput{
+		ProductID:    productID,
+		PlanName:     planName,
+		Interval:     interval,
+		Price:        price,
+		PricingModel: pricingModel,
+		Tiers:        tiers,
+		Meter:        meter,
+		TrialDays:    trialDays,
+		IntroPhases:  introPhases,
+	})
+}
+
+func (s *subscriptionService) GetSubscriptionPlan(ctx context.Context, id string) (*models.SubscriptionPlan, error) {
+	planID, err := parsePlanID(id)
+	if err != nil {
 		return nil, err
 	}
 
-	prodID, err := parseProductID(productID)
+	plan, err := s.repo.WithContext(ctx).GetByID(planID)
+	if err != nil {
+		return nil, apperrors.NewNotFoundError("SubscriptionPlan", id)
+	}
+
+	return plan, nil
+}
+
+// GetSubscriptionPlanAsOf returns the plan as it was at asOf, even if it has
+// since been changed or deleted.
+func (s *subscriptionService) GetSubscriptionPlanAsOf(ctx context.Context, id string, asOf time.Time) (*models.SubscriptionPlan, error) {
+	planID, err := parsePlanID(id)
 	if err != nil {
 		return nil, err
 	}
 
-	if _, err := s.productRepo.GetByID(prodID); err != nil {
-		return nil, apperrors.NewNotFoundError("Product", productID)
+	plan, err := s.repo.WithContext(ctx).GetByIDAsOf(planID, asOf)
+	if err != nil {
+		return nil, apperrors.NewNotFoundError("SubscriptionPlan", id)
 	}
 
-	plan := &models.SubscriptionPlan{
-		ProductID: prodID,
-		PlanName:  planName,
-		Duration:  duration,
-		Price:     price,
+	return plan, nil
+}
+
+func (s *subscriptionService) UpdateSubscriptionPlan(ctx context.Context, id, productID, planName string, interval models.BillingInterval, price float64, pricingModel string, tiers []models.PriceTier, meter *models.Meter, trialDays int, introPhases []models.IntroPhase) (*models.SubscriptionPlan, error) {
+	return s.updatePlan(ctx, s.repo.WithContext(ctx), SubscriptionPlanInput{
+		ID:           id,
+		ProductID:    productID,
+		PlanName:     planName,
+		Interval:     interval,
+		Price:        price,
+		PricingModel: pricingModel,
+		Tiers:        tiers,
+		Meter:        meter,
+		TrialDays:    trialDays,
+		IntroPhases:  introPhases,
+	})
+}
+
+func (s *subscriptionService) DeleteSubscriptionPlan(ctx context.Context, id string) error {
+	_, err := s.deletePlan(ctx, s.repo.WithContext(ctx), id)
+	return err
+}
+
+func (s *subscriptionService) BatchGetSubscriptionPlans(ctx context.Context, ids []string) ([]SubscriptionPlanResult, error) {
+	if err := validateBatchSize(len(ids), s.opts.maxBatchSize); err != nil {
+		return nil, err
 	}
 
-	if err := s.repo.Create(plan); err != nil {
-		return nil, apperrors.NewDatabaseError("create subscription plan", err)
+	results := make([]SubscriptionPlanResult, len(ids))
+	planIDs := make([]uuid.UUID, len(ids))
+	lookup := make([]uuid.UUID, 0, len(ids))
+	for i, id := range ids {
+		planID, err := parsePlanID(id)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		planIDs[i] = planID
+		lookup = append(lookup, planID)
 	}
 
-	return plan, nil
+	plans, err := s.repo.WithContext(ctx).GetByIDs(lookup)
+	if err != nil {
+		return nil, apperrors.NewDatabaseError("batch get subscription plans", err)
+	}
+
+	byID := make(map[uuid.UUID]*models.SubscriptionPlan, len(plans))
+	for i := range plans {
+		byID[plans[i].ID] = &plans[i]
+	}
+
+	for i, id := range ids {
+		if results[i].Err != nil {
+			continue
+		}
+		plan, ok := byID[planIDs[i]]
+		if !ok {
+			results[i].Err = apperrors.NewNotFoundError("SubscriptionPlan", id)
+			continue
+		}
+		results[i].Plan = plan
+	}
+
+	return results, nil
 }
 
-func (s *subscriptionService) GetSubscriptionPlan(id string) (*models.SubscriptionPlan, error) {
-	planID, err := parsePlanID(id)
+func (s *subscriptionService) BatchCreateSubscriptionPlans(ctx context.Context, inputs []SubscriptionPlanInput, atomic bool) ([]SubscriptionPlanResult, error) {
+	return s.batchWrite(ctx, len(inputs), atomic, "batch create subscription plans", func(repo repository.SubscriptionRepository, i int) (*models.SubscriptionPlan, error) {
+		return s.createPlan(ctx, repo, inputs[i])
+	})
+}
+
+func (s *subscriptionService) BatchUpdateSubscriptionPlans(ctx context.Context, inputs []SubscriptionPlanInput, atomic bool) ([]SubscriptionPlanResult, error) {
+	return s.batchWrite(ctx, len(inputs), atomic, "batch update subscription plans", func(repo repository.SubscriptionRepository, i int) (*models.SubscriptionPlan, error) {
+		return s.updatePlan(ctx, repo, inputs[i])
+	})
+}
+
+func (s *subscriptionService) BatchDeleteSubscriptionPlans(ctx context.Context, ids []string, atomic bool) ([]SubscriptionPlanResult, error) {
+	return s.batchWrite(ctx, len(ids), atomic, "batch delete subscription plans", func(repo repository.SubscriptionRepository, i int) (*models.SubscriptionPlan, error) {
+		return s.deletePlan(ctx, repo, ids[i])
+	})
+}
+
+func (s *subscriptionService) batchWrite(ctx context.Context, size int, atomic bool, operation string, fn func(repo repository.SubscriptionRepository, i int) (*models.SubscriptionPlan, error)) ([]SubscriptionPlanResult, error) {
+	if err := validateBatchSize(size, s.opts.maxBatchSize); err != nil {
+		return nil, err
+	}
+
+	results := make([]SubscriptionPlanResult, size)
+	err := runBatch(s.repo.WithContext(ctx), size, atomic, func(repo repository.SubscriptionRepository, i int) error {
+		plan, err := fn(repo, i)
+		results[i] = SubscriptionPlanResult{Plan: plan, Err: err}
+		return err
+	})
 	if err != nil {
+		var itemErr *apperrors.BatchItemError
+		if errors.As(err, &itemErr) {
+			return nil, err
+		}
+		return nil, apperrors.NewDatabaseError(operation, err)
+	}
+
+	return results, nil
+}
+
+func (s *subscriptionService) WatchSubscriptionPlans(ctx context.Context, productType, productID string, fromSequence uint64) (*events.Subscription, error) {
+	filter := events.Filter{
+		TenantID:    tenant.FromContext(ctx),
+		Aggregate:   events.AggregateSubscriptionPlan,
+		ProductType: productType,
+	}
+	if productID != "" {
+		id, err := parseProductID(productID)
+		if err != nil {
+			return nil, err
+		}
+		filter.ProductID = id
+	}
+
+	return s.opts.broker.Subscribe(fromSequence, filter)
+}
+
+func (s *subscriptionService) createPlan(ctx context.Context, repo repository.SubscriptionRepository, in SubscriptionPlanInput) (*models.SubscriptionPlan, error) {
+	if err := validateSubscriptionInput(in.PlanName, in.Interval, in.Price, in.TrialDays, in.IntroPhases); err != nil {
+		return nil, err
+	}
+	pricingModel := pricingModelOrDefault(in.PricingModel)
+	if err := validatePricing(pricingModel, in.Price, in.Tiers, in.IntroPhases); err != nil {
+		return nil, err
+	}
+	if err := validateMeter(in.Meter, pricingModel, in.IntroPhases); err != nil {
 		return nil, err
 	}
 
-	plan, err := s.repo.GetByID(planID)
+	prodID, err := parseProductID(in.ProductID)
 	if err != nil {
-		return nil, apperrors.NewNotFoundError("SubscriptionPlan", id)
+		return nil, err
+	}
+
+	if _, err := s.productRepo.WithContext(ctx).GetByID(prodID); err != nil {
+		return nil, apperrors.NewNotFoundError("Product", in.ProductID)
+	}
+
+	plan := &models.SubscriptionPlan{
+		ProductID:    prodID,
+		PlanName:     in.PlanName,
+		Interval:     in.Interval,
+		Duration:     in.Interval.NominalDays(),
+		Price:        in.Price,
+		PricingModel: pricingModel,
+		Tiers:        in.Tiers,
+		Meter:        in.Meter,
+		TrialDays:    in.TrialDays,
+		IntroPhases:  in.IntroPhases,
+	}
+
+	if err := repo.Create(plan); err != nil {
+		return nil, apperrors.NewDatabaseError("create subscription plan", err)
 	}
 
 	return plan, nil
 }
 
-func (s *subscriptionService) UpdateSubscriptionPlan(id, productID, planName string, duration int, price float64) (*models.SubscriptionPlan, error) {
-	planID, err := parsePlanID(id)
+func (s *subscriptionService) updatePlan(ctx context.Context, repo repository.SubscriptionRepository, in SubscriptionPlanInput) (*models.SubscriptionPlan, error) {
+	planID, err := parsePlanID(in.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	if _, err := s.repo.GetByID(planID); err != nil {
-		return nil, apperrors.NewNotFoundError("SubscriptionPlan", id)
+	if _, err := repo.GetByID(planID); err != nil {
+		return nil, apperrors.NewNotFoundError("SubscriptionPlan", in.ID)
 	}
 
-	if err := validateSubscriptionInput(planName, duration, price); err != nil {
+	if err := validateSubscriptionInput(in.PlanName, in.Interval, in.Price, in.TrialDays, in.IntroPhases); err != nil {
+		return nil, err
+	}
+	pricingModel := pricingModelOrDefault(in.PricingModel)
+	if err := validatePricing(pricingModel, in.Price, in.Tiers, in.IntroPhases); err != nil {
+		return nil, err
+	}
+	if err := validateMeter(in.Meter, pricingModel, in.IntroPhases); err != nil {
 		return nil, err
 	}
 
-	prodID, err := parseProductID(productID)
+	prodID, err := parseProductID(in.ProductID)
 	if err != nil {
 		return nil, err
 	}
 
-	if _, err := s.productRepo.GetByID(prodID); err != nil {
-		return nil, apperrors.NewNotFoundError("Product", productID)
+	if _, err := s.productRepo.WithContext(ctx).GetByID(prodID); err != nil {
+		return nil, apperrors.NewNotFoundError("Product", in.ProductID)
 	}
 
 	plan := &models.SubscriptionPlan{
-		ID:        planID,
-		ProductID: prodID,
-		PlanName:  planName,
-		Duration:  duration,
-		Price:     price,
+		ID:           planID,
+		ProductID:    prodID,
+		PlanName:     in.PlanName,
+		Interval:     in.Interval,
+		Duration:     in.Interval.NominalDays(),
+		Price:        in.Price,
+		PricingModel: pricingModel,
+		Tiers:        in.Tiers,
+		Meter:        in.Meter,
+		TrialDays:    in.TrialDays,
+		IntroPhases:  in.IntroPhases,
 	}
 
-	if err := s.repo.Update(plan); err != nil {
+	if err := repo.Update(plan); err != nil {
 		return nil, apperrors.NewDatabaseError("update subscription plan", err)
 	}
 
-	return s.repo.GetByID(planID)
+	return repo.GetByID(planID)
 }
 
-func (s *subscriptionService) DeleteSubscriptionPlan(id string) error {
+func (s *subscriptionService) deletePlan(ctx context.Context, repo repository.SubscriptionRepository, id string) (*models.SubscriptionPlan, error) {
 	planID, err := parsePlanID(id)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if _, err := s.repo.GetByID(planID); err != nil {
-		return apperrors.NewNotFoundError("SubscriptionPlan", id)
+	plan, err := repo.GetByID(planID)
+	if err != nil {
+		return nil, apperrors.NewNotFoundError("SubscriptionPlan", id)
 	}
 
-	if err := s.repo.Delete(planID); err != nil {
-		return apperrors.NewDatabaseError("delete subscription plan", err)
+	if err := repo.Delete(planID); err != nil {
+		return nil, apperrors.NewDatabaseError("delete subscription plan", err)
 	}
 
-	return nil
+	return plan, nil
+}
+
+func (s *subscriptionService) ListSubscriptionPlans(ctx context.Context, productID string) ([]models.SubscriptionPlan, error) {
+	prodID, err := parseProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	plans, err := s.repo.WithContext(ctx).ListByProductID(prodID)
+	if err != nil {
+		return nil, apperrors.NewDatabaseError("list subscription plans", err)
+	}
+
+	return plans, nil
 }
 
-func (s *subscriptionService) ListSubscriptionPlans(productID string) ([]models.SubscriptionPlan, error) {
+func (s *subscriptionService) ListSubscriptionPlansAsOf(ctx context.Context, productID string, asOf time.Time) ([]models.SubscriptionPlan, error) {
 	prodID, err := parseProductID(productID)
 	if err != nil {
 		return nil, err
 	}
 
-	plans, err := s.repo.ListByProductID(prodID)
+	plans, err := s.repo.WithContext(ctx).ListByProductIDAsOf(prodID, asOf)
 	if err != nil {
 		return nil, apperrors.NewDatabaseError("list subscription plans", err)
 	}
@@ -139,6 +369,46 @@ func (s *subscriptionService) ListSubscriptionPlans(productID string) ([]models.
 	return plans, nil
 }
 
+// GetPriceSchedule returns the trial and first cycles billing cycles of a
+// subscription to the plan started at start, with what each costs.
+func (s *subscriptionService) GetPriceSchedule(ctx context.Context, id string, start time.Time, cycles int) ([]models.PricePeriod, error) {
+	if cycles <= 0 || cycles > maxScheduleCycles {
+		return nil, apperrors.NewValidationError("cycles", "cycles must be between 1 and 120")
+	}
+
+	plan, err := s.GetSubscriptionPlan(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.PriceSchedule(start, cycles), nil
+}
+
+// CalculatePrice returns what quantity units of the plan cost for one billing
+// cycle at its regular price, broken down by tier and taxed at location.
+func (s *subscriptionService) CalculatePrice(ctx context.Context, id string, quantity int, location TaxLocation) (*PriceCalculation, error) {
+	if quantity <= 0 || quantity > maxQuantity {
+		return nil, apperrors.NewValidationError("quantity", "quantity must be between 1 and 1000000")
+	}
+	location, err := normalizeTaxLocation(location)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := s.GetSubscriptionPlan(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	breakdown := plan.Calculate(quantity)
+	taxed, err := calculateTax(ctx, s.opts, location, plan.Product, breakdown.Total)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PriceCalculation{Plan: plan, Breakdown: breakdown, Tax: taxed}, nil
+}
+
 func parsePlanID(id string) (uuid.UUID, error) {
 	if id == "" {
 		return uuid.Nil, apperrors.NewValidationError("id", "subscription plan ID is required")
@@ -152,21 +422,130 @@ func parsePlanID(id string) (uuid.UUID, error) {
 	return planID, nil
 }
 
-func validateSubscriptionInput(planName string, duration int, price float64) error {
+func validateSubscriptionInput(planName string, interval models.BillingInterval, price float64, trialDays int, introPhases []models.IntroPhase) error {
 	if planName == "" {
 		return apperrors.NewValidationError("planName", "plan name is required")
 	}
 	if len(planName) > 255 {
 		return apperrors.NewValidationError("planName", "plan name must be less than 255 characters")
 	}
-	if duration <= 0 {
-		return apperrors.NewValidationError("duration", "duration must be positive")
-	}
-	if duration > 3650 { 
-		return apperrors.NewValidationError("duration", "duration cannot exceed 3650 days")
+	if err := validateInterval(interval); err != nil {
+		return err
 	}
 	if price < 0 {
 		return apperrors.NewValidationError("price", "price cannot be negative")
 	}
+	if trialDays < 0 {
+		return apperrors.NewValidationError("trialDays", "trial days cannot be negative")
+	}
+	if trialDays > maxTrialDays {
+		return apperrors.NewValidationError("trialDays", "trial cannot exceed 365 days")
+	}
+	if len(introPhases) > maxIntroPhases {
+		return apperrors.NewValidationError("introPhases", "a plan can have at most 10 introductory phases")
+	}
+	for _, phase := range introPhases {
+		if phase.Cycles <= 0 {
+			return apperrors.NewValidationError("introPhases", "introductory phase cycles must be positive")
+		}
+		if phase.Price < 0 {
+			return apperrors.NewValidationError("introPhases", "introductory price cannot be negative")
+		}
+	}
+	return nil
+}
+
+func pricingModelOrDefault(pricingModel string) string {
+	if pricingModel == "" {
+		return models.PricingFlat
+	}
+	return pricingModel
+}
+
+// validatePricing checks that the tiers fit the pricing model. Tiers must
+// cover every quantity: each ends above the previous one and only the last is
+// unbounded.
+func validatePricing(pricingModel string, price float64, tiers []models.PriceTier, introPhases []models.IntroPhase) error {
+	switch pricingModel {
+	case models.PricingFlat, models.PricingPerUnit:
+		if len(tiers) > 0 {
+			return apperrors.NewValidationError("tiers", "tiers only apply to graduated and volume pricing")
+		}
+		return nil
+	case models.PricingGraduated, models.PricingVolume:
+	default:
+		return apperrors.NewValidationError("pricingModel", "pricing model must be flat, per_unit, graduated or volume")
+	}
+
+	if price != 0 {
+		return apperrors.NewValidationError("price", "price is set by the tiers for tiered pricing")
+	}
+	if len(introPhases) > 0 {
+		return apperrors.NewValidationError("introPhases", "introductory phases are only supported on flat and per-unit pricing")
+	}
+	if len(tiers) == 0 {
+		return apperrors.NewValidationError("tiers", "tiered pricing needs at least one tier")
+	}
+	if len(tiers) > maxPriceTiers {
+		return apperrors.NewValidationError("tiers", "a plan can have at most 20 tiers")
+	}
+	last := 0
+	for i, tier := range tiers {
+		if tier.UnitPrice < 0 || tier.FlatFee < 0 {
+			return apperrors.NewValidationError("tiers", "tier prices cannot be negative")
+		}
+		if i == len(tiers)-1 {
+			if tier.UpTo != 0 {
+				return apperrors.NewValidationError("tiers", "the last tier must have no upper bound")
+			}
+			break
+		}
+		if tier.UpTo == 0 {
+			return apperrors.NewValidationError("tiers", "only the last tier can be unbounded")
+		}
+		if tier.UpTo <= last {
+			return apperrors.NewValidationError("tiers", "tier upper bounds must increase")
+		}
+		last = tier.UpTo
+	}
+	return nil
+}
+
+// validateMeter checks the meter of a pay-as-you-go plan. Usage is priced per
+// unit, so flat pricing and introductory prices per cycle do not apply.
+func validateMeter(meter *models.Meter, pricingModel string, introPhases []models.IntroPhase) error {
+	if meter == nil {
+		return nil
+	}
+	if meter.Unit == "" {
+		return apperrors.NewValidationError("meter", "meter unit is required")
+	}
+	if len(meter.Unit) > 50 {
+		return apperrors.NewValidationError("meter", "meter unit must be at most 50 characters")
+	}
+	switch meter.Aggregation {
+	case models.AggregationSum, models.AggregationMax, models.AggregationLast:
+	default:
+		return apperrors.NewValidationError("meter", "meter aggregation must be sum, max or last")
+	}
+	if pricingModel == models.PricingFlat {
+		return apperrors.NewValidationError("pricingModel", "metered plans need per_unit, graduated or volume pricing")
+	}
+	if len(introPhases) > 0 {
+		return apperrors.NewValidationError("introPhases", "introductory phases are not supported on metered plans")
+	}
+	return nil
+}
+
+func validateInterval(interval models.BillingInterval) error {
+	if !interval.ValidUnit() {
+		return apperrors.NewValidationError("interval", "interval unit must be day, week, month or year")
+	}
+	if interval.Count <= 0 {
+		return apperrors.NewValidationError("interval", "interval count must be positive")
+	}
+	if interval.NominalDays() > maxIntervalDays {
+		return apperrors.NewValidationError("interval", "interval cannot exceed 10 years")
+	}
 	return nil
 }
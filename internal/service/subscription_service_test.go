@@ -1,15 +1,23 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/events"
 	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/repository"
+	"github.com/microservice-go/product-service/internal/tax"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+var monthly = models.BillingInterval{Unit: models.IntervalMonth, Count: 1}
+
 type MockSubscriptionRepository struct {
 	mock.Mock
 }
@@ -42,6 +50,35 @@ func (m *MockSubscriptionRepository) ListByProductID(productID uuid.UUID) ([]mod
 	return args.Get(0).([]models.SubscriptionPlan), args.Error(1)
 }
 
+func (m *MockSubscriptionRepository) GetByIDs(ids []uuid.UUID) ([]models.SubscriptionPlan, error) {
+	args := m.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SubscriptionPlan), args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) GetByIDAsOf(id uuid.UUID, asOf time.Time) (*models.SubscriptionPlan, error) {
+	args := m.Called(id, asOf)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SubscriptionPlan), args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) ListByProductIDAsOf(productID uuid.UUID, asOf time.Time) ([]models.SubscriptionPlan, error) {
+	args := m.Called(productID, asOf)
+	return args.Get(0).([]models.SubscriptionPlan), args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) Transaction(fn func(repo repository.SubscriptionRepository) error) error {
+	return fn(m)
+}
+
+func (m *MockSubscriptionRepository) WithContext(ctx context.Context) repository.SubscriptionRepository {
+	return m
+}
+
 type MockProductRepositoryForSubscription struct {
 	mock.Mock
 }
@@ -74,6 +111,35 @@ func (m *MockProductRepositoryForSubscription) List(productType string, page, pa
 	return args.Get(0).([]models.Product), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockProductRepositoryForSubscription) GetByIDs(ids []uuid.UUID) ([]models.Product, error) {
+	args := m.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Product), args.Error(1)
+}
+
+func (m *MockProductRepositoryForSubscription) GetByIDAsOf(id uuid.UUID, asOf time.Time) (*models.Product, error) {
+	args := m.Called(id, asOf)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Product), args.Error(1)
+}
+
+func (m *MockProductRepositoryForSubscription) ListAsOf(productType string, page, pageSize int, asOf time.Time) ([]models.Product, int64, error) {
+	args := m.Called(productType, page, pageSize, asOf)
+	return args.Get(0).([]models.Product), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockProductRepositoryForSubscription) Transaction(fn func(repo repository.ProductRepository) error) error {
+	return fn(m)
+}
+
+func (m *MockProductRepositoryForSubscription) WithContext(ctx context.Context) repository.ProductRepository {
+	return m
+}
+
 func TestCreateSubscriptionPlan_Success(t *testing.T) {
 	mockRepo := new(MockSubscriptionRepository)
 	mockProductRepo := new(MockProductRepositoryForSubscription)
@@ -91,11 +157,12 @@ func TestCreateSubscriptionPlan_Success(t *testing.T) {
 	mockProductRepo.On("GetByID", productID).Return(expectedProduct, nil)
 	mockRepo.On("Create", mock.AnythingOfType("*models.SubscriptionPlan")).Return(nil)
 
-	plan, err := service.CreateSubscriptionPlan(productID.String(), "Monthly Plan", 30, 29.99)
+	plan, err := service.CreateSubscriptionPlan(context.Background(), productID.String(), "Monthly Plan", monthly, 29.99, "", nil, nil, 0, nil)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, plan)
 	assert.Equal(t, "Monthly Plan", plan.PlanName)
+	assert.Equal(t, monthly, plan.Interval)
 	assert.Equal(t, 30, plan.Duration)
 	assert.Equal(t, 29.99, plan.Price)
 	assert.Equal(t, productID, plan.ProductID)
@@ -108,7 +175,7 @@ func TestCreateSubscriptionPlan_EmptyPlanName(t *testing.T) {
 	mockProductRepo := new(MockProductRepositoryForSubscription)
 	service := NewSubscriptionService(mockRepo, mockProductRepo)
 
-	plan, err := service.CreateSubscriptionPlan(uuid.New().String(), "", 30, 29.99)
+	plan, err := service.CreateSubscriptionPlan(context.Background(), uuid.New().String(), "", monthly, 29.99, "", nil, nil, 0, nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, plan)
@@ -120,11 +187,11 @@ func TestCreateSubscriptionPlan_InvalidDuration(t *testing.T) {
 	mockProductRepo := new(MockProductRepositoryForSubscription)
 	service := NewSubscriptionService(mockRepo, mockProductRepo)
 
-	plan, err := service.CreateSubscriptionPlan(uuid.New().String(), "Monthly Plan", 0, 29.99)
+	plan, err := service.CreateSubscriptionPlan(context.Background(), uuid.New().String(), "Monthly Plan", models.BillingInterval{Unit: models.IntervalMonth}, 29.99, "", nil, nil, 0, nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, plan)
-	assert.Contains(t, err.Error(), "duration must be positive")
+	assert.Contains(t, err.Error(), "interval count must be positive")
 }
 
 func TestCreateSubscriptionPlan_NegativePrice(t *testing.T) {
@@ -132,7 +199,7 @@ func TestCreateSubscriptionPlan_NegativePrice(t *testing.T) {
 	mockProductRepo := new(MockProductRepositoryForSubscription)
 	service := NewSubscriptionService(mockRepo, mockProductRepo)
 
-	plan, err := service.CreateSubscriptionPlan(uuid.New().String(), "Monthly Plan", 30, -10.0)
+	plan, err := service.CreateSubscriptionPlan(context.Background(), uuid.New().String(), "Monthly Plan", monthly, -10.0, "", nil, nil, 0, nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, plan)
@@ -144,7 +211,7 @@ func TestCreateSubscriptionPlan_InvalidProductID(t *testing.T) {
 	mockProductRepo := new(MockProductRepositoryForSubscription)
 	service := NewSubscriptionService(mockRepo, mockProductRepo)
 
-	plan, err := service.CreateSubscriptionPlan("invalid-uuid", "Monthly Plan", 30, 29.99)
+	plan, err := service.CreateSubscriptionPlan(context.Background(), "invalid-uuid", "Monthly Plan", monthly, 29.99, "", nil, nil, 0, nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, plan)
@@ -159,7 +226,7 @@ func TestCreateSubscriptionPlan_ProductNotFound(t *testing.T) {
 	productID := uuid.New()
 	mockProductRepo.On("GetByID", productID).Return(nil, errors.New("product not found"))
 
-	plan, err := service.CreateSubscriptionPlan(productID.String(), "Monthly Plan", 30, 29.99)
+	plan, err := service.CreateSubscriptionPlan(context.Background(), productID.String(), "Monthly Plan", monthly, 29.99, "", nil, nil, 0, nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, plan)
@@ -167,6 +234,145 @@ func TestCreateSubscriptionPlan_ProductNotFound(t *testing.T) {
 	mockProductRepo.AssertExpectations(t)
 }
 
+func TestCreateSubscriptionPlan_Tiered(t *testing.T) {
+	mockRepo := new(MockSubscriptionRepository)
+	mockProductRepo := new(MockProductRepositoryForSubscription)
+	service := NewSubscriptionService(mockRepo, mockProductRepo)
+
+	productID := uuid.New()
+	tiers := []models.PriceTier{{UpTo: 10, UnitPrice: 10}, {UpTo: 100, UnitPrice: 8}, {UnitPrice: 5}}
+	mockProductRepo.On("GetByID", productID).Return(&models.Product{ID: productID}, nil)
+	mockRepo.On("Create", mock.AnythingOfType("*models.SubscriptionPlan")).Return(nil)
+
+	plan, err := service.CreateSubscriptionPlan(context.Background(), productID.String(), "Seats", monthly, 0, models.PricingGraduated, tiers, nil, 0, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.PricingGraduated, plan.PricingModel)
+	assert.Equal(t, tiers, plan.Tiers)
+
+	plan, err = service.CreateSubscriptionPlan(context.Background(), productID.String(), "Monthly Plan", monthly, 29.99, "", nil, nil, 0, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.PricingFlat, plan.PricingModel, "plans are flat by default")
+}
+
+func TestCreateSubscriptionPlan_InvalidPricing(t *testing.T) {
+	mockRepo := new(MockSubscriptionRepository)
+	mockProductRepo := new(MockProductRepositoryForSubscription)
+	service := NewSubscriptionService(mockRepo, mockProductRepo)
+
+	tiers := []models.PriceTier{{UpTo: 10, UnitPrice: 10}, {UnitPrice: 5}}
+	tests := []struct {
+		name        string
+		model       string
+		price       float64
+		tiers       []models.PriceTier
+		introPhases []models.IntroPhase
+		want        string
+	}{
+		{"unknown model", "stairstep", 10, nil, nil, "pricing model must be flat, per_unit, graduated or volume"},
+		{"tiers on flat", models.PricingFlat, 10, tiers, nil, "tiers only apply to graduated and volume pricing"},
+		{"no tiers", models.PricingVolume, 0, nil, nil, "tiered pricing needs at least one tier"},
+		{"price on tiered", models.PricingGraduated, 10, tiers, nil, "price is set by the tiers"},
+		{"intro phases on tiered", models.PricingVolume, 0, tiers, []models.IntroPhase{{Price: 1, Cycles: 1}}, "introductory phases are only supported"},
+		{"bounded last tier", models.PricingGraduated, 0, []models.PriceTier{{UpTo: 10, UnitPrice: 10}}, nil, "the last tier must have no upper bound"},
+		{"unbounded middle tier", models.PricingGraduated, 0, []models.PriceTier{{UnitPrice: 10}, {UnitPrice: 5}}, nil, "only the last tier can be unbounded"},
+		{"decreasing bounds", models.PricingGraduated, 0, []models.PriceTier{{UpTo: 100, UnitPrice: 10}, {UpTo: 10, UnitPrice: 8}, {UnitPrice: 5}}, nil, "tier upper bounds must increase"},
+		{"negative tier price", models.PricingVolume, 0, []models.PriceTier{{UpTo: 10, UnitPrice: -1}, {UnitPrice: 5}}, nil, "tier prices cannot be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := service.CreateSubscriptionPlan(context.Background(), uuid.New().String(), "Seats", monthly, tt.price, tt.model, tt.tiers, nil, 0, tt.introPhases)
+
+			assert.Nil(t, plan)
+			assert.True(t, apperrors.IsValidationError(err))
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestCreateSubscriptionPlan_InvalidMeter(t *testing.T) {
+	mockRepo := new(MockSubscriptionRepository)
+	mockProductRepo := new(MockProductRepositoryForSubscription)
+	service := NewSubscriptionService(mockRepo, mockProductRepo)
+
+	tests := []struct {
+		name        string
+		model       string
+		meter       *models.Meter
+		introPhases []models.IntroPhase
+		want        string
+	}{
+		{"no unit", models.PricingPerUnit, &models.Meter{Aggregation: models.AggregationSum}, nil, "meter unit is required"},
+		{"unknown aggregation", models.PricingPerUnit, &models.Meter{Unit: "gb", Aggregation: "avg"}, nil, "meter aggregation must be sum, max or last"},
+		{"flat pricing", "", &models.Meter{Unit: "gb", Aggregation: models.AggregationSum}, nil, "metered plans need per_unit, graduated or volume pricing"},
+		{"intro phases", models.PricingPerUnit, &models.Meter{Unit: "gb", Aggregation: models.AggregationSum}, []models.IntroPhase{{Price: 1, Cycles: 1}}, "introductory phases are not supported on metered plans"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := service.CreateSubscriptionPlan(context.Background(), uuid.New().String(), "Storage", monthly, 0.1, tt.model, nil, tt.meter, 0, tt.introPhases)
+
+			assert.Nil(t, plan)
+			assert.True(t, apperrors.IsValidationError(err))
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestCalculatePrice(t *testing.T) {
+	mockRepo := new(MockSubscriptionRepository)
+	mockProductRepo := new(MockProductRepositoryForSubscription)
+	service := NewSubscriptionService(mockRepo, mockProductRepo)
+
+	planID := uuid.New()
+	mockRepo.On("GetByID", planID).Return(&models.SubscriptionPlan{
+		ID:           planID,
+		PricingModel: models.PricingVolume,
+		Tiers:        []models.PriceTier{{UpTo: 10, UnitPrice: 10}, {UpTo: 100, UnitPrice: 8}, {UnitPrice: 5}},
+	}, nil)
+
+	calculation, err := service.CalculatePrice(context.Background(), planID.String(), 12, TaxLocation{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, planID, calculation.Plan.ID)
+	assert.Equal(t, 96.0, calculation.Breakdown.Total)
+	assert.Len(t, calculation.Breakdown.Tiers, 1)
+	assert.Equal(t, tax.Result{Net: 96, Gross: 96}, calculation.Tax)
+
+	_, err = service.CalculatePrice(context.Background(), planID.String(), 0, TaxLocation{})
+	assert.Contains(t, err.Error(), "quantity must be between 1 and 1000000")
+}
+
+func TestCalculatePrice_Tax(t *testing.T) {
+	mockRepo := new(MockSubscriptionRepository)
+	mockProductRepo := new(MockProductRepositoryForSubscription)
+	rates, err := tax.NewTable([]tax.Rate{
+		{Country: "US", Region: "CA", Rate: 0.0725},
+		{Country: "US", Region: "CA", ProductType: "digital", Rate: 0},
+	})
+	assert.NoError(t, err)
+	service := NewSubscriptionService(mockRepo, mockProductRepo, WithTaxCalculator(rates))
+
+	physical := &models.SubscriptionPlan{ID: uuid.New(), PricingModel: models.PricingPerUnit, Price: 10, Product: models.Product{ProductType: "physical"}}
+	digital := &models.SubscriptionPlan{ID: uuid.New(), PricingModel: models.PricingPerUnit, Price: 10, Product: models.Product{ProductType: "digital"}}
+	mockRepo.On("GetByID", physical.ID).Return(physical, nil)
+	mockRepo.On("GetByID", digital.ID).Return(digital, nil)
+
+	calculation, err := service.CalculatePrice(context.Background(), physical.ID.String(), 4, TaxLocation{Country: "us", Region: "ca"})
+	assert.NoError(t, err)
+	assert.Equal(t, tax.Result{Net: 40, Tax: 2.9, Gross: 42.9, Rate: 0.0725}, calculation.Tax)
+
+	calculation, err = service.CalculatePrice(context.Background(), digital.ID.String(), 3, TaxLocation{Country: "US", Region: "CA"})
+	assert.NoError(t, err)
+	assert.Equal(t, tax.Result{Net: 30, Gross: 30}, calculation.Tax, "the product type rate overrides the region rate")
+
+	_, err = service.CalculatePrice(context.Background(), physical.ID.String(), 3, TaxLocation{Region: "CA"})
+	assert.True(t, apperrors.IsValidationError(err))
+	assert.Contains(t, err.Error(), "country is required with a region")
+}
+
 func TestGetSubscriptionPlan_Success(t *testing.T) {
 	mockRepo := new(MockSubscriptionRepository)
 	mockProductRepo := new(MockProductRepositoryForSubscription)
@@ -183,7 +389,7 @@ func TestGetSubscriptionPlan_Success(t *testing.T) {
 
 	mockRepo.On("GetByID", planID).Return(expectedPlan, nil)
 
-	plan, err := service.GetSubscriptionPlan(planID.String())
+	plan, err := service.GetSubscriptionPlan(context.Background(), planID.String())
 
 	assert.NoError(t, err)
 	assert.NotNil(t, plan)
@@ -197,7 +403,7 @@ func TestGetSubscriptionPlan_InvalidID(t *testing.T) {
 	mockProductRepo := new(MockProductRepositoryForSubscription)
 	service := NewSubscriptionService(mockRepo, mockProductRepo)
 
-	plan, err := service.GetSubscriptionPlan("invalid-uuid")
+	plan, err := service.GetSubscriptionPlan(context.Background(), "invalid-uuid")
 
 	assert.Error(t, err)
 	assert.Nil(t, plan)
@@ -212,7 +418,7 @@ func TestGetSubscriptionPlan_NotFound(t *testing.T) {
 	planID := uuid.New()
 	mockRepo.On("GetByID", planID).Return(nil, errors.New("subscription plan not found"))
 
-	plan, err := service.GetSubscriptionPlan(planID.String())
+	plan, err := service.GetSubscriptionPlan(context.Background(), planID.String())
 
 	assert.Error(t, err)
 	assert.Nil(t, plan)
@@ -220,6 +426,60 @@ func TestGetSubscriptionPlan_NotFound(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestGetPriceSchedule(t *testing.T) {
+	mockRepo := new(MockSubscriptionRepository)
+	mockProductRepo := new(MockProductRepositoryForSubscription)
+	service := NewSubscriptionService(mockRepo, mockProductRepo)
+
+	planID := uuid.New()
+	mockRepo.On("GetByID", planID).Return(&models.SubscriptionPlan{
+		ID:        planID,
+		PlanName:  "Monthly Plan",
+		Interval:  monthly,
+		Price:     29.99,
+		TrialDays: 14,
+	}, nil)
+
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	schedule, err := service.GetPriceSchedule(context.Background(), planID.String(), start, 3)
+
+	assert.NoError(t, err)
+	assert.Len(t, schedule, 4)
+	assert.True(t, schedule[0].Trial)
+	assert.Equal(t, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), schedule[1].Start)
+	assert.Equal(t, 29.99, schedule[3].Price)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetPriceSchedule_InvalidCycles(t *testing.T) {
+	service := NewSubscriptionService(new(MockSubscriptionRepository), new(MockProductRepositoryForSubscription))
+
+	for _, cycles := range []int{0, 121} {
+		schedule, err := service.GetPriceSchedule(context.Background(), uuid.New().String(), time.Now(), cycles)
+		assert.Nil(t, schedule)
+		assert.Contains(t, err.Error(), "cycles must be between 1 and 120")
+	}
+}
+
+func TestGetSubscriptionPlanAsOf(t *testing.T) {
+	mockRepo := new(MockSubscriptionRepository)
+	mockProductRepo := new(MockProductRepositoryForSubscription)
+	service := NewSubscriptionService(mockRepo, mockProductRepo)
+
+	planID := uuid.New()
+	asOf := time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)
+	mockRepo.On("GetByIDAsOf", planID, asOf).Return(&models.SubscriptionPlan{ID: planID, Price: 299.99}, nil)
+	mockRepo.On("GetByIDAsOf", planID, asOf.AddDate(-1, 0, 0)).Return(nil, errors.New("subscription plan not found"))
+
+	plan, err := service.GetSubscriptionPlanAsOf(context.Background(), planID.String(), asOf)
+	assert.NoError(t, err)
+	assert.Equal(t, 299.99, plan.Price)
+
+	_, err = service.GetSubscriptionPlanAsOf(context.Background(), planID.String(), asOf.AddDate(-1, 0, 0))
+	assert.True(t, apperrors.IsNotFoundError(err))
+	mockRepo.AssertExpectations(t)
+}
+
 func TestUpdateSubscriptionPlan_Success(t *testing.T) {
 	mockRepo := new(MockSubscriptionRepository)
 	mockProductRepo := new(MockProductRepositoryForSubscription)
@@ -247,7 +507,7 @@ func TestUpdateSubscriptionPlan_Success(t *testing.T) {
 	mockRepo.On("Update", mock.AnythingOfType("*models.SubscriptionPlan")).Return(nil)
 	mockRepo.On("GetByID", planID).Return(expectedPlan, nil).Once()
 
-	plan, err := service.UpdateSubscriptionPlan(planID.String(), productID.String(), "Updated Plan", 60, 49.99)
+	plan, err := service.UpdateSubscriptionPlan(context.Background(), planID.String(), productID.String(), "Updated Plan", models.BillingInterval{Unit: models.IntervalMonth, Count: 2}, 49.99, "", nil, nil, 0, nil)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, plan)
@@ -266,7 +526,7 @@ func TestUpdateSubscriptionPlan_PlanNotFound(t *testing.T) {
 	planID := uuid.New()
 	mockRepo.On("GetByID", planID).Return(nil, errors.New("subscription plan not found"))
 
-	plan, err := service.UpdateSubscriptionPlan(planID.String(), uuid.New().String(), "Updated Plan", 60, 49.99)
+	plan, err := service.UpdateSubscriptionPlan(context.Background(), planID.String(), uuid.New().String(), "Updated Plan", models.BillingInterval{Unit: models.IntervalMonth, Count: 2}, 49.99, "", nil, nil, 0, nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, plan)
@@ -291,7 +551,7 @@ func TestDeleteSubscriptionPlan_Success(t *testing.T) {
 	mockRepo.On("GetByID", planID).Return(expectedPlan, nil)
 	mockRepo.On("Delete", planID).Return(nil)
 
-	err := service.DeleteSubscriptionPlan(planID.String())
+	err := service.DeleteSubscriptionPlan(context.Background(), planID.String())
 
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
@@ -305,7 +565,7 @@ func TestDeleteSubscriptionPlan_PlanNotFound(t *testing.T) {
 	planID := uuid.New()
 	mockRepo.On("GetByID", planID).Return(nil, errors.New("subscription plan not found"))
 
-	err := service.DeleteSubscriptionPlan(planID.String())
+	err := service.DeleteSubscriptionPlan(context.Background(), planID.String())
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "SubscriptionPlan with ID")
@@ -325,7 +585,7 @@ func TestListSubscriptionPlans_Success(t *testing.T) {
 
 	mockRepo.On("ListByProductID", productID).Return(expectedPlans, nil)
 
-	plans, err := service.ListSubscriptionPlans(productID.String())
+	plans, err := service.ListSubscriptionPlans(context.Background(), productID.String())
 
 	assert.NoError(t, err)
 	assert.Equal(t, 2, len(plans))
@@ -339,7 +599,7 @@ func TestListSubscriptionPlans_InvalidProductID(t *testing.T) {
 	mockProductRepo := new(MockProductRepositoryForSubscription)
 	service := NewSubscriptionService(mockRepo, mockProductRepo)
 
-	plans, err := service.ListSubscriptionPlans("invalid-uuid")
+	plans, err := service.ListSubscriptionPlans(context.Background(), "invalid-uuid")
 
 	assert.Error(t, err)
 	assert.Nil(t, plans)
@@ -350,22 +610,24 @@ func TestValidateSubscriptionInput_EdgeCases(t *testing.T) {
 	tests := []struct {
 		name        string
 		planName    string
-		duration    int
+		interval    models.BillingInterval
 		price       float64
+		trialDays   int
+		introPhases []models.IntroPhase
 		expectError bool
 		errorMsg    string
 	}{
 		{
 			name:        "Valid input",
 			planName:    "Monthly Plan",
-			duration:    30,
+			interval:    monthly,
 			price:       29.99,
 			expectError: false,
 		},
 		{
 			name:        "Empty plan name",
 			planName:    "",
-			duration:    30,
+			interval:    monthly,
 			price:       29.99,
 			expectError: true,
 			errorMsg:    "plan name is required",
@@ -373,48 +635,125 @@ func TestValidateSubscriptionInput_EdgeCases(t *testing.T) {
 		{
 			name:        "Plan name too long",
 			planName:    string(make([]byte, 256)),
-			duration:    30,
+			interval:    monthly,
 			price:       29.99,
 			expectError: true,
 			errorMsg:    "plan name must be less than 255 characters",
 		},
 		{
-			name:        "Zero duration",
+			name:        "Zero interval count",
+			planName:    "Monthly Plan",
+			interval:    models.BillingInterval{Unit: models.IntervalMonth, Count: 0},
+			price:       29.99,
+			expectError: true,
+			errorMsg:    "interval count must be positive",
+		},
+		{
+			name:        "Negative interval count",
+			planName:    "Monthly Plan",
+			interval:    models.BillingInterval{Unit: models.IntervalDay, Count: -10},
+			price:       29.99,
+			expectError: true,
+			errorMsg:    "interval count must be positive",
+		},
+		{
+			name:        "Unknown interval unit",
 			planName:    "Monthly Plan",
-			duration:    0,
+			interval:    models.BillingInterval{Unit: "fortnight", Count: 1},
 			price:       29.99,
 			expectError: true,
-			errorMsg:    "duration must be positive",
+			errorMsg:    "interval unit must be day, week, month or year",
 		},
 		{
-			name:        "Negative duration",
+			name:        "Interval too long in days",
 			planName:    "Monthly Plan",
-			duration:    -10,
+			interval:    models.BillingInterval{Unit: models.IntervalDay, Count: 3651},
 			price:       29.99,
 			expectError: true,
-			errorMsg:    "duration must be positive",
+			errorMsg:    "interval cannot exceed 10 years",
 		},
 		{
-			name:        "Duration too long",
+			name:        "Interval too long in years",
 			planName:    "Monthly Plan",
-			duration:    3651, 
+			interval:    models.BillingInterval{Unit: models.IntervalYear, Count: 11},
 			price:       29.99,
 			expectError: true,
-			errorMsg:    "duration cannot exceed 3650 days",
+			errorMsg:    "interval cannot exceed 10 years",
+		},
+		{
+			name:        "Ten years",
+			planName:    "Monthly Plan",
+			interval:    models.BillingInterval{Unit: models.IntervalYear, Count: 10},
+			price:       29.99,
+			expectError: false,
 		},
 		{
 			name:        "Negative price",
 			planName:    "Monthly Plan",
-			duration:    30,
+			interval:    monthly,
 			price:       -10.0,
 			expectError: true,
 			errorMsg:    "price cannot be negative",
 		},
+		{
+			name:        "Trial and introductory phases",
+			planName:    "Monthly Plan",
+			interval:    monthly,
+			price:       29.99,
+			trialDays:   14,
+			introPhases: []models.IntroPhase{{Price: 14.99, Cycles: 3}, {Price: 0, Cycles: 1}},
+			expectError: false,
+		},
+		{
+			name:        "Negative trial",
+			planName:    "Monthly Plan",
+			interval:    monthly,
+			price:       29.99,
+			trialDays:   -1,
+			expectError: true,
+			errorMsg:    "trial days cannot be negative",
+		},
+		{
+			name:        "Trial too long",
+			planName:    "Monthly Plan",
+			interval:    monthly,
+			price:       29.99,
+			trialDays:   366,
+			expectError: true,
+			errorMsg:    "trial cannot exceed 365 days",
+		},
+		{
+			name:        "Introductory phase without cycles",
+			planName:    "Monthly Plan",
+			interval:    monthly,
+			price:       29.99,
+			introPhases: []models.IntroPhase{{Price: 14.99}},
+			expectError: true,
+			errorMsg:    "introductory phase cycles must be positive",
+		},
+		{
+			name:        "Negative introductory price",
+			planName:    "Monthly Plan",
+			interval:    monthly,
+			price:       29.99,
+			introPhases: []models.IntroPhase{{Price: -1, Cycles: 1}},
+			expectError: true,
+			errorMsg:    "introductory price cannot be negative",
+		},
+		{
+			name:        "Too many introductory phases",
+			planName:    "Monthly Plan",
+			interval:    monthly,
+			price:       29.99,
+			introPhases: make([]models.IntroPhase, 11),
+			expectError: true,
+			errorMsg:    "at most 10 introductory phases",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := validateSubscriptionInput(tt.planName, tt.duration, tt.price)
+			err := validateSubscriptionInput(tt.planName, tt.interval, tt.price, tt.trialDays, tt.introPhases)
 			if tt.expectError {
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), tt.errorMsg)
@@ -424,3 +763,76 @@ func TestValidateSubscriptionInput_EdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestBatchDeleteSubscriptionPlans_PartialFailure(t *testing.T) {
+	mockRepo := new(MockSubscriptionRepository)
+	mockProductRepo := new(MockProductRepositoryForSubscription)
+	service := NewSubscriptionService(mockRepo, mockProductRepo)
+
+	existingID := uuid.New()
+	missingID := uuid.New()
+	mockRepo.On("GetByID", existingID).Return(&models.SubscriptionPlan{ID: existingID}, nil)
+	mockRepo.On("GetByID", missingID).Return(nil, errors.New("not found"))
+	mockRepo.On("Delete", existingID).Return(nil)
+
+	results, err := service.BatchDeleteSubscriptionPlans(context.Background(), []string{existingID.String(), missingID.String()}, false)
+
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.NoError(t, results[0].Err)
+	assert.True(t, apperrors.IsNotFoundError(results[1].Err))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBatchCreateSubscriptionPlans_AtomicProductNotFound(t *testing.T) {
+	mockRepo := new(MockSubscriptionRepository)
+	mockProductRepo := new(MockProductRepositoryForSubscription)
+	service := NewSubscriptionService(mockRepo, mockProductRepo)
+
+	productID := uuid.New()
+	mockProductRepo.On("GetByID", productID).Return(nil, errors.New("not found"))
+
+	results, err := service.BatchCreateSubscriptionPlans(context.Background(), []SubscriptionPlanInput{
+		{ProductID: productID.String(), PlanName: "Monthly", Interval: monthly, Price: 9.99},
+	}, true)
+
+	assert.Error(t, err)
+	assert.Nil(t, results)
+	assert.True(t, apperrors.IsNotFoundError(err))
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestBatchGetSubscriptionPlans_ExceedsMaxBatchSize(t *testing.T) {
+	mockRepo := new(MockSubscriptionRepository)
+	mockProductRepo := new(MockProductRepositoryForSubscription)
+	service := NewSubscriptionService(mockRepo, mockProductRepo, WithMaxBatchSize(1))
+
+	results, err := service.BatchGetSubscriptionPlans(context.Background(), []string{uuid.New().String(), uuid.New().String()})
+
+	assert.Error(t, err)
+	assert.Nil(t, results)
+	assert.True(t, apperrors.IsValidationError(err))
+}
+
+func TestWatchSubscriptionPlans_FiltersByProductID(t *testing.T) {
+	mockRepo := new(MockSubscriptionRepository)
+	mockProductRepo := new(MockProductRepositoryForSubscription)
+	broker := events.NewBroker(10)
+	service := NewSubscriptionService(mockRepo, mockProductRepo, WithEventBroker(broker))
+
+	productID := uuid.New()
+	sub, err := service.WatchSubscriptionPlans(context.Background(), "", productID.String(), 0)
+	assert.NoError(t, err)
+	defer sub.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	planID := uuid.New()
+	assert.NoError(t, broker.Publish(ctx, events.NewPlanEvent(events.TypeDeleted, &models.SubscriptionPlan{ID: uuid.New(), ProductID: uuid.New()})))
+	assert.NoError(t, broker.Publish(ctx, events.NewPlanEvent(events.TypeDeleted, &models.SubscriptionPlan{ID: planID, ProductID: productID})))
+
+	event, err := sub.Next(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, events.TypeDeleted, event.Type)
+	assert.Equal(t, planID, event.AggregateID)
+}
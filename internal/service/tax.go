@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/tax"
+)
+
+const maxRegionLength = 10
+
+var countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// TaxLocation is where a customer is taxed. An empty Country means the
+// location is unknown and no tax is applied.
+type TaxLocation struct {
+	Country string
+	Region  string
+}
+
+func normalizeTaxLocation(location TaxLocation) (TaxLocation, error) {
+	location.Country = strings.ToUpper(strings.TrimSpace(location.Country))
+	location.Region = strings.ToUpper(strings.TrimSpace(location.Region))
+	if location.Country == "" {
+		if location.Region != "" {
+			return location, apperrors.NewValidationError("country", "country is required with a region")
+		}
+		return location, nil
+	}
+	if !countryPattern.MatchString(location.Country) {
+		return location, apperrors.NewValidationError("country", "country must be an ISO 3166-1 alpha-2 code")
+	}
+	if len(location.Region) > maxRegionLength {
+		return location, apperrors.NewValidationError("region", "region must be at most 10 characters")
+	}
+	return location, nil
+}
+
+// calculateTax taxes amount of product at location. Products priced with tax
+// included have amount split into net and tax instead of tax added on top.
+func calculateTax(ctx context.Context, opts options, location TaxLocation, product models.Product, amount float64) (tax.Result, error) {
+	req := tax.Request{
+		Country:     location.Country,
+		Region:      location.Region,
+		ProductType: product.ProductType,
+		Amount:      amount,
+		Inclusive:   product.TaxInclusive,
+	}
+	if location.Country == "" {
+		return tax.Split(req, 0), nil
+	}
+	result, err := opts.tax.Calculate(ctx, req)
+	if err != nil {
+		return tax.Result{}, fmt.Errorf("calculate tax: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/repository"
+)
+
+// maxUsageClockSkew is how far ahead of the server's clock a usage timestamp
+// may be.
+const maxUsageClockSkew = 5 * time.Minute
+
+type UsageService interface {
+	RecordUsage(ctx context.Context, subscriptionID, eventID string, quantity int64, timestamp time.Time) (*models.UsageRecord, bool, error)
+	GetUsageSummary(ctx context.Context, subscriptionID string) (*UsageSummary, error)
+}
+
+// UsageSummary is the usage of a subscription over a billing period,
+// aggregated with the meter of its plan and priced with the plan's pricing
+// model. Usage during a trial is free and has no price breakdown.
+type UsageSummary struct {
+	Subscription *models.Subscription
+	Plan         *models.SubscriptionPlan
+	PeriodStart  time.Time
+	PeriodEnd    time.Time
+	Quantity     int64
+	Records      int64
+	Price        models.PriceBreakdown
+}
+
+type usageService struct {
+	repo             repository.UsageRepository
+	subscriptionRepo repository.CustomerSubscriptionRepository
+	planRepo         repository.SubscriptionRepository
+	opts             options
+}
+
+func NewUsageService(repo repository.UsageRepository, subscriptionRepo repository.CustomerSubscriptionRepository, planRepo repository.SubscriptionRepository, opts ...Option) UsageService {
+	return &usageService{
+		repo:             repo,
+		subscriptionRepo: subscriptionRepo,
+		planRepo:         planRepo,
+		opts:             newOptions(opts),
+	}
+}
+
+// RecordUsage records quantity units of usage by a subscription to a metered
+// plan at timestamp, or now when it is zero. Recording an event ID again
+// returns the original record and true instead of counting it twice.
+func (s *usageService) RecordUsage(ctx context.Context, subscriptionID, eventID string, quantity int64, timestamp time.Time) (*models.UsageRecord, bool, error) {
+	if eventID == "" {
+		return nil, false, apperrors.NewValidationError("eventId", "event ID is required")
+	}
+	if len(eventID) > 255 {
+		return nil, false, apperrors.NewValidationError("eventId", "event ID must be less than 255 characters")
+	}
+	if quantity < 0 {
+		return nil, false, apperrors.NewValidationError("quantity", "quantity cannot be negative")
+	}
+	sub, _, err := s.meteredSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	repo := s.repo.WithContext(ctx)
+	if existing, err := repo.GetByEventID(eventID); err == nil {
+		return duplicateUsage(existing, sub.ID, quantity)
+	}
+
+	now := s.opts.now()
+	if timestamp.IsZero() {
+		timestamp = now
+	}
+	if sub.IsEnded() {
+		return nil, false, apperrors.NewValidationError("status", "canceled subscriptions cannot record usage")
+	}
+	if timestamp.Before(sub.UsagePeriodStart()) {
+		return nil, false, apperrors.NewValidationError("timestamp", "usage must fall within the current billing period")
+	}
+	if timestamp.After(now.Add(maxUsageClockSkew)) {
+		return nil, false, apperrors.NewValidationError("timestamp", "usage cannot be recorded in the future")
+	}
+
+	record := &models.UsageRecord{
+		EventID:        eventID,
+		SubscriptionID: sub.ID,
+		Quantity:       quantity,
+		Timestamp:      timestamp,
+	}
+	created, err := repo.Create(record)
+	if err != nil {
+		return nil, false, apperrors.NewDatabaseError("record usage", err)
+	}
+	if !created {
+		// A concurrent request recorded the same event first.
+		existing, err := repo.GetByEventID(eventID)
+		if err != nil {
+			return nil, false, apperrors.NewDatabaseError("record usage", err)
+		}
+		return duplicateUsage(existing, sub.ID, quantity)
+	}
+
+	return record, false, nil
+}
+
+// GetUsageSummary returns the usage of a subscription in its current billing
+// period, since its last plan change if it changed plan during the period, and
+// what it costs so far.
+func (s *usageService) GetUsageSummary(ctx context.Context, subscriptionID string) (*UsageSummary, error) {
+	sub, plan, err := s.meteredSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return summarizeUsage(ctx, s.repo, sub, plan, sub.UsagePeriodStart(), sub.CurrentPeriodEnd)
+}
+
+// summarizeUsage aggregates and prices the usage of sub on the metered plan
+// between start and end.
+func summarizeUsage(ctx context.Context, repo repository.UsageRepository, sub *models.Subscription, plan *models.SubscriptionPlan, start, end time.Time) (*UsageSummary, error) {
+	quantity, records, err := repo.WithContext(ctx).Aggregate(sub.ID, plan.Meter.Aggregation, start, end)
+	if err != nil {
+		return nil, apperrors.NewDatabaseError("aggregate usage", err)
+	}
+
+	summary := &UsageSummary{
+		Subscription: sub,
+		Plan:         plan,
+		PeriodStart:  start,
+		PeriodEnd:    end,
+		Quantity:     quantity,
+		Records:      records,
+		Price:        models.PriceBreakdown{Quantity: int(quantity)},
+	}
+	if sub.Status != models.SubscriptionTrialing && quantity > 0 {
+		summary.Price = plan.Calculate(int(quantity))
+	}
+	return summary, nil
+}
+
+// meteredSubscription returns the subscription and the plan of its current
+// period, which must be metered.
+func (s *usageService) meteredSubscription(ctx context.Context, subscriptionID string) (*models.Subscription, *models.SubscriptionPlan, error) {
+	subID, err := parseSubscriptionID(subscriptionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sub, err := s.subscriptionRepo.WithContext(ctx).GetByID(subID)
+	if err != nil {
+		return nil, nil, apperrors.NewNotFoundError("Subscription", subscriptionID)
+	}
+
+	plan, err := currentPlan(ctx, s.planRepo, sub)
+	if err != nil {
+		return nil, nil, err
+	}
+	if plan.Meter == nil {
+		return nil, nil, apperrors.NewValidationError("subscriptionId", "the subscription's plan is not metered")
+	}
+	return sub, plan, nil
+}
+
+// duplicateUsage answers a retried event with its original record, rejecting
+// an event ID reused for different usage.
+func duplicateUsage(existing *models.UsageRecord, subscriptionID uuid.UUID, quantity int64) (*models.UsageRecord, bool, error) {
+	if existing.SubscriptionID != subscriptionID || existing.Quantity != quantity {
+		return nil, false, apperrors.NewValidationError("eventId", "event ID was already recorded with different usage")
+	}
+	return existing, true, nil
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockUsageRepository struct {
+	mock.Mock
+}
+
+func (m *MockUsageRepository) Create(record *models.UsageRecord) (bool, error) {
+	args := m.Called(record)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUsageRepository) GetByEventID(eventID string) (*models.UsageRecord, error) {
+	args := m.Called(eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UsageRecord), args.Error(1)
+}
+
+func (m *MockUsageRepository) Aggregate(subscriptionID uuid.UUID, aggregation string, from, to time.Time) (int64, int64, error) {
+	args := m.Called(subscriptionID, aggregation, from, to)
+	return args.Get(0).(int64), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUsageRepository) WithContext(ctx context.Context) repository.UsageRepository {
+	return m
+}
+
+type usageFixture struct {
+	usage   *MockUsageRepository
+	subs    *MockCustomerSubscriptionRepository
+	plans   *MockSubscriptionRepository
+	service UsageService
+	now     time.Time
+}
+
+func newUsageFixture() *usageFixture {
+	f := &usageFixture{
+		usage: new(MockUsageRepository),
+		subs:  new(MockCustomerSubscriptionRepository),
+		plans: new(MockSubscriptionRepository),
+		now:   time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC),
+	}
+	f.service = NewUsageService(f.usage, f.subs, f.plans, WithClock(func() time.Time { return f.now }))
+	return f
+}
+
+// subscription returns an active subscription in March 2024 to plan.
+func (f *usageFixture) subscription(plan *models.SubscriptionPlan) *models.Subscription {
+	sub := models.NewSubscription("cus_1", plan, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	sub.ID = uuid.New()
+	f.subs.On("GetByID", sub.ID).Return(sub, nil)
+	f.plans.On("GetByIDAsOf", plan.ID, sub.CurrentPeriodStart).Return(plan, nil)
+	return sub
+}
+
+func meteredPlan(aggregation string) *models.SubscriptionPlan {
+	return &models.SubscriptionPlan{
+		ID:           uuid.New(),
+		Interval:     monthly,
+		PricingModel: models.PricingGraduated,
+		Tiers:        []models.PriceTier{{UpTo: 1000, UnitPrice: 0.01}, {UnitPrice: 0.005}},
+		Meter:        &models.Meter{Unit: "api_call", Aggregation: aggregation},
+	}
+}
+
+func TestUsageService_RecordUsage(t *testing.T) {
+	f := newUsageFixture()
+	sub := f.subscription(meteredPlan(models.AggregationSum))
+	f.usage.On("GetByEventID", "evt_1").Return(nil, errors.New("usage record not found"))
+	f.usage.On("Create", mock.AnythingOfType("*models.UsageRecord")).Return(true, nil)
+
+	record, duplicate, err := f.service.RecordUsage(context.Background(), sub.ID.String(), "evt_1", 25, time.Time{})
+
+	assert.NoError(t, err)
+	assert.False(t, duplicate)
+	assert.Equal(t, sub.ID, record.SubscriptionID)
+	assert.Equal(t, int64(25), record.Quantity)
+	assert.Equal(t, f.now, record.Timestamp, "the timestamp defaults to now")
+}
+
+func TestUsageService_RecordUsage_Duplicate(t *testing.T) {
+	f := newUsageFixture()
+	sub := f.subscription(meteredPlan(models.AggregationSum))
+	original := &models.UsageRecord{ID: uuid.New(), EventID: "evt_1", SubscriptionID: sub.ID, Quantity: 25}
+	f.usage.On("GetByEventID", "evt_1").Return(original, nil)
+
+	record, duplicate, err := f.service.RecordUsage(context.Background(), sub.ID.String(), "evt_1", 25, time.Time{})
+
+	assert.NoError(t, err)
+	assert.True(t, duplicate)
+	assert.Equal(t, original.ID, record.ID)
+
+	_, _, err = f.service.RecordUsage(context.Background(), sub.ID.String(), "evt_1", 30, time.Time{})
+	assert.Contains(t, err.Error(), "event ID was already recorded with different usage")
+	f.usage.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestUsageService_RecordUsage_LostRace(t *testing.T) {
+	f := newUsageFixture()
+	sub := f.subscription(meteredPlan(models.AggregationSum))
+	original := &models.UsageRecord{ID: uuid.New(), EventID: "evt_1", SubscriptionID: sub.ID, Quantity: 25}
+	f.usage.On("GetByEventID", "evt_1").Return(nil, errors.New("usage record not found")).Once()
+	f.usage.On("Create", mock.AnythingOfType("*models.UsageRecord")).Return(false, nil)
+	f.usage.On("GetByEventID", "evt_1").Return(original, nil)
+
+	record, duplicate, err := f.service.RecordUsage(context.Background(), sub.ID.String(), "evt_1", 25, time.Time{})
+
+	assert.NoError(t, err)
+	assert.True(t, duplicate)
+	assert.Equal(t, original.ID, record.ID)
+}
+
+func TestUsageService_RecordUsage_Invalid(t *testing.T) {
+	f := newUsageFixture()
+	sub := f.subscription(meteredPlan(models.AggregationSum))
+	flat := f.subscription(&models.SubscriptionPlan{ID: uuid.New(), Interval: monthly, Price: 10})
+	ended := f.subscription(meteredPlan(models.AggregationSum))
+	ended.Status = models.SubscriptionCanceled
+	f.usage.On("GetByEventID", mock.Anything).Return(nil, errors.New("usage record not found"))
+
+	tests := []struct {
+		name      string
+		sub       *models.Subscription
+		eventID   string
+		quantity  int64
+		timestamp time.Time
+		want      string
+	}{
+		{"no event ID", sub, "", 1, time.Time{}, "event ID is required"},
+		{"negative quantity", sub, "evt", -1, time.Time{}, "quantity cannot be negative"},
+		{"plan not metered", flat, "evt", 1, time.Time{}, "plan is not metered"},
+		{"canceled", ended, "evt", 1, time.Time{}, "canceled subscriptions cannot record usage"},
+		{"before the period", sub, "evt", 1, sub.CurrentPeriodStart.Add(-time.Second), "usage must fall within the current billing period"},
+		{"in the future", sub, "evt", 1, f.now.Add(time.Hour), "usage cannot be recorded in the future"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := f.service.RecordUsage(context.Background(), tt.sub.ID.String(), tt.eventID, tt.quantity, tt.timestamp)
+
+			assert.True(t, apperrors.IsValidationError(err))
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+	f.usage.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestUsageService_GetUsageSummary(t *testing.T) {
+	f := newUsageFixture()
+	plan := meteredPlan(models.AggregationSum)
+	sub := f.subscription(plan)
+	f.usage.On("Aggregate", sub.ID, models.AggregationSum, sub.CurrentPeriodStart, sub.CurrentPeriodEnd).Return(int64(1500), int64(12), nil)
+
+	summary, err := f.service.GetUsageSummary(context.Background(), sub.ID.String())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1500), summary.Quantity)
+	assert.Equal(t, int64(12), summary.Records)
+	assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), summary.PeriodEnd)
+	assert.Equal(t, 12.5, summary.Price.Total, "1000 calls at 0.01 and 500 at 0.005")
+	assert.Len(t, summary.Price.Tiers, 2)
+}
+
+func TestUsageService_GetUsageSummary_Trial(t *testing.T) {
+	f := newUsageFixture()
+	plan := meteredPlan(models.AggregationMax)
+	plan.TrialDays = 30
+	sub := f.subscription(plan)
+	f.usage.On("Aggregate", sub.ID, models.AggregationMax, sub.CurrentPeriodStart, sub.CurrentPeriodEnd).Return(int64(40), int64(3), nil)
+
+	summary, err := f.service.GetUsageSummary(context.Background(), sub.ID.String())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(40), summary.Quantity)
+	assert.Zero(t, summary.Price.Total, "usage during the trial is free")
+	assert.Empty(t, summary.Price.Tiers)
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/events"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/repository"
+)
+
+const minWebhookSecretLength = 16
+
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, url string, eventTypes []string, secret string) (*models.Webhook, error)
+	GetWebhook(ctx context.Context, id string) (*models.Webhook, error)
+	UpdateWebhook(ctx context.Context, id, url string, eventTypes []string, active bool) (*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	ListWebhooks(ctx context.Context) ([]models.Webhook, error)
+	ListWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]models.WebhookDelivery, error)
+	RedeliverWebhook(ctx context.Context, deliveryID string) (*models.WebhookDelivery, error)
+}
+
+type webhookService struct {
+	repo repository.WebhookRepository
+}
+
+func NewWebhookService(repo repository.WebhookRepository) WebhookService {
+	return &webhookService{repo: repo}
+}
+
+// CreateWebhook registers a webhook. When secret is empty a random one is
+// generated; either way it is only returned from this call.
+func (s *webhookService) CreateWebhook(ctx context.Context, url string, eventTypes []string, secret string) (*models.Webhook, error) {
+	if err := validateWebhookInput(url, eventTypes); err != nil {
+		return nil, err
+	}
+
+	if secret == "" {
+		generated, err := generateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	} else if len(secret) < minWebhookSecretLength {
+		return nil, apperrors.NewValidationError("secret", "secret must be at least 16 characters")
+	}
+
+	webhook := &models.Webhook{
+		URL:        url,
+		EventTypes: strings.Join(eventTypes, ","),
+		Secret:     secret,
+		Active:     true,
+	}
+
+	if err := s.repo.WithContext(ctx).Create(webhook); err != nil {
+		return nil, apperrors.NewDatabaseError("create webhook", err)
+	}
+
+	return webhook, nil
+}
+
+func (s *webhookService) GetWebhook(ctx context.Context, id string) (*models.Webhook, error) {
+	webhookID, err := parseWebhookID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook, err := s.repo.WithContext(ctx).GetByID(webhookID)
+	if err != nil {
+		return nil, apperrors.NewNotFoundError("Webhook", id)
+	}
+
+	return webhook, nil
+}
+
+func (s *webhookService) UpdateWebhook(ctx context.Context, id, url string, eventTypes []string, active bool) (*models.Webhook, error) {
+	webhookID, err := parseWebhookID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.WithContext(ctx).GetByID(webhookID); err != nil {
+		return nil, apperrors.NewNotFoundError("Webhook", id)
+	}
+
+	if err := validateWebhookInput(url, eventTypes); err != nil {
+		return nil, err
+	}
+
+	webhook := &models.Webhook{
+		ID:         webhookID,
+		URL:        url,
+		EventTypes: strings.Join(eventTypes, ","),
+		Active:     active,
+	}
+
+	if err := s.repo.WithContext(ctx).Update(webhook); err != nil {
+		return nil, apperrors.NewDatabaseError("update webhook", err)
+	}
+
+	return s.repo.WithContext(ctx).GetByID(webhookID)
+}
+
+func (s *webhookService) DeleteWebhook(ctx context.Context, id string) error {
+	webhookID, err := parseWebhookID(id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.repo.WithContext(ctx).GetByID(webhookID); err != nil {
+		return apperrors.NewNotFoundError("Webhook", id)
+	}
+
+	if err := s.repo.WithContext(ctx).Delete(webhookID); err != nil {
+		return apperrors.NewDatabaseError("delete webhook", err)
+	}
+
+	return nil
+}
+
+func (s *webhookService) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	webhooks, err := s.repo.WithContext(ctx).List()
+	if err != nil {
+		return nil, apperrors.NewDatabaseError("list webhooks", err)
+	}
+	return webhooks, nil
+}
+
+func (s *webhookService) ListWebhookDeliveries(ctx context.Context, webhookID string, limit int) ([]models.WebhookDelivery, error) {
+	id, err := parseWebhookID(webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.repo.WithContext(ctx).ListDeliveries(id, normalizePageSize(limit))
+	if err != nil {
+		return nil, apperrors.NewDatabaseError("list webhook deliveries", err)
+	}
+	return deliveries, nil
+}
+
+// RedeliverWebhook queues a fresh delivery of a previously recorded event,
+// leaving the original delivery untouched in the log.
+func (s *webhookService) RedeliverWebhook(ctx context.Context, deliveryID string) (*models.WebhookDelivery, error) {
+	if deliveryID == "" {
+		return nil, apperrors.NewValidationError("deliveryId", "delivery ID is required")
+	}
+	id, err := uuid.Parse(deliveryID)
+	if err != nil {
+		return nil, apperrors.NewValidationError("deliveryId", "invalid delivery ID format")
+	}
+
+	original, err := s.repo.WithContext(ctx).GetDelivery(id)
+	if err != nil {
+		return nil, apperrors.NewNotFoundError("WebhookDelivery", deliveryID)
+	}
+
+	deliveries := []models.WebhookDelivery{{
+		ID:            uuid.New(),
+		TenantID:      original.TenantID,
+		WebhookID:     original.WebhookID,
+		EventSequence: original.EventSequence,
+		EventType:     original.EventType,
+		Payload:       original.Payload,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: time.Now(),
+	}}
+
+	if err := s.repo.WithContext(ctx).CreateDeliveries(deliveries); err != nil {
+		return nil, apperrors.NewDatabaseError("redeliver webhook", err)
+	}
+
+	return &deliveries[0], nil
+}
+
+func parseWebhookID(id string) (uuid.UUID, error) {
+	if id == "" {
+		return uuid.Nil, apperrors.NewValidationError("id", "webhook ID is required")
+	}
+
+	webhookID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, apperrors.NewValidationError("id", "invalid webhook ID format")
+	}
+
+	return webhookID, nil
+}
+
+func validateWebhookInput(rawURL string, eventTypes []string) error {
+	if rawURL == "" {
+		return apperrors.NewValidationError("url", "webhook URL is required")
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return apperrors.NewValidationError("url", "webhook URL must be an absolute http or https URL")
+	}
+
+	if len(eventTypes) == 0 {
+		return apperrors.NewValidationError("eventTypes", "at least one event type is required")
+	}
+	known := make(map[string]bool)
+	for _, name := range events.Names() {
+		known[name] = true
+	}
+	for _, eventType := range eventTypes {
+		if eventType != models.WildcardEventType && !known[eventType] {
+			return apperrors.NewValidationError("eventTypes", "unknown event type: "+eventType)
+		}
+	}
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	apperrors "github.com/microservice-go/product-service/internal/errors"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockWebhookRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepository) Create(webhook *models.Webhook) error {
+	args := m.Called(webhook)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) GetByID(id uuid.UUID) (*models.Webhook, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) Update(webhook *models.Webhook) error {
+	args := m.Called(webhook)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) Delete(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) List() ([]models.Webhook, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) ListActive() ([]models.Webhook, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	args := m.Called(deliveries)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) GetDelivery(id uuid.UUID) (*models.WebhookDelivery, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepository) UpdateDelivery(delivery *models.WebhookDelivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) ListDeliveries(webhookID uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	args := m.Called(webhookID, limit)
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepository) ClaimDelivery(id uuid.UUID, now, until time.Time) (bool, error) {
+	args := m.Called(id, now, until)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockWebhookRepository) ListDueDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	args := m.Called(now, limit)
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepository) WithContext(ctx context.Context) repository.WebhookRepository {
+	return m
+}
+
+func TestCreateWebhook_GeneratesSecret(t *testing.T) {
+	mockRepo := new(MockWebhookRepository)
+	service := NewWebhookService(mockRepo)
+
+	mockRepo.On("Create", mock.AnythingOfType("*models.Webhook")).Return(nil)
+
+	webhook, err := service.CreateWebhook(context.Background(), "https://example.com/hooks", []string{"product.created", "product.updated"}, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "product.created,product.updated", webhook.EventTypes)
+	assert.Len(t, webhook.Secret, 64)
+	assert.True(t, webhook.Active)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateWebhook_ValidationErrors(t *testing.T) {
+	service := NewWebhookService(new(MockWebhookRepository))
+
+	tests := []struct {
+		name       string
+		url        string
+		eventTypes []string
+		secret     string
+		field      string
+	}{
+		{"missing url", "", []string{"*"}, "", "url"},
+		{"relative url", "/hooks", []string{"*"}, "", "url"},
+		{"unsupported scheme", "ftp://example.com", []string{"*"}, "", "url"},
+		{"no event types", "https://example.com", nil, "", "eventTypes"},
+		{"unknown event type", "https://example.com", []string{"product.archived"}, "", "eventTypes"},
+		{"short secret", "https://example.com", []string{"*"}, "too-short", "secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateWebhook(context.Background(), tt.url, tt.eventTypes, tt.secret)
+
+			var validationErr *apperrors.ValidationError
+			assert.True(t, errors.As(err, &validationErr))
+			assert.Equal(t, tt.field, validationErr.Field)
+		})
+	}
+}
+
+func TestUpdateWebhook_NotFound(t *testing.T) {
+	mockRepo := new(MockWebhookRepository)
+	service := NewWebhookService(mockRepo)
+
+	webhookID := uuid.New()
+	mockRepo.On("GetByID", webhookID).Return(nil, errors.New("webhook not found"))
+
+	webhook, err := service.UpdateWebhook(context.Background(), webhookID.String(), "https://example.com", []string{"*"}, false)
+
+	assert.Nil(t, webhook)
+	assert.True(t, apperrors.IsNotFoundError(err))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRedeliverWebhook_QueuesNewDelivery(t *testing.T) {
+	mockRepo := new(MockWebhookRepository)
+	service := NewWebhookService(mockRepo)
+
+	dedupeKey := "original"
+	original := &models.WebhookDelivery{
+		ID:            uuid.New(),
+		WebhookID:     uuid.New(),
+		EventSequence: 42,
+		EventType:     "product.deleted",
+		Payload:       `{"sequence":42}`,
+		Status:        models.WebhookDeliveryFailed,
+		Attempts:      10,
+		DedupeKey:     &dedupeKey,
+	}
+	mockRepo.On("GetDelivery", original.ID).Return(original, nil)
+	mockRepo.On("CreateDeliveries", mock.MatchedBy(func(deliveries []models.WebhookDelivery) bool {
+		return len(deliveries) == 1 && deliveries[0].DedupeKey == nil && deliveries[0].Attempts == 0
+	})).Return(nil)
+
+	delivery, err := service.RedeliverWebhook(context.Background(), original.ID.String())
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, original.ID, delivery.ID)
+	assert.Equal(t, original.WebhookID, delivery.WebhookID)
+	assert.Equal(t, uint64(42), delivery.EventSequence)
+	assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,155 @@
+package tax
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// Calculator works out the tax on an amount. Implementations may call out to
+// an external tax service, so they take a context and can fail.
+type Calculator interface {
+	Calculate(ctx context.Context, req Request) (Result, error)
+}
+
+// Request describes an amount to tax. Inclusive means Amount already
+// contains the tax; otherwise tax is added on top of it.
+type Request struct {
+	Country     string
+	Region      string
+	ProductType string
+	Amount      float64
+	Inclusive   bool
+}
+
+// Result splits an amount into its net, tax and gross parts, rounded to
+// cents so that Net + Tax == Gross.
+type Result struct {
+	Net       float64
+	Tax       float64
+	Gross     float64
+	Rate      float64
+	Inclusive bool
+}
+
+// Split applies rate to req.Amount.
+func Split(req Request, rate float64) Result {
+	result := Result{Rate: rate, Inclusive: req.Inclusive}
+	if req.Inclusive {
+		result.Gross = roundCents(req.Amount)
+		result.Net = roundCents(req.Amount / (1 + rate))
+		result.Tax = roundCents(result.Gross - result.Net)
+	} else {
+		result.Net = roundCents(req.Amount)
+		result.Tax = roundCents(req.Amount * rate)
+		result.Gross = roundCents(result.Net + result.Tax)
+	}
+	return result
+}
+
+// Rate is the tax rate for a country, optionally narrowed to a region within
+// it and to a product type. Rate is a fraction, so 0.2 is 20%.
+type Rate struct {
+	Country     string  `json:"country"`
+	Region      string  `json:"region,omitempty"`
+	ProductType string  `json:"product_type,omitempty"`
+	Rate        float64 `json:"rate"`
+}
+
+// Table is a Calculator backed by a fixed list of rates. The most specific
+// rate wins: country, region and product type, then country and region,
+// then country and product type, then the country alone. Amounts in
+// countries without a rate are not taxed.
+type Table struct {
+	rates map[rateKey]float64
+}
+
+type rateKey struct {
+	country, region, productType string
+}
+
+func NewTable(rates []Rate) (*Table, error) {
+	t := &Table{rates: make(map[rateKey]float64, len(rates))}
+	for i, rate := range rates {
+		if rate.Country == "" {
+			return nil, fmt.Errorf("rate %d: country is required", i)
+		}
+		if rate.Rate < 0 || rate.Rate >= 1 {
+			return nil, fmt.Errorf("rate %d: rate must be at least 0 and below 1", i)
+		}
+		key := newRateKey(rate.Country, rate.Region, rate.ProductType)
+		if _, ok := t.rates[key]; ok {
+			return nil, fmt.Errorf("rate %d: duplicate rate for %s", i, describe(key))
+		}
+		t.rates[key] = rate.Rate
+	}
+	return t, nil
+}
+
+// LoadTable reads a JSON array of rates from a file.
+func LoadTable(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rates []Rate
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return nil, fmt.Errorf("parse tax rates: %w", err)
+	}
+	return NewTable(rates)
+}
+
+func (t *Table) Calculate(ctx context.Context, req Request) (Result, error) {
+	if req.Amount < 0 {
+		return Result{}, errors.New("amount cannot be negative")
+	}
+	return Split(req, t.Lookup(req.Country, req.Region, req.ProductType)), nil
+}
+
+// Lookup returns the rate that applies to productType in the region of
+// country, or 0 when none does.
+func (t *Table) Lookup(country, region, productType string) float64 {
+	if country == "" {
+		return 0
+	}
+	candidates := []rateKey{
+		newRateKey(country, region, productType),
+		newRateKey(country, region, ""),
+		newRateKey(country, "", productType),
+		newRateKey(country, "", ""),
+	}
+	for _, key := range candidates {
+		if rate, ok := t.rates[key]; ok {
+			return rate
+		}
+	}
+	return 0
+}
+
+func newRateKey(country, region, productType string) rateKey {
+	return rateKey{
+		country:     strings.ToUpper(country),
+		region:      strings.ToUpper(region),
+		productType: strings.ToLower(productType),
+	}
+}
+
+func describe(key rateKey) string {
+	parts := []string{key.country}
+	if key.region != "" {
+		parts = append(parts, key.region)
+	}
+	if key.productType != "" {
+		parts = append(parts, key.productType)
+	}
+	return strings.Join(parts, "/")
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,88 @@
+package tax
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTable(t *testing.T) *Table {
+	table, err := NewTable([]Rate{
+		{Country: "GB", Rate: 0.2},
+		{Country: "GB", ProductType: "physical", Rate: 0.05},
+		{Country: "US", Region: "CA", Rate: 0.0725},
+		{Country: "US", Region: "NY", ProductType: "digital", Rate: 0.04},
+		{Country: "DE", Rate: 0.19},
+	})
+	require.NoError(t, err)
+	return table
+}
+
+func TestTable_Lookup(t *testing.T) {
+	table := newTestTable(t)
+
+	tests := []struct {
+		name                         string
+		country, region, productType string
+		want                         float64
+	}{
+		{"country", "GB", "", "digital", 0.2},
+		{"country and product type", "gb", "", "PHYSICAL", 0.05},
+		{"region", "US", "CA", "digital", 0.0725},
+		{"region and product type", "US", "NY", "digital", 0.04},
+		{"region without a matching type", "US", "NY", "physical", 0},
+		{"unknown region", "US", "TX", "digital", 0},
+		{"unknown country", "JP", "", "digital", 0},
+		{"no country", "", "", "digital", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, table.Lookup(tt.country, tt.region, tt.productType))
+		})
+	}
+}
+
+func TestTable_Calculate(t *testing.T) {
+	table := newTestTable(t)
+
+	exclusive, err := table.Calculate(context.Background(), Request{Country: "DE", ProductType: "digital", Amount: 29.99})
+	require.NoError(t, err)
+	assert.Equal(t, Result{Net: 29.99, Tax: 5.7, Gross: 35.69, Rate: 0.19}, exclusive)
+
+	inclusive, err := table.Calculate(context.Background(), Request{Country: "DE", ProductType: "digital", Amount: 35.69, Inclusive: true})
+	require.NoError(t, err)
+	assert.Equal(t, Result{Net: 29.99, Tax: 5.7, Gross: 35.69, Rate: 0.19, Inclusive: true}, inclusive)
+
+	untaxed, err := table.Calculate(context.Background(), Request{Amount: 10})
+	require.NoError(t, err)
+	assert.Equal(t, Result{Net: 10, Gross: 10}, untaxed)
+
+	_, err = table.Calculate(context.Background(), Request{Country: "DE", Amount: -1})
+	assert.Error(t, err)
+}
+
+func TestNewTable_Invalid(t *testing.T) {
+	_, err := NewTable([]Rate{{Rate: 0.2}})
+	assert.EqualError(t, err, "rate 0: country is required")
+
+	_, err = NewTable([]Rate{{Country: "GB", Rate: 1.5}})
+	assert.EqualError(t, err, "rate 0: rate must be at least 0 and below 1")
+
+	_, err = NewTable([]Rate{{Country: "US", Region: "CA", Rate: 0.07}, {Country: "us", Region: "ca", Rate: 0.08}})
+	assert.EqualError(t, err, "rate 1: duplicate rate for US/CA")
+}
+
+func TestLoadTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"country":"FR","rate":0.2},{"country":"FR","product_type":"physical","rate":0.055}]`), 0o600))
+
+	table, err := LoadTable(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, 0.055, table.Lookup("FR", "", "physical"))
+	assert.Equal(t, 0.2, table.Lookup("FR", "", "digital"))
+}
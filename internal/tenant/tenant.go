@@ -0,0 +1,95 @@
+package tenant
+
+import (
+	"context"
+	"regexp"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// MetadataKey is the gRPC metadata header selecting the tenant of a call.
+const MetadataKey = "x-tenant-id"
+
+// Default is the tenant of calls that do not name one, and of every row that
+// predates multi-tenancy.
+const Default = "default"
+
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+type tenantKey struct{}
+
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+func FromContext(ctx context.Context) string {
+	if ctx != nil {
+		if id, ok := ctx.Value(tenantKey{}).(string); ok && id != "" {
+			return id
+		}
+	}
+	return Default
+}
+
+// Valid reports whether id is a well-formed tenant ID: lowercase letters,
+// digits, "-" and "_", at most 64 characters.
+func Valid(id string) bool {
+	return validID.MatchString(id)
+}
+
+// FromMetadata returns the tenant named in the incoming metadata, or "".
+func FromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(MetadataKey); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// UnaryServerInterceptor copies the x-tenant-id request header into the
+// context. Authentication may later replace it with the caller's own tenant.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := fromRequest(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := fromRequest(stream.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &tenantStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+func fromRequest(ctx context.Context) (context.Context, error) {
+	id := FromMetadata(ctx)
+	if id == "" {
+		return ctx, nil
+	}
+	if !Valid(id) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid tenant ID %q", id)
+	}
+	return WithTenant(ctx, id), nil
+}
+
+type tenantStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tenantStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,70 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, Default, FromContext(context.Background()))
+	assert.Equal(t, "acme", FromContext(WithTenant(context.Background(), "acme")))
+}
+
+func TestValid(t *testing.T) {
+	assert.True(t, Valid("acme"))
+	assert.True(t, Valid("brand-2_eu"))
+	assert.False(t, Valid(""))
+	assert.False(t, Valid("Acme"))
+	assert.False(t, Valid("acme corp"))
+	assert.False(t, Valid("-acme"))
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := UnaryServerInterceptor()
+	var tenantID string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		tenantID = FromContext(ctx)
+		return nil, nil
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, "acme"))
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", tenantID)
+
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, Default, tenantID)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, "../other"))
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	interceptor := StreamServerInterceptor()
+	var tenantID string
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		tenantID = FromContext(stream.Context())
+		return nil
+	}
+
+	stream := &fakeServerStream{ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataKey, "acme"))}
+	assert.NoError(t, interceptor(nil, stream, &grpc.StreamServerInfo{}, handler))
+	assert.Equal(t, "acme", tenantID)
+}
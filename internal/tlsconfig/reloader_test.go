@@ -0,0 +1,199 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns PEM-encoded certificate and key signed by the CA.
+func (ca *testCA) issue(t *testing.T, commonName string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+// serve accepts TLS connections, completing each handshake, until the test
+// ends.
+func serve(t *testing.T, config *tls.Config) string {
+	t.Helper()
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// dial returns the common name of the server certificate.
+func dial(t *testing.T, addr string, config *tls.Config) (string, error) {
+	t.Helper()
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: time.Second}, "tcp", addr, config)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	// TLS 1.3 reports a rejected client certificate only on the first read.
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName, nil
+}
+
+func TestReloader_HotReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "test-ca")
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	start := time.Now().Add(-time.Minute)
+	cert, key := ca.issue(t, "server-1", x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, cert, start)
+	writeFile(t, keyFile, key, start)
+
+	reloader, err := NewReloader(Config{CertFile: certFile, KeyFile: keyFile})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloader.Watch(ctx, 10*time.Millisecond)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientConfig := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+	addr := serve(t, reloader.ServerConfig())
+
+	name, err := dial(t, addr, clientConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "server-1", name)
+
+	// A broken key keeps the previous certificate in service.
+	writeFile(t, keyFile, []byte("not a key"), start.Add(time.Second))
+	time.Sleep(50 * time.Millisecond)
+	name, err = dial(t, addr, clientConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "server-1", name)
+
+	cert, key = ca.issue(t, "server-2", x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, cert, start.Add(2*time.Second))
+	writeFile(t, keyFile, key, start.Add(2*time.Second))
+	assert.Eventually(t, func() bool {
+		name, err := dial(t, addr, clientConfig)
+		return err == nil && name == "server-2"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestReloader_ClientCertificates(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "test-ca")
+	other := newTestCA(t, "other-ca")
+	certFile, keyFile, caFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"), filepath.Join(dir, "clients.pem")
+	cert, key := ca.issue(t, "server", x509.ExtKeyUsageServerAuth)
+	writeFile(t, certFile, cert, time.Now())
+	writeFile(t, keyFile, key, time.Now())
+	writeFile(t, caFile, ca.pem, time.Now())
+
+	reloader, err := NewReloader(Config{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile, RequireClientCert: true})
+	require.NoError(t, err)
+	addr := serve(t, reloader.ServerConfig())
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientCert := func(ca *testCA) []tls.Certificate {
+		certPEM, keyPEM := ca.issue(t, "billing-job", x509.ExtKeyUsageClientAuth)
+		pair, err := tls.X509KeyPair(certPEM, keyPEM)
+		require.NoError(t, err)
+		return []tls.Certificate{pair}
+	}
+
+	_, err = dial(t, addr, &tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: clientCert(ca)})
+	assert.NoError(t, err)
+
+	_, err = dial(t, addr, &tls.Config{RootCAs: roots, ServerName: "localhost"})
+	assert.Error(t, err, "a client certificate is required")
+
+	_, err = dial(t, addr, &tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: clientCert(other)})
+	assert.Error(t, err, "certificates from other CAs are rejected")
+}
+
+func TestNewReloader_Validation(t *testing.T) {
+	_, err := NewReloader(Config{CertFile: "server.crt"})
+	assert.Error(t, err)
+
+	_, err = NewReloader(Config{CertFile: "server.crt", KeyFile: "server.key", RequireClientCert: true})
+	assert.Error(t, err)
+
+	_, err = NewReloader(Config{CertFile: "missing.crt", KeyFile: "missing.key"})
+	assert.Error(t, err)
+}
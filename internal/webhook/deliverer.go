@@ -0,0 +1,195 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/repository"
+	"github.com/microservice-go/product-service/internal/retry"
+	"github.com/microservice-go/product-service/internal/tenant"
+)
+
+type Config struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Timeout      time.Duration
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	MaxAttempts  int
+	// Lease is how long a deliverer holds a delivery it is sending before
+	// another may retry it. It must outlast Timeout.
+	Lease time.Duration
+	Now   func() time.Time
+}
+
+func (c Config) withDefaults() Config {
+	if c.PollInterval <= 0 {
+		c.PollInterval = time.Second
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 50
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.BaseBackoff <= 0 {
+		c.BaseBackoff = 30 * time.Second
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 6 * time.Hour
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 10
+	}
+	if c.Lease <= c.Timeout {
+		c.Lease = c.Timeout + 30*time.Second
+	}
+	if c.Now == nil {
+		c.Now = time.Now
+	}
+	return c
+}
+
+// Deliverer sends pending webhook deliveries, signing each request and
+// retrying failures with exponential backoff until MaxAttempts is reached.
+// Every replica can run a deliverer: each delivery is claimed before it is
+// sent, so a receiver gets it from one deliverer at a time.
+type Deliverer struct {
+	repo   repository.WebhookRepository
+	client *http.Client
+	config Config
+}
+
+func NewDeliverer(repo repository.WebhookRepository, config Config) *Deliverer {
+	config = config.withDefaults()
+	return &Deliverer{
+		repo:   repo,
+		client: &http.Client{Timeout: config.Timeout},
+		config: config,
+	}
+}
+
+// Run polls for due deliveries until ctx is cancelled.
+func (d *Deliverer) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.ProcessOnce(ctx); err != nil {
+			log.Printf("Webhook deliverer: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessOnce attempts every delivery that is currently due and returns how
+// many succeeded.
+func (d *Deliverer) ProcessOnce(ctx context.Context) (int, error) {
+	now := d.config.Now()
+	deliveries, err := d.repo.ListDueDeliveries(now, d.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	webhooks := make(map[uuid.UUID]*models.Webhook)
+	succeeded := 0
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			return succeeded, ctx.Err()
+		}
+
+		delivery := &deliveries[i]
+		claimed, err := d.repo.ClaimDelivery(delivery.ID, now, now.Add(d.config.Lease))
+		if err != nil {
+			return succeeded, err
+		}
+		if !claimed {
+			continue
+		}
+
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			webhook, err = d.repo.WithContext(tenant.WithTenant(ctx, delivery.TenantID)).GetByID(delivery.WebhookID)
+			if err != nil {
+				webhook = nil
+			}
+			webhooks[delivery.WebhookID] = webhook
+		}
+
+		if webhook == nil || !webhook.Active {
+			delivery.Status = models.WebhookDeliveryFailed
+			delivery.LastError = "webhook is deleted or inactive"
+		} else {
+			d.attempt(ctx, webhook, delivery)
+			if delivery.Status == models.WebhookDeliverySucceeded {
+				succeeded++
+			}
+		}
+
+		if err := d.repo.UpdateDelivery(delivery); err != nil {
+			return succeeded, err
+		}
+	}
+
+	return succeeded, nil
+}
+
+func (d *Deliverer) attempt(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) {
+	now := d.config.Now()
+	delivery.Attempts++
+
+	status, err := d.send(ctx, webhook, delivery, now)
+	delivery.ResponseStatus = status
+	if err == nil {
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= d.config.MaxAttempts {
+		delivery.Status = models.WebhookDeliveryFailed
+		return
+	}
+	delivery.NextAttemptAt = now.Add(retry.Backoff(d.config.BaseBackoff, d.config.MaxBackoff, delivery.Attempts))
+}
+
+func (d *Deliverer) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery, now time.Time) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDeliveryID, delivery.ID.String())
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, fmt.Sprint(timestamp))
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver returned %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,300 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/events"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWebhookRepository struct {
+	webhooks   map[uuid.UUID]*models.Webhook
+	deliveries []*models.WebhookDelivery
+}
+
+func newFakeWebhookRepository(webhooks ...*models.Webhook) *fakeWebhookRepository {
+	r := &fakeWebhookRepository{webhooks: make(map[uuid.UUID]*models.Webhook)}
+	for _, webhook := range webhooks {
+		if webhook.ID == uuid.Nil {
+			webhook.ID = uuid.New()
+		}
+		r.webhooks[webhook.ID] = webhook
+	}
+	return r
+}
+
+func (r *fakeWebhookRepository) Create(webhook *models.Webhook) error {
+	r.webhooks[webhook.ID] = webhook
+	return nil
+}
+
+func (r *fakeWebhookRepository) GetByID(id uuid.UUID) (*models.Webhook, error) {
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return nil, errors.New("webhook not found")
+	}
+	return webhook, nil
+}
+
+func (r *fakeWebhookRepository) Update(webhook *models.Webhook) error {
+	r.webhooks[webhook.ID] = webhook
+	return nil
+}
+
+func (r *fakeWebhookRepository) Delete(id uuid.UUID) error {
+	delete(r.webhooks, id)
+	return nil
+}
+
+func (r *fakeWebhookRepository) List() ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	for _, webhook := range r.webhooks {
+		webhooks = append(webhooks, *webhook)
+	}
+	return webhooks, nil
+}
+
+func (r *fakeWebhookRepository) ListActive() ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	for _, webhook := range r.webhooks {
+		if webhook.Active {
+			webhooks = append(webhooks, *webhook)
+		}
+	}
+	return webhooks, nil
+}
+
+func (r *fakeWebhookRepository) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	for i := range deliveries {
+		delivery := deliveries[i]
+		if delivery.DedupeKey != nil && r.findByDedupeKey(*delivery.DedupeKey) != nil {
+			continue
+		}
+		if delivery.ID == uuid.Nil {
+			delivery.ID = uuid.New()
+		}
+		r.deliveries = append(r.deliveries, &delivery)
+	}
+	return nil
+}
+
+func (r *fakeWebhookRepository) findByDedupeKey(key string) *models.WebhookDelivery {
+	for _, delivery := range r.deliveries {
+		if delivery.DedupeKey != nil && *delivery.DedupeKey == key {
+			return delivery
+		}
+	}
+	return nil
+}
+
+func (r *fakeWebhookRepository) GetDelivery(id uuid.UUID) (*models.WebhookDelivery, error) {
+	for _, delivery := range r.deliveries {
+		if delivery.ID == id {
+			return delivery, nil
+		}
+	}
+	return nil, errors.New("webhook delivery not found")
+}
+
+func (r *fakeWebhookRepository) UpdateDelivery(delivery *models.WebhookDelivery) error {
+	for i, existing := range r.deliveries {
+		if existing.ID == delivery.ID {
+			updated := *delivery
+			r.deliveries[i] = &updated
+			return nil
+		}
+	}
+	return errors.New("webhook delivery not found")
+}
+
+func (r *fakeWebhookRepository) ListDeliveries(webhookID uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.WebhookID == webhookID && len(deliveries) < limit {
+			deliveries = append(deliveries, *delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *fakeWebhookRepository) ListDueDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.Status == models.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) && len(deliveries) < limit {
+			deliveries = append(deliveries, *delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *fakeWebhookRepository) ClaimDelivery(id uuid.UUID, now, until time.Time) (bool, error) {
+	delivery, err := r.GetDelivery(id)
+	if err != nil || delivery.Status != models.WebhookDeliveryPending || delivery.NextAttemptAt.After(now) {
+		return false, err
+	}
+	delivery.NextAttemptAt = until
+	return true, nil
+}
+
+func (r *fakeWebhookRepository) WithContext(ctx context.Context) repository.WebhookRepository {
+	return r
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func queueDelivery(t *testing.T, repo *fakeWebhookRepository, webhook *models.Webhook, now time.Time) *models.WebhookDelivery {
+	t.Helper()
+	dispatcher := NewDispatcher(repo)
+	dispatcher.now = func() time.Time { return now }
+	event := events.NewProductEvent(events.TypeCreated, &models.Product{ID: uuid.New(), Name: "Widget"})
+	event.Sequence = uint64(len(repo.deliveries) + 1)
+	require.NoError(t, dispatcher.Publish(context.Background(), event))
+	require.NotEmpty(t, repo.deliveries)
+	return repo.deliveries[len(repo.deliveries)-1]
+}
+
+func TestDeliverer_SignsRequests(t *testing.T) {
+	secret := "0123456789abcdef"
+	var verified atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		verified.Store(Verify(secret, timestamp, body, r.Header.Get(HeaderSignature)) &&
+			r.Header.Get(HeaderEvent) == "product.created")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	webhook := &models.Webhook{URL: server.URL, EventTypes: "product.created", Secret: secret, Active: true}
+	repo := newFakeWebhookRepository(webhook)
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	queueDelivery(t, repo, webhook, clock.now)
+
+	deliverer := NewDeliverer(repo, Config{Now: clock.Now})
+	succeeded, err := deliverer.ProcessOnce(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, succeeded)
+	assert.True(t, verified.Load())
+	delivery := repo.deliveries[0]
+	assert.Equal(t, models.WebhookDeliverySucceeded, delivery.Status)
+	assert.Equal(t, http.StatusNoContent, delivery.ResponseStatus)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.NotNil(t, delivery.DeliveredAt)
+}
+
+func TestDeliverer_RetriesWithBackoffUntilMaxAttempts(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	webhook := &models.Webhook{URL: server.URL, EventTypes: "*", Secret: "0123456789abcdef", Active: true}
+	repo := newFakeWebhookRepository(webhook)
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	queueDelivery(t, repo, webhook, clock.now)
+
+	deliverer := NewDeliverer(repo, Config{
+		BaseBackoff: time.Second,
+		MaxBackoff:  time.Minute,
+		MaxAttempts: 3,
+		Now:         clock.Now,
+	})
+
+	_, err := deliverer.ProcessOnce(context.Background())
+	require.NoError(t, err)
+	delivery := repo.deliveries[0]
+	assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+	assert.Equal(t, http.StatusInternalServerError, delivery.ResponseStatus)
+	assert.Equal(t, clock.now.Add(time.Second), delivery.NextAttemptAt)
+
+	// Not yet due.
+	_, err = deliverer.ProcessOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+
+	clock.now = clock.now.Add(time.Second)
+	_, err = deliverer.ProcessOnce(context.Background())
+	require.NoError(t, err)
+	delivery = repo.deliveries[0]
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.Equal(t, clock.now.Add(2*time.Second), delivery.NextAttemptAt)
+
+	clock.now = clock.now.Add(2 * time.Second)
+	_, err = deliverer.ProcessOnce(context.Background())
+	require.NoError(t, err)
+	delivery = repo.deliveries[0]
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Equal(t, models.WebhookDeliveryFailed, delivery.Status)
+	assert.Contains(t, delivery.LastError, "500")
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestDeliverer_FailsDeliveriesForInactiveWebhooks(t *testing.T) {
+	webhook := &models.Webhook{URL: "http://127.0.0.1:0", EventTypes: "*", Secret: "0123456789abcdef", Active: true}
+	repo := newFakeWebhookRepository(webhook)
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	queueDelivery(t, repo, webhook, clock.now)
+	webhook.Active = false
+
+	_, err := NewDeliverer(repo, Config{Now: clock.Now}).ProcessOnce(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookDeliveryFailed, repo.deliveries[0].Status)
+	assert.Equal(t, 0, repo.deliveries[0].Attempts)
+}
+
+// staleListRepository returns deliveries listed before another deliverer
+// picked them up, as a replica polling at the same moment would see them.
+type staleListRepository struct {
+	*fakeWebhookRepository
+	due []models.WebhookDelivery
+}
+
+func (r *staleListRepository) ListDueDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	return r.due, nil
+}
+
+func TestDeliverer_SendsEachDeliveryOnceAcrossDeliverers(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	webhook := &models.Webhook{URL: server.URL, EventTypes: "*", Secret: "0123456789abcdef", Active: true}
+	repo := newFakeWebhookRepository(webhook)
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	queueDelivery(t, repo, webhook, clock.now)
+	due, err := repo.ListDueDeliveries(clock.now, 10)
+	require.NoError(t, err)
+
+	_, err = NewDeliverer(repo, Config{Now: clock.Now}).ProcessOnce(context.Background())
+	require.NoError(t, err)
+	_, err = NewDeliverer(&staleListRepository{repo, due}, Config{Now: clock.Now}).ProcessOnce(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(1), requests.Load())
+	assert.Equal(t, 1, repo.deliveries[0].Attempts)
+}
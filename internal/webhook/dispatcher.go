@@ -0,0 +1,59 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/microservice-go/product-service/internal/events"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/microservice-go/product-service/internal/repository"
+	"github.com/microservice-go/product-service/internal/tenant"
+)
+
+// Dispatcher is an events.Publisher that fans each event out into a pending
+// delivery for every active webhook of the event's tenant subscribed to it.
+// The Deliverer sends them.
+type Dispatcher struct {
+	repo repository.WebhookRepository
+	now  func() time.Time
+}
+
+func NewDispatcher(repo repository.WebhookRepository) *Dispatcher {
+	return &Dispatcher{repo: repo, now: time.Now}
+}
+
+func (d *Dispatcher) Publish(ctx context.Context, event events.Event) error {
+	webhooks, err := d.repo.WithContext(tenant.WithTenant(ctx, event.TenantID)).ListActive()
+	if err != nil {
+		return err
+	}
+
+	name := event.Name()
+	var payload []byte
+	var deliveries []models.WebhookDelivery
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(name) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(event); err != nil {
+				return err
+			}
+		}
+		dedupeKey := fmt.Sprintf("%s:%d", webhook.ID, event.Sequence)
+		deliveries = append(deliveries, models.WebhookDelivery{
+			TenantID:      webhook.TenantID,
+			WebhookID:     webhook.ID,
+			EventSequence: event.Sequence,
+			EventType:     name,
+			Payload:       string(payload),
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: d.now(),
+			DedupeKey:     &dedupeKey,
+		})
+	}
+
+	return d.repo.CreateDeliveries(deliveries)
+}
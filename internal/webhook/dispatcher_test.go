@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/microservice-go/product-service/internal/events"
+	"github.com/microservice-go/product-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatcher_FansOutToSubscribedWebhooks(t *testing.T) {
+	products := &models.Webhook{URL: "https://example.com/products", EventTypes: "product.created,product.updated", Active: true}
+	everything := &models.Webhook{URL: "https://example.com/all", EventTypes: "*", Active: true}
+	plans := &models.Webhook{URL: "https://example.com/plans", EventTypes: "subscription_plan.created", Active: true}
+	inactive := &models.Webhook{URL: "https://example.com/off", EventTypes: "*", Active: false}
+	repo := newFakeWebhookRepository(products, everything, plans, inactive)
+	dispatcher := NewDispatcher(repo)
+
+	event := events.NewProductEvent(events.TypeCreated, &models.Product{ID: uuid.New(), Name: "Widget"})
+	event.Sequence = 7
+	require.NoError(t, dispatcher.Publish(context.Background(), event))
+
+	require.Len(t, repo.deliveries, 2)
+	targets := map[uuid.UUID]bool{}
+	for _, delivery := range repo.deliveries {
+		targets[delivery.WebhookID] = true
+		assert.Equal(t, uint64(7), delivery.EventSequence)
+		assert.Equal(t, "product.created", delivery.EventType)
+		assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+
+		var payload map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(delivery.Payload), &payload))
+		assert.Equal(t, float64(7), payload["sequence"])
+	}
+	assert.True(t, targets[products.ID])
+	assert.True(t, targets[everything.ID])
+
+	// Redelivering the same outbox event must not create duplicate deliveries.
+	require.NoError(t, dispatcher.Publish(context.Background(), event))
+	assert.Len(t, repo.deliveries, 2)
+}
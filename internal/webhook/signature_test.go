@@ -0,0 +1,19 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"type":"product.created"}`)
+	signature := Sign("0123456789abcdef", 1700000000, body)
+
+	assert.Contains(t, signature, "sha256=")
+	assert.True(t, Verify("0123456789abcdef", 1700000000, body, signature))
+	assert.False(t, Verify("another-secret-value", 1700000000, body, signature))
+	assert.False(t, Verify("0123456789abcdef", 1700000001, body, signature))
+	assert.False(t, Verify("0123456789abcdef", 1700000000, []byte(`{}`), signature))
+	assert.False(t, Verify("0123456789abcdef", 1700000000, body, signature[len("sha256="):]))
+}